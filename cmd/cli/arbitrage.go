@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"uniswap-est/intrenal/models"
+
+	"github.com/spf13/cobra"
+)
+
+func newArbitrageCommand(opts *options) *cobra.Command {
+	req := models.ArbitrageRequest{}
+	var pools []string
+	var maxHops int
+
+	cmd := &cobra.Command{
+		Use:   "arbitrage",
+		Short: "Find profitable cycles over V2 pairs",
+		Long: "Find the profitable cycles over the given pools, or <CHAIN>_ARBITRAGE_POOLS, each\n" +
+			"traded with its optimal input, like GET /arbitrage.",
+		Example: "  cli arbitrage --chain ethereum --pools 0x...,0x...,0x... --max-hops 3",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Pools = strings.Join(pools, ",")
+			if cmd.Flags().Changed("max-hops") {
+				req.MaxHops = strconv.Itoa(maxHops)
+			}
+			if err := validate(&req); err != nil {
+				return describeError(err)
+			}
+
+			return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+				response, err := s.chain.Arbitrage.FindArbitrage(ctx, &req)
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd.OutOrStdout(), response, func(t *tableWriter) {
+					t.row("block", response.BlockNumber)
+					for _, pool := range response.SkippedPools {
+						t.row("skipped", pool)
+					}
+					t.blank()
+					t.row("START", "PATH", "POOLS", "AMOUNT IN", "AMOUNT OUT", "PROFIT")
+					for _, cycle := range response.Opportunities {
+						t.row(cycle.StartToken, strings.Join(cycle.Path, " > "), strings.Join(cycle.Pools, " > "),
+							cycle.AmountIn, cycle.AmountOut, cycle.Profit)
+					}
+				})
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&pools, "pools", nil, "V2 pairs to search (default <CHAIN>_ARBITRAGE_POOLS)")
+	flags.StringVar(&req.Start, "start", "", "only report cycles starting at this token")
+	flags.IntVar(&maxHops, "max-hops", 0, "longest cycle to search (default MAX_ARBITRAGE_HOPS)")
+	return cmd
+}
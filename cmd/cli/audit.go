@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"uniswap-est/intrenal/services"
+
+	"github.com/spf13/cobra"
+)
+
+func newAuditCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Export the audit log of served quotes",
+		Long: "The server records every quote served over /estimate, /estimate/batch and gRPC in\n" +
+			"the SQLite database of AUDIT_DB: the request, the block, the pool state and fee it\n" +
+			"was priced with, the output and the requester.",
+	}
+	cmd.AddCommand(newAuditExportCommand(opts))
+	return cmd
+}
+
+func newAuditExportCommand(opts *options) *cobra.Command {
+	var db, from, to, out string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the quotes served between --from and --to as CSV",
+		Long: "Write the recorded quotes as CSV, oldest first, with a header naming the columns.\n" +
+			"--from and --to are RFC 3339 times or dates; --to is exclusive and either may be\n" +
+			"omitted. The database may be in use by the server.",
+		Example: "  cli audit export --from 2026-09-01 --to 2026-10-01 --out september.csv\n" +
+			"  cli audit export --db /var/lib/estimator/audit.db --from 2026-10-18T09:00:00Z",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if db == "" {
+				return errors.New("pass --db or set AUDIT_DB")
+			}
+			if _, err := os.Stat(db); err != nil {
+				return err
+			}
+			fromTime, err := parseTime("--from", from)
+			if err != nil {
+				return err
+			}
+			toTime, err := parseTime("--to", to)
+			if err != nil {
+				return err
+			}
+
+			audit, err := services.OpenAuditLog(db, 1, 1, 0)
+			if err != nil {
+				return err
+			}
+			defer audit.Close()
+
+			var w io.Writer = cmd.OutOrStdout()
+			var file *os.File
+			if out != "-" {
+				file, err = os.Create(out)
+				if err != nil {
+					return err
+				}
+				w = file
+			}
+
+			count, err := audit.Export(cmd.Context(), w, fromTime, toTime)
+			if file != nil {
+				// Close can report write errors of its own, e.g. on NFS or a full disk
+				err = errors.Join(err, file.Close())
+			}
+			if err != nil {
+				return err
+			}
+			if out != "-" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d quotes to %s\n", count, out)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&db, "db", os.Getenv("AUDIT_DB"), "audit database (default AUDIT_DB)")
+	flags.StringVar(&from, "from", "", "first time to export, e.g. 2026-09-01 or 2026-09-01T12:00:00Z")
+	flags.StringVar(&to, "to", "", "time to export until, exclusive")
+	flags.StringVar(&out, "out", "-", "CSV file to write, - for stdout")
+	return cmd
+}
+
+// parseTime parses an RFC 3339 time or a date in UTC; empty is the zero time
+func parseTime(flag, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time or a date, got %q", flag, value)
+}
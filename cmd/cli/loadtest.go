@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/loadtest"
+
+	"github.com/spf13/cobra"
+)
+
+func newLoadtestCommand(opts *options) *cobra.Command {
+	run := loadtest.Options{}
+	var target, logPath, poolsPath string
+	var inProcess bool
+	var stubPairs, requests, batchSize int
+	var stubLatency time.Duration
+	var seed int64
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Drive the HTTP API at a target rate and report latency, errors and throughput",
+		Long: "Send requests to the API at --rps for --duration and report latency percentiles,\n" +
+			"failed requests by error code and throughput. Requests are replayed from a request\n" +
+			"log (--log: the server's access log, lines like \"GET /estimate?...\", or JSON lines\n" +
+			"{\"method\",\"path\",\"body\"}) or synthesised from a pool list (--pools: a CSV file\n" +
+			"with pool, src, dst and optionally amount and dst_amount columns).\n\n" +
+			"--in-process serves the API inside this process on top of a stub chain of\n" +
+			"--stub-pairs pairs answering every RPC round trip in --stub-latency, and synthesises\n" +
+			"requests over those pairs unless --log or --pools is given.",
+		Example: "  cli loadtest --target http://localhost:1337 --log access.log --rps 200 --duration 1m\n" +
+			"  cli loadtest --target http://localhost:1337 --pools pools.csv --batch 10\n" +
+			"  cli loadtest --in-process --stub-latency 30ms --rps 500 -o json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !inProcess && logPath == "" && poolsPath == "" {
+				return errors.New("pass --log or --pools with the requests to send, or --in-process")
+			}
+
+			var reqs []loadtest.Request
+			switch {
+			case logPath != "":
+				file, err := os.Open(logPath)
+				if err != nil {
+					return err
+				}
+				reqs, err = loadtest.ReadLog(file)
+				file.Close()
+				if err != nil {
+					return fmt.Errorf("%s: %w", logPath, err)
+				}
+			case poolsPath != "":
+				file, err := os.Open(poolsPath)
+				if err != nil {
+					return err
+				}
+				pools, err := loadtest.ReadPools(file)
+				file.Close()
+				if err != nil {
+					return fmt.Errorf("%s: %w", poolsPath, err)
+				}
+				reqs = loadtest.Synthesize(pools, requests, batchSize, seed)
+			}
+
+			run.Target = target
+			if inProcess {
+				cfg, err := config.LoadOfflineConfig()
+				if err != nil {
+					return err
+				}
+				chain := loadtest.NewStubChain(stubPairs, stubLatency)
+				server, err := loadtest.StartServer(cfg, chain)
+				if err != nil {
+					return err
+				}
+				defer server.Close()
+
+				run.Target = server.URL
+				if reqs == nil {
+					reqs = loadtest.Synthesize(chain.Pools(), requests, batchSize, seed)
+				}
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Sending %d requests per second to %s for %s...\n", int(run.RPS), run.Target, run.Duration)
+			report, err := loadtest.Run(cmd.Context(), reqs, run)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), report, func(t *tableWriter) {
+				reportTable(t, report)
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&target, "target", "http://localhost:1337", "base URL of the API to load")
+	flags.StringVar(&logPath, "log", "", "replay the requests of this request log, in order")
+	flags.StringVar(&poolsPath, "pools", "", "synthesise quotes over the pools of this CSV file")
+	flags.Float64Var(&run.RPS, "rps", 50, "requests started per second")
+	flags.DurationVar(&run.Duration, "duration", 30*time.Second, "how long requests are started for")
+	flags.IntVar(&run.Concurrency, "concurrency", 256, "most requests in flight; requests due beyond it are skipped")
+	flags.DurationVar(&run.Timeout, "timeout", 10*time.Second, "per-request timeout")
+	flags.IntVar(&requests, "requests", 10000, "distinct requests to synthesise, sent in a loop")
+	flags.IntVar(&batchSize, "batch", 1, "quotes per synthesised request; above 1, POST /estimate/batch")
+	flags.Int64Var(&seed, "seed", 1, "seed of the synthesised requests")
+	flags.BoolVar(&inProcess, "in-process", false, "serve the API in this process on a stub chain")
+	flags.IntVar(&stubPairs, "stub-pairs", 50, "pairs of the stub chain of --in-process")
+	flags.DurationVar(&stubLatency, "stub-latency", 20*time.Millisecond, "duration of each RPC round trip of the stub chain")
+	cmd.MarkFlagsMutuallyExclusive("log", "pools")
+	cmd.MarkFlagsMutuallyExclusive("in-process", "target")
+	return cmd
+}
+
+// reportTable shows a load test report as field and value rows
+func reportTable(t *tableWriter, report *loadtest.Report) {
+	t.row("target", report.Target)
+	t.row("duration", fmt.Sprintf("%.1fs", report.Duration))
+	t.row("target rps", report.TargetRPS)
+	t.row("sent", report.Sent)
+	t.row("skipped", report.Skipped)
+	t.row("succeeded", report.Succeeded)
+	t.row("failed", report.Failed)
+	t.row("throughput", fmt.Sprintf("%.1f/s", report.Throughput))
+	t.blank()
+
+	t.row("LATENCY", "MS")
+	t.row("mean", report.Latency.Mean)
+	t.row("p50", report.Latency.P50)
+	t.row("p90", report.Latency.P90)
+	t.row("p95", report.Latency.P95)
+	t.row("p99", report.Latency.P99)
+	t.row("max", report.Latency.Max)
+
+	statuses := make([]int, 0, len(report.Statuses))
+	for status := range report.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	t.blank()
+	t.row("STATUS", "RESPONSES")
+	for _, status := range statuses {
+		t.row(status, report.Statuses[status])
+	}
+
+	countsTable(t, "ERROR", report.Errors)
+	countsTable(t, "BATCH ITEM ERROR", report.ItemErrors)
+}
+
+// countsTable shows counts by error code, most frequent first, when there are any
+func countsTable(t *tableWriter, title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	codes := make([]string, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if counts[codes[i]] != counts[codes[j]] {
+			return counts[codes[i]] > counts[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	t.blank()
+	t.row(title, "COUNT")
+	for _, code := range codes {
+		t.row(code, counts[code])
+	}
+}
@@ -0,0 +1,20 @@
+// Command cli quotes swaps and inspects pools and tokens from the command line with the
+// same services as the API server, without starting it.
+//
+// Usage: go run ./cmd/cli quote --pool 0x... --src USDT --dst WETH --amount 1000000000
+package main
+
+import (
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// The same .env as the server; the environment alone is fine too
+	_ = godotenv.Load()
+
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// print writes value as indented JSON with --output json, or as the table written by
+// table otherwise
+func (o *options) print(w io.Writer, value any, table func(*tableWriter)) error {
+	if o.output == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	t := &tableWriter{w: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}
+	table(t)
+	return t.w.Flush()
+}
+
+// tableWriter writes rows of aligned columns
+type tableWriter struct {
+	w *tabwriter.Writer
+}
+
+// row writes one row; empty values are shown as "-"
+func (t *tableWriter) row(values ...any) {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		cells[i] = fmt.Sprint(value)
+		if cells[i] == "" {
+			cells[i] = "-"
+		}
+	}
+	fmt.Fprintln(t.w, strings.Join(cells, "\t"))
+}
+
+// blank writes an empty line between sections
+func (t *tableWriter) blank() {
+	fmt.Fprintln(t.w)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"uniswap-est/intrenal/models"
+
+	"github.com/spf13/cobra"
+)
+
+func newQuoteCommand(opts *options) *cobra.Command {
+	req := models.EstimateRequest{}
+	var csvPath string
+
+	cmd := &cobra.Command{
+		Use:   "quote",
+		Short: "Quote a swap, or a batch of swaps from a CSV file",
+		Long: "Quote a swap like GET /estimate, or every row of a CSV file like POST /estimate/batch.\n" +
+			"The CSV file starts with a header naming its columns: src, dst, src_amount (or amount),\n" +
+			"and optionally pool, dex and verify. Use - to read it from stdin.",
+		Example: "  cli quote --pool 0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852 --src USDT --dst WETH --amount 1000000000\n" +
+			"  cli quote --csv quotes.csv --block 23000000 -o json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if csvPath != "" {
+				reqs, err := readQuoteCSV(cmd.InOrStdin(), csvPath)
+				if err != nil {
+					return err
+				}
+				return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+					response, err := quoteBatch(ctx, s, reqs)
+					if err != nil {
+						return err
+					}
+					return opts.print(cmd.OutOrStdout(), response, func(t *tableWriter) {
+						batchTable(t, reqs, response)
+					})
+				})
+			}
+
+			if err := validate(&req); err != nil {
+				return describeError(err)
+			}
+			return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+				response, err := s.chain.Uniswap.EstimateSwap(ctx, &req)
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd.OutOrStdout(), response, func(t *tableWriter) {
+					quoteTable(t, s.block, &req, response)
+				})
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&req.Pool, "pool", "", "pool address (default: the pair of src and dst derived for --dex)")
+	flags.StringVar(&req.Src, "src", "", "source token address or symbol")
+	flags.StringVar(&req.Dst, "dst", "", "destination token address or symbol")
+	flags.StringVar(&req.SrcAmount, "amount", "", "source amount in token units")
+	flags.StringVar(&req.Dex, "dex", "", "V2 deployment to derive the pool for (default: the chain's primary one)")
+	flags.BoolVar(&req.Verify, "verify", false, "cross-check the quote with the router")
+	flags.StringVar(&csvPath, "csv", "", "quote every row of this CSV file instead")
+	cmd.MarkFlagsMutuallyExclusive("csv", "src")
+	cmd.MarkFlagsMutuallyExclusive("csv", "pool")
+	return cmd
+}
+
+// quoteBatch quotes requests at the session block. Invalid requests get their error
+// right away; only valid ones are quoted, like POST /estimate/batch.
+func quoteBatch(ctx context.Context, s *session, reqs []models.EstimateRequest) (*models.BatchEstimateResponse, error) {
+	response := &models.BatchEstimateResponse{BlockNumber: s.block, Results: make([]models.BatchEstimateResult, len(reqs))}
+
+	valid := make([]models.EstimateRequest, 0, len(reqs))
+	validIndex := make([]int, 0, len(reqs))
+	for i := range reqs {
+		response.Results[i].Index = i
+		if err := validate(&reqs[i]); err != nil {
+			response.Results[i].Error = err.(*models.APIError)
+			continue
+		}
+		valid = append(valid, reqs[i])
+		validIndex = append(validIndex, i)
+	}
+	if len(valid) == 0 {
+		return response, nil
+	}
+
+	quoted, err := s.chain.Uniswap.EstimateBatch(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range quoted.Results {
+		result.Index = validIndex[i]
+		response.Results[validIndex[i]] = result
+	}
+	return response, nil
+}
+
+// readQuoteCSV reads quote requests from a CSV file, or from stdin when path is "-"
+func readQuoteCSV(stdin io.Reader, path string) ([]models.EstimateRequest, error) {
+	input := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	reader := csv.NewReader(input)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: empty file, expected a header", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "amount" {
+			name = "src_amount"
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"src", "dst", "src_amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%s: the header has no %s column", path, required)
+		}
+	}
+
+	var reqs []models.EstimateRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		req := models.EstimateRequest{
+			Pool:      field("pool"),
+			Src:       field("src"),
+			Dst:       field("dst"),
+			SrcAmount: field("src_amount"),
+			Dex:       field("dex"),
+		}
+		if verify := field("verify"); verify != "" {
+			if req.Verify, err = strconv.ParseBool(verify); err != nil {
+				line, _ := reader.FieldPos(0)
+				return nil, fmt.Errorf("%s:%d: verify must be true or false", path, line)
+			}
+		}
+		reqs = append(reqs, req)
+	}
+
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%s: no quotes after the header", path)
+	}
+	return reqs, nil
+}
+
+// quoteTable shows one quote as field and value rows
+func quoteTable(t *tableWriter, block uint64, req *models.EstimateRequest, response *models.EstimateResponse) {
+	pool := req.Pool
+	if response.Pool != "" {
+		pool = response.Pool + " (derived)"
+	}
+
+	t.row("block", block)
+	t.row("pool", pool)
+	t.row("pool type", response.PoolType)
+	t.row("src", tokenLabel(req.Src, response.SrcToken))
+	t.row("dst", tokenLabel(req.Dst, response.DstToken))
+	t.row("src amount", req.SrcAmount)
+	t.row("dst amount", response.DstAmount)
+
+	if response.FeeOnTransfer {
+		t.row("transfer tax", fmt.Sprintf("src %d bps, dst %d bps", response.SrcTransferTaxBps, response.DstTransferTaxBps))
+	}
+	if response.Rebasing {
+		t.row("rebasing", "yes, reserves may be stale")
+	}
+	if response.WrappedNative != "" {
+		t.row("quoted as", response.WrappedNative)
+	}
+	if verification := response.Verification; verification != nil {
+		t.row("router check", verification.Status)
+		if verification.OnChainAmount != "" {
+			t.row("router amount", verification.OnChainAmount)
+		}
+		if verification.Reason != "" {
+			t.row("router note", verification.Reason)
+		}
+	}
+	for _, warning := range response.Warnings {
+		t.row("warning", warning)
+	}
+}
+
+// batchTable shows one row per quote of a batch
+func batchTable(t *tableWriter, reqs []models.EstimateRequest, response *models.BatchEstimateResponse) {
+	t.row("block", response.BlockNumber)
+	t.blank()
+	t.row("#", "POOL", "SRC", "DST", "SRC AMOUNT", "DST AMOUNT", "POOL TYPE", "ERROR")
+	for i, result := range response.Results {
+		req := reqs[i]
+		if result.Error != nil {
+			t.row(i, req.Pool, req.Src, req.Dst, req.SrcAmount, "", "", result.Error.ErrorCode)
+			continue
+		}
+		pool := req.Pool
+		if result.Result.Pool != "" {
+			pool = result.Result.Pool
+		}
+		t.row(i, pool, req.Src, req.Dst, req.SrcAmount, result.Result.DstAmount, result.Result.PoolType, "")
+	}
+}
+
+// tokenLabel shows a token as given, with its token list symbol when known
+func tokenLabel(token string, listed *models.ListedToken) string {
+	if listed == nil || strings.EqualFold(listed.Symbol, token) {
+		return token
+	}
+	return fmt.Sprintf("%s (%s)", token, listed.Symbol)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// poolView is the state of one pool as printed by reserves
+type poolView struct {
+	Address  string      `json:"address"`
+	PoolType string      `json:"pool_type,omitempty"`
+	Tokens   []tokenView `json:"tokens,omitempty"`
+	Reserves []string    `json:"reserves,omitempty"` // Uniswap V2 pairs only, in token order
+	Error    string      `json:"error,omitempty"`
+}
+
+// reservesView is the output of reserves
+type reservesView struct {
+	BlockNumber uint64     `json:"block_number"`
+	Pools       []poolView `json:"pools"`
+}
+
+func newReservesCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reserves <pool>...",
+		Short: "Show the type, tokens and reserves of pools",
+		Long: "Show the type and tokens of any pool the API can quote, and the reserves of\n" +
+			"Uniswap V2 pairs, all read at one block.",
+		Example: "  cli reserves 0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852 --block 23000000",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pools := make([]string, len(args))
+			for i, pool := range args {
+				if !utils.IsValidEthereumAddress(pool) {
+					return fmt.Errorf("invalid pool address %q", pool)
+				}
+				pools[i] = utils.NormalizeAddress(pool)
+			}
+
+			return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+				view, err := readPools(ctx, s, pools)
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd.OutOrStdout(), view, func(t *tableWriter) {
+					t.row("block", view.BlockNumber)
+					t.blank()
+					t.row("POOL", "TYPE", "TOKENS", "RESERVES", "ERROR")
+					for _, pool := range view.Pools {
+						symbols := make([]string, len(pool.Tokens))
+						for i, token := range pool.Tokens {
+							symbols[i] = token.label()
+						}
+						t.row(pool.Address, pool.PoolType, strings.Join(symbols, " / "), strings.Join(pool.Reserves, " / "), pool.Error)
+					}
+				})
+			})
+		},
+	}
+}
+
+// readPools reads the pools, then the metadata of their tokens, at the session block
+func readPools(ctx context.Context, s *session, pools []string) (*reservesView, error) {
+	block := new(big.Int).SetUint64(s.block)
+	state, err := s.chain.Blockchain.GetBatchState(ctx, pools, nil, block)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	for _, pool := range pools {
+		if model := state.Models[pool]; model != nil {
+			tokens = append(tokens, model.Tokens()...)
+		}
+	}
+	if len(tokens) > 0 {
+		if state, err = s.chain.Blockchain.GetBatchState(ctx, pools, tokens, block); err != nil {
+			return nil, err
+		}
+	}
+
+	view := &reservesView{BlockNumber: s.block}
+	for _, pool := range pools {
+		view.Pools = append(view.Pools, poolViewOf(s, state, pool))
+	}
+	return view, nil
+}
+
+func poolViewOf(s *session, state *services.BatchState, pool string) poolView {
+	view := poolView{Address: pool}
+	model := state.Models[pool]
+	if model == nil {
+		view.Error = errorCode(state.PoolErrors[pool])
+		return view
+	}
+
+	view.PoolType = model.PoolType()
+	for _, token := range model.Tokens() {
+		view.Tokens = append(view.Tokens, tokenViewOf(s, state.Tokens[token], token))
+	}
+	if reserves := state.Pools[pool]; reserves != nil {
+		view.Reserves = []string{reserves.Reserve0.String(), reserves.Reserve1.String()}
+	}
+	return view
+}
+
+// errorCode returns the API error code of err, or its message for other errors
+func errorCode(err error) string {
+	var apiErr *models.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
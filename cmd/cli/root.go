@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of --output
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// options are the flags shared by every command
+type options struct {
+	chain    string
+	block    uint64
+	output   string
+	snapshot string
+	verbose  bool
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:   "cli",
+		Short: "Quote swaps and inspect pools and tokens without the HTTP server",
+		Long: "Quote swaps and inspect pools and tokens with the services of the API server.\n" +
+			"Settings come from the environment or .env, like the server. Every command reads\n" +
+			"one block: --block, or the latest block when the command starts.",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.output != outputTable && opts.output != outputJSON {
+				return fmt.Errorf("--output must be %s or %s", outputTable, outputJSON)
+			}
+			// The services log every batched read, which only matters when debugging
+			if !opts.verbose {
+				log.SetOutput(io.Discard)
+			}
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.chain, "chain", "", "chain name or ID (default DEFAULT_CHAIN)")
+	flags.Uint64Var(&opts.block, "block", 0, "read the state at this block (default latest)")
+	flags.StringVarP(&opts.output, "output", "o", outputTable, "output format: table or json")
+	flags.StringVar(&opts.snapshot, "snapshot", "", "read from a snapshot file instead of an RPC node")
+	flags.BoolVarP(&opts.verbose, "verbose", "v", false, "log the reads of the services to stderr")
+
+	root.AddCommand(
+		newQuoteCommand(opts),
+		newReservesCommand(opts),
+		newTokenCommand(opts),
+		newRouteCommand(opts),
+		newArbitrageCommand(opts),
+		newSnapshotCommand(opts),
+		newLoadtestCommand(opts),
+		newAuditCommand(opts),
+	)
+	return root
+}
+
+// session is the configuration and the services of the selected chain for one command,
+// all reading at one block
+type session struct {
+	cfg   *config.Config
+	chain *services.Chain
+	block uint64
+}
+
+// connect loads the configuration and creates the services of the selected chain on
+// top of its RPC node, or of --snapshot, pinned to --block or to the latest block
+func (o *options) connect(ctx context.Context) (*session, error) {
+	var cfg *config.Config
+	var chainConfig *config.ChainConfig
+	var client services.ChainClient
+	var err error
+
+	if o.snapshot != "" {
+		if cfg, err = config.LoadOfflineConfig(); err != nil {
+			return nil, err
+		}
+		snapshot, err := services.LoadSnapshot(o.snapshot)
+		if err != nil {
+			return nil, err
+		}
+		chainConfig = snapshot.ChainConfig()
+		if o.chain != "" && !strings.EqualFold(o.chain, chainConfig.Name) && o.chain != fmt.Sprint(chainConfig.ChainID) {
+			return nil, fmt.Errorf("the snapshot holds chain %s, not %s", chainConfig.Name, o.chain)
+		}
+		client = services.NewSnapshotClient(snapshot)
+	} else {
+		if cfg, err = config.LoadConfig(); err != nil {
+			return nil, err
+		}
+		if chainConfig, err = o.chainConfig(cfg); err != nil {
+			return nil, err
+		}
+		blockchain, err := services.NewBlockchainService(cfg, chainConfig)
+		if err != nil {
+			return nil, err
+		}
+		client = blockchain.Client()
+	}
+
+	// No background router checks: the command exits right after its reads
+	cfg.VerifySampleRate = 0
+
+	block := o.block
+	if block == 0 {
+		if block, err = client.BlockNumber(ctx); err != nil {
+			client.Close()
+			return nil, models.ErrBlockchainConnection.WithCause(err)
+		}
+	}
+
+	blockchain, err := services.NewChainBlockchainService(cfg, chainConfig, services.PinBlock(client, block))
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	var tokens *services.TokenRegistry
+	if len(cfg.TokenLists) > 0 {
+		if tokens, err = services.NewTokenRegistry(cfg.TokenLists); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	chain := services.NewChain(cfg, blockchain, services.NewReserveCache(cfg.HistoryCacheDir), tokens)
+	return &session{cfg: cfg, chain: chain, block: block}, nil
+}
+
+// chainConfig returns the chain named by --chain, or the default chain
+func (o *options) chainConfig(cfg *config.Config) (*config.ChainConfig, error) {
+	if o.chain == "" {
+		return cfg.DefaultChainConfig(), nil
+	}
+	chain, ok := cfg.Chain(o.chain)
+	if !ok {
+		return nil, models.ErrUnsupportedChain.WithDetails("Unknown chain " + o.chain + ", configured in CHAINS: " + chainNames(cfg))
+	}
+	return chain, nil
+}
+
+func chainNames(cfg *config.Config) string {
+	names := make([]string, len(cfg.Chains))
+	for i, chain := range cfg.Chains {
+		names[i] = chain.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// Close closes the client of the session
+func (s *session) Close() {
+	s.chain.Blockchain.Close()
+}
+
+// withSession connects, runs fn within REQUEST_TIMEOUT and reports API errors readably
+func (o *options) withSession(cmd *cobra.Command, fn func(ctx context.Context, s *session) error) error {
+	s, err := o.connect(cmd.Context())
+	if err != nil {
+		return describeError(err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+	return describeError(fn(ctx, s))
+}
+
+// validate checks a request against its `validate` tags, like the API handlers
+func validate(req any) error {
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return models.NewValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// describeError turns API errors into one readable line with their code, details and
+// invalid fields, as the API would report them
+func describeError(err error) error {
+	var apiErr *models.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	message := apiErr.ErrorCode + ": " + apiErr.Message
+	if apiErr.Details != "" {
+		message += ": " + apiErr.Details
+	}
+	for _, field := range apiErr.Fields {
+		message += fmt.Sprintf("\n  %s: %s", field.Field, field.Message)
+	}
+	if cause := errors.Unwrap(apiErr); cause != nil {
+		message += fmt.Sprintf(" (%v)", cause)
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// routeView is one way to swap src for dst: Path lists the tokens from src to dst, Pools
+// and Dexes the pool of each hop and the V2 deployment it was derived for
+type routeView struct {
+	Path      []string         `json:"path"`
+	Pools     []string         `json:"pools,omitempty"`
+	Dexes     []string         `json:"dexes,omitempty"`
+	DstAmount string           `json:"dst_amount,omitempty"`
+	Error     *models.APIError `json:"error,omitempty"`
+}
+
+// routesView is the output of route: the quoted routes, best first, then the failed ones
+type routesView struct {
+	BlockNumber uint64      `json:"block_number"`
+	SrcAmount   string      `json:"src_amount"`
+	Routes      []routeView `json:"routes"`
+	Failed      []routeView `json:"failed,omitempty"`
+}
+
+func newRouteCommand(opts *options) *cobra.Command {
+	req := models.EstimateRequest{}
+	var pools []string
+	var via string
+	var directOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "route",
+		Short: "Compare the routes swapping src for dst",
+		Long: "Quote src to dst through the pair of every V2 deployment of the chain, and through\n" +
+			"every pair of deployments via an intermediate token (the wrapped native token by\n" +
+			"default), best output first. With --pools, only the given pools are compared.",
+		Example: "  cli route --src USDC --dst DAI --amount 1000000000\n" +
+			"  cli route --src USDC --dst WETH --amount 1000000000 --pools 0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc,0x397ff1542f962076d0bfe58ea045ffa2d347aca0",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validate(&req); err != nil {
+				return describeError(err)
+			}
+			for _, pool := range pools {
+				if !utils.IsValidEthereumAddress(pool) {
+					return fmt.Errorf("invalid pool address %q", pool)
+				}
+			}
+
+			return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+				view, err := findRoutes(ctx, s, &req, pools, via, directOnly)
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd.OutOrStdout(), view, func(t *tableWriter) {
+					t.row("block", view.BlockNumber)
+					t.row("src amount", view.SrcAmount)
+					t.blank()
+					t.row("PATH", "DEXES", "POOLS", "DST AMOUNT", "ERROR")
+					for _, route := range append(view.Routes, view.Failed...) {
+						code := ""
+						if route.Error != nil {
+							code = route.Error.ErrorCode
+						}
+						t.row(strings.Join(route.Path, " > "), strings.Join(route.Dexes, " > "),
+							strings.Join(route.Pools, " > "), route.DstAmount, code)
+					}
+				})
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&req.Src, "src", "", "source token address or symbol")
+	flags.StringVar(&req.Dst, "dst", "", "destination token address or symbol")
+	flags.StringVar(&req.SrcAmount, "amount", "", "source amount in token units")
+	flags.StringSliceVar(&pools, "pools", nil, "only compare these pools between src and dst")
+	flags.StringVar(&via, "via", "", "intermediate token of two-hop routes (default the wrapped native token)")
+	flags.BoolVar(&directOnly, "direct", false, "only compare single-pool routes")
+	cmd.MarkFlagsMutuallyExclusive("pools", "via")
+	return cmd
+}
+
+// findRoutes quotes every direct route in one batch, then the second hop of every
+// two-hop route whose first hop succeeded with its output, all at the session block
+func findRoutes(ctx context.Context, s *session, req *models.EstimateRequest, pools []string, via string, directOnly bool) (*routesView, error) {
+	var direct []models.EstimateRequest
+	var directDexes []string
+	if len(pools) > 0 {
+		for _, pool := range pools {
+			direct = append(direct, models.EstimateRequest{Pool: pool, Src: req.Src, Dst: req.Dst, SrcAmount: req.SrcAmount})
+			directDexes = append(directDexes, "")
+		}
+	} else {
+		for _, fork := range s.chain.Config.AllForks() {
+			direct = append(direct, models.EstimateRequest{Src: req.Src, Dst: req.Dst, SrcAmount: req.SrcAmount, Dex: fork.Name})
+			directDexes = append(directDexes, fork.Name)
+		}
+	}
+	if len(direct) == 0 {
+		return nil, fmt.Errorf("%s has no V2 deployment to derive pairs from, pass --pools", s.chain.Config.Name)
+	}
+
+	var firstHops []models.EstimateRequest
+	if len(pools) == 0 && !directOnly {
+		if via == "" {
+			via = s.chain.Config.WrappedNative
+		}
+		if twoHops(s, req, via) {
+			for _, fork := range s.chain.Config.AllForks() {
+				firstHops = append(firstHops, models.EstimateRequest{Src: req.Src, Dst: via, SrcAmount: req.SrcAmount, Dex: fork.Name})
+			}
+		}
+	}
+
+	first, err := s.chain.Uniswap.EstimateBatch(ctx, append(direct, firstHops...))
+	if err != nil {
+		return nil, err
+	}
+
+	view := &routesView{BlockNumber: s.block, SrcAmount: req.SrcAmount, Routes: []routeView{}}
+	for i, result := range first.Results[:len(direct)] {
+		route := routeView{Path: []string{req.Src, req.Dst}, Dexes: nonEmpty(directDexes[i])}
+		addHop(&route, direct[i].Pool, result)
+		view.add(route)
+	}
+
+	// Second hops, from the output of each first hop through the pair of every deployment
+	var secondHops []models.EstimateRequest
+	var secondFrom []int
+	for i, result := range first.Results[len(direct):] {
+		if result.Error != nil {
+			route := routeView{Path: []string{req.Src, via}, Dexes: []string{firstHops[i].Dex}}
+			addHop(&route, "", result)
+			view.add(route)
+			continue
+		}
+		for _, fork := range s.chain.Config.AllForks() {
+			secondHops = append(secondHops, models.EstimateRequest{Src: via, Dst: req.Dst, SrcAmount: result.Result.DstAmount, Dex: fork.Name})
+			secondFrom = append(secondFrom, i)
+		}
+	}
+	if len(secondHops) > 0 {
+		second, err := s.chain.Uniswap.EstimateBatch(ctx, secondHops)
+		if err != nil {
+			return nil, err
+		}
+		for j, result := range second.Results {
+			i := secondFrom[j]
+			route := routeView{Path: []string{req.Src, via, req.Dst}, Dexes: []string{firstHops[i].Dex, secondHops[j].Dex}}
+			addHop(&route, "", first.Results[len(direct)+i])
+			addHop(&route, "", result)
+			view.add(route)
+		}
+	}
+
+	sort.SliceStable(view.Routes, func(i, j int) bool {
+		a, _ := new(big.Int).SetString(view.Routes[i].DstAmount, 10)
+		b, _ := new(big.Int).SetString(view.Routes[j].DstAmount, 10)
+		return a.Cmp(b) > 0
+	})
+	return view, nil
+}
+
+// twoHops reports whether via is a token distinct from src and dst
+func twoHops(s *session, req *models.EstimateRequest, via string) bool {
+	viaAddr, err := resolveToken(s, via)
+	if err != nil {
+		return false
+	}
+	for _, token := range []string{req.Src, req.Dst} {
+		address, err := resolveToken(s, token)
+		if err != nil {
+			return false
+		}
+		if utils.IsNativeToken(address) {
+			address = s.chain.Config.WrappedNative
+		}
+		if address == viaAddr {
+			return false
+		}
+	}
+	return true
+}
+
+// addHop appends the pool and the output of a quoted hop to a route, or its error
+func addHop(route *routeView, pool string, result models.BatchEstimateResult) {
+	if result.Error != nil {
+		route.Error = result.Error
+		route.DstAmount = ""
+		return
+	}
+	if result.Result.Pool != "" {
+		pool = result.Result.Pool
+	}
+	route.Pools = append(route.Pools, utils.NormalizeAddress(pool))
+	route.DstAmount = result.Result.DstAmount
+}
+
+// add files a route under Routes, or under Failed when one of its hops failed
+func (v *routesView) add(route routeView) {
+	if route.Error != nil {
+		v.Failed = append(v.Failed, route)
+		return
+	}
+	v.Routes = append(v.Routes, route)
+}
+
+func nonEmpty(values ...string) []string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
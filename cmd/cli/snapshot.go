@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export and inspect snapshot files",
+		Long: "Snapshots hold the state of a set of pools at one block. The server serves them\n" +
+			"with --snapshot without any RPC node, and so does this tool.",
+	}
+	cmd.AddCommand(newSnapshotExportCommand(opts), newSnapshotShowCommand(opts))
+	return cmd
+}
+
+func newSnapshotExportCommand(opts *options) *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:     "export [pool...]",
+		Short:   "Write the state of pools at --block, or the latest block, to a snapshot file",
+		Example: "  cli snapshot export 0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852 --out snapshot.json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.snapshot != "" {
+				return errors.New("snapshot export reads a live node, drop --snapshot")
+			}
+			for _, pool := range args {
+				if !utils.IsValidEthereumAddress(pool) {
+					return fmt.Errorf("invalid pool address %q", pool)
+				}
+			}
+
+			return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+				pools := args
+				if len(pools) == 0 {
+					pools = s.chain.Config.ArbitragePools
+				}
+				if len(pools) == 0 {
+					return fmt.Errorf("no pools to export: pass them or set %s_ARBITRAGE_POOLS", strings.ToUpper(s.chain.Config.Name))
+				}
+
+				snapshot, err := services.ExportSnapshot(ctx, s.cfg, s.chain.Config, s.chain.Blockchain.Client(), pools)
+				if err != nil {
+					return err
+				}
+				if err := snapshot.Write(out); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d pools, %d tokens and %d calls of %s at block %d to %s\n",
+					len(snapshot.Pools), len(snapshot.Tokens), len(snapshot.Calls), s.chain.Config.Name, snapshot.Block.Number, out)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&out, "out", "snapshot.json", "snapshot file to write")
+	return cmd
+}
+
+func newSnapshotShowCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <file>",
+		Short: "Show the chain, block, pools and tokens of a snapshot file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshot, err := services.LoadSnapshot(args[0])
+			if err != nil {
+				return err
+			}
+
+			// The calls are only meant for replay
+			summary := *snapshot
+			summary.Calls = nil
+			summary.Code = nil
+			return opts.print(cmd.OutOrStdout(), summary, func(t *tableWriter) {
+				symbols := make(map[string]string, len(snapshot.Tokens))
+				for _, token := range snapshot.Tokens {
+					symbols[token.Address] = token.Symbol
+				}
+
+				t.row("chain", fmt.Sprintf("%s (%d)", snapshot.Chain.Name, snapshot.Chain.ChainID))
+				t.row("block", snapshot.Block.Number)
+				t.row("calls", len(snapshot.Calls))
+				t.blank()
+				t.row("POOL", "TYPE", "TOKENS", "RESERVES")
+				for _, pool := range snapshot.Pools {
+					tokens := make([]string, len(pool.Tokens))
+					for i, token := range pool.Tokens {
+						tokens[i] = token
+						if symbols[token] != "" {
+							tokens[i] = symbols[token]
+						}
+					}
+					t.row(pool.Address, pool.PoolType, strings.Join(tokens, " / "), strings.Join(pool.Reserves, " / "))
+				}
+				t.blank()
+				t.row("TOKEN", "SYMBOL", "NAME", "DECIMALS")
+				for _, token := range snapshot.Tokens {
+					t.row(token.Address, token.Symbol, token.Name, token.Decimals)
+				}
+			})
+		},
+	}
+}
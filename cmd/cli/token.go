@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// tokenView is the metadata of one token as printed by token and reserves
+type tokenView struct {
+	Address           string `json:"address"`
+	Symbol            string `json:"symbol,omitempty"`
+	Name              string `json:"name,omitempty"`
+	Decimals          uint8  `json:"decimals"`
+	MetadataAvailable bool   `json:"metadata_available"`
+	TransferTaxBps    uint32 `json:"transfer_tax_bps,omitempty"`
+	Rebasing          bool   `json:"rebasing,omitempty"`
+	List              string `json:"list,omitempty"` // token list having the token
+}
+
+// label returns the symbol of the token, or its address when it has none
+func (t tokenView) label() string {
+	if t.Symbol != "" {
+		return t.Symbol
+	}
+	return t.Address
+}
+
+// tokensView is the output of token
+type tokensView struct {
+	BlockNumber uint64      `json:"block_number"`
+	Tokens      []tokenView `json:"tokens"`
+}
+
+func newTokenCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "token <address|symbol>...",
+		Short: "Show the metadata of tokens read on chain",
+		Long: "Show the decimals, symbol and name of tokens as read on chain, whether they tax\n" +
+			"transfers or rebase, and the token list having them. Symbols are resolved with\n" +
+			"the token lists of TOKEN_LISTS.",
+		Example: "  cli token USDT 0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, token := range args {
+				if !utils.IsTokenSymbol(token) && !utils.IsValidEthereumAddress(token) {
+					return fmt.Errorf("invalid token %q, expected an address or a symbol", token)
+				}
+			}
+
+			return opts.withSession(cmd, func(ctx context.Context, s *session) error {
+				tokens := make([]string, len(args))
+				for i, token := range args {
+					address, err := resolveToken(s, token)
+					if err != nil {
+						return err
+					}
+					tokens[i] = address
+				}
+
+				state, err := s.chain.Blockchain.GetBatchState(ctx, nil, tokens, new(big.Int).SetUint64(s.block))
+				if err != nil {
+					return err
+				}
+
+				view := &tokensView{BlockNumber: s.block}
+				for _, token := range tokens {
+					view.Tokens = append(view.Tokens, tokenViewOf(s, state.Tokens[token], token))
+				}
+				return opts.print(cmd.OutOrStdout(), view, func(t *tableWriter) {
+					t.row("block", view.BlockNumber)
+					t.blank()
+					t.row("ADDRESS", "SYMBOL", "NAME", "DECIMALS", "TAX BPS", "REBASING", "LIST")
+					for _, token := range view.Tokens {
+						decimals := fmt.Sprint(token.Decimals)
+						if !token.MetadataAvailable {
+							decimals = "unknown"
+						}
+						t.row(token.Address, token.Symbol, token.Name, decimals, token.TransferTaxBps, token.Rebasing, token.List)
+					}
+				})
+			})
+		},
+	}
+}
+
+// resolveToken returns the lowercase address of a token address or token list symbol
+func resolveToken(s *session, token string) (string, error) {
+	if !utils.IsTokenSymbol(token) {
+		return utils.NormalizeAddress(token), nil
+	}
+	if s.chain.Tokens == nil {
+		return "", models.ErrUnknownToken.WithDetails("No token lists are configured, use the address of " + token)
+	}
+	return s.chain.Tokens.Resolve(s.chain.Config.ChainID, token)
+}
+
+// tokenViewOf returns the metadata read for a token, completed by its token list entry
+func tokenViewOf(s *session, info *models.TokenInfo, address string) tokenView {
+	view := tokenView{Address: address}
+	if info != nil {
+		view.Symbol = info.Symbol
+		view.Name = info.Name
+		view.Decimals = info.Decimals
+		view.MetadataAvailable = info.MetadataAvailable
+		view.TransferTaxBps = info.TransferTaxBps
+		view.Rebasing = info.Rebasing
+	}
+	if s.chain.Tokens != nil {
+		if listed, ok := s.chain.Tokens.Lookup(s.chain.Config.ChainID, address); ok {
+			view.List = listed.List
+			if view.Symbol == "" {
+				view.Symbol = listed.Symbol
+			}
+		}
+	}
+	return view
+}
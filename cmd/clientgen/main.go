@@ -0,0 +1,31 @@
+// Command clientgen writes the typed Go client generated from the OpenAPI document.
+//
+// Usage: go run ./cmd/clientgen -o client/client.go
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/openapi"
+)
+
+func main() {
+	output := flag.String("o", "client/client.go", "output file")
+	packageName := flag.String("package", "client", "package name of the generated client")
+	flag.Parse()
+
+	// Handlers are never called, the route table is only used to describe the API
+	document := handlers.OpenAPIDocument(handlers.Routes(handlers.Handlers{}), "generated")
+
+	source, err := openapi.GenerateClient(document, *packageName)
+	if err != nil {
+		log.Fatalf("Failed to generate client: %v", err)
+	}
+
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *output, err)
+	}
+	log.Printf("Wrote %s", *output)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/grpcapi"
 	"uniswap-est/intrenal/handlers"
 	"uniswap-est/intrenal/services"
 
@@ -24,24 +30,78 @@ func main() {
 		log.Printf("Warning: .env file not found: %v", err)
 	}
 
-	// Load configuration
-	cfg, err := config.LoadConfig()
+	snapshots := flag.String("snapshot", "", "serve from these comma separated snapshot files instead of RPC nodes, see cli snapshot export")
+	flag.Parse()
+
+	// One-shot commands moved to the command-line tool
+	if flag.NArg() > 0 {
+		log.Fatalf("Unknown argument %q: the server takes no commands, run them with go run ./cmd/cli %s", flag.Arg(0), strings.Join(flag.Args(), " "))
+	}
+
+	// Load configuration; serving snapshots needs no RPC URL
+	loadConfig := config.LoadConfig
+	if *snapshots != "" {
+		loadConfig = config.LoadOfflineConfig
+	}
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize services
-	blockchainService, err := services.NewBlockchainService(cfg)
-	if err != nil {
-		log.Fatalf("Failed to initialize blockchain service: %v", err)
+	// Token lists, shared by every chain; symbols resolve only when lists are configured
+	var tokens *services.TokenRegistry
+	if len(cfg.TokenLists) > 0 {
+		if tokens, err = services.NewTokenRegistry(cfg.TokenLists); err != nil {
+			log.Fatalf("Failed to load token lists: %v", err)
+		}
+	}
+
+	// Initialize services, one set per chain
+	var chains *services.Chains
+	if *snapshots != "" {
+		var cleanup func()
+		chains, cleanup, err = openSnapshots(cfg, strings.Split(*snapshots, ","), tokens)
+		if err != nil {
+			log.Fatalf("Failed to load snapshots: %v", err)
+		}
+		defer cleanup()
+	} else {
+		chains, err = connectChains(cfg, tokens)
+		if err != nil {
+			log.Fatalf("Failed to initialize blockchain service: %v", err)
+		}
+	}
+	defer chains.Close()
+
+	// Audit log of the quotes served to clients, shared by every chain
+	var audit *services.AuditLog
+	if cfg.AuditDB != "" {
+		audit, err = services.OpenAuditLog(cfg.AuditDB, cfg.AuditBatchSize, cfg.AuditQueueSize, cfg.AuditRetention)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer audit.Close() // after stopBackground, once the queued records are written
+		for _, chain := range chains.All() {
+			chain.Uniswap.SetAuditLog(audit)
+		}
+		log.Printf("Audit log: %s", cfg.AuditDB)
 	}
-	defer blockchainService.Close()
 
-	uniswapService := services.NewUniswapService(blockchainService)
+	// Background workers run until shutdown: router cross-check sampling and quote
+	// streams refreshed on every new block, on every chain, and audit log writes
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
 
-	// Initialize handlers
-	estimateHandler := handlers.NewEstimateHandler(uniswapService, cfg.RequestTimeout)
-	healthHandler := handlers.NewHealthHandler(version)
+	for _, chain := range chains.All() {
+		go chain.Verifier.Run(backgroundCtx, cfg.RequestTimeout)
+		go chain.Quotes.Run(backgroundCtx)
+	}
+	if tokens != nil {
+		go tokens.Run(backgroundCtx, cfg.TokenListReloadInterval)
+	}
+	if audit != nil {
+		go audit.Run(backgroundCtx, cfg.AuditFlushInterval)
+	}
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
@@ -52,8 +112,11 @@ func main() {
 
 	// Middleware
 	app.Use(recover.New())
+	// Every response carries a new X-Request-ID, which audited quotes are recorded under
+	app.Use(handlers.RequestID())
+	// The URL keeps the query string, so that the log can be replayed by cli loadtest
 	app.Use(logger.New(logger.Config{
-		Format: "${time} ${status} - ${method} ${path} (${latency})\n",
+		Format: "${time} ${status} - ${method} ${url} (${latency})\n",
 	}))
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
@@ -61,7 +124,23 @@ func main() {
 	}))
 
 	// Routes
-	setupRoutes(app, estimateHandler, healthHandler)
+	setupRoutes(app, handlers.NewHandlers(chains, audit, cfg, version))
+
+	// gRPC server on its own port, sharing the same services
+	grpcServer, grpcHealth := grpcapi.NewGRPCServer(grpcapi.NewServer(chains, cfg.RequestTimeout, cfg.MaxBatchSize))
+	if cfg.GRPCPort != 0 {
+		listener, err := net.Listen("tcp", cfg.GetGRPCAddress())
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+
+		go func() {
+			log.Printf("gRPC server starting on %s", cfg.GetGRPCAddress())
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Printf("gRPC server stopped: %v", err)
+			}
+		}()
+	}
 
 	// Graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -70,6 +149,9 @@ func main() {
 	go func() {
 		<-c
 		log.Println("Gracefully shutting down...")
+		grpcHealth.Shutdown()
+		grpcServer.GracefulStop()
+		stopBackground() // ends open quote streams so Shutdown does not wait for them
 		app.Shutdown()
 	}()
 
@@ -78,54 +160,87 @@ func main() {
 	log.Printf("Server starting on http://%s", serverAddr)
 	log.Printf("Health check: http://%s/health", serverAddr)
 	log.Printf("Estimate endpoint: http://%s/estimate", serverAddr)
+	log.Printf("Quote stream: http://%s/estimate/stream", serverAddr)
+	log.Printf("OpenAPI document: http://%s/openapi.json", serverAddr)
+	log.Printf("Chains: http://%s/chains (default %s)", serverAddr, chains.All()[0].Config.Name)
 
 	if err := app.Listen(serverAddr); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
-// setupRoutes configures all application routes
-func setupRoutes(app *fiber.App, estimateHandler *handlers.EstimateHandler, healthHandler *handlers.HealthHandler) {
-	// API v1 routes
-	v1 := app.Group("/api/v1")
-
-	// Main endpoint - THE 1INCH REQUIREMENT!
-	app.Get("/estimate", estimateHandler.EstimateSwap)
-	v1.Get("/estimate", estimateHandler.EstimateSwap)
-
-	// Health endpoints
-	app.Get("/health", healthHandler.Health)
-	app.Get("/ready", healthHandler.Ready)
-	v1.Get("/health", healthHandler.Health)
-	v1.Get("/ready", healthHandler.Ready)
-
-	// Root endpoint
-	app.Get("/", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"message": "Uniswap V2 Estimator API",
-			"version": version,
-			"endpoints": map[string]string{
-				"estimate": "/estimate?pool={pool}&src={src}&dst={dst}&src_amount={amount}",
-				"health":   "/health",
-				"ready":    "/ready",
-			},
-		})
-	})
-}
+// connectChains connects to every configured chain, checking each node's chain ID,
+// and creates its services. The default chain comes first in the registry.
+func connectChains(cfg *config.Config, tokens *services.TokenRegistry) (*services.Chains, error) {
+	defaultChain := cfg.DefaultChainConfig()
+	ordered := []*config.ChainConfig{defaultChain}
+	for _, chain := range cfg.Chains {
+		if chain != defaultChain {
+			ordered = append(ordered, chain)
+		}
+	}
 
-// customErrorHandler handles application-level errors
-func customErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-	message := "Internal Server Error"
+	cache := services.NewReserveCache(cfg.HistoryCacheDir)
+
+	chains := make([]*services.Chain, 0, len(ordered))
+	for _, chainConfig := range ordered {
+		blockchainService, err := services.NewBlockchainService(cfg, chainConfig)
+		if err != nil {
+			services.NewChains(chains...).Close()
+			return nil, err
+		}
+		log.Printf("Chain %s (ID %d) connected", chainConfig.Name, chainConfig.ChainID)
+		chains = append(chains, services.NewChain(cfg, blockchainService, cache, tokens))
+	}
+	return services.NewChains(chains...), nil
+}
 
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-		message = e.Message
+// openSnapshots serves each snapshot file as a chain, the first one being the default,
+// with the chain settings it was exported with. Snapshot state is the same at every
+// block, so history is cached in a temporary directory removed by cleanup instead of
+// HISTORY_CACHE_DIR, and router cross-checks, which need a node, are off.
+func openSnapshots(cfg *config.Config, paths []string, tokens *services.TokenRegistry) (*services.Chains, func(), error) {
+	cacheDir, err := os.MkdirTemp("", "uniswap-est-snapshot-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(cacheDir) }
+	cache := services.NewReserveCache(cacheDir)
+	cfg.VerifySampleRate = 0
+
+	chains := make([]*services.Chain, 0, len(paths))
+	cfg.Chains = nil
+	for _, path := range paths {
+		snapshot, err := services.LoadSnapshot(strings.TrimSpace(path))
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		chain := snapshot.ChainConfig()
+		if _, ok := cfg.Chain(chain.Name); ok {
+			cleanup()
+			return nil, nil, fmt.Errorf("snapshot %s: chain %s is in another snapshot too", path, chain.Name)
+		}
+
+		blockchainService, err := services.NewChainBlockchainService(cfg, chain, services.NewSnapshotClient(snapshot))
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		log.Printf("Chain %s (ID %d) served from %s at block %d", chain.Name, chain.ChainID, path, snapshot.Block.Number)
+		cfg.Chains = append(cfg.Chains, chain)
+		chains = append(chains, services.NewChain(cfg, blockchainService, cache, tokens))
 	}
+	cfg.DefaultChain = cfg.Chains[0].Name
+	return services.NewChains(chains...), cleanup, nil
+}
 
-	return c.Status(code).JSON(fiber.Map{
-		"error":   true,
-		"code":    code,
-		"message": message,
-	})
+// setupRoutes configures all application routes
+func setupRoutes(app *fiber.App, h handlers.Handlers) {
+	handlers.SetupRoutes(app, handlers.Routes(h), version)
+}
+
+// customErrorHandler handles application-level errors with the same JSON shape as the handlers
+func customErrorHandler(c *fiber.Ctx, err error) error {
+	return handlers.WriteError(c, err)
 }
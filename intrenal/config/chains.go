@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ChainConfig describes one chain and the Uniswap V2 deployment, or fork, quoted on it
+type ChainConfig struct {
+	Name           string
+	ChainID        uint64
+	RPCURLs        []string // tried in order; the first answering with ChainID is used
+	Dex            string   // name of the primary V2 deployment
+	FactoryAddress string
+	InitCodeHash   string // keccak256 of the pair creation code, "" when pairs cannot be derived
+	RouterAddress  string
+	FeeBps         uint32       // V2 swap fee in basis points, 30 = 0.3%
+	WrappedNative  string       // lowercase address of WETH, WBNB, WMATIC...
+	Forks          []ForkConfig // other V2 deployments whose pairs are recognised as canonical
+	ArbitragePools []string
+
+	// Token transfer behaviour of tokens on this chain (keys are lowercase addresses)
+	FeeOnTransferTokens map[string]uint32 // transfer tax in basis points
+	RebasingTokens      map[string]bool
+
+	// Pool validation, see Config.ValidatePools
+	TrustedFactories []string // lowercase factories trusted besides the V2 deployments, checked with getPair or isPair
+	PairCodeHashes   []string // lowercase keccak256 of accepted pair runtime code; empty accepts any
+}
+
+// ForkConfig is a Uniswap V2 deployment whose pair addresses are derived with CREATE2
+type ForkConfig struct {
+	Name           string `json:"name"`
+	FactoryAddress string `json:"factory_address"`
+	InitCodeHash   string `json:"init_code_hash"`
+	FeeBps         uint32 `json:"fee_bps,omitempty"` // swap fee of its pairs, 0 = the chain's FeeBps
+}
+
+// Init code hashes of the pair contracts of the forks in the presets
+const (
+	uniswapInitCodeHash     = "0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"
+	pancakeswapInitCodeHash = "0x00fb7f630766e6a796048ea87d01acd3068e8ff67d078148a3fa3f4a84f69bd5"
+)
+
+// defaultFeeBps is the Uniswap V2 swap fee, charged by most forks
+const defaultFeeBps = 30
+
+// knownChains are the presets of the chains supported out of the box; every field can
+// be overridden with the chain's environment variables
+var knownChains = map[string]ChainConfig{
+	"ethereum": {
+		ChainID:        1,
+		Dex:            "uniswap",
+		FactoryAddress: "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f",
+		InitCodeHash:   uniswapInitCodeHash,
+		RouterAddress:  "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+		FeeBps:         30,
+		WrappedNative:  "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+	},
+	"arbitrum": { // set ARBITRUM_INIT_CODE_HASH to derive pairs
+		ChainID:        42161,
+		Dex:            "sushiswap",
+		FactoryAddress: "0xc35DADB65012eC5796536bD9864eD8773aBc74C4",
+		RouterAddress:  "0x1b02dA8Cb0d097eB8D57A175b88c7D8b47997506",
+		FeeBps:         30,
+		WrappedNative:  "0x82af49447d8a07e3bd95bd0d56f35241523fbab1",
+	},
+	"base": {
+		ChainID:        8453,
+		Dex:            "uniswap",
+		FactoryAddress: "0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6",
+		InitCodeHash:   uniswapInitCodeHash,
+		RouterAddress:  "0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24",
+		FeeBps:         30,
+		WrappedNative:  "0x4200000000000000000000000000000000000006",
+	},
+	"bsc": {
+		ChainID:        56,
+		Dex:            "pancakeswap",
+		FactoryAddress: "0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73",
+		InitCodeHash:   pancakeswapInitCodeHash,
+		RouterAddress:  "0x10ED43C718714eb63d5aA57B78B54704E256024E",
+		FeeBps:         25,
+		WrappedNative:  "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c",
+	},
+	"polygon": {
+		ChainID:        137,
+		Dex:            "quickswap",
+		FactoryAddress: "0x5757371414417b8C6CAad45bAeF941aBc7d3Ab32",
+		InitCodeHash:   uniswapInitCodeHash, // QuickSwap deploys the Uniswap V2 pair unchanged
+		RouterAddress:  "0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff",
+		FeeBps:         30,
+		WrappedNative:  "0x0d500b1d8e8ef31e21c99d1db9a6444d3adf1270",
+	},
+}
+
+// KnownChain returns a copy of the preset of a chain supported out of the box
+func KnownChain(name string) (*ChainConfig, bool) {
+	preset, ok := knownChains[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	preset.Name = strings.ToLower(name)
+	preset.Forks = append([]ForkConfig(nil), preset.Forks...) // callers may modify their copy
+	return &preset, true
+}
+
+// loadChains reads the chains listed in CHAINS. Every setting of a chain comes from
+// <NAME>_RPC_URL, <NAME>_CHAIN_ID, <NAME>_FACTORY_ADDRESS, <NAME>_ROUTER_ADDRESS,
+// <NAME>_FEE_BPS, <NAME>_WRAPPED_NATIVE, <NAME>_DEX, <NAME>_INIT_CODE_HASH, <NAME>_FORKS,
+// <NAME>_TRUSTED_FACTORIES, <NAME>_PAIR_CODE_HASHES and <NAME>_ARBITRAGE_POOLS on top of
+// its preset; chains without a preset must set the
+// chain ID, factory, router and wrapped native token. The RPC URL is optional unless
+// requireRPC is set.
+func loadChains(requireRPC bool) ([]*ChainConfig, error) {
+	names := splitList(getEnvOrDefault("CHAINS", "ethereum"))
+	if len(names) == 0 {
+		return nil, fmt.Errorf("CHAINS must list at least one chain")
+	}
+
+	chains := make([]*ChainConfig, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		chain, err := loadChain(strings.ToLower(name), requireRPC)
+		if err != nil {
+			return nil, err
+		}
+		if seen[chain.Name] {
+			return nil, fmt.Errorf("CHAINS lists %s twice", chain.Name)
+		}
+		seen[chain.Name] = true
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+// loadChain reads the settings of one chain
+func loadChain(name string, requireRPC bool) (*ChainConfig, error) {
+	prefix := strings.ToUpper(name) + "_"
+	chain, known := KnownChain(name)
+	if !known {
+		chain = &ChainConfig{Name: name, FeeBps: defaultFeeBps}
+	}
+
+	chain.RPCURLs = splitList(os.Getenv(prefix + "RPC_URL"))
+	if len(chain.RPCURLs) == 0 && requireRPC {
+		return nil, fmt.Errorf("%sRPC_URL is required", prefix)
+	}
+
+	if value := os.Getenv(prefix + "CHAIN_ID"); value != "" {
+		chainID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || chainID == 0 {
+			return nil, fmt.Errorf("invalid %sCHAIN_ID: must be a positive integer", prefix)
+		}
+		chain.ChainID = chainID
+	}
+	chain.FactoryAddress = getEnvOrDefault(prefix+"FACTORY_ADDRESS", chain.FactoryAddress)
+	chain.RouterAddress = getEnvOrDefault(prefix+"ROUTER_ADDRESS", chain.RouterAddress)
+	chain.WrappedNative = strings.ToLower(getEnvOrDefault(prefix+"WRAPPED_NATIVE", chain.WrappedNative))
+	if chain.Dex == "" {
+		chain.Dex = "uniswap"
+	}
+	chain.Dex = strings.ToLower(getEnvOrDefault(prefix+"DEX", chain.Dex))
+	chain.InitCodeHash = strings.ToLower(getEnvOrDefault(prefix+"INIT_CODE_HASH", chain.InitCodeHash))
+	if chain.InitCodeHash != "" && !isHash(chain.InitCodeHash) {
+		return nil, fmt.Errorf("invalid %sINIT_CODE_HASH: must be 0x followed by 64 hex characters", prefix)
+	}
+
+	// Format: <NAME>_FORKS=name:factory:inithash[:feebps],name:factory:inithash[:feebps]
+	if value := os.Getenv(prefix + "FORKS"); value != "" {
+		forks, err := parseForks(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %sFORKS: %v", prefix, err)
+		}
+		chain.Forks = forks
+	}
+
+	for _, factory := range splitList(os.Getenv(prefix + "TRUSTED_FACTORIES")) {
+		if !isAddress(factory) {
+			return nil, fmt.Errorf("invalid %sTRUSTED_FACTORIES: %q is not an address", prefix, factory)
+		}
+		chain.TrustedFactories = append(chain.TrustedFactories, strings.ToLower(factory))
+	}
+	for _, hash := range splitList(os.Getenv(prefix + "PAIR_CODE_HASHES")) {
+		if !isHash(hash) {
+			return nil, fmt.Errorf("invalid %sPAIR_CODE_HASHES: %q must be 0x followed by 64 hex characters", prefix, hash)
+		}
+		chain.PairCodeHashes = append(chain.PairCodeHashes, strings.ToLower(hash))
+	}
+
+	if value := os.Getenv(prefix + "FEE_BPS"); value != "" {
+		feeBps, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || feeBps >= 10000 {
+			return nil, fmt.Errorf("invalid %sFEE_BPS: must be below 10000", prefix)
+		}
+		chain.FeeBps = uint32(feeBps)
+	}
+
+	for _, pool := range splitList(os.Getenv(prefix + "ARBITRAGE_POOLS")) {
+		chain.ArbitragePools = append(chain.ArbitragePools, strings.ToLower(pool))
+	}
+
+	// Token transfer behaviour - format: <NAME>_FEE_ON_TRANSFER_TOKENS=0xtoken:bps,0xtoken:bps
+	feeOnTransferTokens, err := parseTokenTaxes(os.Getenv(prefix + "FEE_ON_TRANSFER_TOKENS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %sFEE_ON_TRANSFER_TOKENS: %v", prefix, err)
+	}
+	chain.FeeOnTransferTokens = feeOnTransferTokens
+	chain.RebasingTokens = parseTokenSet(os.Getenv(prefix + "REBASING_TOKENS"))
+
+	if chain.ChainID == 0 || chain.FactoryAddress == "" || chain.RouterAddress == "" || chain.WrappedNative == "" {
+		return nil, fmt.Errorf("chain %s has no preset: set %sCHAIN_ID, %sFACTORY_ADDRESS, %sROUTER_ADDRESS and %sWRAPPED_NATIVE",
+			name, prefix, prefix, prefix, prefix)
+	}
+	return chain, nil
+}
+
+// parseForks parses a comma separated list of name:factory:inithash entries, each
+// optionally followed by the swap fee of the fork's pairs as :feebps
+func parseForks(value string) ([]ForkConfig, error) {
+	var forks []ForkConfig
+	for _, entry := range splitList(value) {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || !isAddress(parts[1]) || !isHash(parts[2]) {
+			return nil, fmt.Errorf("entry %q must be name:factory:inithash or name:factory:inithash:feebps", entry)
+		}
+		fork := ForkConfig{
+			Name:           strings.ToLower(parts[0]),
+			FactoryAddress: parts[1],
+			InitCodeHash:   strings.ToLower(parts[2]),
+		}
+		if len(parts) == 4 {
+			feeBps, err := strconv.ParseUint(parts[3], 10, 32)
+			if err != nil || feeBps == 0 || feeBps >= 10000 {
+				return nil, fmt.Errorf("entry %q has invalid feebps: must be between 1 and 9999", entry)
+			}
+			fork.FeeBps = uint32(feeBps)
+		}
+		forks = append(forks, fork)
+	}
+	return forks, nil
+}
+
+// isAddress reports whether value is 0x followed by 40 hex characters
+func isAddress(value string) bool {
+	return isHex(value, 40)
+}
+
+// isHash reports whether value is 0x followed by 64 hex characters
+func isHash(value string) bool {
+	return isHex(value, 64)
+}
+
+func isHex(value string, digits int) bool {
+	hex, ok := strings.CutPrefix(value, "0x")
+	if !ok || len(hex) != digits {
+		return false
+	}
+	for _, c := range strings.ToLower(hex) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// AllForks returns the V2 deployments of the chain whose pairs can be derived, the
+// primary one first
+func (c *ChainConfig) AllForks() []ForkConfig {
+	var forks []ForkConfig
+	if c.InitCodeHash != "" {
+		forks = append(forks, ForkConfig{Name: c.Dex, FactoryAddress: c.FactoryAddress, InitCodeHash: c.InitCodeHash, FeeBps: c.FeeBps})
+	}
+	return append(forks, c.Forks...)
+}
+
+// Fork returns the V2 deployment with the given name, or the first of AllForks when
+// name is empty
+func (c *ChainConfig) Fork(name string) (ForkConfig, bool) {
+	forks := c.AllForks()
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, fork := range forks {
+		if name == "" || fork.Name == name {
+			return fork, true
+		}
+	}
+	return ForkConfig{}, false
+}
+
+// Chain returns the configured chain with the given name or decimal chain ID
+func (c *Config) Chain(nameOrID string) (*ChainConfig, bool) {
+	nameOrID = strings.ToLower(strings.TrimSpace(nameOrID))
+	for _, chain := range c.Chains {
+		if chain.Name == nameOrID || strconv.FormatUint(chain.ChainID, 10) == nameOrID {
+			return chain, true
+		}
+	}
+	return nil, false
+}
+
+// DefaultChainConfig returns the chain quoted when a request names none. A config
+// without chains, as built in tests, defaults to the Ethereum preset.
+func (c *Config) DefaultChainConfig() *ChainConfig {
+	if chain, ok := c.Chain(c.DefaultChain); ok {
+		return chain
+	}
+	if len(c.Chains) > 0 {
+		return c.Chains[0]
+	}
+	chain, _ := KnownChain("ethereum")
+	return chain
+}
@@ -4,19 +4,61 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// minAuditAdminTokenLength keeps AUDIT_ADMIN_TOKEN from being guessable
+const minAuditAdminTokenLength = 16
+
 type Config struct {
-	Host string
-	Port int
+	Host     string
+	Port     int
+	GRPCPort int // 0 disables the gRPC server
+
+	// Chains, in CHAINS order; requests without a chain go to DefaultChain
+	Chains       []*ChainConfig
+	DefaultChain string
+
+	// On-chain verification
+	VerifySampleRate float64
+
+	// Pool validation: V2 and Solidly pairs must be deployed by a trusted factory of their
+	// chain; other pool types are refused
+	ValidatePools bool
 
-	// Blockchain Config
-	EthereumRPCURL string
+	// Token lists resolving symbols, highest priority first, and how often their files
+	// are checked for changes
+	TokenLists              []string
+	TokenListReloadInterval time.Duration
 
 	// Performance settings
 	RequestTimeout time.Duration
 	MaxConnections int
+	MaxBatchSize   int
+
+	// Quote streams
+	StreamPollInterval     time.Duration
+	MaxStreamSubscriptions int // per connection
+
+	// Uniswap V3 pools
+	V3TickWords int // tick bitmap words read on each side of the current tick
+
+	// Historical quotes
+	HistoryCacheDir  string
+	MaxHistoryPoints int
+
+	// Arbitrage detection (the tracked pairs are per chain)
+	MaxArbitrageHops  int
+	MaxArbitragePools int
+
+	// Audit log of served quotes; an empty AuditDB disables it
+	AuditDB            string
+	AuditBatchSize     int
+	AuditFlushInterval time.Duration
+	AuditQueueSize     int
+	AuditRetention     time.Duration // 0 keeps records forever
+	AuditAdminToken    string        // bearer token of audit lookups; empty refuses them
 
 	// Environment
 	Environment string
@@ -24,6 +66,16 @@ type Config struct {
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
+	return load(true)
+}
+
+// LoadOfflineConfig loads configuration like LoadConfig, but chains need no RPC URL;
+// used when serving from snapshot files
+func LoadOfflineConfig() (*Config, error) {
+	return load(false)
+}
+
+func load(requireRPC bool) (*Config, error) {
 	config := &Config{}
 
 	// Server settings
@@ -35,11 +87,64 @@ func LoadConfig() (*Config, error) {
 	}
 	config.Port = port
 
-	// Blockchain settings - REQUIRED for 1inch assignment
-	config.EthereumRPCURL = os.Getenv("ETHEREUM_RPC_URL")
-	if config.EthereumRPCURL == "" {
-		return nil, fmt.Errorf("ETHEREUM_RPC_URL is required")
+	grpcPort, err := strconv.Atoi(getEnvOrDefault("GRPC_PORT", "1338"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GRPC_PORT: %v", err)
 	}
+	config.GRPCPort = grpcPort
+
+	// Blockchain settings - ETHEREUM_RPC_URL is REQUIRED for 1inch assignment
+	config.Chains, err = loadChains(requireRPC)
+	if err != nil {
+		return nil, err
+	}
+	config.DefaultChain = strings.ToLower(getEnvOrDefault("DEFAULT_CHAIN", config.Chains[0].Name))
+	if _, ok := config.Chain(config.DefaultChain); !ok {
+		return nil, fmt.Errorf("invalid DEFAULT_CHAIN: %s is not listed in CHAINS", config.DefaultChain)
+	}
+
+	// Legacy Ethereum-only settings, overridden by their per-chain names
+	if ethereum, ok := config.Chain("ethereum"); ok {
+		if os.Getenv("ETHEREUM_ROUTER_ADDRESS") == "" {
+			ethereum.RouterAddress = getEnvOrDefault("UNISWAP_ROUTER_ADDRESS", ethereum.RouterAddress)
+		}
+		if len(ethereum.ArbitragePools) == 0 {
+			for _, pool := range splitList(os.Getenv("ARBITRAGE_POOLS")) {
+				ethereum.ArbitragePools = append(ethereum.ArbitragePools, strings.ToLower(pool))
+			}
+		}
+		if os.Getenv("ETHEREUM_FEE_ON_TRANSFER_TOKENS") == "" {
+			ethereum.FeeOnTransferTokens, err = parseTokenTaxes(os.Getenv("FEE_ON_TRANSFER_TOKENS"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid FEE_ON_TRANSFER_TOKENS: %v", err)
+			}
+		}
+		if os.Getenv("ETHEREUM_REBASING_TOKENS") == "" {
+			ethereum.RebasingTokens = parseTokenSet(os.Getenv("REBASING_TOKENS"))
+		}
+	}
+
+	// Verification settings - fraction of quotes re-checked against the router
+	sampleRate, err := strconv.ParseFloat(getEnvOrDefault("VERIFY_SAMPLE_RATE", "0"), 64)
+	if err != nil || sampleRate < 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("invalid VERIFY_SAMPLE_RATE: must be between 0 and 1")
+	}
+	config.VerifySampleRate = sampleRate
+
+	// Pool validation - trusted factories and pair code hashes are per chain
+	validatePools, err := strconv.ParseBool(getEnvOrDefault("VALIDATE_POOLS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VALIDATE_POOLS: must be true or false")
+	}
+	config.ValidatePools = validatePools
+
+	// Token list files in the tokenlists.org format - format: TOKEN_LISTS=lists/default.json,lists/extra.json
+	config.TokenLists = splitList(os.Getenv("TOKEN_LISTS"))
+	reloadInterval, _ := strconv.Atoi(getEnvOrDefault("TOKEN_LIST_RELOAD_INTERVAL", "30"))
+	if reloadInterval <= 0 {
+		return nil, fmt.Errorf("invalid TOKEN_LIST_RELOAD_INTERVAL: must be a positive number of seconds")
+	}
+	config.TokenListReloadInterval = time.Duration(reloadInterval) * time.Second
 
 	// Performance settings
 	timeout, _ := strconv.Atoi(getEnvOrDefault("REQUEST_TIMEOUT", "10"))
@@ -48,6 +153,79 @@ func LoadConfig() (*Config, error) {
 	maxConn, _ := strconv.Atoi(getEnvOrDefault("MAX_CONNECTIONS", "100"))
 	config.MaxConnections = maxConn
 
+	maxBatch, _ := strconv.Atoi(getEnvOrDefault("MAX_BATCH_SIZE", "50"))
+	if maxBatch <= 0 {
+		return nil, fmt.Errorf("invalid MAX_BATCH_SIZE: must be positive")
+	}
+	config.MaxBatchSize = maxBatch
+
+	// Stream settings
+	pollInterval, _ := strconv.Atoi(getEnvOrDefault("STREAM_POLL_INTERVAL", "2"))
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid STREAM_POLL_INTERVAL: must be a positive number of seconds")
+	}
+	config.StreamPollInterval = time.Duration(pollInterval) * time.Second
+
+	maxSubscriptions, _ := strconv.Atoi(getEnvOrDefault("MAX_STREAM_SUBSCRIPTIONS", "10"))
+	if maxSubscriptions <= 0 {
+		return nil, fmt.Errorf("invalid MAX_STREAM_SUBSCRIPTIONS: must be positive")
+	}
+	config.MaxStreamSubscriptions = maxSubscriptions
+
+	// Uniswap V3 settings - each bitmap word covers 256 * tickSpacing ticks
+	v3TickWords, _ := strconv.Atoi(getEnvOrDefault("V3_TICK_WORDS", "2"))
+	if v3TickWords < 0 {
+		return nil, fmt.Errorf("invalid V3_TICK_WORDS: must not be negative")
+	}
+	config.V3TickWords = v3TickWords
+
+	// History settings
+	config.HistoryCacheDir = getEnvOrDefault("HISTORY_CACHE_DIR", ".cache/history")
+	maxPoints, _ := strconv.Atoi(getEnvOrDefault("MAX_HISTORY_POINTS", "1000"))
+	if maxPoints <= 0 {
+		return nil, fmt.Errorf("invalid MAX_HISTORY_POINTS: must be positive")
+	}
+	config.MaxHistoryPoints = maxPoints
+
+	// Arbitrage settings - pools per chain, format: <NAME>_ARBITRAGE_POOLS=0xpair,0xpair
+	maxHops, _ := strconv.Atoi(getEnvOrDefault("MAX_ARBITRAGE_HOPS", "3"))
+	if maxHops < 2 {
+		return nil, fmt.Errorf("invalid MAX_ARBITRAGE_HOPS: a cycle needs at least 2 hops")
+	}
+	config.MaxArbitrageHops = maxHops
+	maxArbitragePools, _ := strconv.Atoi(getEnvOrDefault("MAX_ARBITRAGE_POOLS", "100"))
+	if maxArbitragePools <= 0 {
+		return nil, fmt.Errorf("invalid MAX_ARBITRAGE_POOLS: must be positive")
+	}
+	config.MaxArbitragePools = maxArbitragePools
+
+	// Audit settings - quotes are queued and written to SQLite in batches
+	config.AuditDB = os.Getenv("AUDIT_DB")
+	auditBatchSize, _ := strconv.Atoi(getEnvOrDefault("AUDIT_BATCH_SIZE", "100"))
+	if auditBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid AUDIT_BATCH_SIZE: must be positive")
+	}
+	config.AuditBatchSize = auditBatchSize
+	auditFlushInterval, _ := strconv.Atoi(getEnvOrDefault("AUDIT_FLUSH_INTERVAL", "1"))
+	if auditFlushInterval <= 0 {
+		return nil, fmt.Errorf("invalid AUDIT_FLUSH_INTERVAL: must be a positive number of seconds")
+	}
+	config.AuditFlushInterval = time.Duration(auditFlushInterval) * time.Second
+	auditQueueSize, _ := strconv.Atoi(getEnvOrDefault("AUDIT_QUEUE_SIZE", "10000"))
+	if auditQueueSize <= 0 {
+		return nil, fmt.Errorf("invalid AUDIT_QUEUE_SIZE: must be positive")
+	}
+	config.AuditQueueSize = auditQueueSize
+	auditRetentionDays, _ := strconv.Atoi(getEnvOrDefault("AUDIT_RETENTION_DAYS", "0"))
+	if auditRetentionDays < 0 {
+		return nil, fmt.Errorf("invalid AUDIT_RETENTION_DAYS: must not be negative")
+	}
+	config.AuditRetention = time.Duration(auditRetentionDays) * 24 * time.Hour
+	config.AuditAdminToken = os.Getenv("AUDIT_ADMIN_TOKEN")
+	if config.AuditAdminToken != "" && len(config.AuditAdminToken) < minAuditAdminTokenLength {
+		return nil, fmt.Errorf("invalid AUDIT_ADMIN_TOKEN: must be at least %d characters", minAuditAdminTokenLength)
+	}
+
 	// Environment
 	config.Environment = getEnvOrDefault("ENV", "development")
 
@@ -62,6 +240,44 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// parseTokenTaxes parses a comma separated list of address:bps pairs, taxes below 100%
+func parseTokenTaxes(value string) (map[string]uint32, error) {
+	taxes := make(map[string]uint32)
+	for _, entry := range splitList(value) {
+		address, bpsStr, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("entry %q must be address:bps", entry)
+		}
+		// A token taxing all of every transfer can never be swapped
+		bps, err := strconv.ParseUint(bpsStr, 10, 32)
+		if err != nil || bps >= 10000 {
+			return nil, fmt.Errorf("entry %q has invalid bps: must be below 10000", entry)
+		}
+		taxes[strings.ToLower(address)] = uint32(bps)
+	}
+	return taxes, nil
+}
+
+// parseTokenSet parses a comma separated list of addresses
+func parseTokenSet(value string) map[string]bool {
+	set := make(map[string]bool)
+	for _, address := range splitList(value) {
+		set[strings.ToLower(address)] = true
+	}
+	return set
+}
+
+// splitList splits a comma separated value, skipping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // IsProduction checks if running in production mode
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
@@ -70,4 +286,9 @@ func (c *Config) IsProduction() bool {
 // GetServerAddress returns the full server address
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
-}
\ No newline at end of file
+}
+
+// GetGRPCAddress returns the gRPC server address
+func (c *Config) GetGRPCAddress() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.GRPCPort)
+}
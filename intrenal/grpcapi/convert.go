@@ -0,0 +1,87 @@
+package grpcapi
+
+import (
+	"uniswap-est/intrenal/models"
+	estimatorv1 "uniswap-est/proto/estimator/v1"
+)
+
+// fromProtoRequest converts a protobuf request into the shared request model
+func fromProtoRequest(in *estimatorv1.EstimateSwapRequest) *models.EstimateRequest {
+	return &models.EstimateRequest{
+		Pool:      in.GetPool(),
+		Src:       in.GetSrc(),
+		Dst:       in.GetDst(),
+		SrcAmount: in.GetSrcAmount(),
+		Verify:    in.GetVerify(),
+		Dex:       in.GetDex(),
+	}
+}
+
+// toProtoResponse converts the shared response model into protobuf
+func toProtoResponse(response *models.EstimateResponse) *estimatorv1.EstimateSwapResponse {
+	out := &estimatorv1.EstimateSwapResponse{
+		DstAmount:         response.DstAmount,
+		PoolType:          response.PoolType,
+		FeeOnTransfer:     response.FeeOnTransfer,
+		SrcTransferTaxBps: response.SrcTransferTaxBps,
+		DstTransferTaxBps: response.DstTransferTaxBps,
+		Rebasing:          response.Rebasing,
+		SrcNative:         response.SrcNative,
+		DstNative:         response.DstNative,
+		WrappedNative:     response.WrappedNative,
+		SrcToken:          toProtoToken(response.SrcToken),
+		DstToken:          toProtoToken(response.DstToken),
+		Pool:              response.Pool,
+		Warnings:          response.Warnings,
+	}
+
+	if v := response.Verification; v != nil {
+		out.Verification = &estimatorv1.VerificationResult{
+			BlockNumber:    v.BlockNumber,
+			OffchainAmount: v.OffChainAmount,
+			OnchainAmount:  v.OnChainAmount,
+			Match:          v.Match,
+			Status:         v.Status,
+			Reason:         v.Reason,
+		}
+	}
+
+	return out
+}
+
+// toProtoToken converts a token list entry into protobuf; nil stays nil
+func toProtoToken(token *models.ListedToken) *estimatorv1.ListedToken {
+	if token == nil {
+		return nil
+	}
+	return &estimatorv1.ListedToken{
+		ChainId:  token.ChainID,
+		Address:  token.Address,
+		Symbol:   token.Symbol,
+		Name:     token.Name,
+		Decimals: uint32(token.Decimals),
+		LogoUri:  token.LogoURI,
+		List:     token.List,
+	}
+}
+
+// toProtoError converts an error into the protobuf mirror of models.APIError
+func toProtoError(err error) *estimatorv1.Error {
+	apiErr := models.AsAPIError(err)
+
+	out := &estimatorv1.Error{
+		Code:      int32(apiErr.Code),
+		ErrorCode: apiErr.ErrorCode,
+		Message:   apiErr.Message,
+		Details:   apiErr.Details,
+	}
+	for _, field := range apiErr.Fields {
+		out.Fields = append(out.Fields, &estimatorv1.FieldError{
+			Field:   field.Field,
+			Rule:    field.Rule,
+			Message: field.Message,
+		})
+	}
+
+	return out
+}
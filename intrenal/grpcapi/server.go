@@ -0,0 +1,290 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+	estimatorv1 "uniswap-est/proto/estimator/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain identifies our error codes in google.rpc.ErrorInfo details
+const errorDomain = "uniswap-estimator"
+
+// chainMetadataKey is the request metadata naming the chain of a call, like the chain
+// parameter of the REST API; calls without it go to the default chain
+const chainMetadataKey = "chain"
+
+// requestIDMetadataKey is the request metadata carrying the client's request ID, and
+// the response header metadata echoing it; quotes are recorded in the audit log under it
+const requestIDMetadataKey = "x-request-id"
+
+// maxRequestIDLength bounds the request IDs kept from clients, like the REST API
+const maxRequestIDLength = 128
+
+// Server implements EstimatorService on top of the UniswapService of each chain shared
+// with the REST API
+type Server struct {
+	estimatorv1.UnimplementedEstimatorServiceServer
+
+	chains         *services.Chains
+	requestTimeout time.Duration
+	maxBatchSize   int
+}
+
+// NewServer creates a new gRPC estimator service
+func NewServer(chains *services.Chains, timeout time.Duration, maxBatchSize int) *Server {
+	return &Server{
+		chains:         chains,
+		requestTimeout: timeout,
+		maxBatchSize:   maxBatchSize,
+	}
+}
+
+// NewGRPCServer creates a grpc.Server with the estimator and the standard
+// grpc.health.v1 health service registered. The returned health server is used
+// to report NOT_SERVING during shutdown.
+func NewGRPCServer(server *Server) (*grpc.Server, *health.Server) {
+	grpcServer := grpc.NewServer()
+	estimatorv1.RegisterEstimatorServiceServer(grpcServer, server)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(estimatorv1.EstimatorService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	return grpcServer, healthServer
+}
+
+// EstimateSwap quotes one swap
+func (s *Server) EstimateSwap(ctx context.Context, in *estimatorv1.EstimateSwapRequest) (*estimatorv1.EstimateSwapResponse, error) {
+	response, err := s.estimate(services.WithRequester(ctx, requester(ctx)), in)
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return response, nil
+}
+
+// estimate validates and quotes one request, returning API errors unconverted
+func (s *Server) estimate(ctx context.Context, in *estimatorv1.EstimateSwapRequest) (*estimatorv1.EstimateSwapResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	req := fromProtoRequest(in)
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	chain, err := s.chain(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := chain.Uniswap.EstimateSwap(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoResponse(response), nil
+}
+
+// EstimateBatch quotes several swaps at one block with per-item errors
+func (s *Server) EstimateBatch(ctx context.Context, in *estimatorv1.EstimateBatchRequest) (*estimatorv1.EstimateBatchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	if len(in.Requests) == 0 || len(in.Requests) > s.maxBatchSize {
+		return nil, ToStatus(models.ErrInvalidBatchSize)
+	}
+
+	chain, err := s.chain(ctx)
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+
+	// Invalid items get their error right away; only valid ones are quoted
+	results := make([]*estimatorv1.EstimateBatchResult, len(in.Requests))
+	valid := make([]models.EstimateRequest, 0, len(in.Requests))
+	validIndex := make([]int, 0, len(in.Requests))
+
+	for i, item := range in.Requests {
+		req := fromProtoRequest(item)
+		if err := validateRequest(req); err != nil {
+			results[i] = &estimatorv1.EstimateBatchResult{
+				Index:   int32(i),
+				Outcome: &estimatorv1.EstimateBatchResult_Error{Error: toProtoError(err)},
+			}
+			continue
+		}
+		valid = append(valid, *req)
+		validIndex = append(validIndex, i)
+	}
+
+	response := &estimatorv1.EstimateBatchResponse{Results: results}
+
+	if len(valid) > 0 {
+		client := requester(ctx)
+		client.Items = validIndex
+		quoted, err := chain.Uniswap.EstimateBatch(services.WithRequester(ctx, client), valid)
+		if err != nil {
+			return nil, ToStatus(err)
+		}
+
+		response.BlockNumber = quoted.BlockNumber
+		for i, result := range quoted.Results {
+			item := &estimatorv1.EstimateBatchResult{Index: int32(validIndex[i])}
+			if result.Error != nil {
+				item.Outcome = &estimatorv1.EstimateBatchResult_Error{Error: toProtoError(result.Error)}
+			} else {
+				item.Outcome = &estimatorv1.EstimateBatchResult_Result{Result: toProtoResponse(result.Result)}
+			}
+			results[validIndex[i]] = item
+		}
+	}
+
+	return response, nil
+}
+
+// EstimateStream answers each request on the stream in order. Quote failures are sent
+// as stream items; only transport failures end the stream. Quotes are recorded under
+// the request ID of the stream, numbered by message.
+func (s *Server) EstimateStream(stream estimatorv1.EstimatorService_EstimateStreamServer) error {
+	client := requester(stream.Context())
+	for n := 0; ; n++ {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		client.Items = []int{n}
+		response, err := s.estimate(services.WithRequester(stream.Context(), client), in.GetRequest())
+
+		out := &estimatorv1.EstimateStreamResponse{}
+		if err != nil {
+			out.Outcome = &estimatorv1.EstimateStreamResponse_Error{Error: toProtoError(err)}
+		} else {
+			out.Outcome = &estimatorv1.EstimateStreamResponse_Result{Result: response}
+		}
+
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+}
+
+// chain returns the chain named by the call metadata, or the default chain
+func (s *Server) chain(ctx context.Context) (*services.Chain, error) {
+	var name string
+	if values := metadata.ValueFromIncomingContext(ctx, chainMetadataKey); len(values) > 0 {
+		name = values[0]
+	}
+	return s.chains.Get(name)
+}
+
+// requester identifies the client of a call for the audit log. Quotes are recorded under
+// a new request ID, echoed in the x-request-id response header metadata; the client's
+// own x-request-id metadata is only kept alongside.
+func requester(ctx context.Context) services.Requester {
+	var clientRequestID, userAgent string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDMetadataKey); len(values) > 0 {
+		clientRequestID = values[0]
+	}
+	if len(clientRequestID) > maxRequestIDLength {
+		clientRequestID = ""
+	}
+	requestID := services.NewRequestID()
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, requestID))
+
+	if values := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(values) > 0 {
+		userAgent = values[0]
+	}
+
+	var address string
+	if p, ok := peer.FromContext(ctx); ok {
+		address = p.Addr.String()
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+	}
+
+	return services.Requester{
+		RequestID:       requestID,
+		ClientRequestID: clientRequestID,
+		Address:         address,
+		UserAgent:       userAgent,
+		Transport:       services.TransportGRPC,
+	}
+}
+
+// validateRequest validates the request against its `validate` tags, like the REST handler
+func validateRequest(req *models.EstimateRequest) error {
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return models.NewValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// ToStatus converts an error into a gRPC status. models.APIError codes are mapped to
+// gRPC codes and the stable error code is attached as google.rpc.ErrorInfo.
+func ToStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	apiErr := models.AsAPIError(err)
+
+	st := status.New(grpcCode(apiErr.Code), apiErr.Message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   apiErr.ErrorCode,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"details": apiErr.Details},
+	}}
+
+	if len(apiErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(apiErr.Fields))
+		for i, field := range apiErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode maps the HTTP status of an API error to the closest gRPC code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ArbitrageHandler serves arbitrage cycles over tracked pools
+type ArbitrageHandler struct {
+	chains         *services.Chains
+	requestTimeout time.Duration
+}
+
+// NewArbitrageHandler creates a new arbitrage handler
+func NewArbitrageHandler(chains *services.Chains, timeout time.Duration) *ArbitrageHandler {
+	return &ArbitrageHandler{
+		chains:         chains,
+		requestTimeout: timeout,
+	}
+}
+
+// Arbitrage handles GET /arbitrage endpoint
+// Example: GET /arbitrage?pools=0x...,0x...,0x...&start=0x...&max_hops=3
+func (h *ArbitrageHandler) Arbitrage(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	req := &models.ArbitrageRequest{
+		Pools:   c.Query("pools"),
+		Start:   c.Query("start"),
+		MaxHops: c.Query("max_hops"),
+		Chain:   c.Query("chain"),
+	}
+
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return WriteError(c, models.NewValidationError(fieldErrors))
+	}
+
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	response, err := chain.Arbitrage.FindArbitrage(ctx, req)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(response)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRequestIDLength bounds the request IDs taken from clients; longer ones are not kept
+const maxRequestIDLength = 128
+
+// requestIDLocal is the fiber local holding the request ID assigned by RequestID
+const requestIDLocal = "requestid"
+
+// AuditHandler serves the audit log of served quotes
+type AuditHandler struct {
+	audit          *services.AuditLog // nil when AUDIT_DB is not set
+	adminToken     string             // empty refuses lookups
+	requestTimeout time.Duration
+}
+
+// NewAuditHandler creates a new audit handler; audit may be nil. Lookups need
+// adminToken as a bearer token and are refused when it is empty.
+func NewAuditHandler(audit *services.AuditLog, adminToken string, timeout time.Duration) *AuditHandler {
+	return &AuditHandler{
+		audit:          audit,
+		adminToken:     adminToken,
+		requestTimeout: timeout,
+	}
+}
+
+// Lookup handles GET /audit/quotes/:request_id endpoint; it needs the admin token, as
+// records hold the address and user agent of clients
+// Example: GET /audit/quotes/3f9a1c0e5b7d42e8a6c4b2d0e8f6a4c2 (Authorization: Bearer <token>)
+func (h *AuditHandler) Lookup(c *fiber.Ctx) error {
+	if !h.authorized(c) {
+		return WriteError(c, models.ErrUnauthorized)
+	}
+	if h.audit == nil {
+		return WriteError(c, models.ErrAuditDisabled)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	requestID := c.Params("request_id")
+	quotes, err := h.audit.Lookup(ctx, requestID)
+	if err != nil {
+		return WriteError(c, err)
+	}
+	if len(quotes) == 0 {
+		return WriteError(c, models.ErrQuoteNotFound)
+	}
+
+	return c.Status(http.StatusOK).JSON(models.AuditLookupResponse{RequestID: requestID, Quotes: quotes})
+}
+
+// Stats handles GET /audit/stats endpoint
+func (h *AuditHandler) Stats(c *fiber.Ctx) error {
+	if h.audit == nil {
+		return WriteError(c, models.ErrAuditDisabled)
+	}
+	return c.Status(http.StatusOK).JSON(h.audit.Stats())
+}
+
+// authorized reports whether the request carries the admin token as bearer token
+func (h *AuditHandler) authorized(c *fiber.Ctx) bool {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	return ok && h.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+// RequestID gives every request a new request ID, returned in the X-Request-ID response
+// header. It replaces any X-Request-ID the client sent, so that clients cannot choose
+// the ID their quotes are recorded under.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := services.NewRequestID()
+		c.Locals(requestIDLocal, requestID)
+		c.Set(fiber.HeaderXRequestID, requestID)
+		return c.Next()
+	}
+}
+
+// requester identifies the client of a request for the audit log. Quotes are recorded
+// under the request ID of the RequestID middleware, or a new one when it is not used,
+// echoed in the X-Request-ID response header; the client's own X-Request-ID is only
+// kept alongside.
+func requester(c *fiber.Ctx) services.Requester {
+	requestID, _ := c.Locals(requestIDLocal).(string)
+	if requestID == "" {
+		requestID = services.NewRequestID()
+		c.Set(fiber.HeaderXRequestID, requestID)
+	}
+	clientRequestID := c.Get(fiber.HeaderXRequestID)
+	if len(clientRequestID) > maxRequestIDLength {
+		clientRequestID = ""
+	}
+
+	return services.Requester{
+		RequestID:       requestID,
+		ClientRequestID: clientRequestID,
+		Address:         c.IP(),
+		UserAgent:       c.Get(fiber.HeaderUserAgent),
+		Transport:       services.TransportHTTP,
+	}
+}
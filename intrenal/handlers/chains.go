@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+	"uniswap-est/intrenal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ChainsHandler lists the chains requests can name
+type ChainsHandler struct {
+	chains *services.Chains
+}
+
+// NewChainsHandler creates a new chains handler
+func NewChainsHandler(chains *services.Chains) *ChainsHandler {
+	return &ChainsHandler{chains: chains}
+}
+
+// List handles GET /chains endpoint
+func (h *ChainsHandler) List(c *fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(h.chains.Info())
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"uniswap-est/intrenal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WriteError writes err as a models.APIError JSON response.
+// Every error response of the API goes through here so they all share one shape.
+func WriteError(c *fiber.Ctx, err error) error {
+	apiErr := ToAPIError(err)
+
+	if apiErr.Code >= http.StatusInternalServerError {
+		log.Printf("%s %s failed: %v", c.Method(), c.Path(), err)
+	}
+
+	return c.Status(apiErr.Code).JSON(apiErr)
+}
+
+// ToAPIError converts any error into a models.APIError with models.AsAPIError, mapping
+// the errors Fiber returns for unknown routes and unreadable requests to their codes
+func ToAPIError(err error) *models.APIError {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		switch fiberErr.Code {
+		case fiber.StatusNotFound:
+			return models.ErrRouteNotFound.WithCause(err)
+		case fiber.StatusMethodNotAllowed:
+			return models.ErrMethodNotAllowed.WithCause(err)
+		case fiber.StatusRequestTimeout:
+			return models.ErrRequestTimeout.WithCause(err)
+		}
+		if fiberErr.Code < http.StatusInternalServerError {
+			return models.ErrInvalidRequestBody.WithCause(err).WithDetails(fiberErr.Message)
+		}
+	}
+
+	return models.AsAPIError(err)
+}
+
+// ErrorCatalogueResponse lists every error the API can return
+type ErrorCatalogueResponse struct {
+	Errors []models.APIError `json:"errors"`
+}
+
+// ListErrors handles GET /errors endpoint - the catalogue of error codes
+func ListErrors(c *fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(ErrorCatalogueResponse{
+		Errors: models.ErrorCatalogue(),
+	})
+}
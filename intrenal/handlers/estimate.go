@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 	"uniswap-est/intrenal/models"
@@ -12,40 +13,47 @@ import (
 )
 
 type EstimateHandler struct {
-	uniswapService *services.UniswapService
+	chains         *services.Chains
 	requestTimeout time.Duration
+	maxBatchSize   int
 }
 
 // NewEstimateHandler creates a new estimate handler
-func NewEstimateHandler(uniswapService *services.UniswapService, timeout time.Duration) *EstimateHandler {
+func NewEstimateHandler(chains *services.Chains, timeout time.Duration, maxBatchSize int) *EstimateHandler {
 	return &EstimateHandler{
-		uniswapService: uniswapService,
+		chains:         chains,
 		requestTimeout: timeout,
+		maxBatchSize:   maxBatchSize,
 	}
 }
 
-// EstimateSwap handles POST /estimate endpoint
-// Example: GET /estimate?pool=0x...&src=0x...&dst=0x...&src_amount=1000000
+// EstimateSwap handles GET and POST /estimate endpoint
+// Example: GET /estimate?pool=0x...&src=0x...&dst=0x...&src_amount=1000000&chain=arbitrum
+// Example: POST /estimate {"pool":"0x...","src":"0x...","dst":"0x...","src_amount":"1000000"}
 func (h *EstimateHandler) EstimateSwap(c *fiber.Ctx) error {
 	// Create request context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
 	defer cancel()
 
-	// Parse query parameters into request model
-	req := &models.EstimateRequest{
-		Pool:      c.Query("pool"),
-		Src:       c.Query("src"),
-		Dst:       c.Query("dst"),
-		SrcAmount: c.Query("src_amount"),
+	// Parse query parameters or JSON body into request model
+	req, err := h.parseRequest(c)
+	if err != nil {
+		return h.handleError(c, err)
 	}
 
 	// Validate request
-	if err := h.validateRequest(req); err != nil {
+	if err := validateRequest(req); err != nil {
+		return h.handleError(c, err)
+	}
+
+	// Pick the services of the requested chain
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
 		return h.handleError(c, err)
 	}
 
-	// Process the estimate
-	response, err := h.uniswapService.EstimateSwap(ctx, req)
+	// Process the estimate, recorded in the audit log for this client
+	response, err := chain.Uniswap.EstimateSwap(services.WithRequester(ctx, requester(c)), req)
 	if err != nil {
 		return h.handleError(c, err)
 	}
@@ -54,68 +62,124 @@ func (h *EstimateHandler) EstimateSwap(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(response)
 }
 
-// validateRequest validates the incoming request
-func (h *EstimateHandler) validateRequest(req *models.EstimateRequest) error {
-	// Validate pool address
-	if !utils.IsValidEthereumAddress(req.Pool) {
-		return models.ErrInvalidPoolAddress
+// EstimateBatch handles POST /estimate/batch endpoint
+// Example: POST /estimate/batch {"chain":"bsc","requests":[{"pool":"0x...","src":"0x...","dst":"0x...","src_amount":"1000000"}]}
+func (h *EstimateHandler) EstimateBatch(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	var batch models.BatchEstimateRequest
+	if err := c.BodyParser(&batch); err != nil {
+		return h.handleError(c, models.ErrInvalidRequestBody.WithCause(err))
+	}
+
+	if len(batch.Requests) == 0 || len(batch.Requests) > h.maxBatchSize {
+		return h.handleError(c, models.ErrInvalidBatchSize.WithDetails(
+			fmt.Sprintf("A batch must contain between 1 and %d requests", h.maxBatchSize),
+		))
+	}
+
+	chain, err := h.chains.Get(batch.Chain)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	// Invalid items get their error right away; only valid ones are quoted
+	results := make([]models.BatchEstimateResult, len(batch.Requests))
+	valid := make([]models.EstimateRequest, 0, len(batch.Requests))
+	validIndex := make([]int, 0, len(batch.Requests))
+
+	for i := range batch.Requests {
+		results[i].Index = i
+		if err := validateRequest(&batch.Requests[i]); err != nil {
+			results[i].Error = ToAPIError(err)
+			continue
+		}
+		if err := sameChain(h.chains, chain, batch.Requests[i].Chain); err != nil {
+			results[i].Error = ToAPIError(err)
+			continue
+		}
+		valid = append(valid, batch.Requests[i])
+		validIndex = append(validIndex, i)
 	}
 
-	// Validate source token address
-	if !utils.IsValidEthereumAddress(req.Src) {
-		return models.NewAPIError(
-			http.StatusBadRequest,
-			"Invalid source token address",
-			"Source token address must be a valid Ethereum address",
-		)
+	response := &models.BatchEstimateResponse{Results: results}
+
+	if len(valid) > 0 {
+		client := requester(c)
+		client.Items = validIndex
+		quoted, err := chain.Uniswap.EstimateBatch(services.WithRequester(ctx, client), valid)
+		if err != nil {
+			return h.handleError(c, err)
+		}
+
+		response.BlockNumber = quoted.BlockNumber
+		for i, result := range quoted.Results {
+			result.Index = validIndex[i]
+			results[validIndex[i]] = result
+		}
 	}
 
-	// Validate destination token address
-	if !utils.IsValidEthereumAddress(req.Dst) {
-		return models.NewAPIError(
-			http.StatusBadRequest,
-			"Invalid destination token address",
-			"Destination token address must be a valid Ethereum address",
-		)
+	return c.Status(http.StatusOK).JSON(response)
+}
+
+// parseRequest reads the request model from the JSON body (POST) or query parameters (GET)
+func (h *EstimateHandler) parseRequest(c *fiber.Ctx) (*models.EstimateRequest, error) {
+	req := &models.EstimateRequest{}
+
+	if c.Method() == fiber.MethodPost {
+		if err := c.BodyParser(req); err != nil {
+			return nil, models.ErrInvalidRequestBody.WithCause(err)
+		}
+		return req, nil
 	}
 
-	// Validate amount
-	if !utils.IsValidAmount(req.SrcAmount) {
-		return models.ErrInvalidAmount
+	req.Pool = c.Query("pool")
+	req.Src = c.Query("src")
+	req.Dst = c.Query("dst")
+	req.SrcAmount = c.Query("src_amount")
+	req.Verify = c.QueryBool("verify")
+	req.Chain = c.Query("chain")
+	req.Dex = c.Query("dex")
+	return req, nil
+}
+
+// VerificationStats handles GET /verify/stats endpoint
+// Example: GET /verify/stats?chain=bsc
+func (h *EstimateHandler) VerificationStats(c *fiber.Ctx) error {
+	chain, err := h.chains.Get(c.Query("chain"))
+	if err != nil {
+		return h.handleError(c, err)
 	}
+	return c.Status(http.StatusOK).JSON(chain.Uniswap.VerificationStats())
+}
 
-	// Ensure src and dst are different
-	if utils.NormalizeAddress(req.Src) == utils.NormalizeAddress(req.Dst) {
-		return models.NewAPIError(
-			http.StatusBadRequest,
-			"Invalid token pair",
-			"Source and destination tokens must be different",
-		)
+// sameChain checks that an item of a batch or stream names no chain or the chain of
+// the whole request
+func sameChain(chains *services.Chains, chain *services.Chain, itemChain string) error {
+	if itemChain == "" {
+		return nil
+	}
+	item, err := chains.Get(itemChain)
+	if err != nil {
+		return err
 	}
+	if item != chain {
+		return models.ErrUnsupportedChain.WithDetails(
+			fmt.Sprintf("The request is on %s; an item cannot name %s", chain.Config.Name, item.Config.Name))
+	}
+	return nil
+}
 
+// validateRequest validates the incoming request against its `validate` tags
+func validateRequest(req *models.EstimateRequest) error {
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return models.NewValidationError(fieldErrors)
+	}
 	return nil
 }
 
 // handleError handles API errors consistently
 func (h *EstimateHandler) handleError(c *fiber.Ctx, err error) error {
-	// Check if it's our custom API error
-	if apiErr, ok := err.(*models.APIError); ok {
-		return c.Status(apiErr.Code).JSON(apiErr)
-	}
-
-	// Handle context timeout
-	if err == context.DeadlineExceeded {
-		return c.Status(http.StatusRequestTimeout).JSON(&models.APIError{
-			Code:    http.StatusRequestTimeout,
-			Message: "Request timeout",
-			Details: "The request took too long to process",
-		})
-	}
-
-	// Generic server error
-	return c.Status(http.StatusInternalServerError).JSON(&models.APIError{
-		Code:    http.StatusInternalServerError,
-		Message: "Internal server error",
-		Details: "An unexpected error occurred",
-	})
+	return WriteError(c, err)
 }
@@ -28,6 +28,12 @@ type HealthResponse struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// ReadyResponse represents readiness check response
+type ReadyResponse struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // Health handles GET /health endpoint
 func (h *HealthHandler) Health(c *fiber.Ctx) error {
 	uptime := time.Since(h.startTime)
@@ -47,8 +53,8 @@ func (h *HealthHandler) Ready(c *fiber.Ctx) error {
 	// You can add more sophisticated readiness checks here
 	// For example: check blockchain connection, database connection, etc.
 	
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"status": "ready",
-		"timestamp": time.Now().UTC(),
+	return c.Status(http.StatusOK).JSON(ReadyResponse{
+		Status:    "ready",
+		Timestamp: time.Now().UTC(),
 	})
 }
\ No newline at end of file
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HistoryHandler serves quotes over past block ranges
+type HistoryHandler struct {
+	chains         *services.Chains
+	requestTimeout time.Duration
+}
+
+// NewHistoryHandler creates a new history handler
+func NewHistoryHandler(chains *services.Chains, timeout time.Duration) *HistoryHandler {
+	return &HistoryHandler{
+		chains:         chains,
+		requestTimeout: timeout,
+	}
+}
+
+// History handles GET /history endpoint
+// Example: GET /history?pool=0x...&src=0x...&dst=0x...&src_amount=1000000&from_block=19000000&to_block=19001000&step=100
+func (h *HistoryHandler) History(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	req := &models.HistoryRequest{
+		Pool:      c.Query("pool"),
+		Src:       c.Query("src"),
+		Dst:       c.Query("dst"),
+		SrcAmount: c.Query("src_amount"),
+		FromBlock: c.Query("from_block"),
+		ToBlock:   c.Query("to_block"),
+		Step:      c.Query("step"),
+		Chain:     c.Query("chain"),
+	}
+
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return WriteError(c, models.NewValidationError(fieldErrors))
+	}
+
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	response, err := chain.History.EstimateHistory(ctx, req)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(response)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LiquidityHandler serves LP token estimates for deposits and withdrawals
+type LiquidityHandler struct {
+	chains         *services.Chains
+	requestTimeout time.Duration
+}
+
+// NewLiquidityHandler creates a new liquidity handler
+func NewLiquidityHandler(chains *services.Chains, timeout time.Duration) *LiquidityHandler {
+	return &LiquidityHandler{
+		chains:         chains,
+		requestTimeout: timeout,
+	}
+}
+
+// AddLiquidity handles GET /liquidity/add endpoint
+// Example: GET /liquidity/add?pool=0x...&token_a=0x...&token_b=0x...&amount_a=1000000&amount_b=500000000000000
+func (h *LiquidityHandler) AddLiquidity(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	req := &models.AddLiquidityRequest{
+		Pool:    c.Query("pool"),
+		TokenA:  c.Query("token_a"),
+		TokenB:  c.Query("token_b"),
+		AmountA: c.Query("amount_a"),
+		AmountB: c.Query("amount_b"),
+		Chain:   c.Query("chain"),
+	}
+
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return WriteError(c, models.NewValidationError(fieldErrors))
+	}
+
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	response, err := chain.Liquidity.AddLiquidity(ctx, req)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(response)
+}
+
+// RemoveLiquidity handles GET /liquidity/remove endpoint
+// Example: GET /liquidity/remove?pool=0x...&liquidity=1000000000000
+func (h *LiquidityHandler) RemoveLiquidity(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	req := &models.RemoveLiquidityRequest{
+		Pool:      c.Query("pool"),
+		Liquidity: c.Query("liquidity"),
+		Chain:     c.Query("chain"),
+	}
+
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return WriteError(c, models.NewValidationError(fieldErrors))
+	}
+
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	response, err := chain.Liquidity.RemoveLiquidity(ctx, req)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(response)
+}
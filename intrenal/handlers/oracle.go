@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OracleHandler serves time-weighted average prices
+type OracleHandler struct {
+	chains         *services.Chains
+	requestTimeout time.Duration
+}
+
+// NewOracleHandler creates a new oracle handler
+func NewOracleHandler(chains *services.Chains, timeout time.Duration) *OracleHandler {
+	return &OracleHandler{
+		chains:         chains,
+		requestTimeout: timeout,
+	}
+}
+
+// TWAP handles GET /twap endpoint
+// Example: GET /twap?pool=0x...&src=0x...&dst=0x...&from_block=19000000&to_block=19000300
+// Example: GET /twap?pool=0x...&src=0x...&dst=0x...&window=3600&src_amount=1000000
+func (h *OracleHandler) TWAP(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	defer cancel()
+
+	req := &models.TwapRequest{
+		Pool:      c.Query("pool"),
+		Src:       c.Query("src"),
+		Dst:       c.Query("dst"),
+		SrcAmount: c.Query("src_amount"),
+		FromBlock: c.Query("from_block"),
+		ToBlock:   c.Query("to_block"),
+		Window:    c.Query("window"),
+		Chain:     c.Query("chain"),
+	}
+
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return WriteError(c, models.NewValidationError(fieldErrors))
+	}
+
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	response, err := chain.Oracle.TWAP(ctx, req)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(response)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/openapi"
+	"uniswap-est/intrenal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIPrefix is the versioned prefix every route is also served under
+const APIPrefix = "/api/v1"
+
+// Handlers groups the handlers the routes are bound to
+type Handlers struct {
+	Estimate  *EstimateHandler
+	Stream    *StreamHandler
+	History   *HistoryHandler
+	Oracle    *OracleHandler
+	Liquidity *LiquidityHandler
+	Arbitrage *ArbitrageHandler
+	Chains    *ChainsHandler
+	Tokens    *TokensHandler
+	Audit     *AuditHandler
+	Health    *HealthHandler
+}
+
+// NewHandlers creates every handler on top of the chain registry, with the limits of cfg.
+// audit is nil when served quotes are not recorded.
+func NewHandlers(chains *services.Chains, audit *services.AuditLog, cfg *config.Config, version string) Handlers {
+	return Handlers{
+		Estimate:  NewEstimateHandler(chains, cfg.RequestTimeout, cfg.MaxBatchSize),
+		Stream:    NewStreamHandler(chains, cfg.MaxStreamSubscriptions),
+		History:   NewHistoryHandler(chains, cfg.RequestTimeout),
+		Oracle:    NewOracleHandler(chains, cfg.RequestTimeout),
+		Liquidity: NewLiquidityHandler(chains, cfg.RequestTimeout),
+		Arbitrage: NewArbitrageHandler(chains, cfg.RequestTimeout),
+		Chains:    NewChainsHandler(chains),
+		Tokens:    NewTokensHandler(chains),
+		Audit:     NewAuditHandler(audit, cfg.AuditAdminToken, cfg.RequestTimeout),
+		Health:    NewHealthHandler(version),
+	}
+}
+
+// Route binds an endpoint description to its handler.
+// The same table registers the routes and generates the OpenAPI document.
+type Route struct {
+	openapi.Endpoint
+	Handler fiber.Handler
+}
+
+// Routes returns every API route
+func Routes(h Handlers) []Route {
+	return []Route{
+		// Main endpoint - THE 1INCH REQUIREMENT!
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/estimate", OperationID: "estimateSwap", Tag: "estimate",
+			Summary: "Estimate a swap output from query parameters",
+			Query:   models.EstimateRequest{}, Response: models.EstimateResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Estimate.EstimateSwap},
+		{openapi.Endpoint{
+			Method: fiber.MethodPost, Path: "/estimate", OperationID: "estimateSwapPost", Tag: "estimate",
+			Summary: "Estimate a swap output from a JSON body",
+			Body:    models.EstimateRequest{}, Response: models.EstimateResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Estimate.EstimateSwap},
+		{openapi.Endpoint{
+			Method: fiber.MethodPost, Path: "/estimate/batch", OperationID: "estimateBatch", Tag: "estimate",
+			Summary: "Estimate several swaps at one block with per-item errors",
+			Body:    models.BatchEstimateRequest{}, Response: models.BatchEstimateResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Estimate.EstimateBatch},
+
+		// Quote streams (Server-Sent Events)
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/estimate/stream", OperationID: "streamQuote", Tag: "estimate",
+			Summary: "Stream a quote on every new block as Server-Sent Events",
+			Query:   models.EstimateRequest{}, Response: models.QuoteUpdate{}, ContentType: "text/event-stream",
+			Errors: []int{http.StatusBadRequest},
+		}, h.Stream.Subscribe},
+		{openapi.Endpoint{
+			Method: fiber.MethodPost, Path: "/estimate/stream", OperationID: "streamQuotes", Tag: "estimate",
+			Summary: "Stream several quotes on every new block as Server-Sent Events",
+			Body:    models.QuoteSubscriptionRequest{}, Response: models.QuoteUpdate{}, ContentType: "text/event-stream",
+			Errors: []int{http.StatusBadRequest},
+		}, h.Stream.Subscribe},
+
+		// Backtesting over past blocks
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/history", OperationID: "history", Tag: "history",
+			Summary: "Reserves, spot price and quote at every step-th block of a range",
+			Query:   models.HistoryRequest{}, Response: models.HistoryResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.History.History},
+
+		// Price oracle
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/twap", OperationID: "twap", Tag: "history",
+			Summary: "Time-weighted average price between two blocks or over a time window",
+			Query:   models.TwapRequest{}, Response: models.TwapResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Oracle.TWAP},
+
+		// Liquidity positions
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/liquidity/add", OperationID: "addLiquidity", Tag: "liquidity",
+			Summary: "LP tokens minted for a deposit at the pool ratio",
+			Query:   models.AddLiquidityRequest{}, Response: models.AddLiquidityResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Liquidity.AddLiquidity},
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/liquidity/remove", OperationID: "removeLiquidity", Tag: "liquidity",
+			Summary: "Underlying token amounts for burning an LP token balance",
+			Query:   models.RemoveLiquidityRequest{}, Response: models.RemoveLiquidityResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Liquidity.RemoveLiquidity},
+
+		// Arbitrage cycles
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/arbitrage", OperationID: "arbitrage", Tag: "arbitrage",
+			Summary: "Profitable cycles over V2 pools with their optimal input",
+			Query:   models.ArbitrageRequest{}, Response: models.ArbitrageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusRequestTimeout, http.StatusServiceUnavailable},
+		}, h.Arbitrage.Arbitrage},
+
+		// Verification counters
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/verify/stats", OperationID: "verificationStats", Tag: "estimate",
+			Summary: "Router cross-check counters",
+			Query:   models.ChainRequest{}, Response: models.VerificationStats{},
+			Errors: []int{http.StatusBadRequest},
+		}, h.Estimate.VerificationStats},
+
+		// Audit log of served quotes
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/audit/quotes/:request_id", OperationID: "auditQuotes", Tag: "audit",
+			Summary:  "Quotes served under a request ID, from its X-Request-ID response header; needs the admin token",
+			Response: models.AuditLookupResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusRequestTimeout},
+		}, h.Audit.Lookup},
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/audit/stats", OperationID: "auditStats", Tag: "audit",
+			Summary:  "Audit log counters, including dropped and failed writes",
+			Response: models.AuditStats{},
+			Errors:   []int{http.StatusNotFound},
+		}, h.Audit.Stats},
+
+		// Configured chains
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/chains", OperationID: "listChains", Tag: "meta",
+			Summary:  "Chains a request can name with the chain parameter",
+			Response: models.ChainsResponse{},
+		}, h.Chains.List},
+
+		// Token lists
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/tokens/search", OperationID: "searchTokens", Tag: "meta",
+			Summary: "Search the token lists by symbol, name or address",
+			Query:   models.TokenSearchRequest{}, Response: models.TokenSearchResponse{},
+			Errors: []int{http.StatusBadRequest},
+		}, h.Tokens.Search},
+
+		// Health endpoints
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/health", OperationID: "health", Tag: "health",
+			Summary:  "Liveness check",
+			Response: HealthResponse{},
+		}, h.Health.Health},
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/ready", OperationID: "ready", Tag: "health",
+			Summary:  "Readiness check",
+			Response: ReadyResponse{},
+		}, h.Health.Ready},
+
+		// Error code catalogue
+		{openapi.Endpoint{
+			Method: fiber.MethodGet, Path: "/errors", OperationID: "listErrors", Tag: "meta",
+			Summary:  "Catalogue of error codes",
+			Response: ErrorCatalogueResponse{},
+		}, ListErrors},
+	}
+}
+
+// OpenAPIDocument generates the OpenAPI document for the routes
+func OpenAPIDocument(routes []Route, version string) *openapi.Document {
+	endpoints := make([]openapi.Endpoint, 0, len(routes)+2)
+	for _, route := range routes {
+		endpoints = append(endpoints, route.Endpoint)
+	}
+
+	// Routes added by SetupRoutes itself
+	endpoints = append(endpoints,
+		openapi.Endpoint{Method: fiber.MethodGet, Path: "/", OperationID: "index", Tag: "meta", Summary: "API overview"},
+		openapi.Endpoint{Method: fiber.MethodGet, Path: "/openapi.json", OperationID: "openAPI", Tag: "meta", Summary: "This OpenAPI document"},
+	)
+
+	return openapi.Generate(
+		openapi.Info{
+			Title:       "Uniswap Estimator API",
+			Description: "Off-chain Uniswap swap estimation from live blockchain state",
+			Version:     version,
+		},
+		[]openapi.Server{{URL: "/"}, {URL: APIPrefix}},
+		endpoints,
+		models.APIError{},
+	)
+}
+
+// SetupRoutes registers every route at the root and under APIPrefix, together with
+// the OpenAPI document at /openapi.json and an overview at /
+func SetupRoutes(app *fiber.App, routes []Route, version string) {
+	document := OpenAPIDocument(routes, version)
+
+	v1 := app.Group(APIPrefix)
+	for _, route := range routes {
+		app.Add(route.Method, route.Path, route.Handler)
+		v1.Add(route.Method, route.Path, route.Handler)
+	}
+
+	serveDocument := func(c *fiber.Ctx) error {
+		return c.Status(http.StatusOK).JSON(document)
+	}
+	app.Get("/openapi.json", serveDocument)
+	v1.Get("/openapi.json", serveDocument)
+
+	// Root endpoint
+	endpoints := endpointSummary(document, routes)
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"message":   "Uniswap Estimator API",
+			"version":   version,
+			"endpoints": endpoints,
+			"openapi":   "/openapi.json",
+		})
+	})
+}
+
+// endpointSummary lists each operation with its method, path and query parameters
+func endpointSummary(document *openapi.Document, routes []Route) map[string]string {
+	endpoints := make(map[string]string, len(routes))
+	for _, route := range routes {
+		summary := route.Method + " " + route.Path
+
+		var params []string
+		for _, param := range document.Operation(route.Method, openapi.PathTemplate(route.Path)).Parameters {
+			if param.In == "query" {
+				params = append(params, param.Name+"={"+param.Name+"}")
+			}
+		}
+		if len(params) > 0 {
+			summary += "?" + strings.Join(params, "&")
+		}
+
+		endpoints[route.OperationID] = summary
+	}
+	return endpoints
+}
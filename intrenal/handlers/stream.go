@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamKeepAlive is how often an idle stream gets a comment line, so proxies keep it
+// open and closed connections are noticed
+const streamKeepAlive = 15 * time.Second
+
+// StreamHandler serves quote subscriptions as Server-Sent Events
+type StreamHandler struct {
+	chains           *services.Chains
+	maxSubscriptions int
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(chains *services.Chains, maxSubscriptions int) *StreamHandler {
+	return &StreamHandler{
+		chains:           chains,
+		maxSubscriptions: maxSubscriptions,
+	}
+}
+
+// Subscribe handles GET and POST /estimate/stream endpoint. Each quote is sent as a
+// `quote` event holding a models.QuoteUpdate, on subscribe and on every new block, and
+// recorded in the audit log under the request ID of the stream.
+// Example: GET /estimate/stream?pool=0x...&src=0x...&dst=0x...&src_amount=1000000
+// Example: POST /estimate/stream {"chain":"base","requests":[{"pool":"0x...","src":"0x...","dst":"0x...","src_amount":"1000000"}]}
+func (h *StreamHandler) Subscribe(c *fiber.Ctx) error {
+	chain, reqs, err := h.parseSubscriptions(c)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	sub := chain.Quotes.Subscribe(reqs, requester(c))
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-sub.Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case <-sub.Updates():
+				for _, update := range sub.Drain() {
+					data, err := json.Marshal(update)
+					if err != nil {
+						return
+					}
+					fmt.Fprintf(w, "event: quote\nid: %d\ndata: %s\n\n", update.BlockNumber, data)
+				}
+			}
+
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// parseSubscriptions reads one subscription from the query (GET) or several from the
+// JSON body (POST), validates them and returns the chain they follow
+func (h *StreamHandler) parseSubscriptions(c *fiber.Ctx) (*services.Chain, []models.EstimateRequest, error) {
+	if c.Method() != fiber.MethodPost {
+		req := models.EstimateRequest{
+			Pool:      c.Query("pool"),
+			Src:       c.Query("src"),
+			Dst:       c.Query("dst"),
+			SrcAmount: c.Query("src_amount"),
+			Chain:     c.Query("chain"),
+			Dex:       c.Query("dex"),
+		}
+		if err := validateRequest(&req); err != nil {
+			return nil, nil, err
+		}
+		chain, err := h.chains.Get(req.Chain)
+		if err != nil {
+			return nil, nil, err
+		}
+		return chain, []models.EstimateRequest{req}, nil
+	}
+
+	var subscription models.QuoteSubscriptionRequest
+	if err := c.BodyParser(&subscription); err != nil {
+		return nil, nil, models.ErrInvalidRequestBody.WithCause(err)
+	}
+
+	if len(subscription.Requests) == 0 || len(subscription.Requests) > h.maxSubscriptions {
+		return nil, nil, models.ErrTooManySubscriptions.WithDetails(
+			fmt.Sprintf("A stream must subscribe to between 1 and %d quotes", h.maxSubscriptions),
+		)
+	}
+
+	// Field errors are reported for every request, prefixed with its position
+	var fieldErrors []models.FieldError
+	for i := range subscription.Requests {
+		for _, fieldError := range utils.ValidateStruct(&subscription.Requests[i]) {
+			fieldError.Field = "requests[" + strconv.Itoa(i) + "]." + fieldError.Field
+			fieldErrors = append(fieldErrors, fieldError)
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, nil, models.NewValidationError(fieldErrors)
+	}
+
+	// The stream follows one chain
+	chain, err := h.chains.Get(subscription.Chain)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range subscription.Requests {
+		if err := sameChain(h.chains, chain, subscription.Requests[i].Chain); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return chain, subscription.Requests, nil
+}
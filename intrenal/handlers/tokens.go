@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TokensHandler searches the token lists
+type TokensHandler struct {
+	chains *services.Chains
+}
+
+// NewTokensHandler creates a new tokens handler
+func NewTokensHandler(chains *services.Chains) *TokensHandler {
+	return &TokensHandler{chains: chains}
+}
+
+// Search handles GET /tokens/search endpoint
+// Example: GET /tokens/search?q=usd&chain=base&limit=5
+func (h *TokensHandler) Search(c *fiber.Ctx) error {
+	req := &models.TokenSearchRequest{
+		Query: c.Query("q"),
+		Limit: c.Query("limit"),
+		Chain: c.Query("chain"),
+	}
+
+	if fieldErrors := utils.ValidateStruct(req); len(fieldErrors) > 0 {
+		return WriteError(c, models.NewValidationError(fieldErrors))
+	}
+
+	chain, err := h.chains.Get(req.Chain)
+	if err != nil {
+		return WriteError(c, err)
+	}
+
+	// Without token lists nothing matches
+	if chain.Tokens == nil {
+		return c.Status(http.StatusOK).JSON(models.TokenSearchResponse{Tokens: []models.ListedToken{}})
+	}
+	return c.Status(http.StatusOK).JSON(chain.Tokens.SearchRequest(chain.Config.ChainID, req))
+}
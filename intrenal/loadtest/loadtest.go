@@ -0,0 +1,254 @@
+// Package loadtest drives the HTTP API at a target request rate with recorded or
+// synthesised requests and reports latency percentiles, errors and throughput.
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error codes of requests that got no API error response
+const (
+	CodeTransport     = "TRANSPORT_ERROR" // no response: refused connection, reset...
+	CodeClientTimeout = "CLIENT_TIMEOUT"  // no response within Options.Timeout
+)
+
+// Options configure a run
+type Options struct {
+	Target      string        // base URL of the API, e.g. http://localhost:1337
+	RPS         float64       // requests started per second
+	Duration    time.Duration // how long requests are started for
+	Concurrency int           // most requests in flight; requests due beyond it are skipped
+	Timeout     time.Duration // per request
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Target     string         `json:"target"`
+	TargetRPS  float64        `json:"target_rps"`
+	Duration   float64        `json:"duration_seconds"` // from the first request to the last response
+	Sent       int            `json:"sent"`
+	Skipped    int            `json:"skipped"` // due while Concurrency requests were in flight
+	Succeeded  int            `json:"succeeded"`
+	Failed     int            `json:"failed"`
+	Throughput float64        `json:"throughput_rps"` // successful responses per second
+	Latency    Latency        `json:"latency_ms"`     // of every request that got a response
+	Statuses   map[int]int    `json:"statuses"`       // responses by HTTP status
+	Errors     map[string]int `json:"errors"`         // failed requests by error_code
+	ItemErrors map[string]int `json:"item_errors"`    // failed items of batch responses by error_code
+}
+
+// Latency holds latency statistics in milliseconds
+type Latency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// result is the outcome of one request
+type result struct {
+	latency    time.Duration
+	status     int // 0 when there was no response
+	errorCode  string
+	itemErrors []string
+}
+
+// Run sends requests to the target, cycling through them in order, at opts.RPS for
+// opts.Duration, and waits for the responses. Requests are started on schedule whatever
+// the latency of earlier ones, like independent clients would, up to opts.Concurrency
+// in flight. Cancelling ctx stops the run early; the report covers what was sent.
+func Run(ctx context.Context, requests []Request, opts Options) (*Report, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("no requests to send")
+	}
+	if opts.RPS <= 0 || opts.Duration <= 0 || opts.Concurrency <= 0 {
+		return nil, errors.New("rps, duration and concurrency must be positive")
+	}
+
+	client := &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: opts.Concurrency, MaxConnsPerHost: opts.Concurrency},
+	}
+	defer client.CloseIdleConnections()
+	target := strings.TrimSuffix(opts.Target, "/")
+
+	var mu sync.Mutex
+	var results []result
+	skipped := 0
+
+	inFlight := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	interval := float64(time.Second) / opts.RPS
+	start := time.Now()
+
+	for i := 0; ; i++ {
+		due := time.Duration(float64(i) * interval)
+		if due >= opts.Duration {
+			break
+		}
+		if wait := time.Until(start.Add(due)); wait > 0 {
+			time.Sleep(wait)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			skipped++
+			continue
+		}
+
+		wg.Add(1)
+		go func(req Request) {
+			defer wg.Done()
+			outcome := send(ctx, client, target, req)
+			<-inFlight
+
+			mu.Lock()
+			results = append(results, outcome)
+			mu.Unlock()
+		}(requests[i%len(requests)])
+	}
+	wg.Wait()
+
+	report := summarize(results, time.Since(start))
+	report.Target = opts.Target
+	report.TargetRPS = opts.RPS
+	report.Skipped = skipped
+	return report, nil
+}
+
+// send makes one request and classifies its response
+func send(ctx context.Context, client *http.Client, target string, req Request) result {
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target+req.Path, body)
+	if err != nil {
+		return result{errorCode: CodeTransport}
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	started := time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		var timeout interface{ Timeout() bool }
+		if errors.As(err, &timeout) && timeout.Timeout() {
+			return result{errorCode: CodeClientTimeout}
+		}
+		return result{errorCode: CodeTransport}
+	}
+	payload, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	outcome := result{latency: time.Since(started), status: resp.StatusCode}
+	if err != nil {
+		outcome.errorCode = CodeTransport
+		return outcome
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			ErrorCode string `json:"error_code"`
+		}
+		if json.Unmarshal(payload, &apiErr) != nil || apiErr.ErrorCode == "" {
+			apiErr.ErrorCode = fmt.Sprintf("HTTP_%d", resp.StatusCode)
+		}
+		outcome.errorCode = apiErr.ErrorCode
+		return outcome
+	}
+
+	// Batch responses report failed items in an otherwise successful response
+	if strings.HasPrefix(req.Path, "/estimate/batch") || strings.HasPrefix(req.Path, "/api/v1/estimate/batch") {
+		var batch struct {
+			Results []struct {
+				Error *struct {
+					ErrorCode string `json:"error_code"`
+				} `json:"error"`
+			} `json:"results"`
+		}
+		if json.Unmarshal(payload, &batch) == nil {
+			for _, item := range batch.Results {
+				if item.Error != nil {
+					outcome.itemErrors = append(outcome.itemErrors, item.Error.ErrorCode)
+				}
+			}
+		}
+	}
+	return outcome
+}
+
+// summarize aggregates the results of a run that took elapsed
+func summarize(results []result, elapsed time.Duration) *Report {
+	report := &Report{
+		Duration:   elapsed.Seconds(),
+		Sent:       len(results),
+		Statuses:   make(map[int]int),
+		Errors:     make(map[string]int),
+		ItemErrors: make(map[string]int),
+	}
+
+	var latencies []time.Duration
+	for _, r := range results {
+		if r.status != 0 {
+			report.Statuses[r.status]++
+			latencies = append(latencies, r.latency)
+		}
+		if r.errorCode != "" {
+			report.Failed++
+			report.Errors[r.errorCode]++
+		} else {
+			report.Succeeded++
+		}
+		for _, code := range r.itemErrors {
+			report.ItemErrors[code]++
+		}
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Succeeded) / elapsed.Seconds()
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, latency := range latencies {
+			total += latency
+		}
+		report.Latency = Latency{
+			Mean: milliseconds(total / time.Duration(len(latencies))),
+			P50:  milliseconds(percentile(latencies, 50)),
+			P90:  milliseconds(percentile(latencies, 90)),
+			P95:  milliseconds(percentile(latencies, 95)),
+			P99:  milliseconds(percentile(latencies, 99)),
+			Max:  milliseconds(latencies[len(latencies)-1]),
+		}
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
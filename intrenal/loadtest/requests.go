@@ -0,0 +1,199 @@
+package loadtest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"net/url"
+	"strings"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// Request is one API request to send: a method, a path with its query string, and a
+// JSON body for POST requests
+type Request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Pool is a pool to synthesise quotes from: src is sold for dst around Amount, or dst
+// for src around DstAmount, both in units of the token sold
+type Pool struct {
+	Address   string
+	Src       string
+	Dst       string
+	Amount    string
+	DstAmount string
+}
+
+// methods are the HTTP methods recognised in request logs
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// ReadLog reads the requests of a request log, one per line, in order. A line is either
+// a Request as JSON, for POST bodies, or any text holding a method followed by a path,
+// such as the server's access log ("... 200 - GET /estimate?pool=... (1.2ms)") or
+// "GET /estimate?pool=...". Lines without a request are skipped.
+func ReadLog(r io.Reader) ([]Request, error) {
+	var requests []Request
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "{") {
+			var req Request
+			if err := json.Unmarshal([]byte(text), &req); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			req.Method = strings.ToUpper(req.Method)
+			if req.Method == "" || !strings.HasPrefix(req.Path, "/") {
+				return nil, fmt.Errorf("line %d: a JSON request needs a method and a path starting with /", line)
+			}
+			requests = append(requests, req)
+			continue
+		}
+
+		if req, ok := parseLogLine(text); ok {
+			requests = append(requests, req)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, errors.New("no requests in the log")
+	}
+	return requests, nil
+}
+
+// parseLogLine finds a method followed by a path in a log line
+func parseLogLine(line string) (Request, bool) {
+	fields := strings.Fields(strings.ReplaceAll(line, `"`, " "))
+	for i := 0; i+1 < len(fields); i++ {
+		for _, method := range methods {
+			if fields[i] == method && strings.HasPrefix(fields[i+1], "/") {
+				return Request{Method: method, Path: fields[i+1]}, true
+			}
+		}
+	}
+	return Request{}, false
+}
+
+// ReadPools reads pools from a CSV file whose header names the columns pool, src and
+// dst, and optionally amount (default 10^18) and dst_amount (default amount)
+func ReadPools(r io.Reader) ([]Pool, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty pool list, expected a header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"pool", "src", "dst"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the pool list header has no %s column", required)
+		}
+	}
+
+	var pools []Pool
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		pool := Pool{
+			Address: field("pool"), Src: field("src"), Dst: field("dst"),
+			Amount: field("amount"), DstAmount: field("dst_amount"),
+		}
+		if !utils.IsValidEthereumAddress(pool.Address) {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: invalid pool address %q", line, pool.Address)
+		}
+		if pool.Amount == "" {
+			pool.Amount = "1000000000000000000"
+		}
+		if pool.DstAmount == "" {
+			pool.DstAmount = pool.Amount
+		}
+		for _, amount := range []string{pool.Amount, pool.DstAmount} {
+			if _, err := utils.ParseBigInt(amount); err != nil {
+				line, _ := reader.FieldPos(0)
+				return nil, fmt.Errorf("line %d: invalid amount %q", line, amount)
+			}
+		}
+		pools = append(pools, pool)
+	}
+	if len(pools) == 0 {
+		return nil, errors.New("no pools after the header")
+	}
+	return pools, nil
+}
+
+// Synthesize returns count quote requests over random pools, in random directions, for
+// amounts spread from 1% to 100x of the pool amount of the token sold. With batchSize
+// above 1, each request is a POST /estimate/batch of batchSize quotes instead of a
+// GET /estimate. The same seed gives the same requests.
+func Synthesize(pools []Pool, count, batchSize int, seed int64) []Request {
+	random := rand.New(rand.NewSource(seed))
+
+	quote := func() models.EstimateRequest {
+		pool := pools[random.Intn(len(pools))]
+		src, dst, base := pool.Src, pool.Dst, pool.Amount
+		if random.Intn(2) == 1 {
+			src, dst, base = pool.Dst, pool.Src, pool.DstAmount
+		}
+
+		// Log-uniform factor between 10^-2 and 10^2, in hundredths
+		amount, _ := utils.ParseBigInt(base)
+		factor := int64(math.Pow(10, random.Float64()*4-2) * 100)
+		amount = new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(max(factor, 1))), big.NewInt(100))
+		return models.EstimateRequest{Pool: pool.Address, Src: src, Dst: dst, SrcAmount: amount.String()}
+	}
+
+	requests := make([]Request, count)
+	for i := range requests {
+		if batchSize <= 1 {
+			q := quote()
+			query := url.Values{"pool": {q.Pool}, "src": {q.Src}, "dst": {q.Dst}, "src_amount": {q.SrcAmount}}
+			requests[i] = Request{Method: "GET", Path: "/estimate?" + query.Encode()}
+			continue
+		}
+
+		batch := models.BatchEstimateRequest{Requests: make([]models.EstimateRequest, batchSize)}
+		for j := range batch.Requests {
+			batch.Requests[j] = quote()
+		}
+		body, _ := json.Marshal(batch)
+		requests[i] = Request{Method: "POST", Path: "/estimate/batch", Body: body}
+	}
+	return requests
+}
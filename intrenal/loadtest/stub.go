@@ -0,0 +1,232 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gofiber/fiber/v2"
+)
+
+// stubBlockTime is the block interval of the stub chain
+const stubBlockTime = 12 * time.Second
+
+// stubFirstBlock is the stub chain head when it is created
+const stubFirstBlock = 20000000
+
+// stubContract maps a 4-byte function selector to its raw return data; other functions revert
+type stubContract map[string][]byte
+
+// StubChain is an in-memory services.ChainClient serving synthetic Uniswap V2 pairs
+// and their ERC20 tokens, to load test the API without a node. Every RPC round trip
+// (a call, a batch, a block number) takes latency, like a node would; the head moves
+// every 12 seconds.
+type StubChain struct {
+	contracts map[common.Address]stubContract
+	pools     []Pool
+	latency   time.Duration
+	created   time.Time
+	calls     atomic.Int64
+}
+
+// NewStubChain creates a chain of pairs pairs, the i-th trading token i for token i+1.
+// Every third token has 6 decimals, the others 18.
+func NewStubChain(pairs int, latency time.Duration) *StubChain {
+	s := &StubChain{contracts: make(map[common.Address]stubContract), latency: latency, created: time.Now()}
+
+	decimals := make([]uint8, pairs+1)
+	for i := range decimals {
+		decimals[i] = 18
+		if i%3 == 2 {
+			decimals[i] = 6
+		}
+		s.contracts[stubToken(i)] = stubContract{
+			selector("decimals()"): encodeUint(big.NewInt(int64(decimals[i]))),
+			selector("symbol()"):   encodeString(fmt.Sprintf("TK%d", i)),
+			selector("name()"):     encodeString(fmt.Sprintf("Stub Token %d", i)),
+		}
+	}
+
+	for i := 0; i < pairs; i++ {
+		// A million units of token i against (i+2) million units of token i+1
+		reserve0 := new(big.Int).Mul(big.NewInt(1000000), pow10(decimals[i]))
+		reserve1 := new(big.Int).Mul(big.NewInt(int64(i+2)*1000000), pow10(decimals[i+1]))
+
+		pair := common.BigToAddress(big.NewInt(int64(0x20000 + i)))
+		s.contracts[pair] = stubContract{
+			selector("getReserves()"): append(append(encodeUint(reserve0), encodeUint(reserve1)...), encodeUint(big.NewInt(1700000000))...),
+			selector("token0()"):      common.LeftPadBytes(stubToken(i).Bytes(), 32),
+			selector("token1()"):      common.LeftPadBytes(stubToken(i+1).Bytes(), 32),
+		}
+		s.pools = append(s.pools, Pool{
+			Address:   utils.NormalizeAddress(pair.Hex()),
+			Src:       utils.NormalizeAddress(stubToken(i).Hex()),
+			Dst:       utils.NormalizeAddress(stubToken(i + 1).Hex()),
+			Amount:    pow10(decimals[i]).String(), // one token
+			DstAmount: pow10(decimals[i+1]).String(),
+		})
+	}
+	return s
+}
+
+// Pools returns the pairs of the chain, to synthesise requests from
+func (s *StubChain) Pools() []Pool {
+	return s.pools
+}
+
+// Calls returns the number of RPC round trips served so far
+func (s *StubChain) Calls() int64 {
+	return s.calls.Load()
+}
+
+// roundTrip waits for the latency of one RPC round trip
+func (s *StubChain) roundTrip(ctx context.Context) error {
+	s.calls.Add(1)
+	if s.latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(s.latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *StubChain) call(msg ethereum.CallMsg) ([]byte, error) {
+	contract, ok := s.contracts[*msg.To]
+	if !ok {
+		return []byte{}, nil // no code at address
+	}
+	if len(msg.Data) >= 4 {
+		if result, ok := contract[string(msg.Data[:4])]; ok {
+			return result, nil
+		}
+	}
+	return nil, services.RevertError{}
+}
+
+func (s *StubChain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := s.roundTrip(ctx); err != nil {
+		return nil, err
+	}
+	return s.call(msg)
+}
+
+func (s *StubChain) BatchCallContract(ctx context.Context, calls []services.ContractCall) error {
+	if err := s.roundTrip(ctx); err != nil {
+		return err
+	}
+	for i := range calls {
+		calls[i].Result, calls[i].Error = s.call(calls[i].Msg)
+	}
+	return nil
+}
+
+func (s *StubChain) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := s.roundTrip(ctx); err != nil {
+		return 0, err
+	}
+	return stubFirstBlock + uint64(time.Since(s.created)/stubBlockTime), nil
+}
+
+func (s *StubChain) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (s *StubChain) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if err := s.roundTrip(ctx); err != nil {
+		return nil, err
+	}
+	if _, ok := s.contracts[account]; !ok {
+		return []byte{}, nil
+	}
+	return []byte{0x60, 0x80}, nil
+}
+
+func (s *StubChain) BlockTimestamp(ctx context.Context, number *big.Int) (uint64, error) {
+	block := uint64(stubFirstBlock)
+	if number != nil {
+		block = number.Uint64()
+	}
+	return uint64(s.created.Unix()) + (block-stubFirstBlock)*uint64(stubBlockTime/time.Second), nil
+}
+
+func (s *StubChain) Close() {}
+
+// Server is the API served in-process on a local port
+type Server struct {
+	URL   string // base URL, e.g. http://127.0.0.1:41234
+	app   *fiber.App
+	chain *services.Chain
+}
+
+// StartServer serves the API on a free local port on top of client, as the ethereum
+// preset, with the handlers and limits of the real server. Router cross-checks are off.
+func StartServer(cfg *config.Config, client services.ChainClient) (*Server, error) {
+	ethereum, _ := config.KnownChain("ethereum")
+	served := *cfg
+	served.Chains = []*config.ChainConfig{ethereum}
+	served.DefaultChain = ethereum.Name
+	served.VerifySampleRate = 0
+
+	blockchain, err := services.NewChainBlockchainService(&served, ethereum, client)
+	if err != nil {
+		return nil, err
+	}
+	chain := services.NewChain(&served, blockchain, services.NewReserveCache(served.HistoryCacheDir), nil)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true, ErrorHandler: handlers.WriteError})
+	handlers.SetupRoutes(app, handlers.Routes(handlers.NewHandlers(services.NewChains(chain), nil, &served, "loadtest")), "loadtest")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	go app.Listener(listener)
+
+	return &Server{URL: "http://" + listener.Addr().String(), app: app, chain: chain}, nil
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	s.chain.Blockchain.Close()
+	return s.app.Shutdown()
+}
+
+func stubToken(i int) common.Address {
+	return common.BigToAddress(big.NewInt(int64(0x10000 + i)))
+}
+
+func selector(signature string) string {
+	return string(crypto.Keccak256([]byte(signature))[:4])
+}
+
+func encodeUint(value *big.Int) []byte {
+	return common.LeftPadBytes(value.Bytes(), 32)
+}
+
+// encodeString ABI-encodes a string return value: offset, length, padded bytes
+func encodeString(value string) []byte {
+	data := append(encodeUint(big.NewInt(32)), encodeUint(big.NewInt(int64(len(value))))...)
+	padded := make([]byte, (len(value)+31)/32*32)
+	copy(padded, value)
+	return append(data, padded...)
+}
+
+func pow10(decimals uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+}
@@ -1,65 +1,249 @@
 package models
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
+// Stable machine-readable error codes. Clients should match on these, never on messages.
+const (
+	CodeInvalidRequest        = "INVALID_REQUEST"
+	CodeValidationFailed      = "VALIDATION_FAILED"
+	CodeInvalidPoolAddress    = "INVALID_POOL_ADDRESS"
+	CodeInvalidTokenAddress   = "INVALID_TOKEN_ADDRESS"
+	CodeInvalidAmount         = "INVALID_AMOUNT"
+	CodeInvalidBatchSize      = "INVALID_BATCH_SIZE"
+	CodeTooManySubscriptions  = "TOO_MANY_SUBSCRIPTIONS"
+	CodeInvalidBlockRange     = "INVALID_BLOCK_RANGE"
+	CodeInvalidPoolList       = "INVALID_POOL_LIST"
+	CodeUnsupportedChain      = "UNSUPPORTED_CHAIN"
+	CodeUnknownToken          = "UNKNOWN_TOKEN"
+	CodeAmbiguousToken        = "AMBIGUOUS_TOKEN"
+	CodePoolNotFound          = "POOL_NOT_FOUND"
+	CodeUnsupportedPool       = "UNSUPPORTED_POOL_TYPE"
+	CodeUntrustedPool         = "UNTRUSTED_POOL"
+	CodeTokenMismatch         = "TOKEN_MISMATCH"
+	CodeInsufficientLiquidity = "INSUFFICIENT_LIQUIDITY"
+	CodeRPCUnavailable        = "RPC_UNAVAILABLE"
+	CodeRequestTimeout        = "REQUEST_TIMEOUT"
+	CodeQuoteNotFound         = "QUOTE_NOT_FOUND"
+	CodeAuditDisabled         = "AUDIT_DISABLED"
+	CodeUnauthorized          = "UNAUTHORIZED"
+	CodeRouteNotFound         = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed      = "METHOD_NOT_ALLOWED"
+	CodeInternal              = "INTERNAL_ERROR"
+)
+
 // Custom error types for better error handling
 type APIError struct {
-	Code    int    `json:"code"`
+	Code      int          `json:"code"`       // HTTP status
+	ErrorCode string       `json:"error_code"` // Stable machine-readable code
+	Message   string       `json:"message"`
+	Details   string       `json:"details,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+
+	cause error // underlying error, never serialized
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
 }
 
 func (e *APIError) Error() string {
-	return fmt.Sprintf("API Error %d: %s", e.Code, e.Message)
+	if e.cause != nil {
+		return fmt.Sprintf("API Error %d %s: %s: %v", e.Code, e.ErrorCode, e.Message, e.cause)
+	}
+	return fmt.Sprintf("API Error %d %s: %s", e.Code, e.ErrorCode, e.Message)
 }
 
-// Predefined errors
-var (
-	ErrInvalidPoolAddress = &APIError{
-		Code:    http.StatusBadRequest,
-		Message: "Invalid pool address",
-		Details: "Pool address must be a valid Ethereum address (42 characters)",
-	}
-	
-	ErrInvalidTokenAddress = &APIError{
-		Code:    http.StatusBadRequest,
-		Message: "Invalid token address",
-		Details: "Token addresses must be valid Ethereum addresses",
-	}
-	
-	ErrInvalidAmount = &APIError{
-		Code:    http.StatusBadRequest,
-		Message: "Invalid amount",
-		Details: "Amount must be a positive integer",
-	}
-	
-	ErrPoolNotFound = &APIError{
-		Code:    http.StatusNotFound,
-		Message: "Pool not found",
-		Details: "The specified pool address does not exist or is not a Uniswap V2 pair",
+// Unwrap returns the underlying cause so errors.Is/As can see through API errors
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+// Is matches API errors by error code, so copies made by WithCause or WithDetails
+// still match the predefined error with errors.Is
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.ErrorCode == e.ErrorCode
+}
+
+// AsAPIError converts any error into an APIError, keeping the original as cause. Every
+// transport goes through it so they report the same code for the same failure. A
+// deadline wins over the API error wrapping it: services report a timed out RPC call as
+// ErrBlockchainConnection, but the request ran out of time, the node did not fail.
+func AsAPIError(err error) *APIError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrRequestTimeout.WithCause(err)
 	}
-	
-	ErrInsufficientLiquidity = &APIError{
-		Code:    http.StatusBadRequest,
-		Message: "Insufficient liquidity",
-		Details: "The pool does not have enough liquidity for this swap",
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
 	}
-	
-	ErrBlockchainConnection = &APIError{
-		Code:    http.StatusServiceUnavailable,
-		Message: "Blockchain connection error",
-		Details: "Unable to fetch current state from Ethereum network",
+
+	return ErrInternal.WithCause(err)
+}
+
+// WithCause returns a copy of the error wrapping cause
+func (e *APIError) WithCause(cause error) *APIError {
+	wrapped := *e
+	wrapped.cause = cause
+	return &wrapped
+}
+
+// WithDetails returns a copy of the error with different details
+func (e *APIError) WithDetails(details string) *APIError {
+	detailed := *e
+	detailed.Details = details
+	return &detailed
+}
+
+// catalogue lists every predefined error in definition order
+var catalogue []*APIError
+
+// define creates a predefined error and registers it in the catalogue
+func define(code int, errorCode, message, details string) *APIError {
+	err := &APIError{
+		Code:      code,
+		ErrorCode: errorCode,
+		Message:   message,
+		Details:   details,
 	}
+	catalogue = append(catalogue, err)
+	return err
+}
+
+// Predefined errors
+var (
+	ErrInvalidRequestBody = define(http.StatusBadRequest, CodeInvalidRequest,
+		"Invalid request body",
+		"Request body must be valid JSON matching the documented schema")
+
+	ErrValidationFailed = define(http.StatusBadRequest, CodeValidationFailed,
+		"Validation failed",
+		"One or more request fields are invalid, see fields")
+
+	ErrInvalidPoolAddress = define(http.StatusBadRequest, CodeInvalidPoolAddress,
+		"Invalid pool address",
+		"Pool address must be a valid Ethereum address (42 characters)")
+
+	ErrInvalidTokenAddress = define(http.StatusBadRequest, CodeInvalidTokenAddress,
+		"Invalid token address",
+		"Token addresses must be valid Ethereum addresses")
+
+	ErrInvalidAmount = define(http.StatusBadRequest, CodeInvalidAmount,
+		"Invalid amount",
+		"Amount must be a positive integer")
+
+	ErrInvalidBatchSize = define(http.StatusBadRequest, CodeInvalidBatchSize,
+		"Invalid batch size",
+		"A batch must contain at least one and at most MAX_BATCH_SIZE requests")
+
+	ErrTooManySubscriptions = define(http.StatusBadRequest, CodeTooManySubscriptions,
+		"Too many subscriptions",
+		"A stream must subscribe to at least one and at most MAX_STREAM_SUBSCRIPTIONS quotes")
+
+	ErrInvalidBlockRange = define(http.StatusBadRequest, CodeInvalidBlockRange,
+		"Invalid block range",
+		"from_block must not exceed to_block, to_block must not exceed the latest block and the range must have at most MAX_HISTORY_POINTS steps")
+
+	ErrInvalidPoolList = define(http.StatusBadRequest, CodeInvalidPoolList,
+		"Invalid pool list",
+		"Arbitrage needs at least two and at most MAX_ARBITRAGE_POOLS pools, from pools or <CHAIN>_ARBITRAGE_POOLS")
+
+	ErrUnsupportedChain = define(http.StatusBadRequest, CodeUnsupportedChain,
+		"Unsupported chain",
+		"chain must be the name or chain ID of a chain listed in CHAINS, see /chains")
+
+	ErrUnknownToken = define(http.StatusBadRequest, CodeUnknownToken,
+		"Unknown token",
+		"No token with this symbol on the chain in the token lists of TOKEN_LISTS, see /tokens/search or use its address")
+
+	ErrAmbiguousToken = define(http.StatusBadRequest, CodeAmbiguousToken,
+		"Ambiguous token symbol",
+		"Several tokens of the highest priority token list have this symbol; use the address of the one you mean")
+
+	ErrPoolNotFound = define(http.StatusNotFound, CodePoolNotFound,
+		"Pool not found",
+		"The specified pool address does not exist or is not a supported pool (Uniswap V2/V3, Solidly, Curve, Balancer weighted)")
+
+	ErrUnsupportedPool = define(http.StatusBadRequest, CodeUnsupportedPool,
+		"Unsupported pool type",
+		"This endpoint supports Uniswap V2 pairs only; Uniswap V3, Solidly, Curve and Balancer pools can be quoted with /estimate")
+
+	ErrUntrustedPool = define(http.StatusBadRequest, CodeUntrustedPool,
+		"Untrusted pool",
+		"The pool answers like a Uniswap V2 pair but was not deployed by a trusted factory of the chain, see <CHAIN>_TRUSTED_FACTORIES")
+
+	ErrTokenMismatch = define(http.StatusBadRequest, CodeTokenMismatch,
+		"Token mismatch",
+		"Provided tokens don't match the pool tokens")
+
+	ErrInsufficientLiquidity = define(http.StatusBadRequest, CodeInsufficientLiquidity,
+		"Insufficient liquidity",
+		"The pool does not have enough liquidity for this swap")
+
+	ErrBlockchainConnection = define(http.StatusServiceUnavailable, CodeRPCUnavailable,
+		"Blockchain connection error",
+		"Unable to fetch current state from Ethereum network")
+
+	ErrRequestTimeout = define(http.StatusRequestTimeout, CodeRequestTimeout,
+		"Request timeout",
+		"The request took too long to process")
+
+	ErrQuoteNotFound = define(http.StatusNotFound, CodeQuoteNotFound,
+		"Quote not found",
+		"The audit log has no quote served under this request ID; records older than AUDIT_RETENTION are pruned")
+
+	ErrAuditDisabled = define(http.StatusNotFound, CodeAuditDisabled,
+		"Audit log disabled",
+		"Served quotes are only recorded when AUDIT_DB is set")
+
+	ErrUnauthorized = define(http.StatusUnauthorized, CodeUnauthorized,
+		"Unauthorized",
+		"Audit lookups need an Authorization: Bearer header with AUDIT_ADMIN_TOKEN, and are refused while it is unset")
+
+	ErrRouteNotFound = define(http.StatusNotFound, CodeRouteNotFound,
+		"Route not found",
+		"No endpoint matches this path, see GET / for the list of endpoints")
+
+	ErrMethodNotAllowed = define(http.StatusMethodNotAllowed, CodeMethodNotAllowed,
+		"Method not allowed",
+		"The endpoint does not support this HTTP method")
+
+	ErrInternal = define(http.StatusInternalServerError, CodeInternal,
+		"Internal server error",
+		"An unexpected error occurred")
 )
 
+// ErrorCatalogue returns every predefined error, for documentation and GET /errors
+func ErrorCatalogue() []APIError {
+	entries := make([]APIError, len(catalogue))
+	for i, err := range catalogue {
+		entries[i] = *err
+	}
+	return entries
+}
+
+// NewValidationError creates a 400 error listing every invalid field
+func NewValidationError(fields []FieldError) *APIError {
+	err := ErrValidationFailed.WithDetails(fmt.Sprintf("%d invalid field(s)", len(fields)))
+	err.Fields = fields
+	return err
+}
+
 // NewAPIError creates a custom API error
-func NewAPIError(code int, message, details string) *APIError {
+func NewAPIError(code int, errorCode, message, details string) *APIError {
 	return &APIError{
-		Code:    code,
-		Message: message,
-		Details: details,
+		Code:      code,
+		ErrorCode: errorCode,
+		Message:   message,
+		Details:   details,
 	}
-}
\ No newline at end of file
+}
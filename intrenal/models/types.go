@@ -2,43 +2,424 @@ package models
 
 import (
 	"math/big"
+	"time"
 )
 
 // EstimateRequest represents the input parameters for swap estimation
 type EstimateRequest struct {
-	Pool      string `json:"pool" validate:"required,len=42"`      // Uniswap V2 pair address
-	Src       string `json:"src" validate:"required,len=42"`       // Source token address
-	Dst       string `json:"dst" validate:"required,len=42"`       // Destination token address
-	SrcAmount string `json:"src_amount" validate:"required"`       // Input amount as string
+	Pool      string `json:"pool,omitempty" validate:"len=42,eth_address"` // Pool address; derived from src and dst when omitted
+	Src       string `json:"src" validate:"required,token"`                // Source token address or token list symbol
+	Dst       string `json:"dst" validate:"required,token,nefield=Src"`    // Destination token address or token list symbol
+	SrcAmount string `json:"src_amount" validate:"required,uint256"`       // Input amount as string
+	Verify    bool   `json:"verify,omitempty"`                             // Cross-check the result against the router
+	Chain     string `json:"chain,omitempty"`                              // Chain name or ID, default DEFAULT_CHAIN
+	Dex       string `json:"dex,omitempty"`                                // V2 deployment a derived pool belongs to, default the chain's
 }
 
 // EstimateResponse represents the API response
 type EstimateResponse struct {
-	DstAmount string `json:"dst_amount"` // Output amount calculated off-chain
+	DstAmount    string              `json:"dst_amount"`             // Output amount calculated off-chain
+	Pool         string              `json:"pool,omitempty"`         // The pair derived from src and dst when pool was omitted
+	PoolType     string              `json:"pool_type,omitempty"`    // PoolTypeV2 or PoolTypeV3, detected on chain
+	Verification *VerificationResult `json:"verification,omitempty"` // Present when verify=true
+
+	// Token transfer flags - set only for non-standard tokens
+	FeeOnTransfer     bool   `json:"fee_on_transfer,omitempty"`
+	SrcTransferTaxBps uint32 `json:"src_transfer_tax_bps,omitempty"`
+	DstTransferTaxBps uint32 `json:"dst_transfer_tax_bps,omitempty"`
+	Rebasing          bool   `json:"rebasing,omitempty"`
+
+	// Native token flags - set when src or dst is a native token sentinel, quoted as the
+	// wrapped native token. A swap must then use the router's ETH variants
+	// (swapExactETHForTokens, swapExactTokensForETH) to wrap or unwrap it.
+	SrcNative     bool   `json:"src_native,omitempty"`
+	DstNative     bool   `json:"dst_native,omitempty"`
+	WrappedNative string `json:"wrapped_native,omitempty"` // the token quoted in place of the native one
+
+	// Token list details of src and dst, when a token list has them
+	SrcToken *ListedToken `json:"src_token,omitempty"`
+	DstToken *ListedToken `json:"dst_token,omitempty"`
+
+	// Warnings about the request that did not prevent the quote, e.g. a V2 pool that is
+	// not the canonical pair of the tokens of any known factory
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// BatchEstimateRequest holds several quote requests for POST /estimate/batch. The
+// batch is quoted on one chain; items naming another chain fail.
+type BatchEstimateRequest struct {
+	Requests []EstimateRequest `json:"requests"`
+	Chain    string            `json:"chain,omitempty"`
+}
+
+// BatchEstimateResult is the outcome of one batch item: either a result or an error
+type BatchEstimateResult struct {
+	Index  int               `json:"index"`
+	Result *EstimateResponse `json:"result,omitempty"`
+	Error  *APIError         `json:"error,omitempty"`
+}
+
+// BatchEstimateResponse holds batch results in request order, all quoted at one block
+type BatchEstimateResponse struct {
+	BlockNumber uint64                `json:"block_number"`
+	Results     []BatchEstimateResult `json:"results"`
+}
+
+// QuoteSubscriptionRequest subscribes to several quotes on one stream (POST /estimate/stream).
+// The stream follows one chain; requests naming another chain are refused.
+type QuoteSubscriptionRequest struct {
+	Requests []EstimateRequest `json:"requests"`
+	Chain    string            `json:"chain,omitempty"`
+}
+
+// QuoteUpdate is one quote pushed to a stream subscriber; Index is the position of the
+// subscription in the request (always 0 for GET /estimate/stream)
+type QuoteUpdate struct {
+	Index       int               `json:"index"`
+	BlockNumber uint64            `json:"block_number"`
+	Result      *EstimateResponse `json:"result,omitempty"`
+	Error       *APIError         `json:"error,omitempty"`
+}
+
+// HistoryRequest asks for the quote of one swap at every step-th block of a range
+type HistoryRequest struct {
+	Pool      string `json:"pool" validate:"required,len=42,eth_address"`
+	Src       string `json:"src" validate:"required,len=42,eth_address"`
+	Dst       string `json:"dst" validate:"required,len=42,eth_address,nefield=Src"`
+	SrcAmount string `json:"src_amount" validate:"required,uint256"`
+	FromBlock string `json:"from_block" validate:"required,uint64"`
+	ToBlock   string `json:"to_block" validate:"required,uint64"`
+	Step      string `json:"step,omitempty" validate:"uint64"` // default 1
+	Chain     string `json:"chain,omitempty"`
+}
+
+// HistoryPoint is the pool state and quote at one block. Blocks where the pool did
+// not exist yet or could not be quoted carry an error instead.
+type HistoryPoint struct {
+	BlockNumber uint64    `json:"block_number"`
+	Reserve0    string    `json:"reserve0,omitempty"`
+	Reserve1    string    `json:"reserve1,omitempty"`
+	SpotPrice   string    `json:"spot_price,omitempty"` // dst per src in whole tokens, before fees
+	DstAmount   string    `json:"dst_amount,omitempty"`
+	Error       *APIError `json:"error,omitempty"`
+}
+
+// HistoryResponse is the time series of a pool, oldest block first
+type HistoryResponse struct {
+	Pool   string         `json:"pool"`
+	Token0 string         `json:"token0"`
+	Token1 string         `json:"token1"`
+	Points []HistoryPoint `json:"points"`
+}
+
+// TwapRequest asks for the time-weighted average price between from_block and to_block,
+// or over the window seconds before to_block. to_block defaults to the latest block.
+type TwapRequest struct {
+	Pool      string `json:"pool" validate:"required,len=42,eth_address"`
+	Src       string `json:"src" validate:"required,len=42,eth_address"`
+	Dst       string `json:"dst" validate:"required,len=42,eth_address,nefield=Src"`
+	SrcAmount string `json:"src_amount,omitempty" validate:"uint256"` // optional amount to price
+	FromBlock string `json:"from_block,omitempty" validate:"uint64"`
+	ToBlock   string `json:"to_block,omitempty" validate:"uint64"`
+	Window    string `json:"window,omitempty" validate:"uint64"` // seconds, instead of from_block
+	Chain     string `json:"chain,omitempty"`
+}
+
+// TwapResponse is the time-weighted average price of src in dst over an interval
+type TwapResponse struct {
+	FromBlock      uint64 `json:"from_block"`
+	ToBlock        uint64 `json:"to_block"`
+	FromTimestamp  uint64 `json:"from_timestamp"`
+	ToTimestamp    uint64 `json:"to_timestamp"`
+	Price          string `json:"price"`                // dst per src in whole tokens
+	PriceUQ112x112 string `json:"price_uq112x112"`      // raw average price, dst units per src unit
+	DstAmount      string `json:"dst_amount,omitempty"` // src_amount at the average price, no fee
+}
+
+// AddLiquidityRequest asks how many LP tokens a deposit of up to amount_a and amount_b mints
+type AddLiquidityRequest struct {
+	Pool    string `json:"pool" validate:"required,len=42,eth_address"`
+	TokenA  string `json:"token_a" validate:"required,len=42,eth_address"`
+	TokenB  string `json:"token_b" validate:"required,len=42,eth_address,nefield=TokenA"`
+	AmountA string `json:"amount_a" validate:"required,uint256"` // desired amount of token_a
+	AmountB string `json:"amount_b" validate:"required,uint256"` // desired amount of token_b
+	Chain   string `json:"chain,omitempty"`
+}
+
+// AddLiquidityResponse is the deposit UniswapV2Router02.addLiquidity would make: the
+// amounts actually taken at the pool ratio and the LP tokens minted for them
+type AddLiquidityResponse struct {
+	BlockNumber uint64 `json:"block_number"`
+	AmountA     string `json:"amount_a"`
+	AmountB     string `json:"amount_b"`
+	Liquidity   string `json:"liquidity"`
+	TotalSupply string `json:"total_supply"` // after the deposit, including any protocol fee
+	PoolShare   string `json:"pool_share"`   // fraction of the pool owned by the minted tokens
+}
+
+// RemoveLiquidityRequest asks for the underlying amounts of an LP token balance
+type RemoveLiquidityRequest struct {
+	Pool      string `json:"pool" validate:"required,len=42,eth_address"`
+	Liquidity string `json:"liquidity" validate:"required,uint256"` // LP tokens to burn
+	Chain     string `json:"chain,omitempty"`
+}
+
+// RemoveLiquidityResponse holds the token amounts burning the LP tokens returns
+type RemoveLiquidityResponse struct {
+	BlockNumber uint64 `json:"block_number"`
+	Token0      string `json:"token0"`
+	Token1      string `json:"token1"`
+	Amount0     string `json:"amount0"`
+	Amount1     string `json:"amount1"`
+	TotalSupply string `json:"total_supply"` // before the burn, including any protocol fee
+	PoolShare   string `json:"pool_share"`   // fraction of the pool the burned tokens own
+}
+
+// ArbitrageRequest asks for profitable cycles over a set of pools. pools is a comma
+// separated list of V2 pairs and defaults to the tracked pools of the chain.
+type ArbitrageRequest struct {
+	Pools   string `json:"pools,omitempty" validate:"eth_address_list"`
+	Start   string `json:"start,omitempty" validate:"len=42,eth_address"` // only cycles from this token
+	MaxHops string `json:"max_hops,omitempty" validate:"uint64"`          // default and cap MAX_ARBITRAGE_HOPS
+	Chain   string `json:"chain,omitempty"`
+}
+
+// ArbitrageOpportunity is a profitable cycle traded with its optimal input. Path lists
+// the tokens from the start token back to it, Pools the pair used for each hop.
+type ArbitrageOpportunity struct {
+	StartToken string   `json:"start_token"`
+	Path       []string `json:"path"`
+	Pools      []string `json:"pools"`
+	AmountIn   string   `json:"amount_in"`
+	AmountOut  string   `json:"amount_out"`
+	Profit     string   `json:"profit"` // amount_out - amount_in, in the start token
+}
+
+// ArbitrageResponse lists profitable cycles at one block, largest profit first per start token
+type ArbitrageResponse struct {
+	BlockNumber   uint64                 `json:"block_number"`
+	Opportunities []ArbitrageOpportunity `json:"opportunities"`
+	SkippedPools  []string               `json:"skipped_pools,omitempty"` // not V2 pairs or empty
+}
+
+// VerificationResult compares the off-chain output with UniswapV2Router02.getAmountsOut.
+// The router only prices the canonical pair of its factory, so quotes on other pools
+// are not compared; Status and Reason say why.
+type VerificationResult struct {
+	BlockNumber    uint64 `json:"block_number"`
+	Status         string `json:"status"` // one of the Verification* constants
+	OffChainAmount string `json:"offchain_amount"`
+	OnChainAmount  string `json:"onchain_amount,omitempty"`
+	Match          bool   `json:"match"`
+	Reason         string `json:"reason,omitempty"` // set when the quote was not compared
+}
+
+// Verification statuses
+const (
+	VerificationMatch         = "match"
+	VerificationMismatch      = "mismatch"
+	VerificationNotVerifiable = "not_verifiable" // the pool is not the router's pair
+	VerificationFailed        = "failed"         // the router call reverted or timed out
+)
+
+// VerificationStats holds counters for all verifications performed so far
+type VerificationStats struct {
+	Checks        uint64 `json:"checks"`
+	Mismatches    uint64 `json:"mismatches"`
+	Failures      uint64 `json:"failures"`
+	NotVerifiable uint64 `json:"not_verifiable"`
+	Dropped       uint64 `json:"dropped"`
+}
+
+// QuoteRecord is the audit record of one served quote: the request as received and as
+// resolved, the block and pool state it was quoted from, the output, and who asked
+type QuoteRecord struct {
+	RequestID       string    `json:"request_id"`                  // generated by the server
+	ClientRequestID string    `json:"client_request_id,omitempty"` // X-Request-ID sent by the client
+	Item            int       `json:"item"`                        // position in the batch, 0 for single quotes
+	Time            time.Time `json:"time"`
+	Requester       string    `json:"requester"` // client IP address
+	UserAgent       string    `json:"user_agent,omitempty"`
+	Transport       string    `json:"transport"` // http or grpc
+
+	// Inputs: the tokens as requested (symbols, native sentinels) and as quoted
+	Chain        string `json:"chain"`
+	Pool         string `json:"pool"`
+	Src          string `json:"src"`
+	Dst          string `json:"dst"`
+	RequestedSrc string `json:"requested_src"`
+	RequestedDst string `json:"requested_dst"`
+	SrcAmount    string `json:"src_amount"`
+	Dex          string `json:"dex,omitempty"` // V2 deployment of a derived pool
+
+	// State the quote was computed from
+	BlockNumber uint64   `json:"block_number"`
+	PoolType    string   `json:"pool_type"`
+	FeeModel    string   `json:"fee_model"` // e.g. "30 bps"
+	Reserves    []string `json:"reserves"`  // by pool token; liquidity and sqrtPriceX96 for V3
+
+	DstAmount string `json:"dst_amount"`
+}
+
+// AuditLookupResponse lists the quotes served under one request ID, in batch order
+type AuditLookupResponse struct {
+	RequestID string        `json:"request_id"`
+	Quotes    []QuoteRecord `json:"quotes"`
+}
+
+// AuditStats holds counters of the audit log since startup
+type AuditStats struct {
+	Written uint64 `json:"written"`
+	Dropped uint64 `json:"dropped"` // the queue was full
+	Failed  uint64 `json:"failed"`  // records lost to failed writes
+	Pruned  uint64 `json:"pruned"`  // records older than the retention
+	Pending int    `json:"pending"` // queued, not written yet
+}
+
+// ChainRequest selects the chain of endpoints that take no other parameter
+type ChainRequest struct {
+	Chain string `json:"chain,omitempty"` // name or chain ID, default DEFAULT_CHAIN
+}
+
+// ChainInfo describes one configured chain and its V2 deployment
+type ChainInfo struct {
+	Name           string `json:"name"`
+	ChainID        uint64 `json:"chain_id"`
+	Default        bool   `json:"default,omitempty"`
+	FactoryAddress string `json:"factory_address"`
+	RouterAddress  string `json:"router_address"`
+	FeeBps         uint32 `json:"fee_bps"`
+	WrappedNative  string `json:"wrapped_native"`
+}
+
+// ChainsResponse lists the configured chains in CHAINS order
+type ChainsResponse struct {
+	Chains []ChainInfo `json:"chains"`
 }
 
 // TokenInfo holds token metadata
 type TokenInfo struct {
-	Address  string
-	Decimals uint8
-	Symbol   string
+	Address           string
+	Decimals          uint8
+	Symbol            string
+	Name              string
+	MetadataAvailable bool   // False when decimals() or symbol() is missing or non-standard
+	TransferTaxBps    uint32 // Fee-on-transfer tax in basis points (0 = standard token)
+	Rebasing          bool   // Balance changes without transfers, reserves may be stale
 }
 
 // PoolReserves holds current pool state from blockchain
 type PoolReserves struct {
-	Reserve0   *big.Int
-	Reserve1   *big.Int
-	Token0     string
-	Token1     string
-	BlockTime  uint32
+	Reserve0  *big.Int
+	Reserve1  *big.Int
+	Token0    string
+	Token1    string
+	BlockTime uint32
+}
+
+// Pool types reported by /estimate
+const (
+	PoolTypeV2               = "uniswap_v2"
+	PoolTypeV3               = "uniswap_v3"
+	PoolTypeSolidlyVolatile  = "solidly_volatile"
+	PoolTypeSolidlyStable    = "solidly_stable"
+	PoolTypeCurve            = "curve_stableswap"
+	PoolTypeBalancerWeighted = "balancer_weighted"
+)
+
+// V3PoolState holds the state of a Uniswap V3 pool needed to simulate swaps. Only the
+// tick bitmap words around the current tick are loaded; swaps leaving them fail.
+type V3PoolState struct {
+	Token0       string
+	Token1       string
+	SqrtPriceX96 *big.Int
+	Tick         int32
+	Liquidity    *big.Int // in range at the current tick
+	Fee          uint32   // in hundredths of a basis point, 3000 = 0.3%
+	TickSpacing  int32
+	TickBitmap   map[int16]*big.Int // loaded bitmap words
+	LiquidityNet map[int32]*big.Int // per initialized tick in the loaded words
+}
+
+// SolidlyPoolState holds the state of a Solidly/Velodrome-style pair, which prices
+// stable pairs with x³y + y³x = k and volatile pairs with x * y = k
+type SolidlyPoolState struct {
+	Token0    string
+	Token1    string
+	Reserve0  *big.Int
+	Reserve1  *big.Int
+	Decimals0 *big.Int // 10^decimals of token0
+	Decimals1 *big.Int // 10^decimals of token1
+	Stable    bool
+	FeeBps    uint32
+}
+
+// CurvePoolState holds the state of a Curve StableSwap plain pool
+type CurvePoolState struct {
+	Coins    []string   // lowercase addresses, by coin index
+	Balances []*big.Int // by coin index
+	Rates    []*big.Int // 10^(36 - decimals) by coin index
+	A        *big.Int   // amplification coefficient
+	Fee      *big.Int   // in 1e-10 units, 4000000 = 0.04%
+}
+
+// BalancerPoolState holds the state of a Balancer V2 weighted pool, from the Vault's
+// getPoolTokens and the pool's getNormalizedWeights and getSwapFeePercentage
+type BalancerPoolState struct {
+	PoolID         string     // 0x-prefixed bytes32
+	Tokens         []string   // lowercase addresses, in Vault order
+	Balances       []*big.Int // by token
+	Weights        []*big.Int // 18-decimal, summing to 1e18
+	ScalingFactors []*big.Int // 10^(18 - decimals) by token
+	SwapFee        *big.Int   // 18-decimal, 3e15 = 0.3%
+}
+
+// PairObservation holds the price oracle state of a pair at one block
+type PairObservation struct {
+	Block                uint64
+	Reserve0             *big.Int
+	Reserve1             *big.Int
+	BlockTimestampLast   uint32 // last sync, modulo 2^32
+	Price0CumulativeLast *big.Int
+	Price1CumulativeLast *big.Int
+}
+
+// PairLiquidity holds the LP token state of a pair
+type PairLiquidity struct {
+	Reserves    *PoolReserves
+	TotalSupply *big.Int
+	KLast       *big.Int // reserve0 * reserve1 after the last liquidity event, 0 when the protocol fee is off
+	FeeOn       bool     // the factory has feeTo set, so the protocol fee is minted on mint and burn
 }
 
 // SwapCalculation holds intermediate calculation data
 type SwapCalculation struct {
-	AmountIn     *big.Int
-	AmountOut    *big.Int
-	ReserveIn    *big.Int
-	ReserveOut   *big.Int
-	TokenIn      *TokenInfo
-	TokenOut     *TokenInfo
-}
\ No newline at end of file
+	PoolType  string
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	TokenIn   *TokenInfo
+	TokenOut  *TokenInfo
+}
+
+// ListedToken is a token of a token list (https://tokenlists.org)
+type ListedToken struct {
+	ChainID  uint64 `json:"chain_id"`
+	Address  string `json:"address"` // lowercase
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals uint8  `json:"decimals"`
+	LogoURI  string `json:"logo_uri,omitempty"`
+	List     string `json:"list"` // name of the token list it comes from
+}
+
+// TokenSearchRequest searches the token lists by symbol, name or address
+type TokenSearchRequest struct {
+	Query string `json:"q" validate:"required"`
+	Limit string `json:"limit,omitempty" validate:"uint64"` // default 20
+	Chain string `json:"chain,omitempty"`
+}
+
+// TokenSearchResponse lists the matching tokens, exact symbol matches first
+type TokenSearchResponse struct {
+	Tokens []ListedToken `json:"tokens"`
+}
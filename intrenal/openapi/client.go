@@ -0,0 +1,380 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// GenerateClient renders a typed Go client for the document. The output is gofmt-ed
+// source for package packageName; cmd/clientgen writes it to client/client.go.
+func GenerateClient(doc *Document, packageName string) ([]byte, error) {
+	g := &clientGenerator{doc: doc}
+
+	data := struct {
+		Package    string
+		Title      string
+		Types      []clientType
+		Operations []clientOperation
+	}{
+		Package:    packageName,
+		Title:      doc.Info.Title,
+		Types:      g.types(),
+		Operations: g.operations(),
+	}
+
+	var buf bytes.Buffer
+	if err := clientTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated client does not compile: %v", err)
+	}
+	return source, nil
+}
+
+type clientField struct {
+	Name    string
+	Type    string
+	JSONTag string
+}
+
+type clientType struct {
+	Name   string
+	Fields []clientField
+}
+
+type clientParam struct {
+	Name   string // Go field name
+	Key    string // query key
+	Encode string // expression producing the string value, empty value skipped
+	Type   string
+}
+
+type clientOperation struct {
+	Name        string
+	Summary     string
+	Method      string
+	Path        string // fmt format with %s for path params
+	PathArgs    []string
+	QueryType   string
+	QueryParams []clientParam
+	BodyType    string
+	ResultType  string // empty for non-JSON responses
+}
+
+type clientGenerator struct {
+	doc *Document
+}
+
+// types renders every component schema as a struct
+func (g *clientGenerator) types() []clientType {
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	types := make([]clientType, 0, len(names))
+	for _, name := range names {
+		types = append(types, clientType{Name: name, Fields: g.fields(g.doc.Components.Schemas[name])})
+	}
+	return types
+}
+
+func (g *clientGenerator) fields(schema *Schema) []clientField {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	fields := make([]clientField, 0, len(schema.order))
+	for _, name := range schema.order {
+		tag := name
+		if !required[name] {
+			tag += ",omitempty"
+		}
+		fields = append(fields, clientField{
+			Name:    goName(name),
+			Type:    g.goType(schema.Properties[name], !required[name]),
+			JSONTag: tag,
+		})
+	}
+	return fields
+}
+
+// goType maps a schema to a Go type; optional references become pointers
+func (g *clientGenerator) goType(schema *Schema, optional bool) string {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		if optional {
+			return "*" + name
+		}
+		return name
+	}
+
+	switch schema.Type {
+	case "string":
+		if schema.Format == "date-time" {
+			return "time.Time"
+		}
+		return "string"
+	case "boolean":
+		return "bool"
+	case "integer":
+		switch schema.Format {
+		case "int32":
+			return "int"
+		case "uint32":
+			return "uint32"
+		case "uint64":
+			return "uint64"
+		}
+		return "int64"
+	case "number":
+		return "float64"
+	case "array":
+		return "[]" + g.goType(schema.Items, false)
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "map[string]" + g.goType(schema.AdditionalProperties, false)
+		}
+		return "map[string]interface{}"
+	}
+	return "json.RawMessage"
+}
+
+// operations renders every operation, sorted by name
+func (g *clientGenerator) operations() []clientOperation {
+	var operations []clientOperation
+
+	for path, item := range g.doc.Paths {
+		for method, op := range *item {
+			operation := clientOperation{
+				Name:    goName(op.OperationID),
+				Summary: op.Summary,
+				Method:  strings.ToUpper(method),
+				Path:    path,
+			}
+
+			for _, param := range op.Parameters {
+				switch param.In {
+				case "path":
+					operation.Path = strings.Replace(operation.Path, "{"+param.Name+"}", "%s", 1)
+					operation.PathArgs = append(operation.PathArgs, lowerFirst(goName(param.Name)))
+				case "query":
+					operation.QueryType = operation.Name + "Params"
+					operation.QueryParams = append(operation.QueryParams, queryParam(param, g.goType(param.Schema, false)))
+				}
+			}
+
+			if op.RequestBody != nil {
+				operation.BodyType = g.goType(op.RequestBody.Content["application/json"].Schema, false)
+			}
+
+			if media, ok := op.Responses["200"].Content["application/json"]; ok {
+				operation.ResultType = g.goType(media.Schema, false)
+			}
+
+			operations = append(operations, operation)
+		}
+	}
+
+	sort.Slice(operations, func(i, j int) bool { return operations[i].Name < operations[j].Name })
+	return operations
+}
+
+func queryParam(param Parameter, goType string) clientParam {
+	p := clientParam{Name: goName(param.Name), Key: param.Name, Type: goType}
+	switch goType {
+	case "string":
+		p.Encode = "params." + p.Name
+	case "bool":
+		p.Encode = "formatBool(params." + p.Name + ")"
+	case "uint32", "uint64":
+		p.Encode = "formatUint(uint64(params." + p.Name + "))"
+	case "int", "int64":
+		p.Encode = "formatInt(int64(params." + p.Name + "))"
+	default:
+		p.Encode = "fmt.Sprint(params." + p.Name + ")"
+	}
+	return p
+}
+
+// goName converts snake_case or camelCase to an exported Go identifier
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if upper := strings.ToUpper(part); upper == "ID" || upper == "URL" || upper == "API" || upper == "RPC" {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by cmd/clientgen from the OpenAPI document. DO NOT EDIT.
+
+// Package {{.Package}} is a typed client for the {{.Title}}.
+package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the API at BaseURL
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Token      string // sent as bearer token when set, e.g. AUDIT_ADMIN_TOKEN for audit lookups
+}
+
+// New creates a client for the API served at baseURL, e.g. http://localhost:1337
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+{{range .Types}}
+// {{.Name}} mirrors the {{.Name}} schema
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`json:\"{{.JSONTag}}\"`" + `
+{{- end}}
+}
+{{end}}
+// Error implements error so API failures can be inspected with errors.As
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API Error %d %s: %s", e.Code, e.ErrorCode, e.Message)
+}
+{{- range .Operations}}{{if .QueryType}}
+// {{.QueryType}} holds the query parameters of {{.Name}}; zero values are omitted
+type {{.QueryType}} struct {
+{{- range .QueryParams}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}{{end}}
+{{- range .Operations}}
+// {{.Name}} calls {{.Method}} {{.Path}}{{if .Summary}}: {{.Summary}}{{end}}
+{{- if not .ResultType}}
+// The caller must close the response body.{{end}}
+func (c *Client) {{.Name}}(ctx context.Context{{range .PathArgs}}, {{.}} string{{end}}{{if .QueryType}}, params {{.QueryType}}{{end}}{{if .BodyType}}, body *{{.BodyType}}{{end}}) ({{if .ResultType}}*{{.ResultType}}{{else}}*http.Response{{end}}, error) {
+	path := {{if .PathArgs}}fmt.Sprintf("{{.Path}}"{{range .PathArgs}}, url.PathEscape({{.}}){{end}}){{else}}"{{.Path}}"{{end}}
+	query := url.Values{}
+{{- range .QueryParams}}
+	if value := {{.Encode}}; value != "" {
+		query.Set("{{.Key}}", value)
+	}
+{{- end}}
+{{- if .ResultType}}
+	var result {{.ResultType}}
+	if err := c.do(ctx, "{{.Method}}", path, query, {{if .BodyType}}body{{else}}nil{{end}}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+{{- else}}
+	return c.send(ctx, "{{.Method}}", path, query, {{if .BodyType}}body{{else}}nil{{end}})
+{{- end}}
+}
+{{end}}
+// do sends a request and decodes a JSON response into out, or an *APIError on failure
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a request and returns the response when the status is 2xx
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := &APIError{Code: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
+
+func formatBool(value bool) string {
+	if !value {
+		return ""
+	}
+	return "true"
+}
+
+func formatUint(value uint64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatUint(value, 10)
+}
+
+func formatInt(value int64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatInt(value, 10)
+}
+`))
@@ -0,0 +1,350 @@
+package openapi
+
+import (
+	"math/big"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version of the OpenAPI specification the documents conform to
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document (only the parts this API uses)
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info holds API metadata
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the API is served under
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to operations
+type PathItem map[string]*Operation
+
+// Operation describes one endpoint
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a query or path parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes one response status
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema subset: objects, arrays, scalars and references
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// order keeps struct field order for code generation
+	order []string
+}
+
+// Components holds named schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Endpoint describes an API operation in terms of Go types.
+// Query, Body and Response are zero values of the models; nil means none
+// (for Response: a free-form JSON object).
+type Endpoint struct {
+	Method      string
+	Path        string // fiber syntax, e.g. /quotes/:id
+	OperationID string
+	Summary     string
+	Tag         string
+	Query       interface{} // struct whose json fields are query parameters
+	Body        interface{} // JSON request body
+	Response    interface{} // 200 response body
+	ContentType string      // 200 content type, default application/json
+	Errors      []int       // statuses answered with models.APIError
+}
+
+var pathParamRegex = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// Generate builds the document for the given endpoints. errorModel is the type
+// every error response uses (models.APIError).
+func Generate(info Info, servers []Server, endpoints []Endpoint, errorModel interface{}) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Servers:    servers,
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+
+	errorSchema := doc.schemaFor(reflect.TypeOf(errorModel))
+
+	for _, endpoint := range endpoints {
+		path := PathTemplate(endpoint.Path)
+
+		operation := &Operation{
+			OperationID: endpoint.OperationID,
+			Summary:     endpoint.Summary,
+			Responses:   make(map[string]*Response),
+		}
+		if endpoint.Tag != "" {
+			operation.Tags = []string{endpoint.Tag}
+		}
+
+		for _, match := range pathParamRegex.FindAllStringSubmatch(endpoint.Path, -1) {
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+
+		if endpoint.Query != nil {
+			operation.Parameters = append(operation.Parameters, queryParameters(reflect.TypeOf(endpoint.Query))...)
+		}
+
+		if endpoint.Body != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(doc.schemaFor(reflect.TypeOf(endpoint.Body))),
+			}
+		}
+
+		contentType := endpoint.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		responseSchema := &Schema{Type: "object"}
+		if endpoint.Response != nil {
+			responseSchema = doc.schemaFor(reflect.TypeOf(endpoint.Response))
+		}
+		operation.Responses["200"] = &Response{
+			Description: "Successful response",
+			Content:     map[string]MediaType{contentType: {Schema: responseSchema}},
+		}
+
+		for _, status := range endpoint.Errors {
+			operation.Responses[strconv.Itoa(status)] = &Response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(errorSchema),
+			}
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(endpoint.Method)] = operation
+	}
+
+	return doc
+}
+
+// PathTemplate converts a fiber path (/quotes/:id) to an OpenAPI path template (/quotes/{id})
+func PathTemplate(path string) string {
+	return pathParamRegex.ReplaceAllString(path, "{$1}")
+}
+
+// Schema returns a component schema by name, or nil
+func (d *Document) Schema(name string) *Schema {
+	return d.Components.Schemas[name]
+}
+
+// Resolve follows a $ref to its component schema
+func (d *Document) Resolve(schema *Schema) *Schema {
+	if schema != nil && schema.Ref != "" {
+		return d.Schema(strings.TrimPrefix(schema.Ref, "#/components/schemas/"))
+	}
+	return schema
+}
+
+// Operation returns the operation for a method and path in OpenAPI syntax, or nil
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return (*item)[strings.ToLower(method)]
+}
+
+// Order returns property names in struct field order
+func (s *Schema) Order() []string {
+	return s.order
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// schemaFor returns the schema of a Go type; named structs become $ref components
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(big.Int{}):
+		return &Schema{Type: "string", Format: "uint256"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "uint32"}
+	case reflect.Uint64:
+		return &Schema{Type: "integer", Format: "uint64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		if _, ok := d.Components.Schemas[t.Name()]; !ok {
+			d.Components.Schemas[t.Name()] = &Schema{} // placeholder for recursive types
+			d.Components.Schemas[t.Name()] = d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	return &Schema{}
+}
+
+// structSchema describes the JSON-visible fields of a struct
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for _, field := range jsonFields(t) {
+		schema.Properties[field.name] = d.schemaFor(field.Type)
+		schema.order = append(schema.order, field.name)
+		if field.required {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+
+	return schema
+}
+
+// queryParameters describes the fields of a query model as query parameters
+func queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	scratch := &Document{Components: Components{Schemas: make(map[string]*Schema)}}
+	for _, field := range jsonFields(t) {
+		params = append(params, Parameter{
+			Name:     field.name,
+			In:       "query",
+			Required: hasRule(field.Tag.Get("validate"), "required"),
+			Schema:   scratch.schemaFor(field.Type),
+		})
+	}
+	return params
+}
+
+type jsonField struct {
+	reflect.StructField
+	name     string
+	required bool
+}
+
+// jsonFields lists exported fields as encoding/json sees them. A field is required when
+// it is always serialized (no omitempty) or when its validate tag requires it.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		omitEmpty := strings.Contains(options, "omitempty")
+
+		fields = append(fields, jsonField{
+			StructField: field,
+			name:        name,
+			required:    !omitEmpty || hasRule(field.Tag.Get("validate"), "required"),
+		})
+	}
+	return fields
+}
+
+func hasRule(rules, rule string) bool {
+	for _, r := range strings.Split(rules, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
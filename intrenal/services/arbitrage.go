@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// ArbitrageService searches short cycles over V2 pools for profitable arbitrage: the same
+// pair priced differently on two forks, or triangular cycles through three pairs
+type ArbitrageService struct {
+	blockchain *BlockchainService
+	pools      []string // tracked pools, used when a request names none
+	maxHops    int
+	maxPools   int
+}
+
+// NewArbitrageService creates a new arbitrage service
+func NewArbitrageService(blockchain *BlockchainService, pools []string, maxHops, maxPools int) *ArbitrageService {
+	return &ArbitrageService{
+		blockchain: blockchain,
+		pools:      pools,
+		maxHops:    maxHops,
+		maxPools:   maxPools,
+	}
+}
+
+// edge is one direction of a pool in the token graph
+type edge struct {
+	pool     string
+	tokenOut string
+	hop      utils.Hop
+}
+
+// FindArbitrage reads every pool at the latest block and returns the profitable cycles
+// of at most max_hops pools, each traded with its optimal input
+func (as *ArbitrageService) FindArbitrage(ctx context.Context, req *models.ArbitrageRequest) (*models.ArbitrageResponse, error) {
+	pools := as.pools
+	if req.Pools != "" {
+		pools = nil
+		for _, pool := range strings.Split(req.Pools, ",") {
+			pools = append(pools, strings.TrimSpace(pool))
+		}
+	}
+	pools = uniqueAddresses(pools)
+	if len(pools) < 2 || len(pools) > as.maxPools {
+		return nil, models.ErrInvalidPoolList.WithDetails(
+			fmt.Sprintf("Got %d pools, need between 2 and %d", len(pools), as.maxPools),
+		)
+	}
+
+	maxHops := as.maxHops
+	if req.MaxHops != "" {
+		hops, _ := strconv.Atoi(req.MaxHops)
+		if hops < 2 {
+			return nil, models.NewValidationError([]models.FieldError{
+				{Field: "max_hops", Rule: "min", Message: "a cycle needs at least 2 hops"},
+			})
+		}
+		maxHops = min(hops, as.maxHops)
+	}
+
+	block, err := as.blockchain.LatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := as.blockchain.GetBatchState(ctx, pools, nil, block)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ArbitrageResponse{
+		BlockNumber:   block.Uint64(),
+		Opportunities: []models.ArbitrageOpportunity{},
+	}
+
+	// Token graph with one edge per pool direction, in pool order. Each pool charges
+	// the fee of the fork that deployed it.
+	graph := make(map[string][]edge)
+	for _, pool := range pools {
+		reserves, ok := state.Pools[pool]
+		if !ok || reserves.Reserve0.Sign() == 0 || reserves.Reserve1.Sign() == 0 {
+			response.SkippedPools = append(response.SkippedPools, pool)
+			continue
+		}
+		feeBps := state.Models[pool].(constantProductModel).feeBps
+		graph[reserves.Token0] = append(graph[reserves.Token0], edge{
+			pool: pool, tokenOut: reserves.Token1,
+			hop: utils.Hop{ReserveIn: reserves.Reserve0, ReserveOut: reserves.Reserve1, FeeBps: feeBps},
+		})
+		graph[reserves.Token1] = append(graph[reserves.Token1], edge{
+			pool: pool, tokenOut: reserves.Token0,
+			hop: utils.Hop{ReserveIn: reserves.Reserve1, ReserveOut: reserves.Reserve0, FeeBps: feeBps},
+		})
+	}
+
+	starts := make([]string, 0, len(graph))
+	if req.Start != "" {
+		starts = append(starts, utils.NormalizeAddress(req.Start))
+	} else {
+		for token := range graph {
+			starts = append(starts, token)
+		}
+		sort.Strings(starts)
+	}
+
+	// Without a start token every cycle is found once from each of its tokens; only
+	// the first profitable rotation, in start token order, is reported
+	reported := make(map[string]bool)
+	for _, start := range starts {
+		var found []models.ArbitrageOpportunity
+		searchCycles(graph, start, maxHops, func(path []edge) {
+			key := cycleKey(path)
+			if reported[key] {
+				return
+			}
+			if opportunity, ok := evaluateCycle(start, path); ok {
+				reported[key] = true
+				found = append(found, opportunity)
+			}
+		})
+
+		sort.SliceStable(found, func(i, j int) bool {
+			pi, _ := new(big.Int).SetString(found[i].Profit, 10)
+			pj, _ := new(big.Int).SetString(found[j].Profit, 10)
+			return pi.Cmp(pj) > 0
+		})
+		response.Opportunities = append(response.Opportunities, found...)
+	}
+
+	return response, nil
+}
+
+// searchCycles calls visit for every cycle from start back to start of 2 to maxHops
+// pools, using each pool at most once
+func searchCycles(graph map[string][]edge, start string, maxHops int, visit func([]edge)) {
+	path := make([]edge, 0, maxHops)
+	used := make(map[string]bool, maxHops)
+
+	var extend func(token string)
+	extend = func(token string) {
+		for _, next := range graph[token] {
+			if used[next.pool] {
+				continue
+			}
+
+			path = append(path, next)
+			used[next.pool] = true
+
+			if next.tokenOut == start {
+				if len(path) >= 2 {
+					visit(path)
+				}
+			} else if len(path) < maxHops {
+				extend(next.tokenOut)
+			}
+
+			used[next.pool] = false
+			path = path[:len(path)-1]
+		}
+	}
+
+	extend(start)
+}
+
+// cycleKey identifies a cycle by its sorted pools, the same for every rotation. A
+// cycle and its reverse share the key too, but at most one of them is profitable.
+func cycleKey(path []edge) string {
+	pools := make([]string, len(path))
+	for i, step := range path {
+		pools[i] = step.pool
+	}
+	sort.Strings(pools)
+	return strings.Join(pools, ",")
+}
+
+// evaluateCycle trades a cycle with its optimal input and reports it when profitable
+func evaluateCycle(start string, path []edge) (models.ArbitrageOpportunity, bool) {
+	hops := make([]utils.Hop, len(path))
+	for i, step := range path {
+		hops[i] = step.hop
+	}
+
+	amountIn := utils.OptimalArbitrageInput(hops)
+	if amountIn.Sign() == 0 {
+		return models.ArbitrageOpportunity{}, false
+	}
+
+	// The optimum comes from continuous math; integer rounding can still eat the profit
+	amountOut, err := utils.CycleAmountOut(amountIn, hops)
+	if err != nil || amountOut.Cmp(amountIn) <= 0 {
+		return models.ArbitrageOpportunity{}, false
+	}
+
+	opportunity := models.ArbitrageOpportunity{
+		StartToken: start,
+		Path:       []string{start},
+		AmountIn:   amountIn.String(),
+		AmountOut:  amountOut.String(),
+		Profit:     new(big.Int).Sub(amountOut, amountIn).String(),
+	}
+	for _, step := range path {
+		opportunity.Path = append(opportunity.Path, step.tokenOut)
+		opportunity.Pools = append(opportunity.Pools, step.pool)
+	}
+	return opportunity, true
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"uniswap-est/intrenal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// auditPruneInterval is how often records older than the retention are deleted
+const auditPruneInterval = time.Hour
+
+// auditSchema creates the quote table; times are Unix nanoseconds and reserves are
+// space separated. request_id is generated by the server, client_request_id is the
+// X-Request-ID the client sent, if any.
+const auditSchema = `
+CREATE TABLE IF NOT EXISTS quotes (
+	id                INTEGER PRIMARY KEY AUTOINCREMENT,
+	request_id        TEXT    NOT NULL,
+	client_request_id TEXT    NOT NULL,
+	item              INTEGER NOT NULL,
+	time              INTEGER NOT NULL,
+	requester         TEXT    NOT NULL,
+	user_agent        TEXT    NOT NULL,
+	transport         TEXT    NOT NULL,
+	chain             TEXT    NOT NULL,
+	pool              TEXT    NOT NULL,
+	src               TEXT    NOT NULL,
+	dst               TEXT    NOT NULL,
+	requested_src     TEXT    NOT NULL,
+	requested_dst     TEXT    NOT NULL,
+	src_amount        TEXT    NOT NULL,
+	dex               TEXT    NOT NULL,
+	block_number      INTEGER NOT NULL,
+	pool_type         TEXT    NOT NULL,
+	fee_model         TEXT    NOT NULL,
+	reserves          TEXT    NOT NULL,
+	dst_amount        TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS quotes_request_id ON quotes (request_id);
+CREATE INDEX IF NOT EXISTS quotes_time ON quotes (time);`
+
+// auditColumns are the columns of a record, in QuoteRecord and CSV order
+const auditColumns = "request_id, client_request_id, item, time, requester, user_agent, transport, chain, " +
+	"pool, src, dst, requested_src, requested_dst, src_amount, dex, block_number, pool_type, fee_model, reserves, dst_amount"
+
+// Transports a quote can be served over
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
+// Requester identifies the client request a quote is served for. Quotes are only
+// recorded in the audit log when their context carries one, see WithRequester.
+type Requester struct {
+	RequestID       string // generated by the server, quotes are looked up by it
+	ClientRequestID string // the client's own request ID, kept for reference only
+	Address         string // client IP address
+	UserAgent       string
+	Transport       string // TransportHTTP or TransportGRPC
+
+	// Items is the position in the client's batch of each request quoted, when items
+	// that failed validation were left out; nil when they are the same
+	Items []int
+}
+
+type requesterKey struct{}
+
+// WithRequester returns a context whose quotes are recorded for requester
+func WithRequester(ctx context.Context, requester Requester) context.Context {
+	return context.WithValue(ctx, requesterKey{}, requester)
+}
+
+// requesterOf returns the requester of a context, if any
+func requesterOf(ctx context.Context) (Requester, bool) {
+	requester, ok := ctx.Value(requesterKey{}).(Requester)
+	return requester, ok
+}
+
+// item returns the batch position of the i-th quoted request
+func (r Requester) item(i int) int {
+	if i < len(r.Items) {
+		return r.Items[i]
+	}
+	return i
+}
+
+// NewRequestID returns a random request ID. Audited quotes are always recorded under
+// one of these, never under an ID the client chose.
+func NewRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// AuditLog records every quote served to a client in an SQLite database. Record never
+// blocks a request: records are queued and written in batches by Run, and a record
+// that finds the queue full is dropped and counted, like one lost to a failed write.
+type AuditLog struct {
+	db        *sql.DB
+	batchSize int
+	retention time.Duration
+	records   chan models.QuoteRecord
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	pruned  atomic.Uint64
+	pending atomic.Int64
+
+	mu      sync.Mutex
+	running bool // Run has started
+	closed  bool // Close has been called; Run no longer starts
+	stopped chan struct{}
+}
+
+// OpenAuditLog opens or creates the audit database at path. Up to queueSize records
+// wait to be written, batchSize at a time; records older than retention are pruned,
+// none when it is 0.
+func OpenAuditLog(path string, batchSize, queueSize int, retention time.Duration) (*AuditLog, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(auditSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("audit log %s: %w", path, err)
+	}
+
+	return &AuditLog{
+		db:        db,
+		batchSize: max(batchSize, 1),
+		retention: retention,
+		records:   make(chan models.QuoteRecord, queueSize),
+		stopped:   make(chan struct{}),
+	}, nil
+}
+
+// Record queues a served quote. It never blocks: when the queue is full the record is
+// dropped and counted.
+func (a *AuditLog) Record(record models.QuoteRecord) {
+	a.pending.Add(1)
+	select {
+	case a.records <- record:
+	default:
+		a.pending.Add(-1)
+		a.dropped.Add(1)
+	}
+}
+
+// Run writes queued records until ctx is cancelled, in batches of batchSize or every
+// flushInterval, whichever comes first, and prunes expired records. On cancellation
+// it writes what is queued before returning.
+func (a *AuditLog) Run(ctx context.Context, flushInterval time.Duration) {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return // Close writes the queue itself
+	}
+	a.running = true
+	a.mu.Unlock()
+	defer close(a.stopped)
+
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(auditPruneInterval)
+	defer prune.Stop()
+
+	a.prune(ctx)
+
+	batch := make([]models.QuoteRecord, 0, a.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			a.writeQueued(batch)
+			return
+		case record := <-a.records:
+			batch = append(batch, record)
+			if len(batch) >= a.batchSize {
+				a.write(batch)
+				batch = batch[:0]
+			}
+		case <-flush.C:
+			a.write(batch)
+			batch = batch[:0]
+		case <-prune.C:
+			a.prune(ctx)
+		}
+	}
+}
+
+// writeQueued writes batch and every record still queued
+func (a *AuditLog) writeQueued(batch []models.QuoteRecord) {
+	for {
+		select {
+		case record := <-a.records:
+			batch = append(batch, record)
+		default:
+			a.write(batch)
+			return
+		}
+	}
+}
+
+// write inserts a batch in one transaction. A failed batch is logged and counted,
+// not retried. Writes are not cancelled with Run, so that a batch is not lost on
+// shutdown.
+func (a *AuditLog) write(batch []models.QuoteRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	defer a.pending.Add(-int64(len(batch)))
+
+	if err := a.insert(context.Background(), batch); err != nil {
+		a.failed.Add(uint64(len(batch)))
+		log.Printf("Audit log: failed to write %d quote records: %v", len(batch), err)
+		return
+	}
+	a.written.Add(uint64(len(batch)))
+}
+
+func (a *AuditLog) insert(ctx context.Context, batch []models.QuoteRecord) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", strings.Count(auditColumns, ",")+1), ", ")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO quotes ("+auditColumns+") VALUES ("+placeholders+")")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range batch {
+		if _, err := stmt.ExecContext(ctx, r.RequestID, r.ClientRequestID, r.Item, r.Time.UnixNano(), r.Requester, r.UserAgent,
+			r.Transport, r.Chain, r.Pool, r.Src, r.Dst, r.RequestedSrc, r.RequestedDst, r.SrcAmount, r.Dex,
+			int64(r.BlockNumber), r.PoolType, r.FeeModel, strings.Join(r.Reserves, " "), r.DstAmount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// prune deletes the records older than the retention
+func (a *AuditLog) prune(ctx context.Context) {
+	if a.retention <= 0 {
+		return
+	}
+	if _, err := a.Prune(ctx, time.Now().Add(-a.retention)); err != nil {
+		log.Printf("Audit log: failed to prune records: %v", err)
+	}
+}
+
+// Prune deletes the records served before cutoff and returns how many were deleted
+func (a *AuditLog) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := a.db.ExecContext(ctx, "DELETE FROM quotes WHERE time < ?", cutoff.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	a.pruned.Add(uint64(deleted))
+	return deleted, nil
+}
+
+// Lookup returns the written records of a request ID in batch order; records still
+// queued are not found yet
+func (a *AuditLog) Lookup(ctx context.Context, requestID string) ([]models.QuoteRecord, error) {
+	rows, err := a.db.QueryContext(ctx,
+		"SELECT "+auditColumns+" FROM quotes WHERE request_id = ? ORDER BY item, id", requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []models.QuoteRecord
+	for rows.Next() {
+		record, err := scanQuoteRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// Export writes the records served from from until to as CSV, oldest first, with a
+// header naming the QuoteRecord fields; a zero from or to leaves that end open. It
+// returns the number of records written.
+func (a *AuditLog) Export(ctx context.Context, w io.Writer, from, to time.Time) (int, error) {
+	query := "SELECT " + auditColumns + " FROM quotes WHERE time >= ?"
+	args := []any{int64(0)}
+	if !from.IsZero() {
+		args[0] = from.UnixNano()
+	}
+	if !to.IsZero() {
+		query += " AND time < ?"
+		args = append(args, to.UnixNano())
+	}
+
+	rows, err := a.db.QueryContext(ctx, query+" ORDER BY time, id", args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	out := csv.NewWriter(w)
+	if err := out.Write(strings.Split(auditColumns, ", ")); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		r, err := scanQuoteRecord(rows)
+		if err != nil {
+			return count, err
+		}
+		if err := out.Write([]string{
+			r.RequestID, r.ClientRequestID, strconv.Itoa(r.Item), r.Time.Format(time.RFC3339Nano), r.Requester, r.UserAgent,
+			r.Transport, r.Chain, r.Pool, r.Src, r.Dst, r.RequestedSrc, r.RequestedDst, r.SrcAmount, r.Dex,
+			strconv.FormatUint(r.BlockNumber, 10), r.PoolType, r.FeeModel, strings.Join(r.Reserves, " "), r.DstAmount,
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	out.Flush()
+	return count, out.Error()
+}
+
+// scanQuoteRecord reads a row selected with auditColumns
+func scanQuoteRecord(rows *sql.Rows) (models.QuoteRecord, error) {
+	var r models.QuoteRecord
+	var nanos, block int64
+	var reserves string
+	err := rows.Scan(&r.RequestID, &r.ClientRequestID, &r.Item, &nanos, &r.Requester, &r.UserAgent, &r.Transport, &r.Chain,
+		&r.Pool, &r.Src, &r.Dst, &r.RequestedSrc, &r.RequestedDst, &r.SrcAmount, &r.Dex, &block,
+		&r.PoolType, &r.FeeModel, &reserves, &r.DstAmount)
+	r.Time = time.Unix(0, nanos).UTC()
+	r.BlockNumber = uint64(block)
+	r.Reserves = strings.Fields(reserves)
+	return r, err
+}
+
+// Stats returns a snapshot of the audit counters
+func (a *AuditLog) Stats() models.AuditStats {
+	return models.AuditStats{
+		Written: a.written.Load(),
+		Dropped: a.dropped.Load(),
+		Failed:  a.failed.Load(),
+		Pruned:  a.pruned.Load(),
+		Pending: int(a.pending.Load()),
+	}
+}
+
+// Close waits for Run, if it was started, to write the queued records and closes the
+// database. The context of Run must be cancelled first. When Run has not started, as in
+// a shutdown racing its goroutine, Close writes the queue itself and Run never starts.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	running := a.running
+	a.mu.Unlock()
+
+	if running {
+		<-a.stopped
+	} else {
+		a.writeQueued(nil)
+	}
+	return a.db.Close()
+}
@@ -1,20 +1,25 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math/big"
 	"strings"
+	"time"
+	"unicode/utf8"
 	"uniswap-est/intrenal/config"
 	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// ERC20 ABI for decimals() and symbol() functions
+// ERC20 ABI for decimals(), symbol() and name() functions
 const erc20ABI = `[
 	{
 		"constant": true,
@@ -29,10 +34,18 @@ const erc20ABI = `[
 		"name": "symbol", 
 		"outputs": [{"name": "", "type": "string"}],
 		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "name",
+		"outputs": [{"name": "", "type": "string"}],
+		"type": "function"
 	}
 ]`
 
-// Uniswap V2 Pair ABI for getReserves() function
+// Uniswap V2 Pair ABI for reserves, token order, the price oracle accumulators and the
+// LP token supply
 const pairABI = `[
 	{
 		"constant": true,
@@ -58,24 +71,137 @@ const pairABI = `[
 		"name": "token1", 
 		"outputs": [{"name": "", "type": "address"}],
 		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "price0CumulativeLast",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "price1CumulativeLast",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "totalSupply",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "kLast",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "factory",
+		"outputs": [{"name": "", "type": "address"}],
+		"type": "function"
+	}
+]`
+
+// Uniswap V2 Factory ABI for feeTo(), which switches the protocol fee on, and getPair(),
+// the pair it deployed for two tokens
+const factoryABI = `[
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "feeTo",
+		"outputs": [{"name": "", "type": "address"}],
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [
+			{"name": "tokenA", "type": "address"},
+			{"name": "tokenB", "type": "address"}
+		],
+		"name": "getPair",
+		"outputs": [{"name": "pair", "type": "address"}],
+		"type": "function"
+	}
+]`
+
+// Uniswap V2 Router02 ABI for getAmountsOut() - used only to verify off-chain math
+const routerABI = `[
+	{
+		"constant": true,
+		"inputs": [
+			{"name": "amountIn", "type": "uint256"},
+			{"name": "path", "type": "address[]"}
+		],
+		"name": "getAmountsOut",
+		"outputs": [{"name": "amounts", "type": "uint256[]"}],
+		"type": "function"
 	}
 ]`
 
 type BlockchainService struct {
-	client   *ethclient.Client
-	erc20ABI abi.ABI
-	pairABI  abi.ABI
-	config   *config.Config
+	client            ChainClient
+	erc20ABI          abi.ABI
+	pairABI           abi.ABI
+	factoryABI        abi.ABI
+	routerABI         abi.ABI
+	v3PoolABI         abi.ABI
+	solidlyPairABI    abi.ABI
+	solidlyFactoryABI abi.ABI
+	curvePoolABI      abi.ABI
+	balancerPoolABI   abi.ABI
+	balancerVaultABI  abi.ABI
+	config            *config.Config
+	chain             *config.ChainConfig
+	pairVerdicts      *pairVerdicts // nil unless cfg.ValidatePools
 }
 
-// NewBlockchainService creates a new blockchain service
-func NewBlockchainService(cfg *config.Config) (*BlockchainService, error) {
-	// Connect to Ethereum node (Alchemy)
-	client, err := ethclient.Dial(cfg.EthereumRPCURL)
-	if err != nil {
-		return nil, err
+// chainIDTimeout bounds the eth_chainId check of each RPC endpoint at startup
+const chainIDTimeout = 10 * time.Second
+
+// NewBlockchainService connects to the first RPC endpoint of the chain that answers
+// eth_chainId with the configured chain ID. Endpoints that cannot be reached or serve
+// another chain are skipped with a log line.
+func NewBlockchainService(cfg *config.Config, chain *config.ChainConfig) (*BlockchainService, error) {
+	var failures []error
+	for _, url := range chain.RPCURLs {
+		ctx, cancel := context.WithTimeout(context.Background(), chainIDTimeout)
+		client, err := DialChainClient(ctx, url)
+		if err == nil {
+			var service *BlockchainService
+			service, err = NewChainBlockchainService(cfg, chain, client)
+			if err == nil {
+				err = service.CheckChainID(ctx)
+			}
+			if err == nil {
+				cancel()
+				return service, nil
+			}
+			client.Close()
+		}
+		cancel()
+
+		log.Printf("Chain %s: RPC endpoint %d unusable: %v", chain.Name, len(failures)+1, err)
+		failures = append(failures, err)
 	}
+	return nil, fmt.Errorf("chain %s: no usable RPC endpoint: %w", chain.Name, errors.Join(failures...))
+}
+
+// NewBlockchainServiceWithClient creates a blockchain service for the default chain on
+// top of an existing client
+func NewBlockchainServiceWithClient(cfg *config.Config, client ChainClient) (*BlockchainService, error) {
+	return NewChainBlockchainService(cfg, cfg.DefaultChainConfig(), client)
+}
 
+// NewChainBlockchainService creates a blockchain service for a chain on top of an
+// existing client. The chain ID is not checked; see CheckChainID.
+func NewChainBlockchainService(cfg *config.Config, chain *config.ChainConfig, client ChainClient) (*BlockchainService, error) {
 	// Parse ABIs
 	erc20Parsed, err := abi.JSON(strings.NewReader(erc20ABI))
 	if err != nil {
@@ -87,159 +213,740 @@ func NewBlockchainService(cfg *config.Config) (*BlockchainService, error) {
 		return nil, err
 	}
 
-	return &BlockchainService{
-		client:   client,
-		erc20ABI: erc20Parsed,
-		pairABI:  pairParsed,
-		config:   cfg,
-	}, nil
-}
+	factoryParsed, err := abi.JSON(strings.NewReader(factoryABI))
+	if err != nil {
+		return nil, err
+	}
 
-// GetTokenInfo fetches token decimals and symbol from blockchain
-func (bs *BlockchainService) GetTokenInfo(ctx context.Context, tokenAddress string) (*models.TokenInfo, error) {
-	address := common.HexToAddress(tokenAddress)
+	routerParsed, err := abi.JSON(strings.NewReader(routerABI))
+	if err != nil {
+		return nil, err
+	}
 
-	// Get decimals
-	decimalsData, err := bs.erc20ABI.Pack("decimals")
+	v3PoolParsed, err := abi.JSON(strings.NewReader(v3PoolABI))
 	if err != nil {
 		return nil, err
 	}
 
-	decimalsResult, err := bs.client.CallContract(ctx, ethereum.CallMsg{
-		To:   &address,
-		Data: decimalsData,
-	}, nil)
+	solidlyPairParsed, err := abi.JSON(strings.NewReader(solidlyPairABI))
 	if err != nil {
-		return nil, models.ErrBlockchainConnection
+		return nil, err
 	}
 
-	var decimals uint8
-	err = bs.erc20ABI.UnpackIntoInterface(&decimals, "decimals", decimalsResult)
+	solidlyFactoryParsed, err := abi.JSON(strings.NewReader(solidlyFactoryABI))
 	if err != nil {
 		return nil, err
 	}
 
-	// Get symbol
-	symbolData, err := bs.erc20ABI.Pack("symbol")
+	curvePoolParsed, err := abi.JSON(strings.NewReader(curvePoolABI))
 	if err != nil {
 		return nil, err
 	}
 
-	symbolResult, err := bs.client.CallContract(ctx, ethereum.CallMsg{
-		To:   &address,
-		Data: symbolData,
-	}, nil)
+	balancerPoolParsed, err := abi.JSON(strings.NewReader(balancerPoolABI))
 	if err != nil {
-		return nil, models.ErrBlockchainConnection
+		return nil, err
 	}
 
-	var symbol string
-	err = bs.erc20ABI.UnpackIntoInterface(&symbol, "symbol", symbolResult)
+	balancerVaultParsed, err := abi.JSON(strings.NewReader(balancerVaultABI))
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.TokenInfo{
-		Address:  tokenAddress,
-		Decimals: decimals,
-		Symbol:   symbol,
+	return &BlockchainService{
+		client:            client,
+		erc20ABI:          erc20Parsed,
+		pairABI:           pairParsed,
+		factoryABI:        factoryParsed,
+		routerABI:         routerParsed,
+		v3PoolABI:         v3PoolParsed,
+		solidlyPairABI:    solidlyPairParsed,
+		solidlyFactoryABI: solidlyFactoryParsed,
+		curvePoolABI:      curvePoolParsed,
+		balancerPoolABI:   balancerPoolParsed,
+		balancerVaultABI:  balancerVaultParsed,
+		config:            cfg,
+		chain:             chain,
+		pairVerdicts:      newPairVerdicts(cfg.ValidatePools),
 	}, nil
 }
 
+// Client returns the client the service reads through
+func (bs *BlockchainService) Client() ChainClient {
+	return bs.client
+}
+
+// Chain returns the chain the service reads
+func (bs *BlockchainService) Chain() *config.ChainConfig {
+	return bs.chain
+}
+
+// CheckChainID asks the node for its chain ID and fails unless it is the configured one,
+// so that a misconfigured RPC URL cannot quote another chain's pools
+func (bs *BlockchainService) CheckChainID(ctx context.Context) error {
+	chainID, err := bs.client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("eth_chainId failed: %w", err)
+	}
+	if !chainID.IsUint64() || chainID.Uint64() != bs.chain.ChainID {
+		return fmt.Errorf("the node serves chain ID %s, expected %d for %s", chainID, bs.chain.ChainID, bs.chain.Name)
+	}
+	return nil
+}
+
+// BatchState holds pool and token state fetched in a single batched read.
+// Keys are lowercase addresses. Every recognised pool has a pricing model in Models;
+// only Uniswap V2 pairs also have reserves in Pools; the other pools are in PoolErrors
+// with ErrUnsupportedPool, so that endpoints built on V2 reserves refuse them.
+type BatchState struct {
+	Pools      map[string]*models.PoolReserves
+	Models     map[string]PricingModel
+	PoolErrors map[string]error
+	NoCode     map[string]bool // pools with no contract at the block, also in PoolErrors
+	Tokens     map[string]*models.TokenInfo
+}
+
+// addModel records the pricing model of a pool that is not a Uniswap V2 pair
+func (state *BatchState) addModel(pool string, model PricingModel) {
+	state.Models[pool] = model
+	state.PoolErrors[pool] = models.ErrUnsupportedPool
+}
+
+// GetTokenInfo fetches token decimals, symbol and name from blockchain.
+// Metadata is best effort: tokens that revert or return non-standard data are reported
+// with MetadataAvailable=false instead of an error, since quoting does not need it.
+// Only transport failures are returned as errors.
+func (bs *BlockchainService) GetTokenInfo(ctx context.Context, tokenAddress string) (*models.TokenInfo, error) {
+	state, err := bs.GetBatchState(ctx, nil, []string{tokenAddress}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return state.Tokens[strings.ToLower(tokenAddress)], nil
+}
+
+// LatestBlock returns the current block number so that related reads can be pinned to it
+func (bs *BlockchainService) LatestBlock(ctx context.Context) (*big.Int, error) {
+	number, err := bs.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, models.ErrBlockchainConnection.WithCause(err)
+	}
+	return new(big.Int).SetUint64(number), nil
+}
+
 // GetPoolReserves fetches current reserves from Uniswap V2 pair
 func (bs *BlockchainService) GetPoolReserves(ctx context.Context, poolAddress string) (*models.PoolReserves, error) {
-	address := common.HexToAddress(poolAddress)
-
-	// Debug logging
-	log.Printf("Fetching reserves for pool: %s", poolAddress)
-	log.Printf("Using RPC: %s", bs.config.EthereumRPCURL)
+	return bs.GetPoolReservesAt(ctx, poolAddress, nil)
+}
 
-	// Get reserves
-	reservesData, err := bs.pairABI.Pack("getReserves")
+// GetPoolReservesAt fetches reserves from Uniswap V2 pair at the given block (nil = latest)
+func (bs *BlockchainService) GetPoolReservesAt(ctx context.Context, poolAddress string, block *big.Int) (*models.PoolReserves, error) {
+	state, err := bs.GetBatchState(ctx, []string{poolAddress}, nil, block)
 	if err != nil {
-		log.Printf("Error packing getReserves: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Packed data length: %d bytes", len(reservesData))
+	normalized := strings.ToLower(poolAddress)
+	if err := state.PoolErrors[normalized]; err != nil {
+		return nil, err
+	}
+	return state.Pools[normalized], nil
+}
+
+// GetBatchState fetches reserves and token order for every pool and metadata for every
+// token in one batched JSON-RPC read pinned to block (nil = latest). Duplicate addresses
+// are fetched once. Pairs at the CREATE2 address of a V2 deployment of the chain need
+// nothing more. The other pools are told apart in one more batched read: pairs answering
+// stable() are Solidly-style pairs, and pools that are not pairs are probed as Uniswap
+// V3, Curve and Balancer weighted pools at once; the pools found then take the reads of
+// their type. Pools that are none of these, and V2 pairs failing pool validation, are
+// reported in PoolErrors.
+func (bs *BlockchainService) GetBatchState(ctx context.Context, pools, tokens []string, block *big.Int) (*BatchState, error) {
+	pools = uniqueAddresses(pools)
+	tokens = uniqueAddresses(tokens)
+
+	poolMethods := []string{"getReserves", "token0", "token1"}
+	tokenMethods := []string{"decimals", "symbol", "name"}
+
+	calls := make([]ContractCall, 0, len(pools)*len(poolMethods)+len(tokens)*len(tokenMethods))
+	for _, pool := range pools {
+		for _, method := range poolMethods {
+			call, err := newContractCall(bs.pairABI, pool, method, block)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+		}
+	}
+	for _, token := range tokens {
+		for _, method := range tokenMethods {
+			call, err := newContractCall(bs.erc20ABI, token, method, block)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+		}
+	}
+
+	log.Printf("Fetching state for %d pools and %d tokens in %d calls", len(pools), len(tokens), len(calls))
 
-	reservesResult, err := bs.client.CallContract(ctx, ethereum.CallMsg{
-		To:   &address,
-		Data: reservesData,
-	}, nil)
+	err := bs.client.BatchCallContract(ctx, calls)
 	if err != nil {
-		log.Printf("CallContract ERROR: %v", err)
-		log.Printf("Pool address: %s", poolAddress)
-		log.Printf("Call data: %x", reservesData)
-		return nil, models.ErrPoolNotFound
+		log.Printf("Batch call failed: %v", err)
+		return nil, models.ErrBlockchainConnection.WithCause(err)
 	}
 
-	log.Printf("Got reserves result: %x", reservesResult)
+	// Anything other than an EVM-level failure means the node did not answer properly
+	for _, call := range calls {
+		if call.Error != nil && !isExecutionError(call.Error) {
+			log.Printf("Call to %s failed: %v", call.Msg.To.Hex(), call.Error)
+			return nil, models.ErrBlockchainConnection.WithCause(call.Error)
+		}
+	}
 
-	var reserves struct {
-		Reserve0           *big.Int
-		Reserve1           *big.Int
-		BlockTimestampLast uint32
+	state := &BatchState{
+		Pools:      make(map[string]*models.PoolReserves, len(pools)),
+		Models:     make(map[string]PricingModel, len(pools)),
+		PoolErrors: make(map[string]error),
+		NoCode:     make(map[string]bool),
+		Tokens:     make(map[string]*models.TokenInfo, len(tokens)),
+	}
+
+	var otherPairs, notPairs []string
+	pairs := make(map[string]*models.PoolReserves)
+	offset := 0
+	for _, pool := range pools {
+		poolCalls := calls[offset : offset+len(poolMethods)]
+		reserves, err := bs.decodePool(poolCalls)
+		switch {
+		case err != nil && hasNoCode(poolCalls):
+			state.PoolErrors[pool] = err // nothing to probe
+			state.NoCode[pool] = true
+		case err != nil:
+			state.PoolErrors[pool] = err
+			notPairs = append(notPairs, pool)
+		case bs.isV2Pair(pool, reserves):
+			state.Pools[pool] = reserves
+			state.Models[pool] = constantProductModel{reserves, bs.pairFeeBps(pool, reserves)}
+		default:
+			pairs[pool] = reserves
+			otherPairs = append(otherPairs, pool)
+		}
+		offset += len(poolMethods)
+	}
+	for _, token := range tokens {
+		state.Tokens[token] = bs.decodeToken(token, calls[offset:offset+len(tokenMethods)])
+		offset += len(tokenMethods)
 	}
 
-	err = bs.pairABI.UnpackIntoInterface(&reserves, "getReserves", reservesResult)
+	probe, err := bs.probePools(ctx, otherPairs, pairs, notPairs, block)
 	if err != nil {
-		log.Printf("Error unpacking reserves: %v", err)
 		return nil, err
 	}
 
-	// Get token0 address
-	token0Data, err := bs.pairABI.Pack("token0")
+	// Pairs that did not answer stable() are plain V2 pairs of another deployment
+	for _, pool := range otherPairs {
+		if probe.solidly[pool] == nil {
+			state.Pools[pool] = pairs[pool]
+			state.Models[pool] = constantProductModel{pairs[pool], bs.pairFeeBps(pool, pairs[pool])}
+		} else {
+			state.PoolErrors[pool] = models.ErrPoolNotFound // until its decimals are read
+		}
+	}
+
+	solidlyPools, err := bs.getSolidlyPools(ctx, probe.solidly, block)
 	if err != nil {
 		return nil, err
 	}
+	for pool, solidly := range solidlyPools {
+		state.addModel(pool, solidlyModel{solidly})
+	}
 
-	token0Result, err := bs.client.CallContract(ctx, ethereum.CallMsg{
-		To:   &address,
-		Data: token0Data,
-	}, nil)
+	v3Pools, err := bs.getV3Pools(ctx, probe.v3, block)
 	if err != nil {
-		return nil, models.ErrBlockchainConnection
+		return nil, err
+	}
+	for pool, v3 := range v3Pools {
+		state.addModel(pool, concentratedLiquidityModel{v3})
 	}
 
-	var token0 common.Address
-	err = bs.pairABI.UnpackIntoInterface(&token0, "token0", token0Result)
+	curvePools, err := bs.getCurvePools(ctx, probe.curve, block)
 	if err != nil {
 		return nil, err
 	}
+	for pool, curve := range curvePools {
+		state.addModel(pool, stableSwapModel{curve})
+	}
 
-	// Get token1 address
-	token1Data, err := bs.pairABI.Pack("token1")
+	balancerPools, err := bs.getBalancerPools(ctx, probe.balancer, probe.vaults, block)
 	if err != nil {
 		return nil, err
 	}
+	for pool, balancer := range balancerPools {
+		state.addModel(pool, weightedModel{balancer})
+	}
 
-	token1Result, err := bs.client.CallContract(ctx, ethereum.CallMsg{
-		To:   &address,
-		Data: token1Data,
-	}, nil)
-	if err != nil {
-		return nil, models.ErrBlockchainConnection
+	if err := bs.validatePairs(ctx, state, block); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// poolProbe holds the pools recognised by probePools, by type
+type poolProbe struct {
+	solidly  map[string]*models.SolidlyPoolState
+	v3       map[string]*models.V3PoolState
+	curve    map[string]*models.CurvePoolState
+	balancer map[string]*models.BalancerPoolState
+	vaults   map[string]common.Address // the Vault holding each Balancer pool's tokens
+}
+
+// probePools tells pool types apart in one batched read: stable() for pairs, and the
+// state reads of a Uniswap V3, a Curve and a Balancer weighted pool for pools that are
+// not pairs. A pool answering like several types is taken as the first of these.
+func (bs *BlockchainService) probePools(ctx context.Context, pairs []string, reserves map[string]*models.PoolReserves, others []string, block *big.Int) (*poolProbe, error) {
+	probe := &poolProbe{
+		solidly:  make(map[string]*models.SolidlyPoolState),
+		v3:       make(map[string]*models.V3PoolState),
+		curve:    make(map[string]*models.CurvePoolState),
+		balancer: make(map[string]*models.BalancerPoolState),
+		vaults:   make(map[string]common.Address),
+	}
+	if len(pairs) == 0 && len(others) == 0 {
+		return probe, nil
 	}
 
-	var token1 common.Address
-	err = bs.pairABI.UnpackIntoInterface(&token1, "token1", token1Result)
+	var calls []ContractCall
+	for _, pool := range pairs {
+		call, err := newContractCall(bs.solidlyPairABI, pool, "stable", block)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	for _, pool := range others {
+		for _, probeCalls := range []func(string, *big.Int) ([]ContractCall, error){bs.v3ProbeCalls, bs.curveProbeCalls, bs.balancerProbeCalls} {
+			poolCalls, err := probeCalls(pool, block)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, poolCalls...)
+		}
+	}
+
+	log.Printf("Probing %d pairs and %d other pools in %d calls", len(pairs), len(others), len(calls))
+
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, pool := range pairs {
+		var stable bool
+		if calls[i].Error != nil || bs.solidlyPairABI.UnpackIntoInterface(&stable, "stable", calls[i].Result) != nil {
+			continue // not a bool: a plain V2 pair
+		}
+		probe.solidly[pool] = &models.SolidlyPoolState{
+			Token0:   reserves[pool].Token0,
+			Token1:   reserves[pool].Token1,
+			Reserve0: reserves[pool].Reserve0,
+			Reserve1: reserves[pool].Reserve1,
+			Stable:   stable,
+		}
+	}
+
+	offset := len(pairs)
+	for _, pool := range others {
+		v3Calls := calls[offset : offset+len(v3StateMethods)]
+		offset += len(v3StateMethods)
+		curveCalls := calls[offset : offset+curveProbeSize]
+		offset += curveProbeSize
+		balancerCalls := calls[offset : offset+len(balancerPoolMethods)]
+		offset += len(balancerPoolMethods)
+
+		if v3 := bs.decodeV3Pool(v3Calls); v3 != nil {
+			probe.v3[pool] = v3
+		} else if curve := bs.decodeCurvePool(curveCalls); curve != nil {
+			probe.curve[pool] = curve
+		} else if balancer, vault := bs.decodeBalancerPool(balancerCalls); balancer != nil {
+			probe.balancer[pool] = balancer
+			probe.vaults[pool] = vault
+		}
+	}
+
+	return probe, nil
+}
+
+// hasNoCode reports whether calls all succeeded without returning data, which is how a
+// node answers calls to an address without code
+func hasNoCode(calls []ContractCall) bool {
+	for _, call := range calls {
+		if call.Error != nil || len(call.Result) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// v2Fork returns the V2 deployment of the chain whose CREATE2 pair of the pool's tokens
+// the pool is
+func (bs *BlockchainService) v2Fork(pool string, reserves *models.PoolReserves) (config.ForkConfig, bool) {
+	for _, fork := range bs.chain.AllForks() {
+		if utils.PairAddress(fork.FactoryAddress, fork.InitCodeHash, reserves.Token0, reserves.Token1) == pool {
+			return fork, true
+		}
+	}
+	return config.ForkConfig{}, false
+}
+
+// isV2Pair reports whether pool is the pair of a V2 deployment of the chain, which is
+// never a Solidly-style pair
+func (bs *BlockchainService) isV2Pair(pool string, reserves *models.PoolReserves) bool {
+	_, ok := bs.v2Fork(pool, reserves)
+	return ok
+}
+
+// pairFeeBps returns the swap fee of a V2 pair: the fee of the fork whose CREATE2
+// address the pair is, or the fee of the chain's V2 deployment for any other pair
+func (bs *BlockchainService) pairFeeBps(pool string, reserves *models.PoolReserves) uint32 {
+	if fork, ok := bs.v2Fork(pool, reserves); ok && fork.FeeBps != 0 {
+		return fork.FeeBps
+	}
+	return bs.chain.FeeBps
+}
+
+// decodePool decodes getReserves, token0 and token1 results into pool reserves
+func (bs *BlockchainService) decodePool(calls []ContractCall) (*models.PoolReserves, error) {
+	for _, call := range calls {
+		if call.Error != nil {
+			return nil, models.ErrPoolNotFound
+		}
+	}
+
+	reserves, err := bs.decodeReserves(calls[0].Result)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("Successfully fetched pool data - Token0: %s, Token1: %s", token0.Hex(), token1.Hex())
+	var token0, token1 common.Address
+	if err := bs.pairABI.UnpackIntoInterface(&token0, "token0", calls[1].Result); err != nil {
+		return nil, models.ErrPoolNotFound
+	}
+	if err := bs.pairABI.UnpackIntoInterface(&token1, "token1", calls[2].Result); err != nil {
+		return nil, models.ErrPoolNotFound
+	}
+
+	reserves.Token0 = strings.ToLower(token0.Hex())
+	reserves.Token1 = strings.ToLower(token1.Hex())
+	return reserves, nil
+}
+
+// decodeReserves decodes a getReserves result; token addresses are left empty
+func (bs *BlockchainService) decodeReserves(result []byte) (*models.PoolReserves, error) {
+	var reserves struct {
+		Reserve0           *big.Int
+		Reserve1           *big.Int
+		BlockTimestampLast uint32
+	}
+	if err := bs.pairABI.UnpackIntoInterface(&reserves, "getReserves", result); err != nil {
+		return nil, models.ErrPoolNotFound
+	}
 
 	return &models.PoolReserves{
 		Reserve0:  reserves.Reserve0,
 		Reserve1:  reserves.Reserve1,
-		Token0:    strings.ToLower(token0.Hex()),
-		Token1:    strings.ToLower(token1.Hex()),
 		BlockTime: reserves.BlockTimestampLast,
 	}, nil
 }
 
+// GetReservesSeries fetches getReserves of a pair at each of the given blocks in one
+// batched read; old blocks need an archive node. Token addresses are left empty.
+// Blocks at which the pair did not exist yet map to nil.
+func (bs *BlockchainService) GetReservesSeries(ctx context.Context, poolAddress string, blocks []uint64) (map[uint64]*models.PoolReserves, error) {
+	calls := make([]ContractCall, len(blocks))
+	for i, block := range blocks {
+		call, err := newContractCall(bs.pairABI, poolAddress, "getReserves", new(big.Int).SetUint64(block))
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = call
+	}
+
+	log.Printf("Fetching reserves of %s at %d blocks", poolAddress, len(blocks))
+
+	if err := bs.client.BatchCallContract(ctx, calls); err != nil {
+		log.Printf("Batch call failed: %v", err)
+		return nil, models.ErrBlockchainConnection.WithCause(err)
+	}
+
+	series := make(map[uint64]*models.PoolReserves, len(blocks))
+	for i, call := range calls {
+		if call.Error != nil && !isExecutionError(call.Error) {
+			log.Printf("Call to %s failed: %v", poolAddress, call.Error)
+			return nil, models.ErrBlockchainConnection.WithCause(call.Error)
+		}
+
+		var reserves *models.PoolReserves
+		if call.Error == nil {
+			reserves, _ = bs.decodeReserves(call.Result)
+		}
+		series[blocks[i]] = reserves
+	}
+
+	return series, nil
+}
+
+// GetObservations reads the oracle state of a pair at each of the given blocks: reserves
+// and price accumulators in one batched read. Block header timestamps are not read;
+// callers already know them. A pair that did not exist at one of the blocks is reported
+// as ErrPoolNotFound.
+func (bs *BlockchainService) GetObservations(ctx context.Context, poolAddress string, blocks []uint64) ([]*models.PairObservation, error) {
+	methods := []string{"getReserves", "price0CumulativeLast", "price1CumulativeLast"}
+
+	calls := make([]ContractCall, 0, len(blocks)*len(methods))
+	for _, block := range blocks {
+		for _, method := range methods {
+			call, err := newContractCall(bs.pairABI, poolAddress, method, new(big.Int).SetUint64(block))
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+		}
+	}
+
+	if err := bs.client.BatchCallContract(ctx, calls); err != nil {
+		log.Printf("Batch call failed: %v", err)
+		return nil, models.ErrBlockchainConnection.WithCause(err)
+	}
+
+	observations := make([]*models.PairObservation, len(blocks))
+	for i, block := range blocks {
+		blockCalls := calls[i*len(methods) : (i+1)*len(methods)]
+		for _, call := range blockCalls {
+			if call.Error != nil && !isExecutionError(call.Error) {
+				return nil, models.ErrBlockchainConnection.WithCause(call.Error)
+			}
+			if call.Error != nil {
+				return nil, models.ErrPoolNotFound.WithDetails(fmt.Sprintf("The pool did not exist at block %d", block))
+			}
+		}
+
+		reserves, err := bs.decodeReserves(blockCalls[0].Result)
+		if err != nil {
+			return nil, err
+		}
+		var price0, price1 *big.Int
+		if err := bs.pairABI.UnpackIntoInterface(&price0, "price0CumulativeLast", blockCalls[1].Result); err != nil {
+			return nil, models.ErrPoolNotFound
+		}
+		if err := bs.pairABI.UnpackIntoInterface(&price1, "price1CumulativeLast", blockCalls[2].Result); err != nil {
+			return nil, models.ErrPoolNotFound
+		}
+
+		observations[i] = &models.PairObservation{
+			Block:                block,
+			Reserve0:             reserves.Reserve0,
+			Reserve1:             reserves.Reserve1,
+			BlockTimestampLast:   reserves.BlockTime,
+			Price0CumulativeLast: price0,
+			Price1CumulativeLast: price1,
+		}
+	}
+
+	return observations, nil
+}
+
+// GetPairLiquidity reads the reserves, token order, LP token supply and kLast of a pair
+// in one batched read pinned to block, then whether its factory charges the protocol fee
+func (bs *BlockchainService) GetPairLiquidity(ctx context.Context, poolAddress string, block *big.Int) (*models.PairLiquidity, error) {
+	methods := []string{"getReserves", "token0", "token1", "totalSupply", "kLast", "factory"}
+
+	calls := make([]ContractCall, len(methods))
+	for i, method := range methods {
+		call, err := newContractCall(bs.pairABI, poolAddress, method, block)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = call
+	}
+
+	if err := bs.client.BatchCallContract(ctx, calls); err != nil {
+		log.Printf("Batch call failed: %v", err)
+		return nil, models.ErrBlockchainConnection.WithCause(err)
+	}
+	for _, call := range calls {
+		if call.Error != nil && !isExecutionError(call.Error) {
+			return nil, models.ErrBlockchainConnection.WithCause(call.Error)
+		}
+	}
+
+	reserves, err := bs.decodePool(calls[:3])
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.checkPair(ctx, strings.ToLower(poolAddress), reserves, block); err != nil {
+		return nil, err
+	}
+
+	liquidity := &models.PairLiquidity{Reserves: reserves}
+	var factory common.Address
+	for i, target := range []interface{}{&liquidity.TotalSupply, &liquidity.KLast, &factory} {
+		call := calls[3+i]
+		if call.Error != nil {
+			return nil, models.ErrPoolNotFound
+		}
+		if err := bs.pairABI.UnpackIntoInterface(target, methods[3+i], call.Result); err != nil {
+			return nil, models.ErrPoolNotFound
+		}
+	}
+
+	callData, err := bs.factoryABI.Pack("feeTo")
+	if err != nil {
+		return nil, err
+	}
+	result, err := bs.client.CallContract(ctx, ethereum.CallMsg{To: &factory, Data: callData}, block)
+	if err != nil && !isExecutionError(err) {
+		return nil, models.ErrBlockchainConnection.WithCause(err)
+	}
+
+	// Factories without feeTo never charge the protocol fee
+	var feeTo common.Address
+	if err == nil && bs.factoryABI.UnpackIntoInterface(&feeTo, "feeTo", result) == nil {
+		liquidity.FeeOn = feeTo != (common.Address{})
+	}
+
+	return liquidity, nil
+}
+
+// BlockTimestamp returns the header timestamp of a block
+func (bs *BlockchainService) BlockTimestamp(ctx context.Context, block uint64) (uint64, error) {
+	timestamp, err := bs.client.BlockTimestamp(ctx, new(big.Int).SetUint64(block))
+	if err != nil {
+		return 0, models.ErrBlockchainConnection.WithCause(err)
+	}
+	return timestamp, nil
+}
+
+// decodeToken decodes decimals, symbol and name results into token metadata.
+// Reverted or malformed results leave the field empty and mark metadata unavailable.
+func (bs *BlockchainService) decodeToken(tokenAddress string, calls []ContractCall) *models.TokenInfo {
+	decimals, decimalsOK := decodeDecimals(calls[0].Result)
+	symbol, symbolOK := bs.decodeText("symbol", calls[1].Result)
+	name, _ := bs.decodeText("name", calls[2].Result) // optional in ERC20
+
+	token := &models.TokenInfo{
+		Address:           tokenAddress,
+		Decimals:          decimals,
+		Symbol:            symbol,
+		Name:              name,
+		MetadataAvailable: decimalsOK && symbolOK,
+		TransferTaxBps:    bs.chain.FeeOnTransferTokens[tokenAddress],
+		Rebasing:          bs.chain.RebasingTokens[tokenAddress],
+	}
+
+	if !token.MetadataAvailable {
+		log.Printf("Token metadata unavailable for %s (decimals=%t, symbol=%t)", tokenAddress, decimalsOK, symbolOK)
+	}
+
+	return token
+}
+
+// decodeDecimals decodes a decimals() result; some tokens return uint256 instead of uint8
+func decodeDecimals(result []byte) (uint8, bool) {
+	if len(result) < 32 {
+		return 0, false
+	}
+
+	value := new(big.Int).SetBytes(result[:32])
+	if !value.IsUint64() || value.Uint64() > 255 {
+		return 0, false
+	}
+
+	return uint8(value.Uint64()), true
+}
+
+// decodeText decodes a symbol() or name() result, either as an ABI string or as bytes32
+func (bs *BlockchainService) decodeText(method string, result []byte) (string, bool) {
+	if len(result) == 0 {
+		return "", false
+	}
+
+	var text string
+	if err := bs.erc20ABI.UnpackIntoInterface(&text, method, result); err == nil {
+		return text, true
+	}
+
+	// bytes32 return value: right-padded with zero bytes
+	if len(result) == 32 {
+		text = string(bytes.TrimRight(result, "\x00"))
+		if utf8.ValidString(text) {
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+// newContractCall packs a view call
+func newContractCall(contractABI abi.ABI, address, method string, block *big.Int, args ...interface{}) (ContractCall, error) {
+	callData, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return ContractCall{}, err
+	}
+
+	to := common.HexToAddress(address)
+	return ContractCall{
+		Msg:   ethereum.CallMsg{To: &to, Data: callData},
+		Block: block,
+	}, nil
+}
+
+// uniqueAddresses lowercases addresses and removes duplicates, keeping order
+func uniqueAddresses(addresses []string) []string {
+	seen := make(map[string]bool, len(addresses))
+	unique := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		normalized := strings.ToLower(address)
+		if !seen[normalized] {
+			seen[normalized] = true
+			unique = append(unique, normalized)
+		}
+	}
+	return unique
+}
+
+// GetAmountsOut asks UniswapV2Router02 for the output of a swap along path at the given block.
+// This is an on-chain calculation, so it is only used to cross-check the off-chain math.
+func (bs *BlockchainService) GetAmountsOut(ctx context.Context, amountIn *big.Int, path []string, block *big.Int) ([]*big.Int, error) {
+	router := common.HexToAddress(bs.chain.RouterAddress)
+
+	addresses := make([]common.Address, len(path))
+	for i, token := range path {
+		addresses[i] = common.HexToAddress(token)
+	}
+
+	callData, err := bs.routerABI.Pack("getAmountsOut", amountIn, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := bs.client.CallContract(ctx, ethereum.CallMsg{
+		To:   &router,
+		Data: callData,
+	}, block)
+	if err != nil {
+		return nil, err
+	}
+
+	var amounts []*big.Int
+	err = bs.routerABI.UnpackIntoInterface(&amounts, "getAmountsOut", result)
+	if err != nil {
+		return nil, err
+	}
+
+	return amounts, nil
+}
+
 // Close closes the blockchain connection
 func (bs *BlockchainService) Close() {
 	bs.client.Close()
-}
\ No newline at end of file
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"strings"
+	"uniswap-est/intrenal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Balancer V2 weighted pool ABI: the pool id and Vault its tokens are held by, the
+// normalized weights and the swap fee
+const balancerPoolABI = `[
+	{
+		"inputs": [],
+		"name": "getPoolId",
+		"outputs": [{"name": "", "type": "bytes32"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "getVault",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "getNormalizedWeights",
+		"outputs": [{"name": "", "type": "uint256[]"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "getSwapFeePercentage",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// Balancer V2 Vault ABI for the tokens and balances of a pool
+const balancerVaultABI = `[
+	{
+		"inputs": [{"name": "poolId", "type": "bytes32"}],
+		"name": "getPoolTokens",
+		"outputs": [
+			{"name": "tokens", "type": "address[]"},
+			{"name": "balances", "type": "uint256[]"},
+			{"name": "lastChangeBlock", "type": "uint256"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// balancerPoolMethods are read from every pool probed as a Balancer weighted pool
+var balancerPoolMethods = []string{"getPoolId", "getVault", "getNormalizedWeights", "getSwapFeePercentage"}
+
+// balancerProbeCalls returns the calls probing pool as a Balancer weighted pool
+func (bs *BlockchainService) balancerProbeCalls(pool string, block *big.Int) ([]ContractCall, error) {
+	calls := make([]ContractCall, 0, len(balancerPoolMethods))
+	for _, method := range balancerPoolMethods {
+		call, err := newContractCall(bs.balancerPoolABI, pool, method, block)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// getBalancerPools completes the state of the pools probed as Balancer V2 weighted
+// pools: the tokens and balances from their Vault in one batched read, then the token
+// decimals. Pools whose Vault lists a different number of tokens are left out.
+func (bs *BlockchainService) getBalancerPools(ctx context.Context, balancerPools map[string]*models.BalancerPoolState, vaults map[string]common.Address, block *big.Int) (map[string]*models.BalancerPoolState, error) {
+	if len(balancerPools) == 0 {
+		return balancerPools, nil
+	}
+
+	// Step 1: tokens and balances from the Vault
+	vaultPools := make([]string, 0, len(balancerPools))
+	var calls []ContractCall
+	for pool, state := range balancerPools {
+		call, err := newContractCall(bs.balancerVaultABI, vaults[pool].Hex(), "getPoolTokens", block, common.HexToHash(state.PoolID))
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+		vaultPools = append(vaultPools, pool)
+	}
+	if len(calls) == 0 {
+		return balancerPools, nil
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, pool := range vaultPools {
+		state := balancerPools[pool]
+		values, err := bs.balancerVaultABI.Unpack("getPoolTokens", calls[i].Result)
+		if calls[i].Error != nil || err != nil {
+			log.Printf("Pool %s: Vault tokens unreadable", pool)
+			delete(balancerPools, pool)
+			continue
+		}
+		tokens := values[0].([]common.Address)
+		balances := values[1].([]*big.Int)
+		if len(tokens) != len(state.Weights) || len(balances) != len(tokens) {
+			log.Printf("Pool %s: the Vault lists %d tokens for %d weights", pool, len(tokens), len(state.Weights))
+			delete(balancerPools, pool)
+			continue
+		}
+		for _, token := range tokens {
+			state.Tokens = append(state.Tokens, strings.ToLower(token.Hex()))
+		}
+		state.Balances = balances
+	}
+
+	// Step 2: token decimals, for the scaling factors
+	type poolToken struct {
+		pool  string
+		index int
+	}
+	var tokens []poolToken
+	calls = calls[:0]
+	for pool, state := range balancerPools {
+		state.ScalingFactors = make([]*big.Int, len(state.Tokens))
+		for k, token := range state.Tokens {
+			call, err := newContractCall(bs.erc20ABI, token, "decimals", block)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+			tokens = append(tokens, poolToken{pool, k})
+		}
+	}
+	if len(calls) == 0 {
+		return balancerPools, nil
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range tokens {
+		state := balancerPools[entry.pool]
+		if state == nil {
+			continue
+		}
+		decimals, ok := decodeDecimals(calls[i].Result)
+		if calls[i].Error != nil || !ok || decimals > 18 {
+			log.Printf("Pool %s: decimals of token %d unreadable", entry.pool, entry.index)
+			delete(balancerPools, entry.pool)
+			continue
+		}
+		state.ScalingFactors[entry.index] = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(18-int(decimals))), nil)
+	}
+
+	return balancerPools, nil
+}
+
+// decodeBalancerPool decodes the pool calls, returning nil when the contract is not a
+// weighted pool with at least two weights
+func (bs *BlockchainService) decodeBalancerPool(calls []ContractCall) (*models.BalancerPoolState, common.Address) {
+	for _, call := range calls {
+		if call.Error != nil || len(call.Result) == 0 {
+			return nil, common.Address{}
+		}
+	}
+
+	var poolID [32]byte
+	var vault common.Address
+	var weights []*big.Int
+	var fee *big.Int
+	if bs.balancerPoolABI.UnpackIntoInterface(&poolID, "getPoolId", calls[0].Result) != nil ||
+		bs.balancerPoolABI.UnpackIntoInterface(&vault, "getVault", calls[1].Result) != nil ||
+		bs.balancerPoolABI.UnpackIntoInterface(&weights, "getNormalizedWeights", calls[2].Result) != nil ||
+		bs.balancerPoolABI.UnpackIntoInterface(&fee, "getSwapFeePercentage", calls[3].Result) != nil {
+		return nil, common.Address{}
+	}
+	if len(weights) < 2 {
+		return nil, common.Address{}
+	}
+
+	return &models.BalancerPoolState{
+		PoolID:  hexutil.Encode(poolID[:]),
+		Weights: weights,
+		SwapFee: fee,
+	}, vault
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"strings"
+	"uniswap-est/intrenal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Solidly/Velodrome pair ABI: stable() tells these pairs apart from Uniswap V2 pairs,
+// which otherwise answer the same getReserves, token0 and token1
+const solidlyPairABI = `[
+	{
+		"inputs": [],
+		"name": "stable",
+		"outputs": [{"name": "", "type": "bool"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "factory",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// Solidly-style factory fee getters: Velodrome V1 charges per pool type, Velodrome V2 per
+// pool. The original Solidly factory has neither and charges solidlyDefaultFeeBps.
+// isPair and getPair tell whether the factory deployed a pair, for pool validation.
+const solidlyFactoryABI = `[
+	{
+		"inputs": [{"name": "pair", "type": "address"}],
+		"name": "isPair",
+		"outputs": [{"name": "", "type": "bool"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "tokenA", "type": "address"},
+			{"name": "tokenB", "type": "address"},
+			{"name": "stable", "type": "bool"}
+		],
+		"name": "getPair",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "_stable", "type": "bool"}],
+		"name": "getFee",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "pool", "type": "address"},
+			{"name": "_stable", "type": "bool"}
+		],
+		"name": "getFee",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// Curve StableSwap plain pool ABI for the amplification, fee, coins and balances
+const curvePoolABI = `[
+	{
+		"inputs": [],
+		"name": "A",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "fee",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "i", "type": "uint256"}],
+		"name": "coins",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "i", "type": "uint256"}],
+		"name": "balances",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+const (
+	// solidlyDefaultFeeBps is the fee of pairs whose factory has no getFee, 0.01%
+	solidlyDefaultFeeBps = 1
+
+	// maxCurveCoins is the most coins a Curve plain pool holds
+	maxCurveCoins = 4
+
+	// curveProbeSize is the number of calls probing a pool as a Curve pool
+	curveProbeSize = 2 + 2*maxCurveCoins
+)
+
+// curveNativeCoin is how Curve pools list ETH among their coins
+const curveNativeCoin = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+// getSolidlyPools completes the state of pairs that answered stable(): token decimals
+// and factory in one batched read, then the fee from the factory in another. Pairs whose
+// decimals cannot be read are left out.
+func (bs *BlockchainService) getSolidlyPools(ctx context.Context, pairs map[string]*models.SolidlyPoolState, block *big.Int) (map[string]*models.SolidlyPoolState, error) {
+	solidlyPools := make(map[string]*models.SolidlyPoolState, len(pairs))
+	if len(pairs) == 0 {
+		return solidlyPools, nil
+	}
+
+	pools := make([]string, 0, len(pairs))
+	for pool := range pairs {
+		pools = append(pools, pool)
+	}
+
+	// Step 1: factory and both token decimals
+	calls := make([]ContractCall, 0, len(pools)*3)
+	for _, pool := range pools {
+		factory, err := newContractCall(bs.solidlyPairABI, pool, "factory", block)
+		if err != nil {
+			return nil, err
+		}
+		decimals0, err := newContractCall(bs.erc20ABI, pairs[pool].Token0, "decimals", block)
+		if err != nil {
+			return nil, err
+		}
+		decimals1, err := newContractCall(bs.erc20ABI, pairs[pool].Token1, "decimals", block)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, factory, decimals0, decimals1)
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	factories := make(map[string]string, len(pools))
+	for i, pool := range pools {
+		decimals0, ok0 := decodeDecimals(calls[i*3+1].Result)
+		decimals1, ok1 := decodeDecimals(calls[i*3+2].Result)
+		if calls[i*3+1].Error != nil || calls[i*3+2].Error != nil || !ok0 || !ok1 {
+			log.Printf("Pool %s: token decimals unreadable", pool)
+			continue
+		}
+
+		state := pairs[pool]
+		state.Decimals0 = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals0)), nil)
+		state.Decimals1 = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals1)), nil)
+		state.FeeBps = solidlyDefaultFeeBps
+		solidlyPools[pool] = state
+
+		var factory common.Address
+		if calls[i*3].Error == nil && bs.solidlyPairABI.UnpackIntoInterface(&factory, "factory", calls[i*3].Result) == nil {
+			factories[pool] = factory.Hex()
+		}
+	}
+
+	// Step 2: the fee, from whichever getFee the factory has
+	feePools := make([]string, 0, len(factories))
+	calls = calls[:0]
+	for pool, factory := range factories {
+		byType, err := newContractCall(bs.solidlyFactoryABI, factory, "getFee", block, solidlyPools[pool].Stable)
+		if err != nil {
+			return nil, err
+		}
+		// go-ethereum names the second getFee overload getFee0
+		byPool, err := newContractCall(bs.solidlyFactoryABI, factory, "getFee0", block, common.HexToAddress(pool), solidlyPools[pool].Stable)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, byType, byPool)
+		feePools = append(feePools, pool)
+	}
+	if len(calls) == 0 {
+		return solidlyPools, nil
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, pool := range feePools {
+		for _, call := range calls[i*2 : i*2+2] {
+			if call.Error != nil || len(call.Result) == 0 {
+				continue
+			}
+			var fee *big.Int
+			if err := bs.solidlyFactoryABI.UnpackIntoInterface(&fee, "getFee", call.Result); err == nil && fee.Cmp(big.NewInt(10000)) < 0 {
+				solidlyPools[pool].FeeBps = uint32(fee.Uint64())
+				break
+			}
+		}
+	}
+
+	return solidlyPools, nil
+}
+
+// curveProbeCalls returns the calls probing pool as a Curve pool: A, fee, then coins(k)
+// and balances(k) for every possible coin
+func (bs *BlockchainService) curveProbeCalls(pool string, block *big.Int) ([]ContractCall, error) {
+	calls := make([]ContractCall, 0, curveProbeSize)
+	for _, method := range []string{"A", "fee"} {
+		call, err := newContractCall(bs.curvePoolABI, pool, method, block)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	for k := 0; k < maxCurveCoins; k++ {
+		for _, method := range []string{"coins", "balances"} {
+			call, err := newContractCall(bs.curvePoolABI, pool, method, block, big.NewInt(int64(k)))
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+		}
+	}
+	return calls, nil
+}
+
+// getCurvePools completes the state of the pools probed as Curve StableSwap pools with
+// the coin decimals, in one batched read. Pools with a coin whose decimals cannot be
+// read are left out.
+func (bs *BlockchainService) getCurvePools(ctx context.Context, curvePools map[string]*models.CurvePoolState, block *big.Int) (map[string]*models.CurvePoolState, error) {
+	if len(curvePools) == 0 {
+		return curvePools, nil
+	}
+
+	// Coin decimals; ETH has 18
+	type poolCoin struct {
+		pool  string
+		index int
+	}
+	var coins []poolCoin
+	var calls []ContractCall
+	for pool, state := range curvePools {
+		for k, coin := range state.Coins {
+			if coin == curveNativeCoin {
+				state.Rates[k] = curveRate(18)
+				continue
+			}
+			call, err := newContractCall(bs.erc20ABI, coin, "decimals", block)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+			coins = append(coins, poolCoin{pool, k})
+		}
+	}
+	if len(calls) == 0 {
+		return curvePools, nil
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range coins {
+		state := curvePools[entry.pool]
+		if state == nil {
+			continue
+		}
+		decimals, ok := decodeDecimals(calls[i].Result)
+		if calls[i].Error != nil || !ok || decimals > 36 {
+			log.Printf("Pool %s: decimals of coin %d unreadable", entry.pool, entry.index)
+			delete(curvePools, entry.pool)
+			continue
+		}
+		state.Rates[entry.index] = curveRate(decimals)
+	}
+
+	return curvePools, nil
+}
+
+// decodeCurvePool decodes A, fee and the coin calls, returning nil when the contract is
+// not a Curve pool. The coins end at the first coins(k) that reverts.
+func (bs *BlockchainService) decodeCurvePool(calls []ContractCall) *models.CurvePoolState {
+	var amp, fee *big.Int
+	if calls[0].Error != nil || bs.curvePoolABI.UnpackIntoInterface(&amp, "A", calls[0].Result) != nil {
+		return nil
+	}
+	if calls[1].Error != nil || bs.curvePoolABI.UnpackIntoInterface(&fee, "fee", calls[1].Result) != nil {
+		return nil
+	}
+
+	state := &models.CurvePoolState{A: amp, Fee: fee}
+	for k := 0; k < maxCurveCoins; k++ {
+		coinCall, balanceCall := calls[2+2*k], calls[3+2*k]
+		if coinCall.Error != nil || len(coinCall.Result) == 0 {
+			break
+		}
+
+		var coin common.Address
+		var balance *big.Int
+		if bs.curvePoolABI.UnpackIntoInterface(&coin, "coins", coinCall.Result) != nil {
+			return nil
+		}
+		if balanceCall.Error != nil || bs.curvePoolABI.UnpackIntoInterface(&balance, "balances", balanceCall.Result) != nil {
+			return nil
+		}
+
+		state.Coins = append(state.Coins, strings.ToLower(coin.Hex()))
+		state.Balances = append(state.Balances, balance)
+		state.Rates = append(state.Rates, nil) // filled in with the coin decimals
+	}
+
+	if len(state.Coins) < 2 || amp.Sign() <= 0 {
+		return nil
+	}
+	return state
+}
+
+// curveRate returns the rate that scales a coin with the given decimals to 18, like the
+// RATES constant of plain pools: 10^(36 - decimals)
+func curveRate(decimals uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(36-int(decimals))), nil)
+}
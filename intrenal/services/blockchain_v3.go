@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"uniswap-est/intrenal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Uniswap V3 Pool ABI for the swap state: price, in-range liquidity, fee and the
+// initialized ticks
+const v3PoolABI = `[
+	{
+		"inputs": [],
+		"name": "slot0",
+		"outputs": [
+			{"name": "sqrtPriceX96", "type": "uint160"},
+			{"name": "tick", "type": "int24"},
+			{"name": "observationIndex", "type": "uint16"},
+			{"name": "observationCardinality", "type": "uint16"},
+			{"name": "observationCardinalityNext", "type": "uint16"},
+			{"name": "feeProtocol", "type": "uint8"},
+			{"name": "unlocked", "type": "bool"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "liquidity",
+		"outputs": [{"name": "", "type": "uint128"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "fee",
+		"outputs": [{"name": "", "type": "uint24"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "tickSpacing",
+		"outputs": [{"name": "", "type": "int24"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "token0",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "token1",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "wordPosition", "type": "int16"}],
+		"name": "tickBitmap",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "tick", "type": "int24"}],
+		"name": "ticks",
+		"outputs": [
+			{"name": "liquidityGross", "type": "uint128"},
+			{"name": "liquidityNet", "type": "int128"},
+			{"name": "feeGrowthOutside0X128", "type": "uint256"},
+			{"name": "feeGrowthOutside1X128", "type": "uint256"},
+			{"name": "tickCumulativeOutside", "type": "int56"},
+			{"name": "secondsPerLiquidityOutsideX128", "type": "uint160"},
+			{"name": "secondsOutside", "type": "uint32"},
+			{"name": "initialized", "type": "bool"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// v3StateMethods are read from every pool probed as a Uniswap V3 pool
+var v3StateMethods = []string{"slot0", "liquidity", "fee", "tickSpacing", "token0", "token1"}
+
+// v3ProbeCalls returns the pool state calls probing pool as a Uniswap V3 pool
+func (bs *BlockchainService) v3ProbeCalls(pool string, block *big.Int) ([]ContractCall, error) {
+	calls := make([]ContractCall, 0, len(v3StateMethods))
+	for _, method := range v3StateMethods {
+		call, err := newContractCall(bs.v3PoolABI, pool, method, block)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// getV3Pools completes the state of the pools probed as Uniswap V3 pools in two batched
+// reads: the bitmap words around the current tick, then the initialized ticks in them.
+// Pools whose ticks cannot be read are left out.
+func (bs *BlockchainService) getV3Pools(ctx context.Context, v3Pools map[string]*models.V3PoolState, block *big.Int) (map[string]*models.V3PoolState, error) {
+	if len(v3Pools) == 0 {
+		return v3Pools, nil
+	}
+	found := make([]string, 0, len(v3Pools))
+	for pool := range v3Pools {
+		found = append(found, pool)
+	}
+	sort.Strings(found)
+
+	log.Printf("Loading ticks of %d Uniswap V3 pools", len(found))
+
+	// Step 1: bitmap words on both sides of the current tick
+	type poolTick struct {
+		pool string
+		tick int32 // word position in step 1, tick in step 2
+	}
+	var words []poolTick
+
+	var calls []ContractCall
+	for _, pool := range found {
+		center := int32(v3WordPosition(v3Pools[pool].Tick, v3Pools[pool].TickSpacing))
+		for word := center - int32(bs.config.V3TickWords); word <= center+int32(bs.config.V3TickWords); word++ {
+			if word < math.MinInt16 || word > math.MaxInt16 {
+				continue
+			}
+			call, err := newContractCall(bs.v3PoolABI, pool, "tickBitmap", block, int16(word))
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+			words = append(words, poolTick{pool, word})
+		}
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	var initialized []poolTick
+	for i, entry := range words {
+		state := v3Pools[entry.pool]
+		if state == nil {
+			continue
+		}
+
+		word, err := bs.decodeUint256("tickBitmap", calls[i])
+		if err != nil {
+			// The pool answered slot0 but not its bitmap, so it cannot be simulated
+			log.Printf("Pool %s: no tick bitmap: %v", entry.pool, err)
+			delete(v3Pools, entry.pool)
+			continue
+		}
+		state.TickBitmap[int16(entry.tick)] = word
+
+		for bit := 0; bit < 256; bit++ {
+			if word.Bit(bit) == 1 {
+				compressed := entry.tick*256 + int32(bit)
+				initialized = append(initialized, poolTick{entry.pool, compressed * state.TickSpacing})
+			}
+		}
+	}
+	if len(initialized) == 0 {
+		return v3Pools, nil
+	}
+
+	// Step 2: liquidityNet of every initialized tick
+	calls = calls[:0]
+	for _, entry := range initialized {
+		call, err := newContractCall(bs.v3PoolABI, entry.pool, "ticks", block, big.NewInt(int64(entry.tick)))
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range initialized {
+		state := v3Pools[entry.pool]
+		if state == nil {
+			continue
+		}
+		if calls[i].Error != nil {
+			log.Printf("Pool %s: tick %d unreadable: %v", entry.pool, entry.tick, calls[i].Error)
+			delete(v3Pools, entry.pool)
+			continue
+		}
+		values, err := bs.v3PoolABI.Unpack("ticks", calls[i].Result)
+		if err != nil {
+			log.Printf("Pool %s: tick %d undecodable: %v", entry.pool, entry.tick, err)
+			delete(v3Pools, entry.pool)
+			continue
+		}
+		state.LiquidityNet[entry.tick] = values[1].(*big.Int)
+	}
+
+	return v3Pools, nil
+}
+
+// decodeV3Pool decodes the pool state calls, returning nil when the contract is not a
+// V3 pool
+func (bs *BlockchainService) decodeV3Pool(calls []ContractCall) *models.V3PoolState {
+	for _, call := range calls {
+		if call.Error != nil || len(call.Result) == 0 {
+			return nil
+		}
+	}
+
+	slot0, err := bs.v3PoolABI.Unpack("slot0", calls[0].Result)
+	if err != nil {
+		return nil
+	}
+	liquidity, err := bs.decodeUint256("liquidity", calls[1])
+	if err != nil {
+		return nil
+	}
+	fee, err := bs.decodeUint256("fee", calls[2])
+	if err != nil {
+		return nil
+	}
+	spacing, err := bs.v3PoolABI.Unpack("tickSpacing", calls[3].Result)
+	if err != nil {
+		return nil
+	}
+
+	var token0, token1 common.Address
+	if err := bs.v3PoolABI.UnpackIntoInterface(&token0, "token0", calls[4].Result); err != nil {
+		return nil
+	}
+	if err := bs.v3PoolABI.UnpackIntoInterface(&token1, "token1", calls[5].Result); err != nil {
+		return nil
+	}
+
+	tickSpacing := spacing[0].(*big.Int).Int64()
+	if tickSpacing <= 0 {
+		return nil
+	}
+
+	return &models.V3PoolState{
+		Token0:       strings.ToLower(token0.Hex()),
+		Token1:       strings.ToLower(token1.Hex()),
+		SqrtPriceX96: slot0[0].(*big.Int),
+		Tick:         int32(slot0[1].(*big.Int).Int64()),
+		Liquidity:    liquidity,
+		Fee:          uint32(fee.Uint64()),
+		TickSpacing:  int32(tickSpacing),
+		TickBitmap:   make(map[int16]*big.Int),
+		LiquidityNet: make(map[int32]*big.Int),
+	}
+}
+
+// decodeUint256 decodes a call returning a single unsigned integer
+func (bs *BlockchainService) decodeUint256(method string, call ContractCall) (*big.Int, error) {
+	if call.Error != nil {
+		return nil, call.Error
+	}
+	var value *big.Int
+	if err := bs.v3PoolABI.UnpackIntoInterface(&value, method, call.Result); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// batchCall runs calls in one batched read, treating anything other than an EVM-level
+// failure as a connection error like GetBatchState does
+func (bs *BlockchainService) batchCall(ctx context.Context, calls []ContractCall) error {
+	if err := bs.client.BatchCallContract(ctx, calls); err != nil {
+		log.Printf("Batch call failed: %v", err)
+		return models.ErrBlockchainConnection.WithCause(err)
+	}
+	for _, call := range calls {
+		if call.Error != nil && !isExecutionError(call.Error) {
+			log.Printf("Call to %s failed: %v", call.Msg.To.Hex(), call.Error)
+			return models.ErrBlockchainConnection.WithCause(call.Error)
+		}
+	}
+	return nil
+}
+
+// v3WordPosition returns the tick bitmap word holding tick, like TickBitmap.position
+// on the compressed tick
+func v3WordPosition(tick, tickSpacing int32) int16 {
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed--
+	}
+	return int16(compressed >> 8)
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+)
+
+// Chain holds the services of one chain, all reading through its own BlockchainService
+type Chain struct {
+	Config     *config.ChainConfig
+	Blockchain *BlockchainService
+	Verifier   *Verifier
+	Uniswap    *UniswapService
+	Quotes     *QuoteHub
+	History    *HistoryService
+	Oracle     *OracleService
+	Liquidity  *LiquidityService
+	Arbitrage  *ArbitrageService
+	Tokens     *TokenRegistry // shared between chains, may be nil
+}
+
+// NewChain creates every service of a chain on top of its blockchain service. The
+// reserve cache and the token registry are shared between chains; their entries are
+// keyed by chain ID.
+func NewChain(cfg *config.Config, blockchain *BlockchainService, cache *ReserveCache, tokens *TokenRegistry) *Chain {
+	chain := blockchain.Chain()
+	verifier := NewVerifier(blockchain, cfg.VerifySampleRate)
+	uniswap := NewUniswapService(blockchain, verifier, tokens)
+
+	return &Chain{
+		Config:     chain,
+		Blockchain: blockchain,
+		Verifier:   verifier,
+		Uniswap:    uniswap,
+		Quotes:     NewQuoteHub(uniswap, blockchain, cfg.StreamPollInterval, cfg.RequestTimeout),
+		History:    NewHistoryService(uniswap, blockchain, cache, cfg.MaxHistoryPoints),
+		Oracle:     NewOracleService(blockchain),
+		Liquidity:  NewLiquidityService(blockchain),
+		Arbitrage:  NewArbitrageService(blockchain, chain.ArbitragePools, cfg.MaxArbitrageHops, cfg.MaxArbitragePools),
+		Tokens:     tokens,
+	}
+}
+
+// Chains is the registry of configured chains that requests pick with their chain
+// parameter
+type Chains struct {
+	chains       []*Chain
+	defaultChain *Chain
+}
+
+// NewChains creates a registry. The first chain is the default one, quoted for requests
+// that name no chain.
+func NewChains(chains ...*Chain) *Chains {
+	registry := &Chains{chains: chains}
+	if len(chains) > 0 {
+		registry.defaultChain = chains[0]
+	}
+	return registry
+}
+
+// Get returns the chain with the given name or decimal chain ID, or the default chain
+// when name is empty
+func (c *Chains) Get(name string) (*Chain, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" && c.defaultChain != nil {
+		return c.defaultChain, nil
+	}
+
+	for _, chain := range c.chains {
+		if chain.Config.Name == name || strconv.FormatUint(chain.Config.ChainID, 10) == name {
+			return chain, nil
+		}
+	}
+
+	names := make([]string, len(c.chains))
+	for i, chain := range c.chains {
+		names[i] = chain.Config.Name
+	}
+	return nil, models.ErrUnsupportedChain.WithDetails(
+		"Unknown chain " + name + ", configured: " + strings.Join(names, ", "))
+}
+
+// All returns every chain in registry order, the default one first
+func (c *Chains) All() []*Chain {
+	return c.chains
+}
+
+// Info describes the configured chains for GET /chains
+func (c *Chains) Info() *models.ChainsResponse {
+	response := &models.ChainsResponse{Chains: make([]models.ChainInfo, len(c.chains))}
+	for i, chain := range c.chains {
+		response.Chains[i] = models.ChainInfo{
+			Name:           chain.Config.Name,
+			ChainID:        chain.Config.ChainID,
+			Default:        chain == c.defaultChain,
+			FactoryAddress: strings.ToLower(chain.Config.FactoryAddress),
+			RouterAddress:  strings.ToLower(chain.Config.RouterAddress),
+			FeeBps:         chain.Config.FeeBps,
+			WrappedNative:  chain.Config.WrappedNative,
+		}
+	}
+	return response
+}
+
+// Close closes the blockchain client of every chain
+func (c *Chains) Close() {
+	for _, chain := range c.chains {
+		if chain.Blockchain != nil {
+			chain.Blockchain.Close()
+		}
+	}
+}
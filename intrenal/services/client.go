@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxBatchCalls limits the size of a single JSON-RPC batch; providers reject huge batches
+const maxBatchCalls = 100
+
+// ContractCall is one eth_call in a batch. Result and Error are filled in by BatchCallContract.
+type ContractCall struct {
+	Msg    ethereum.CallMsg
+	Block  *big.Int // nil = latest
+	Result []byte
+	Error  error
+}
+
+// ChainClient is the subset of the Ethereum JSON-RPC API used by BlockchainService.
+// DialChainClient returns the real implementation; tests provide stub contracts instead.
+type ChainClient interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	// BatchCallContract executes all calls as JSON-RPC batches. The returned error is a
+	// transport failure; per-call failures are reported in ContractCall.Error.
+	BatchCallContract(ctx context.Context, calls []ContractCall) error
+	BlockNumber(ctx context.Context) (uint64, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	// BlockTimestamp returns the timestamp of a block header (nil = latest)
+	BlockTimestamp(ctx context.Context, number *big.Int) (uint64, error)
+	Close()
+}
+
+// rpcChainClient is an ethclient.Client with batched eth_call support
+type rpcChainClient struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+// DialChainClient connects to an Ethereum JSON-RPC endpoint
+func DialChainClient(ctx context.Context, rawURL string) (ChainClient, error) {
+	rpcClient, err := rpc.DialContext(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcChainClient{
+		Client: ethclient.NewClient(rpcClient),
+		rpc:    rpcClient,
+	}, nil
+}
+
+// BlockTimestamp reads the block header and returns its timestamp
+func (c *rpcChainClient) BlockTimestamp(ctx context.Context, number *big.Int) (uint64, error) {
+	header, err := c.HeaderByNumber(ctx, number)
+	if err != nil {
+		return 0, err
+	}
+	return header.Time, nil
+}
+
+// BatchCallContract sends eth_call requests in batches of at most maxBatchCalls
+func (c *rpcChainClient) BatchCallContract(ctx context.Context, calls []ContractCall) error {
+	for start := 0; start < len(calls); start += maxBatchCalls {
+		end := min(start+maxBatchCalls, len(calls))
+		if err := c.batchCall(ctx, calls[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *rpcChainClient) batchCall(ctx context.Context, calls []ContractCall) error {
+	results := make([]hexutil.Bytes, len(calls))
+	elems := make([]rpc.BatchElem, len(calls))
+	for i, call := range calls {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{toCallArg(call.Msg), toBlockNumArg(call.Block)},
+			Result: &results[i],
+		}
+	}
+
+	if err := c.rpc.BatchCallContext(ctx, elems); err != nil {
+		return err
+	}
+
+	for i := range calls {
+		calls[i].Result = results[i]
+		calls[i].Error = elems[i].Error
+	}
+	return nil
+}
+
+// toCallArg encodes a call message the way ethclient does for eth_call
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"to":    msg.To,
+		"input": hexutil.Bytes(msg.Data),
+	}
+	if msg.From != (ethereum.CallMsg{}).From {
+		arg["from"] = msg.From
+	}
+	return arg
+}
+
+// toBlockNumArg encodes a block number, nil meaning latest
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+// revertErrorCode is the JSON-RPC error code nodes use for reverts carrying revert data
+const revertErrorCode = 3
+
+// RevertError is the JSON-RPC error of a reverted call, which isExecutionError tells
+// apart from transport failures. ChainClients that serve calls without a node, such as
+// snapshots and stub chains, return it for calls that revert.
+type RevertError struct{}
+
+func (RevertError) Error() string  { return "execution reverted" }
+func (RevertError) ErrorCode() int { return revertErrorCode }
+
+// isExecutionError reports whether err was returned by the EVM (e.g. a revert)
+// rather than by the transport or by node-side limits.
+func isExecutionError(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if rpcErr.ErrorCode() == revertErrorCode {
+		return true
+	}
+
+	message := strings.ToLower(rpcErr.Error())
+	return strings.Contains(message, "execution reverted") || strings.Contains(message, "invalid opcode")
+}
+
+// pinnedClient reads at a fixed block whatever is read at the latest block, and
+// reports that block as the latest one, so that services answer as of that block
+type pinnedClient struct {
+	ChainClient
+	block *big.Int
+}
+
+// PinBlock returns a client that serves the state at block to services reading the
+// latest state, e.g. to quote as of a past block
+func PinBlock(client ChainClient, block uint64) ChainClient {
+	return &pinnedClient{ChainClient: client, block: new(big.Int).SetUint64(block)}
+}
+
+func (c *pinnedClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return c.ChainClient.CallContract(ctx, msg, c.at(blockNumber))
+}
+
+func (c *pinnedClient) BatchCallContract(ctx context.Context, calls []ContractCall) error {
+	for i := range calls {
+		calls[i].Block = c.at(calls[i].Block)
+	}
+	return c.ChainClient.BatchCallContract(ctx, calls)
+}
+
+func (c *pinnedClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.block.Uint64(), nil
+}
+
+func (c *pinnedClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return c.ChainClient.CodeAt(ctx, account, c.at(blockNumber))
+}
+
+func (c *pinnedClient) BlockTimestamp(ctx context.Context, number *big.Int) (uint64, error) {
+	return c.ChainClient.BlockTimestamp(ctx, c.at(number))
+}
+
+// at returns the block to read for a requested block, nil meaning latest
+func (c *pinnedClient) at(number *big.Int) *big.Int {
+	if number == nil {
+		return c.block
+	}
+	return number
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// historyFinalityDepth is how far behind the head a block must be before its reserves
+// are cached; younger blocks may still be reorganized
+const historyFinalityDepth = 64
+
+// HistoryService replays quotes over past blocks for backtesting
+type HistoryService struct {
+	uniswap    *UniswapService
+	blockchain *BlockchainService
+	cache      *ReserveCache
+	maxPoints  int
+}
+
+// NewHistoryService creates a new history service
+func NewHistoryService(uniswap *UniswapService, blockchain *BlockchainService, cache *ReserveCache, maxPoints int) *HistoryService {
+	return &HistoryService{
+		uniswap:    uniswap,
+		blockchain: blockchain,
+		cache:      cache,
+		maxPoints:  maxPoints,
+	}
+}
+
+// EstimateHistory quotes the swap at every step-th block from FromBlock to ToBlock.
+// Reserves of all blocks are read in one batched call and cached on disk once final;
+// token order and metadata are read once, at ToBlock.
+func (hs *HistoryService) EstimateHistory(ctx context.Context, req *models.HistoryRequest) (*models.HistoryResponse, error) {
+	amountIn, err := utils.ParseBigInt(req.SrcAmount)
+	if err != nil {
+		return nil, models.ErrInvalidAmount.WithCause(err)
+	}
+
+	blocks, err := hs.blockRange(req)
+	if err != nil {
+		return nil, err
+	}
+	toBlock := blocks[len(blocks)-1]
+
+	head, err := hs.blockchain.LatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if toBlock > head.Uint64() {
+		return nil, models.ErrInvalidBlockRange.WithDetails(
+			fmt.Sprintf("to_block %d is after the latest block %d", toBlock, head.Uint64()),
+		)
+	}
+
+	poolAddr := utils.NormalizeAddress(req.Pool)
+	srcAddr := utils.NormalizeAddress(req.Src)
+	dstAddr := utils.NormalizeAddress(req.Dst)
+
+	state, err := hs.blockchain.GetBatchState(ctx, []string{poolAddr}, []string{srcAddr, dstAddr}, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return nil, err
+	}
+	if err := state.PoolErrors[poolAddr]; err != nil {
+		return nil, err
+	}
+	pair := state.Pools[poolAddr]
+
+	series, err := hs.reserves(ctx, poolAddr, blocks, head.Uint64())
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.HistoryResponse{
+		Pool:   poolAddr,
+		Token0: pair.Token0,
+		Token1: pair.Token1,
+		Points: make([]models.HistoryPoint, len(blocks)),
+	}
+
+	quoteReq := &models.EstimateRequest{Pool: req.Pool, Src: req.Src, Dst: req.Dst, SrcAmount: req.SrcAmount}
+	for i, block := range blocks {
+		point := &response.Points[i]
+		point.BlockNumber = block
+
+		reserves := series[block]
+		if reserves == nil {
+			point.Error = models.ErrPoolNotFound.WithDetails(fmt.Sprintf("The pool did not exist at block %d", block))
+			continue
+		}
+		point.Reserve0 = reserves.Reserve0.String()
+		point.Reserve1 = reserves.Reserve1.String()
+
+		blockReserves := &models.PoolReserves{
+			Reserve0:  reserves.Reserve0,
+			Reserve1:  reserves.Reserve1,
+			Token0:    pair.Token0,
+			Token1:    pair.Token1,
+			BlockTime: reserves.BlockTime,
+		}
+		blockState := &BatchState{
+			Pools:  map[string]*models.PoolReserves{poolAddr: blockReserves},
+			Models: map[string]PricingModel{poolAddr: constantProductModel{blockReserves, hs.blockchain.pairFeeBps(poolAddr, blockReserves)}},
+			Tokens: state.Tokens,
+		}
+
+		quote, calculation, _, err := hs.uniswap.simulate(quoteReq, amountIn, blockState)
+		if err != nil {
+			point.Error = models.AsAPIError(err)
+			continue
+		}
+		point.DstAmount = quote.DstAmount
+		reserveIn, reserveOut := blockReserves.Reserve0, blockReserves.Reserve1
+		if srcAddr == pair.Token1 {
+			reserveIn, reserveOut = reserveOut, reserveIn
+		}
+		point.SpotPrice, _ = utils.SpotPrice(reserveIn, reserveOut,
+			calculation.TokenIn.Decimals, calculation.TokenOut.Decimals)
+	}
+
+	return response, nil
+}
+
+// blockRange lists the requested blocks, checking the range against maxPoints
+func (hs *HistoryService) blockRange(req *models.HistoryRequest) ([]uint64, error) {
+	from, err := strconv.ParseUint(req.FromBlock, 10, 64)
+	if err != nil {
+		return nil, models.ErrInvalidBlockRange.WithCause(err)
+	}
+	to, err := strconv.ParseUint(req.ToBlock, 10, 64)
+	if err != nil {
+		return nil, models.ErrInvalidBlockRange.WithCause(err)
+	}
+
+	step := uint64(1)
+	if req.Step != "" {
+		step, err = strconv.ParseUint(req.Step, 10, 64)
+		if err != nil || step == 0 {
+			return nil, models.ErrInvalidBlockRange.WithDetails("step must be a positive integer")
+		}
+	}
+
+	if from > to {
+		return nil, models.ErrInvalidBlockRange.WithDetails("from_block must not exceed to_block")
+	}
+	if points := (to-from)/step + 1; points > uint64(hs.maxPoints) {
+		return nil, models.ErrInvalidBlockRange.WithDetails(
+			fmt.Sprintf("The range has %d points, at most %d are allowed; use a larger step", points, hs.maxPoints),
+		)
+	}
+
+	var blocks []uint64
+	for block := from; block <= to; block += step {
+		blocks = append(blocks, block)
+		if to-block < step {
+			break // the next step would pass to, or overflow
+		}
+	}
+	return blocks, nil
+}
+
+// reserves returns the reserves of pool at each block, from the cache where possible.
+// The rest is fetched in one batched read; blocks at least historyFinalityDepth behind
+// head are then added to the cache.
+func (hs *HistoryService) reserves(ctx context.Context, pool string, blocks []uint64, head uint64) (map[uint64]*models.PoolReserves, error) {
+	series := make(map[uint64]*models.PoolReserves, len(blocks))
+
+	var missing []uint64
+	for _, block := range blocks {
+		if reserves, ok := hs.cache.Get(hs.blockchain.chain.ChainID, pool, block); ok {
+			series[block] = reserves
+		} else {
+			missing = append(missing, block)
+		}
+	}
+
+	if len(missing) == 0 {
+		return series, nil
+	}
+
+	fetched, err := hs.blockchain.GetReservesSeries(ctx, pool, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	final := make(map[uint64]*models.PoolReserves, len(fetched))
+	for block, reserves := range fetched {
+		series[block] = reserves
+		if block+historyFinalityDepth <= head {
+			final[block] = reserves
+		}
+	}
+
+	if err := hs.cache.Put(hs.blockchain.chain.ChainID, pool, final); err != nil {
+		log.Printf("Failed to cache reserves of %s: %v", pool, err)
+	}
+
+	return series, nil
+}
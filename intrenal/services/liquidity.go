@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// LiquidityService estimates Uniswap V2 liquidity deposits and withdrawals off-chain
+type LiquidityService struct {
+	blockchain *BlockchainService
+}
+
+// NewLiquidityService creates a new liquidity service
+func NewLiquidityService(blockchain *BlockchainService) *LiquidityService {
+	return &LiquidityService{blockchain: blockchain}
+}
+
+// AddLiquidity estimates UniswapV2Router02.addLiquidity: the desired amounts are trimmed
+// to the pool ratio and the pair mints LP tokens after minting the protocol fee
+func (ls *LiquidityService) AddLiquidity(ctx context.Context, req *models.AddLiquidityRequest) (*models.AddLiquidityResponse, error) {
+	block, pair, err := ls.pairLiquidity(ctx, req.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredA, err := utils.ParseBigInt(req.AmountA)
+	if err != nil {
+		return nil, models.ErrInvalidAmount.WithCause(err)
+	}
+	desiredB, err := utils.ParseBigInt(req.AmountB)
+	if err != nil {
+		return nil, models.ErrInvalidAmount.WithCause(err)
+	}
+
+	tokenA := utils.NormalizeAddress(req.TokenA)
+	tokenB := utils.NormalizeAddress(req.TokenB)
+	reserves := pair.Reserves
+
+	// Work in pair order: token0, token1
+	var desired0, desired1 *big.Int
+	switch {
+	case tokenA == reserves.Token0 && tokenB == reserves.Token1:
+		desired0, desired1 = desiredA, desiredB
+	case tokenA == reserves.Token1 && tokenB == reserves.Token0:
+		desired0, desired1 = desiredB, desiredA
+	default:
+		return nil, models.ErrTokenMismatch
+	}
+
+	amount0, amount1, err := utils.OptimalAddAmounts(desired0, desired1, reserves.Reserve0, reserves.Reserve1)
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithCause(err)
+	}
+
+	totalSupply := ls.supplyWithFee(pair)
+	liquidity, err := utils.LiquidityMinted(amount0, amount1, reserves.Reserve0, reserves.Reserve1, totalSupply)
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithDetails(err.Error())
+	}
+
+	// The first mint also locks MinimumLiquidity
+	totalSupply.Add(totalSupply, liquidity)
+	if pair.TotalSupply.Sign() == 0 {
+		totalSupply.Add(totalSupply, utils.MinimumLiquidity)
+	}
+
+	amountA, amountB := amount0, amount1
+	if tokenA != reserves.Token0 {
+		amountA, amountB = amount1, amount0
+	}
+
+	return &models.AddLiquidityResponse{
+		BlockNumber: block.Uint64(),
+		AmountA:     amountA.String(),
+		AmountB:     amountB.String(),
+		Liquidity:   liquidity.String(),
+		TotalSupply: totalSupply.String(),
+		PoolShare:   utils.PoolShare(liquidity, totalSupply),
+	}, nil
+}
+
+// RemoveLiquidity estimates UniswapV2Pair.burn: the token amounts an LP token balance
+// is worth, after minting the protocol fee
+func (ls *LiquidityService) RemoveLiquidity(ctx context.Context, req *models.RemoveLiquidityRequest) (*models.RemoveLiquidityResponse, error) {
+	block, pair, err := ls.pairLiquidity(ctx, req.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	liquidity, err := utils.ParseBigInt(req.Liquidity)
+	if err != nil {
+		return nil, models.ErrInvalidAmount.WithCause(err)
+	}
+
+	reserves := pair.Reserves
+	totalSupply := ls.supplyWithFee(pair)
+	amount0, amount1, err := utils.BurnAmounts(liquidity, reserves.Reserve0, reserves.Reserve1, totalSupply)
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithDetails(err.Error())
+	}
+
+	return &models.RemoveLiquidityResponse{
+		BlockNumber: block.Uint64(),
+		Token0:      reserves.Token0,
+		Token1:      reserves.Token1,
+		Amount0:     amount0.String(),
+		Amount1:     amount1.String(),
+		TotalSupply: totalSupply.String(),
+		PoolShare:   utils.PoolShare(liquidity, totalSupply),
+	}, nil
+}
+
+// pairLiquidity reads the pair state pinned to the latest block
+func (ls *LiquidityService) pairLiquidity(ctx context.Context, pool string) (*big.Int, *models.PairLiquidity, error) {
+	block, err := ls.blockchain.LatestBlock(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := ls.blockchain.GetPairLiquidity(ctx, utils.NormalizeAddress(pool), block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, pair, nil
+}
+
+// supplyWithFee returns the LP token supply once the pending protocol fee is minted
+func (ls *LiquidityService) supplyWithFee(pair *models.PairLiquidity) *big.Int {
+	totalSupply := new(big.Int).Set(pair.TotalSupply)
+	if pair.FeeOn {
+		totalSupply.Add(totalSupply, utils.ProtocolFeeLiquidity(
+			pair.Reserves.Reserve0, pair.Reserves.Reserve1, pair.TotalSupply, pair.KLast,
+		))
+	}
+	return totalSupply
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// OracleService computes time-weighted average prices from the V2 pair price accumulators
+type OracleService struct {
+	blockchain *BlockchainService
+}
+
+// NewOracleService creates a new oracle service
+func NewOracleService(blockchain *BlockchainService) *OracleService {
+	return &OracleService{blockchain: blockchain}
+}
+
+// TWAP returns the time-weighted average price of src in dst. Both ends are read like
+// UniswapV2OracleLibrary.currentCumulativePrices: the accumulators are extended from the
+// pair's last sync to the exact end timestamp, so the pair need not be synced at either end.
+func (o *OracleService) TWAP(ctx context.Context, req *models.TwapRequest) (*models.TwapResponse, error) {
+	if (req.FromBlock == "") == (req.Window == "") {
+		return nil, models.ErrInvalidBlockRange.WithDetails("Set either from_block or window")
+	}
+
+	head, err := o.blockchain.LatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	toBlock := head.Uint64()
+	if req.ToBlock != "" {
+		toBlock, _ = strconv.ParseUint(req.ToBlock, 10, 64)
+		if toBlock > head.Uint64() {
+			return nil, models.ErrInvalidBlockRange.WithDetails(
+				fmt.Sprintf("to_block %d is after the latest block %d", toBlock, head.Uint64()),
+			)
+		}
+	}
+
+	poolAddr := utils.NormalizeAddress(req.Pool)
+	srcAddr := utils.NormalizeAddress(req.Src)
+	dstAddr := utils.NormalizeAddress(req.Dst)
+
+	// Token order and decimals, read once at the end of the interval
+	state, err := o.blockchain.GetBatchState(ctx, []string{poolAddr}, []string{srcAddr, dstAddr}, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return nil, err
+	}
+	if err := state.PoolErrors[poolAddr]; err != nil {
+		return nil, err
+	}
+	pair := state.Pools[poolAddr]
+
+	// price0 accumulates token1 per token0, price1 token0 per token1
+	var srcIsToken0 bool
+	switch {
+	case srcAddr == pair.Token0 && dstAddr == pair.Token1:
+		srcIsToken0 = true
+	case srcAddr == pair.Token1 && dstAddr == pair.Token0:
+		srcIsToken0 = false
+	default:
+		return nil, models.ErrTokenMismatch
+	}
+
+	toTimestamp, err := o.blockchain.BlockTimestamp(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromBlock, fromTimestamp uint64
+	if req.Window != "" {
+		window, _ := strconv.ParseUint(req.Window, 10, 64)
+		if window == 0 || window >= toTimestamp {
+			return nil, models.ErrInvalidBlockRange.WithDetails("window must be a positive number of seconds")
+		}
+		fromTimestamp = toTimestamp - window
+		fromBlock, err = o.blockAtTime(ctx, fromTimestamp, toBlock, toTimestamp)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fromBlock, _ = strconv.ParseUint(req.FromBlock, 10, 64)
+		if fromBlock >= toBlock {
+			return nil, models.ErrInvalidBlockRange.WithDetails("from_block must be before to_block")
+		}
+		fromTimestamp, err = o.blockchain.BlockTimestamp(ctx, fromBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	observations, err := o.blockchain.GetObservations(ctx, poolAddr, []uint64{fromBlock, toBlock})
+	if err != nil {
+		return nil, err
+	}
+
+	start0, start1 := cumulativePricesAt(observations[0], fromTimestamp)
+	end0, end1 := cumulativePricesAt(observations[1], toTimestamp)
+	start, end := start1, end1
+	if srcIsToken0 {
+		start, end = start0, end0
+	}
+
+	average, err := utils.TimeWeightedAveragePrice(start, end, toTimestamp-fromTimestamp)
+	if err != nil {
+		return nil, models.ErrInvalidBlockRange.WithDetails("from_block and to_block have the same timestamp")
+	}
+
+	response := &models.TwapResponse{
+		FromBlock:      fromBlock,
+		ToBlock:        toBlock,
+		FromTimestamp:  fromTimestamp,
+		ToTimestamp:    toTimestamp,
+		Price:          utils.UQ112x112ToDecimal(average, state.Tokens[srcAddr].Decimals, state.Tokens[dstAddr].Decimals),
+		PriceUQ112x112: average.String(),
+	}
+
+	if req.SrcAmount != "" {
+		amountIn, err := utils.ParseBigInt(req.SrcAmount)
+		if err != nil {
+			return nil, models.ErrInvalidAmount.WithCause(err)
+		}
+		response.DstAmount = utils.MulDecodeUQ112x112(average, amountIn).String()
+	}
+
+	return response, nil
+}
+
+// blockAtTime finds the last block at or before timestamp, searching back from toBlock.
+// Block timestamps increase by at least one second, which bounds the search range.
+func (o *OracleService) blockAtTime(ctx context.Context, timestamp, toBlock, toTimestamp uint64) (uint64, error) {
+	low := toBlock - min(toBlock, toTimestamp-timestamp)
+	high := toBlock
+
+	lowTimestamp, err := o.blockchain.BlockTimestamp(ctx, low)
+	if err != nil {
+		return 0, err
+	}
+	if lowTimestamp > timestamp {
+		return 0, models.ErrInvalidBlockRange.WithDetails("window starts before the first block")
+	}
+
+	for low < high {
+		mid := low + (high-low+1)/2
+		midTimestamp, err := o.blockchain.BlockTimestamp(ctx, mid)
+		if err != nil {
+			return 0, err
+		}
+		if midTimestamp <= timestamp {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	return low, nil
+}
+
+// cumulativePricesAt extends the accumulators of an observation to timestamp
+func cumulativePricesAt(observation *models.PairObservation, timestamp uint64) (*big.Int, *big.Int) {
+	return utils.CounterfactualCumulativePrices(
+		observation.Price0CumulativeLast,
+		observation.Price1CumulativeLast,
+		observation.Reserve0,
+		observation.Reserve1,
+		observation.BlockTimestampLast,
+		timestamp,
+	)
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// pairVerdicts caches the outcome of pool validation per pair. The factory, tokens and
+// code of a deployed contract never change, so verdicts never expire.
+type pairVerdicts struct {
+	mu       sync.Mutex
+	verdicts map[string]error // by lowercase pool; nil means genuine
+}
+
+// newPairVerdicts returns an empty cache, or nil when pool validation is off
+func newPairVerdicts(enabled bool) *pairVerdicts {
+	if !enabled {
+		return nil
+	}
+	return &pairVerdicts{verdicts: make(map[string]error)}
+}
+
+func (v *pairVerdicts) get(pool string) (error, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	verdict, ok := v.verdicts[pool]
+	return verdict, ok
+}
+
+func (v *pairVerdicts) put(pool string, verdict error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.verdicts[pool] = verdict
+}
+
+// validatePairs moves the pools of state that are not genuine to PoolErrors with
+// ErrUntrustedPool, so that a contract answering getReserves with made-up numbers is
+// never quoted. Every pool with a pricing model gets a verdict. Nothing is checked
+// unless pool validation is on.
+func (bs *BlockchainService) validatePairs(ctx context.Context, state *BatchState, block *big.Int) error {
+	if bs.pairVerdicts == nil || len(state.Models) == 0 {
+		return nil
+	}
+
+	verdicts, err := bs.pairVerdictsOf(ctx, state.Models, block)
+	if err != nil {
+		return err
+	}
+	for pool, verdict := range verdicts {
+		if verdict != nil {
+			delete(state.Pools, pool)
+			delete(state.Models, pool)
+			state.PoolErrors[pool] = verdict
+		}
+	}
+	return nil
+}
+
+// checkPair validates one V2 pair, returning its ErrUntrustedPool if it is not genuine
+func (bs *BlockchainService) checkPair(ctx context.Context, pool string, reserves *models.PoolReserves, block *big.Int) error {
+	if bs.pairVerdicts == nil {
+		return nil
+	}
+
+	verdicts, err := bs.pairVerdictsOf(ctx, map[string]PricingModel{pool: constantProductModel{reserves: reserves}}, block)
+	if err != nil {
+		return err
+	}
+	return verdicts[pool]
+}
+
+// pairVerdictsOf returns the verdict of every pool, reading what is not cached yet.
+// A V2 pair is genuine when its factory() is a trusted factory of the chain and the pair
+// is the one that factory deploys for its tokens: the CREATE2 address for the V2
+// deployments of the chain, whose init code hash is known, and getPair() for the other
+// trusted factories. A Solidly-style pair is genuine when its factory() is trusted and
+// answers isPair() or getPair() with the pair. With <CHAIN>_PAIR_CODE_HASHES set, the
+// keccak256 of the runtime code must also be one of them. Other pool types cannot be
+// authenticated and are never genuine. Only transport failures are returned as errors.
+func (bs *BlockchainService) pairVerdictsOf(ctx context.Context, pools map[string]PricingModel, block *big.Int) (map[string]error, error) {
+	verdicts := make(map[string]error, len(pools))
+	var pending []string
+	for pool := range pools {
+		if verdict, ok := bs.pairVerdicts.get(pool); ok {
+			verdicts[pool] = verdict
+		} else {
+			pending = append(pending, pool)
+		}
+	}
+	if len(pending) == 0 {
+		return verdicts, nil
+	}
+	slices.Sort(pending)
+
+	checked := make(map[string]error, len(pending))
+	var pairs []string
+	for _, pool := range pending {
+		switch model := pools[pool].(type) {
+		case constantProductModel, solidlyModel:
+			pairs = append(pairs, pool)
+		default:
+			checked[pool] = models.ErrUntrustedPool.WithDetails(fmt.Sprintf(
+				"Pool %s is a %s pool, which cannot be authenticated; with pool validation on only V2 and Solidly pairs are quoted",
+				pool, model.PoolType()))
+		}
+	}
+
+	factories, err := bs.pairFactories(ctx, pairs, block)
+	if err != nil {
+		return nil, err
+	}
+
+	trusted := bs.trustedFactories()
+	var byGetPair, bySolidlyFactory []string
+	for _, pool := range pairs {
+		factory, ok := factories[pool]
+		if !ok {
+			checked[pool] = models.ErrUntrustedPool.WithDetails(fmt.Sprintf(
+				"Pool %s does not answer factory() like a pair", pool))
+			continue
+		}
+		initCodeHash, ok := trusted[factory]
+		tokens := pools[pool].Tokens()
+		_, solidly := pools[pool].(solidlyModel)
+		switch {
+		case !ok:
+			checked[pool] = models.ErrUntrustedPool.WithDetails(fmt.Sprintf(
+				"Pool %s was deployed by %s, which is not a trusted factory on %s, see %s_TRUSTED_FACTORIES",
+				pool, factory, bs.chain.Name, strings.ToUpper(bs.chain.Name)))
+		case solidly:
+			bySolidlyFactory = append(bySolidlyFactory, pool)
+		case initCodeHash == "":
+			byGetPair = append(byGetPair, pool)
+		case utils.PairAddress(factory, initCodeHash, tokens[0], tokens[1]) != pool:
+			checked[pool] = notThePair(pool, factory, tokens)
+		default:
+			checked[pool] = nil
+		}
+	}
+
+	if err := bs.checkGetPair(ctx, byGetPair, factories, pools, checked, block); err != nil {
+		return nil, err
+	}
+	if err := bs.checkSolidlyPair(ctx, bySolidlyFactory, factories, pools, checked, block); err != nil {
+		return nil, err
+	}
+	if err := bs.checkPairCode(ctx, checked, block); err != nil {
+		return nil, err
+	}
+
+	for pool, verdict := range checked {
+		bs.pairVerdicts.put(pool, verdict)
+		verdicts[pool] = verdict
+	}
+	return verdicts, nil
+}
+
+// pairFactories reads factory() of every pool in one batched read. Pools that revert or
+// answer something else than an address are left out.
+func (bs *BlockchainService) pairFactories(ctx context.Context, pools []string, block *big.Int) (map[string]string, error) {
+	factories := make(map[string]string, len(pools))
+	if len(pools) == 0 {
+		return factories, nil
+	}
+
+	calls := make([]ContractCall, len(pools))
+	for i, pool := range pools {
+		call, err := newContractCall(bs.pairABI, pool, "factory", block)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = call
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	for i, call := range calls {
+		var factory common.Address
+		if call.Error != nil || bs.pairABI.UnpackIntoInterface(&factory, "factory", call.Result) != nil {
+			continue
+		}
+		factories[pools[i]] = strings.ToLower(factory.Hex())
+	}
+	return factories, nil
+}
+
+// checkGetPair asks the factory of each pool for the pair of its tokens in one batched
+// read and records the verdicts in checked
+func (bs *BlockchainService) checkGetPair(
+	ctx context.Context,
+	pools []string,
+	factories map[string]string,
+	pairs map[string]PricingModel,
+	checked map[string]error,
+	block *big.Int,
+) error {
+	if len(pools) == 0 {
+		return nil
+	}
+
+	calls := make([]ContractCall, len(pools))
+	for i, pool := range pools {
+		tokens := pairs[pool].Tokens()
+		call, err := newContractCall(bs.factoryABI, factories[pool], "getPair", block,
+			common.HexToAddress(tokens[0]), common.HexToAddress(tokens[1]))
+		if err != nil {
+			return err
+		}
+		calls[i] = call
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return err
+	}
+
+	for i, call := range calls {
+		pool := pools[i]
+		var pair common.Address
+		if call.Error != nil || bs.factoryABI.UnpackIntoInterface(&pair, "getPair", call.Result) != nil ||
+			strings.ToLower(pair.Hex()) != pool {
+			checked[pool] = notThePair(pool, factories[pool], pairs[pool].Tokens())
+			continue
+		}
+		checked[pool] = nil
+	}
+	return nil
+}
+
+// checkSolidlyPair asks the factory of each Solidly-style pair both isPair(pair) and
+// getPair(token0, token1, stable) in one batched read, and records the verdicts in
+// checked. Factories answer at least one of them; either confirming the pair will do.
+func (bs *BlockchainService) checkSolidlyPair(
+	ctx context.Context,
+	pools []string,
+	factories map[string]string,
+	pairs map[string]PricingModel,
+	checked map[string]error,
+	block *big.Int,
+) error {
+	if len(pools) == 0 {
+		return nil
+	}
+
+	calls := make([]ContractCall, 0, len(pools)*2)
+	for _, pool := range pools {
+		solidly := pairs[pool].(solidlyModel).pool
+		isPair, err := newContractCall(bs.solidlyFactoryABI, factories[pool], "isPair", block, common.HexToAddress(pool))
+		if err != nil {
+			return err
+		}
+		getPair, err := newContractCall(bs.solidlyFactoryABI, factories[pool], "getPair", block,
+			common.HexToAddress(solidly.Token0), common.HexToAddress(solidly.Token1), solidly.Stable)
+		if err != nil {
+			return err
+		}
+		calls = append(calls, isPair, getPair)
+	}
+	if err := bs.batchCall(ctx, calls); err != nil {
+		return err
+	}
+
+	for i, pool := range pools {
+		isPairCall, getPairCall := calls[2*i], calls[2*i+1]
+		var isPair bool
+		var pair common.Address
+		switch {
+		case isPairCall.Error == nil && bs.solidlyFactoryABI.UnpackIntoInterface(&isPair, "isPair", isPairCall.Result) == nil && isPair:
+			checked[pool] = nil
+		case getPairCall.Error == nil && bs.solidlyFactoryABI.UnpackIntoInterface(&pair, "getPair", getPairCall.Result) == nil &&
+			strings.ToLower(pair.Hex()) == pool:
+			checked[pool] = nil
+		default:
+			checked[pool] = notThePair(pool, factories[pool], pairs[pool].Tokens())
+		}
+	}
+	return nil
+}
+
+// checkPairCode compares the runtime code hash of the pools still considered genuine
+// with <CHAIN>_PAIR_CODE_HASHES, when set
+func (bs *BlockchainService) checkPairCode(ctx context.Context, checked map[string]error, block *big.Int) error {
+	if len(bs.chain.PairCodeHashes) == 0 {
+		return nil
+	}
+
+	for pool, verdict := range checked {
+		if verdict != nil {
+			continue
+		}
+		code, err := bs.client.CodeAt(ctx, common.HexToAddress(pool), block)
+		if err != nil {
+			return models.ErrBlockchainConnection.WithCause(err)
+		}
+		if hash := crypto.Keccak256Hash(code).Hex(); !slices.Contains(bs.chain.PairCodeHashes, hash) {
+			checked[pool] = models.ErrUntrustedPool.WithDetails(fmt.Sprintf(
+				"The runtime code of pool %s hashes to %s, which is not in %s_PAIR_CODE_HASHES",
+				pool, hash, strings.ToUpper(bs.chain.Name)))
+		}
+	}
+	return nil
+}
+
+// trustedFactories returns the lowercase factories trusted on the chain with the init
+// code hash of their pairs, "" when it is unknown
+func (bs *BlockchainService) trustedFactories() map[string]string {
+	trusted := map[string]string{strings.ToLower(bs.chain.FactoryAddress): bs.chain.InitCodeHash}
+	for _, fork := range bs.chain.AllForks() {
+		trusted[strings.ToLower(fork.FactoryAddress)] = fork.InitCodeHash
+	}
+	for _, factory := range bs.chain.TrustedFactories {
+		if _, ok := trusted[factory]; !ok {
+			trusted[factory] = ""
+		}
+	}
+	return trusted
+}
+
+// notThePair is the verdict of a pool that names a trusted factory which did not deploy it
+func notThePair(pool, factory string, tokens []string) error {
+	return models.ErrUntrustedPool.WithDetails(fmt.Sprintf(
+		"Pool %s names factory %s, but is not the pair it deploys for %s and %s",
+		pool, factory, tokens[0], tokens[1]))
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// PricingModel quotes exact input swaps through one pool from already fetched state,
+// without any RPC call. Every kind of pool GetBatchState recognises has one.
+type PricingModel interface {
+	// PoolType names the model, one of the models.PoolType constants
+	PoolType() string
+
+	// Tokens returns the lowercase addresses of the tokens the pool trades
+	Tokens() []string
+
+	// Reserves returns the pool state the model prices with, by token for pools with
+	// reserves or balances, and liquidity then sqrtPriceX96 for Uniswap V3 pools
+	Reserves() []*big.Int
+
+	// Fee returns the swap fee of the pool in basis points, e.g. "30 bps"
+	Fee() string
+
+	// AmountOut returns what the pool pays out for amountIn of tokenIn, in token units.
+	// Tokens are lowercase addresses; a pair the pool does not hold is ErrTokenMismatch.
+	AmountOut(amountIn *big.Int, tokenIn, tokenOut string) (*big.Int, error)
+}
+
+// constantProductModel prices Uniswap V2 pairs with x * y = k and the fee of the V2
+// deployment that created the pair, 0.3% for Uniswap itself
+type constantProductModel struct {
+	reserves *models.PoolReserves
+	feeBps   uint32
+}
+
+func (m constantProductModel) PoolType() string { return models.PoolTypeV2 }
+
+func (m constantProductModel) Tokens() []string {
+	return []string{m.reserves.Token0, m.reserves.Token1}
+}
+
+func (m constantProductModel) Reserves() []*big.Int {
+	return []*big.Int{m.reserves.Reserve0, m.reserves.Reserve1}
+}
+
+func (m constantProductModel) Fee() string { return formatBps(big.NewInt(int64(m.feeBps)), 1) }
+
+func (m constantProductModel) AmountOut(amountIn *big.Int, tokenIn, tokenOut string) (*big.Int, error) {
+	var reserveIn, reserveOut *big.Int
+	if tokenIn == m.reserves.Token0 && tokenOut == m.reserves.Token1 {
+		reserveIn, reserveOut = m.reserves.Reserve0, m.reserves.Reserve1
+	} else if tokenIn == m.reserves.Token1 && tokenOut == m.reserves.Token0 {
+		reserveIn, reserveOut = m.reserves.Reserve1, m.reserves.Reserve0
+	} else {
+		return nil, models.ErrTokenMismatch
+	}
+
+	amountOut, err := utils.CalculateAmountOutWithFee(amountIn, reserveIn, reserveOut, m.feeBps)
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity
+	}
+	return amountOut, nil
+}
+
+// concentratedLiquidityModel prices Uniswap V3 pools with the tick math of the pool
+type concentratedLiquidityModel struct {
+	pool *models.V3PoolState
+}
+
+func (m concentratedLiquidityModel) PoolType() string { return models.PoolTypeV3 }
+
+func (m concentratedLiquidityModel) Tokens() []string { return []string{m.pool.Token0, m.pool.Token1} }
+
+func (m concentratedLiquidityModel) Reserves() []*big.Int {
+	return []*big.Int{m.pool.Liquidity, m.pool.SqrtPriceX96}
+}
+
+func (m concentratedLiquidityModel) Fee() string {
+	return formatBps(big.NewInt(int64(m.pool.Fee)), 100)
+}
+
+func (m concentratedLiquidityModel) AmountOut(amountIn *big.Int, tokenIn, tokenOut string) (*big.Int, error) {
+	var zeroForOne bool
+	if tokenIn == m.pool.Token0 && tokenOut == m.pool.Token1 {
+		zeroForOne = true
+	} else if tokenIn != m.pool.Token1 || tokenOut != m.pool.Token0 {
+		return nil, models.ErrTokenMismatch
+	}
+	if amountIn.Sign() <= 0 {
+		return nil, models.ErrInsufficientLiquidity
+	}
+
+	result, err := utils.SwapV3(m.pool, zeroForOne, amountIn, nil)
+	if errors.Is(err, utils.ErrTicksNotLoaded) {
+		return nil, models.ErrInsufficientLiquidity.WithDetails(
+			"The swap moves the price past the ticks loaded around the current price, see V3_TICK_WORDS")
+	}
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithCause(err)
+	}
+	if result.AmountUnswapped.Sign() != 0 {
+		return nil, models.ErrInsufficientLiquidity
+	}
+
+	// The pool pays out the negative delta
+	if zeroForOne {
+		return new(big.Int).Neg(result.Amount1), nil
+	}
+	return new(big.Int).Neg(result.Amount0), nil
+}
+
+// solidlyModel prices Solidly-style pairs with x³y + y³x = k or x * y = k and the pair fee
+type solidlyModel struct {
+	pool *models.SolidlyPoolState
+}
+
+func (m solidlyModel) PoolType() string {
+	if m.pool.Stable {
+		return models.PoolTypeSolidlyStable
+	}
+	return models.PoolTypeSolidlyVolatile
+}
+
+func (m solidlyModel) Tokens() []string { return []string{m.pool.Token0, m.pool.Token1} }
+
+func (m solidlyModel) Reserves() []*big.Int { return []*big.Int{m.pool.Reserve0, m.pool.Reserve1} }
+
+func (m solidlyModel) Fee() string { return formatBps(big.NewInt(int64(m.pool.FeeBps)), 1) }
+
+func (m solidlyModel) AmountOut(amountIn *big.Int, tokenIn, tokenOut string) (*big.Int, error) {
+	pool := m.pool
+	var amountOut *big.Int
+	var err error
+	if tokenIn == pool.Token0 && tokenOut == pool.Token1 {
+		amountOut, err = utils.SolidlyAmountOut(amountIn, pool.Reserve0, pool.Reserve1, pool.Decimals0, pool.Decimals1, pool.Stable, pool.FeeBps)
+	} else if tokenIn == pool.Token1 && tokenOut == pool.Token0 {
+		amountOut, err = utils.SolidlyAmountOut(amountIn, pool.Reserve1, pool.Reserve0, pool.Decimals1, pool.Decimals0, pool.Stable, pool.FeeBps)
+	} else {
+		return nil, models.ErrTokenMismatch
+	}
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithCause(err)
+	}
+	return amountOut, nil
+}
+
+// stableSwapModel prices Curve StableSwap plain pools like their get_dy
+type stableSwapModel struct {
+	pool *models.CurvePoolState
+}
+
+func (m stableSwapModel) PoolType() string { return models.PoolTypeCurve }
+
+func (m stableSwapModel) Tokens() []string { return m.pool.Coins }
+
+func (m stableSwapModel) Reserves() []*big.Int { return m.pool.Balances }
+
+func (m stableSwapModel) Fee() string { return formatBps(m.pool.Fee, 1e6) }
+
+func (m stableSwapModel) AmountOut(amountIn *big.Int, tokenIn, tokenOut string) (*big.Int, error) {
+	i, j := coinIndex(m.pool.Coins, tokenIn), coinIndex(m.pool.Coins, tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, models.ErrTokenMismatch
+	}
+
+	amountOut, err := utils.CurveAmountOut(i, j, amountIn, m.pool.Balances, m.pool.Rates, m.pool.A, m.pool.Fee)
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithCause(err)
+	}
+	return amountOut, nil
+}
+
+// weightedModel prices Balancer V2 weighted pools like their onSwap
+type weightedModel struct {
+	pool *models.BalancerPoolState
+}
+
+func (m weightedModel) PoolType() string { return models.PoolTypeBalancerWeighted }
+
+func (m weightedModel) Tokens() []string { return m.pool.Tokens }
+
+func (m weightedModel) Reserves() []*big.Int { return m.pool.Balances }
+
+func (m weightedModel) Fee() string { return formatBps(m.pool.SwapFee, 1e14) }
+
+func (m weightedModel) AmountOut(amountIn *big.Int, tokenIn, tokenOut string) (*big.Int, error) {
+	pool := m.pool
+	i, j := coinIndex(pool.Tokens, tokenIn), coinIndex(pool.Tokens, tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, models.ErrTokenMismatch
+	}
+
+	amountOut, err := utils.BalancerSwapGivenIn(amountIn,
+		pool.Balances[i], pool.Weights[i], pool.ScalingFactors[i],
+		pool.Balances[j], pool.Weights[j], pool.ScalingFactors[j],
+		pool.SwapFee)
+	if errors.Is(err, utils.ErrMaxInRatio) {
+		return nil, models.ErrInsufficientLiquidity.WithDetails(
+			"Balancer weighted pools accept at most 30% of the input token balance per swap")
+	}
+	if err != nil {
+		return nil, models.ErrInsufficientLiquidity.WithCause(err)
+	}
+	return amountOut, nil
+}
+
+// coinIndex returns the position of token in tokens, or -1
+func coinIndex(tokens []string, token string) int {
+	for i, candidate := range tokens {
+		if candidate == token {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatBps formats a fee of value / unit basis points, e.g. "30 bps" or "0.5 bps"
+func formatBps(value *big.Int, unit int64) string {
+	bps := new(big.Rat).SetFrac(value, big.NewInt(unit)).FloatString(6)
+	bps = strings.TrimRight(strings.TrimRight(bps, "0"), ".")
+	return bps + " bps"
+}
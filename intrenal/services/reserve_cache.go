@@ -0,0 +1,158 @@
+package services
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"uniswap-est/intrenal/models"
+)
+
+// reserveCachePools is how many pools a ReserveCache keeps in memory; the least
+// recently used ones are dropped and read from disk again when asked for
+const reserveCachePools = 256
+
+// ReserveCache keeps pair reserves per block on disk, one JSON file per pool in a
+// directory per chain ID, so the same address on two chains never shares entries.
+// Reserves at a finalized block never change, so entries never expire; only the
+// reserveCachePools most recently used pools are held in memory.
+type ReserveCache struct {
+	dir string
+
+	mu     sync.Mutex
+	pools  map[string]*list.Element // by cacheKey, loaded lazily from disk
+	recent *list.List               // of *cachedPool, most recently used first
+}
+
+// cachedPool is the in-memory copy of one pool file
+type cachedPool struct {
+	key     string
+	entries map[uint64]cachedReserves
+}
+
+// cachedReserves is the on-disk form of one block; Missing means the pair did not exist
+type cachedReserves struct {
+	Reserve0  string `json:"reserve0,omitempty"`
+	Reserve1  string `json:"reserve1,omitempty"`
+	BlockTime uint32 `json:"block_time,omitempty"`
+	Missing   bool   `json:"missing,omitempty"`
+}
+
+// NewReserveCache creates a cache storing its files in dir
+func NewReserveCache(dir string) *ReserveCache {
+	return &ReserveCache{
+		dir:    dir,
+		pools:  make(map[string]*list.Element),
+		recent: list.New(),
+	}
+}
+
+// Get returns the reserves of pool on a chain at block. The second result is false on
+// a cache miss; (nil, true) means the pair did not exist at that block.
+func (c *ReserveCache) Get(chainID uint64, pool string, block uint64) (*models.PoolReserves, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.load(cacheKey(chainID, pool))[block]
+	if !ok {
+		return nil, false
+	}
+	if entry.Missing {
+		return nil, true
+	}
+
+	reserve0, _ := new(big.Int).SetString(entry.Reserve0, 10)
+	reserve1, _ := new(big.Int).SetString(entry.Reserve1, 10)
+	return &models.PoolReserves{Reserve0: reserve0, Reserve1: reserve1, BlockTime: entry.BlockTime}, true
+}
+
+// Put adds reserves of pool on a chain at several blocks and writes the pool file.
+// A nil entry records that the pair did not exist at that block.
+func (c *ReserveCache) Put(chainID uint64, pool string, series map[uint64]*models.PoolReserves) error {
+	if len(series) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(chainID, pool)
+	entries := c.load(key)
+	for block, reserves := range series {
+		if reserves == nil {
+			entries[block] = cachedReserves{Missing: true}
+			continue
+		}
+		entries[block] = cachedReserves{
+			Reserve0:  reserves.Reserve0.String(),
+			Reserve1:  reserves.Reserve1.String(),
+			BlockTime: reserves.BlockTime,
+		}
+	}
+
+	return c.save(key, entries)
+}
+
+// cacheKey identifies a pool on a chain; it is also the file path below the cache dir
+func cacheKey(chainID uint64, pool string) string {
+	return filepath.Join(strconv.FormatUint(chainID, 10), strings.ToLower(pool))
+}
+
+// load returns the entries of a pool, reading its file when it is not in memory and
+// dropping the least recently used pool beyond reserveCachePools. c.mu must be held.
+func (c *ReserveCache) load(key string) map[uint64]cachedReserves {
+	if element, ok := c.pools[key]; ok {
+		c.recent.MoveToFront(element)
+		return element.Value.(*cachedPool).entries
+	}
+
+	entries := make(map[uint64]cachedReserves)
+	data, err := os.ReadFile(c.path(key))
+	if err == nil {
+		// A corrupt file is treated as empty and rewritten on the next Put
+		_ = json.Unmarshal(data, &entries)
+	}
+
+	c.pools[key] = c.recent.PushFront(&cachedPool{key: key, entries: entries})
+	if c.recent.Len() > reserveCachePools {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.pools, oldest.Value.(*cachedPool).key)
+	}
+	return entries
+}
+
+// save writes the pool file atomically, so readers never see a partial file. c.mu must be held.
+func (c *ReserveCache) save(key string, entries map[uint64]cachedReserves) error {
+	dir := filepath.Dir(c.path(key))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "reserves-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *ReserveCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
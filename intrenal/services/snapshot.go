@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// snapshotVersion is the version of the snapshot file format written by ExportSnapshot
+const snapshotVersion = 1
+
+// snapshotBlockTime is the block interval assumed for the timestamps of blocks other
+// than the snapshot block
+const snapshotBlockTime = 12
+
+// Snapshot is the state of a set of pools on one chain at one block, read from a live
+// node, in a JSON file. Pools and Tokens describe the state for readers; Calls and Code
+// are what the services read, replayed by the client of NewSnapshotClient.
+type Snapshot struct {
+	Version int               `json:"version"`
+	Chain   SnapshotChain     `json:"chain"`
+	Block   SnapshotBlock     `json:"block"`
+	Pools   []SnapshotPool    `json:"pools"`
+	Tokens  []SnapshotToken   `json:"tokens"`
+	Calls   []SnapshotCall    `json:"calls"`
+	Code    map[string]string `json:"code"` // hex runtime code by lowercase address
+}
+
+// SnapshotChain is the chain configuration the snapshot was exported with, without the
+// RPC URLs, so that it is quoted with the same fee and deployments
+type SnapshotChain struct {
+	Name             string              `json:"name"`
+	ChainID          uint64              `json:"chain_id"`
+	Dex              string              `json:"dex"`
+	FactoryAddress   string              `json:"factory_address"`
+	InitCodeHash     string              `json:"init_code_hash,omitempty"`
+	RouterAddress    string              `json:"router_address"`
+	FeeBps           uint32              `json:"fee_bps"`
+	WrappedNative    string              `json:"wrapped_native"`
+	Forks            []config.ForkConfig `json:"forks,omitempty"`
+	ArbitragePools   []string            `json:"arbitrage_pools,omitempty"`
+	TrustedFactories []string            `json:"trusted_factories,omitempty"`
+	PairCodeHashes   []string            `json:"pair_code_hashes,omitempty"`
+}
+
+// SnapshotBlock is the block every read of the snapshot was pinned to
+type SnapshotBlock struct {
+	Number    uint64 `json:"number"`
+	Timestamp uint64 `json:"timestamp"`
+}
+
+// SnapshotPool describes one exported pool; reserves are set for Uniswap V2 pairs only
+type SnapshotPool struct {
+	Address  string   `json:"address"`
+	PoolType string   `json:"pool_type"`
+	Tokens   []string `json:"tokens"`
+	Reserves []string `json:"reserves,omitempty"`
+}
+
+// SnapshotToken is the metadata of a token of an exported pool
+type SnapshotToken struct {
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// SnapshotCall is one eth_call answer: its result, or a revert
+type SnapshotCall struct {
+	To       string `json:"to"`
+	Data     string `json:"data"`
+	Result   string `json:"result,omitempty"`
+	Reverted bool   `json:"reverted,omitempty"`
+}
+
+// ExportSnapshot reads pools, their tokens and everything the endpoints read about them
+// from client at the latest block and returns it as a snapshot. Pools that are not
+// supported pools fail the export.
+func ExportSnapshot(ctx context.Context, cfg *config.Config, chain *config.ChainConfig, client ChainClient, pools []string) (*Snapshot, error) {
+	recorder := &recordingClient{ChainClient: client, calls: make(map[string]SnapshotCall), code: make(map[string]string)}
+
+	blockchain, err := NewChainBlockchainService(cfg, chain, recorder)
+	if err != nil {
+		return nil, err
+	}
+	if err := blockchain.CheckChainID(ctx); err != nil {
+		return nil, err
+	}
+
+	block, err := blockchain.LatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := blockchain.BlockTimestamp(ctx, block.Uint64())
+	if err != nil {
+		return nil, err
+	}
+
+	// The pools first, to learn their tokens, then the tokens with them
+	pools = uniqueAddresses(pools)
+	state, err := blockchain.GetBatchState(ctx, pools, nil, block)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	for _, pool := range pools {
+		model := state.Models[pool]
+		if model == nil {
+			return nil, fmt.Errorf("pool %s: %w", pool, state.PoolErrors[pool])
+		}
+		tokens = append(tokens, model.Tokens()...)
+	}
+	if state, err = blockchain.GetBatchState(ctx, pools, tokens, block); err != nil {
+		return nil, err
+	}
+
+	// Reads of the V2 endpoints and of pool validation, which the quote does not make
+	validating := *cfg
+	validating.ValidatePools = true
+	validator, err := NewChainBlockchainService(&validating, chain, recorder)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		if _, ok := state.Pools[pool]; !ok {
+			continue
+		}
+		if _, err := blockchain.GetPairLiquidity(ctx, pool, block); err != nil && !isPoolError(err) {
+			return nil, err
+		}
+		if _, err := blockchain.GetObservations(ctx, pool, []uint64{block.Uint64()}); err != nil && !isPoolError(err) {
+			return nil, err
+		}
+	}
+	if _, err := validator.pairVerdictsOf(ctx, state.Models, block); err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		if _, err := recorder.CodeAt(ctx, common.HexToAddress(pool), block); err != nil {
+			return nil, models.ErrBlockchainConnection.WithCause(err)
+		}
+	}
+
+	snapshot := &Snapshot{
+		Version: snapshotVersion,
+		Chain:   snapshotChainOf(chain),
+		Block:   SnapshotBlock{Number: block.Uint64(), Timestamp: timestamp},
+		Calls:   recorder.recorded(),
+		Code:    recorder.code,
+	}
+	for _, pool := range pools {
+		entry := SnapshotPool{Address: pool, PoolType: state.Models[pool].PoolType(), Tokens: state.Models[pool].Tokens()}
+		if reserves := state.Pools[pool]; reserves != nil {
+			entry.Reserves = []string{reserves.Reserve0.String(), reserves.Reserve1.String()}
+		}
+		snapshot.Pools = append(snapshot.Pools, entry)
+	}
+	for _, token := range uniqueAddresses(tokens) {
+		info := state.Tokens[token]
+		snapshot.Tokens = append(snapshot.Tokens, SnapshotToken{
+			Address: token, Symbol: info.Symbol, Name: info.Name, Decimals: info.Decimals,
+		})
+	}
+	return snapshot, nil
+}
+
+// isPoolError reports whether err is about the pool rather than the node
+func isPoolError(err error) bool {
+	var apiErr *models.APIError
+	return errors.As(err, &apiErr) && !errors.Is(err, models.ErrBlockchainConnection)
+}
+
+// snapshotChainOf returns the chain settings stored in a snapshot
+func snapshotChainOf(chain *config.ChainConfig) SnapshotChain {
+	return SnapshotChain{
+		Name:             chain.Name,
+		ChainID:          chain.ChainID,
+		Dex:              chain.Dex,
+		FactoryAddress:   chain.FactoryAddress,
+		InitCodeHash:     chain.InitCodeHash,
+		RouterAddress:    chain.RouterAddress,
+		FeeBps:           chain.FeeBps,
+		WrappedNative:    chain.WrappedNative,
+		Forks:            chain.Forks,
+		ArbitragePools:   chain.ArbitragePools,
+		TrustedFactories: chain.TrustedFactories,
+		PairCodeHashes:   chain.PairCodeHashes,
+	}
+}
+
+// ChainConfig returns the configuration of the snapshot's chain; it has no RPC URL
+func (s *Snapshot) ChainConfig() *config.ChainConfig {
+	return &config.ChainConfig{
+		Name:             s.Chain.Name,
+		ChainID:          s.Chain.ChainID,
+		Dex:              s.Chain.Dex,
+		FactoryAddress:   s.Chain.FactoryAddress,
+		InitCodeHash:     s.Chain.InitCodeHash,
+		RouterAddress:    s.Chain.RouterAddress,
+		FeeBps:           s.Chain.FeeBps,
+		WrappedNative:    s.Chain.WrappedNative,
+		Forks:            s.Chain.Forks,
+		ArbitragePools:   s.Chain.ArbitragePools,
+		TrustedFactories: s.Chain.TrustedFactories,
+		PairCodeHashes:   s.Chain.PairCodeHashes,
+	}
+}
+
+// Write writes the snapshot to path atomically
+func (s *Snapshot) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads a snapshot file written by Write
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", path, err)
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s: version %d is not supported, expected %d", path, snapshot.Version, snapshotVersion)
+	}
+	if snapshot.Chain.Name == "" || snapshot.Chain.ChainID == 0 {
+		return nil, fmt.Errorf("snapshot %s: the chain needs a name and a chain ID", path)
+	}
+	return snapshot, nil
+}
+
+// recordingClient records the answers of every eth_call and the code of every account
+// read through it
+type recordingClient struct {
+	ChainClient
+
+	mu    sync.Mutex
+	calls map[string]SnapshotCall // by callKey
+	code  map[string]string
+}
+
+func (r *recordingClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	result, err := r.ChainClient.CallContract(ctx, msg, blockNumber)
+	r.record(msg, result, err)
+	return result, err
+}
+
+func (r *recordingClient) BatchCallContract(ctx context.Context, calls []ContractCall) error {
+	if err := r.ChainClient.BatchCallContract(ctx, calls); err != nil {
+		return err
+	}
+	for _, call := range calls {
+		r.record(call.Msg, call.Result, call.Error)
+	}
+	return nil
+}
+
+func (r *recordingClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	code, err := r.ChainClient.CodeAt(ctx, account, blockNumber)
+	if err == nil {
+		r.mu.Lock()
+		r.code[strings.ToLower(account.Hex())] = hexutil.Encode(code)
+		r.mu.Unlock()
+	}
+	return code, err
+}
+
+// record keeps the answer of a call; transport failures are not answers
+func (r *recordingClient) record(msg ethereum.CallMsg, result []byte, err error) {
+	if err != nil && !isExecutionError(err) {
+		return
+	}
+
+	call := SnapshotCall{To: strings.ToLower(msg.To.Hex()), Data: hexutil.Encode(msg.Data)}
+	if err != nil {
+		call.Reverted = true
+	} else {
+		call.Result = hexutil.Encode(result)
+	}
+
+	r.mu.Lock()
+	r.calls[callKey(call.To, call.Data)] = call
+	r.mu.Unlock()
+}
+
+// recorded returns the recorded calls in a stable order, so that exports diff well
+func (r *recordingClient) recorded() []SnapshotCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]SnapshotCall, 0, len(r.calls))
+	for _, call := range r.calls {
+		calls = append(calls, call)
+	}
+	slices.SortFunc(calls, func(a, b SnapshotCall) int {
+		return strings.Compare(callKey(a.To, a.Data), callKey(b.To, b.Data))
+	})
+	return calls
+}
+
+func callKey(to, data string) string {
+	return to + "/" + data
+}
+
+// snapshotClient is a ChainClient answering from a snapshot. The state is the same at
+// every block; calls that were not recorded revert, like calls to a contract without
+// the function.
+type snapshotClient struct {
+	snapshot *Snapshot
+	calls    map[string]SnapshotCall // by callKey
+}
+
+// NewSnapshotClient returns a ChainClient serving the state of a snapshot, so that the
+// services run without any RPC node
+func NewSnapshotClient(snapshot *Snapshot) ChainClient {
+	calls := make(map[string]SnapshotCall, len(snapshot.Calls))
+	for _, call := range snapshot.Calls {
+		calls[callKey(strings.ToLower(call.To), strings.ToLower(call.Data))] = call
+	}
+	return &snapshotClient{snapshot: snapshot, calls: calls}
+}
+
+func (c *snapshotClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	call, ok := c.calls[callKey(strings.ToLower(msg.To.Hex()), hexutil.Encode(msg.Data))]
+	if !ok || call.Reverted {
+		return nil, RevertError{}
+	}
+	return hexutil.Decode(call.Result)
+}
+
+func (c *snapshotClient) BatchCallContract(ctx context.Context, calls []ContractCall) error {
+	for i := range calls {
+		calls[i].Result, calls[i].Error = c.CallContract(ctx, calls[i].Msg, calls[i].Block)
+	}
+	return nil
+}
+
+func (c *snapshotClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.snapshot.Block.Number, nil
+}
+
+func (c *snapshotClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).SetUint64(c.snapshot.Chain.ChainID), nil
+}
+
+func (c *snapshotClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	code, ok := c.snapshot.Code[strings.ToLower(account.Hex())]
+	if !ok {
+		return nil, nil
+	}
+	return hexutil.Decode(code)
+}
+
+// BlockTimestamp returns the snapshot block time, counting snapshotBlockTime seconds per
+// block before or after it
+func (c *snapshotClient) BlockTimestamp(ctx context.Context, number *big.Int) (uint64, error) {
+	block := c.snapshot.Block
+	if number == nil {
+		return block.Timestamp, nil
+	}
+	offset := int64(number.Uint64()-block.Number) * snapshotBlockTime
+	return uint64(int64(block.Timestamp) + offset), nil
+}
+
+func (c *snapshotClient) Close() {}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// QuoteHub pushes fresh quotes to stream subscribers on every new block.
+// Identical subscriptions from any number of connections share one entry and are
+// quoted once per block, all of them together in one batched read.
+type QuoteHub struct {
+	uniswap      *UniswapService
+	blockchain   *BlockchainService
+	pollInterval time.Duration
+	timeout      time.Duration
+
+	mu            sync.Mutex
+	subscriptions map[string]*quoteSubscription
+	lastBlock     uint64
+	refresh       chan struct{}
+}
+
+// quoteSubscription is one unique (pool, src, dst, amount) tuple
+type quoteSubscription struct {
+	req         models.EstimateRequest
+	subscribers map[*QuoteSubscriber][]int // positions of the tuple in each subscriber's request
+	last        *streamUpdate              // replayed to new subscribers
+}
+
+// streamUpdate is an update with the audit record of its quote, nil when it failed or
+// there is no audit log. The record is filed for each subscriber it is sent to.
+type streamUpdate struct {
+	update models.QuoteUpdate
+	record *models.QuoteRecord
+}
+
+// QuoteSubscriber receives the updates of one connection. Updates are coalesced:
+// a slow consumer only ever has the latest undelivered update per subscription
+// pending, so it can never hold up the hub or grow memory.
+type QuoteSubscriber struct {
+	hub       *QuoteHub
+	keys      []string
+	requester Requester // the quotes sent are recorded for it
+
+	mu      sync.Mutex
+	pending map[int]streamUpdate
+	notify  chan struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewQuoteHub creates a hub polling the chain head every pollInterval
+func NewQuoteHub(uniswap *UniswapService, blockchain *BlockchainService, pollInterval, timeout time.Duration) *QuoteHub {
+	return &QuoteHub{
+		uniswap:       uniswap,
+		blockchain:    blockchain,
+		pollInterval:  pollInterval,
+		timeout:       timeout,
+		subscriptions: make(map[string]*quoteSubscription),
+		refresh:       make(chan struct{}, 1),
+	}
+}
+
+// Subscribe registers a subscriber for the given validated requests. The subscriber
+// gets the last known quote of already active tuples right away; new tuples are
+// quoted without waiting for the next block. Quotes are recorded for requester as
+// they are drained, numbered by the position of their subscription.
+func (h *QuoteHub) Subscribe(reqs []models.EstimateRequest, requester Requester) *QuoteSubscriber {
+	sub := &QuoteSubscriber{
+		hub:       h,
+		requester: requester,
+		pending:   make(map[int]streamUpdate),
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	added := false
+	for i, req := range reqs {
+		key := subscriptionKey(req)
+
+		entry, ok := h.subscriptions[key]
+		if !ok {
+			req.Verify = false // streams are never cross-checked against the router
+			entry = &quoteSubscription{req: req, subscribers: make(map[*QuoteSubscriber][]int)}
+			h.subscriptions[key] = entry
+			added = true
+		}
+
+		if _, ok := entry.subscribers[sub]; !ok {
+			sub.keys = append(sub.keys, key)
+		}
+		entry.subscribers[sub] = append(entry.subscribers[sub], i)
+
+		if entry.last != nil {
+			sub.push(i, *entry.last)
+		}
+	}
+
+	if added {
+		select {
+		case h.refresh <- struct{}{}:
+		default:
+		}
+	}
+
+	return sub
+}
+
+// Subscriptions returns the number of unique subscriptions being quoted
+func (h *QuoteHub) Subscriptions() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscriptions)
+}
+
+// Run polls for new blocks until ctx is cancelled, then closes every subscriber
+func (h *QuoteHub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-ticker.C:
+			h.poll(ctx)
+		case <-h.refresh:
+			h.poll(ctx)
+		}
+	}
+}
+
+// poll quotes every subscription when the chain has a new block, and otherwise only
+// the subscriptions that have not been quoted yet
+func (h *QuoteHub) poll(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	block, err := h.blockchain.LatestBlock(ctx)
+	if err != nil {
+		log.Printf("Quote stream: failed to read block number: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	newBlock := block.Uint64() != h.lastBlock
+	var keys []string
+	var reqs []models.EstimateRequest
+	for key, entry := range h.subscriptions {
+		if newBlock || entry.last == nil {
+			keys = append(keys, key)
+			reqs = append(reqs, entry.req)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(reqs) == 0 {
+		return
+	}
+
+	updates := make([]streamUpdate, len(reqs))
+	response, records, err := h.uniswap.estimateBatch(ctx, reqs)
+	if err != nil {
+		// Every subscriber learns that quoting failed; the next tick retries
+		apiErr := models.AsAPIError(err)
+		for i := range updates {
+			updates[i].update.Error = apiErr
+		}
+	} else {
+		for i, result := range response.Results {
+			updates[i] = streamUpdate{
+				update: models.QuoteUpdate{BlockNumber: response.BlockNumber, Result: result.Result, Error: result.Error},
+				record: records[i],
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		h.lastBlock = response.BlockNumber
+	}
+
+	for i, key := range keys {
+		entry, ok := h.subscriptions[key]
+		if !ok {
+			continue // every subscriber left while quoting
+		}
+		if err == nil {
+			entry.last = &updates[i]
+		}
+		for sub, indices := range entry.subscribers {
+			for _, index := range indices {
+				sub.push(index, updates[i])
+			}
+		}
+	}
+}
+
+// unsubscribe removes the subscriber and drops tuples nobody subscribes to anymore
+func (h *QuoteHub) unsubscribe(sub *QuoteSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, key := range sub.keys {
+		entry, ok := h.subscriptions[key]
+		if !ok {
+			continue
+		}
+		delete(entry.subscribers, sub)
+		if len(entry.subscribers) == 0 {
+			delete(h.subscriptions, key)
+		}
+	}
+}
+
+// closeAll ends every subscription, used on shutdown
+func (h *QuoteHub) closeAll() {
+	h.mu.Lock()
+	subscribers := make(map[*QuoteSubscriber]bool)
+	for _, entry := range h.subscriptions {
+		for sub := range entry.subscribers {
+			subscribers[sub] = true
+		}
+	}
+	h.mu.Unlock()
+
+	for sub := range subscribers {
+		sub.Close()
+	}
+}
+
+// push replaces the pending update of a subscription and wakes the consumer
+func (s *QuoteSubscriber) push(index int, update streamUpdate) {
+	update.update.Index = index
+
+	s.mu.Lock()
+	s.pending[index] = update
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Updates signals that Drain has updates to return
+func (s *QuoteSubscriber) Updates() <-chan struct{} {
+	return s.notify
+}
+
+// Drain returns the pending updates ordered by index, to be sent, and records their
+// quotes in the audit log
+func (s *QuoteSubscriber) Drain() []models.QuoteUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updates := make([]models.QuoteUpdate, 0, len(s.pending))
+	for index, pending := range s.pending {
+		updates = append(updates, pending.update)
+		s.hub.uniswap.recordFor(s.requester, index, pending.record)
+	}
+	clear(s.pending)
+
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Index < updates[j].Index })
+	return updates
+}
+
+// Done is closed when the subscriber is closed, by Close or on hub shutdown
+func (s *QuoteSubscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close unsubscribes from every tuple; it is safe to call more than once
+func (s *QuoteSubscriber) Close() {
+	s.closeOnce.Do(func() {
+		s.hub.unsubscribe(s)
+		close(s.done)
+	})
+}
+
+// subscriptionKey identifies identical subscriptions regardless of address case
+// and leading zeros in the amount
+func subscriptionKey(req models.EstimateRequest) string {
+	amount := req.SrcAmount
+	if parsed, err := utils.ParseBigInt(amount); err == nil {
+		amount = parsed.String()
+	}
+	return strings.ToLower(req.Pool + ":" + req.Dex + ":" + req.Src + ":" + req.Dst + ":" + amount)
+}
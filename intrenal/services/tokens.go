@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// Number of tokens /tokens/search returns without a limit, and at most
+const (
+	defaultTokenSearchLimit = 20
+	maxTokenSearchLimit     = 100
+)
+
+// TokenRegistry resolves token symbols with token list files in the standard Uniswap
+// format (https://tokenlists.org). Lists are given in priority order: when a symbol is
+// in several lists, the first list having it decides. Files are reloaded when they change.
+type TokenRegistry struct {
+	paths []string
+
+	mu        sync.RWMutex
+	lists     []*tokenList                      // in priority order, as paths
+	bySymbol  map[symbolKey][]listedTokenRef    // by chain and uppercase symbol
+	byAddress map[addressKey]models.ListedToken // by chain and lowercase address, from the first list having it
+}
+
+// tokenList is a loaded list file and the file version it was read from
+type tokenList struct {
+	Name   string          `json:"name"`
+	Tokens []tokenListItem `json:"tokens"`
+
+	modTime time.Time
+	size    int64
+}
+
+// tokenListItem is one token of a list file, in the token list schema
+type tokenListItem struct {
+	ChainID  uint64 `json:"chainId"`
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals uint8  `json:"decimals"`
+	LogoURI  string `json:"logoURI"`
+}
+
+type symbolKey struct {
+	chainID uint64
+	symbol  string
+}
+
+type addressKey struct {
+	chainID uint64
+	address string
+}
+
+// listedTokenRef is a token and the priority of its list, 0 being the highest
+type listedTokenRef struct {
+	token    models.ListedToken
+	priority int
+}
+
+// NewTokenRegistry loads the token lists at paths, highest priority first. Any list
+// failing to load is an error, so that a typo in TOKEN_LISTS is noticed at startup.
+func NewTokenRegistry(paths []string) (*TokenRegistry, error) {
+	registry := &TokenRegistry{paths: paths, lists: make([]*tokenList, len(paths))}
+	for i, path := range paths {
+		list, err := loadTokenList(path)
+		if err != nil {
+			return nil, err
+		}
+		registry.lists[i] = list
+	}
+	registry.index()
+	return registry, nil
+}
+
+// loadTokenList reads and checks one list file
+func loadTokenList(path string) (*tokenList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("token list %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("token list %s: %w", path, err)
+	}
+
+	list := &tokenList{modTime: info.ModTime(), size: info.Size()}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("token list %s: %w", path, err)
+	}
+	for i, token := range list.Tokens {
+		if !utils.IsValidEthereumAddress(token.Address) || token.ChainID == 0 || token.Symbol == "" {
+			return nil, fmt.Errorf("token list %s: token %d needs a chainId, an address and a symbol", path, i)
+		}
+	}
+	if list.Name == "" {
+		list.Name = path
+	}
+	return list, nil
+}
+
+// index rebuilds the lookup maps from the loaded lists
+func (r *TokenRegistry) index() {
+	bySymbol := make(map[symbolKey][]listedTokenRef)
+	byAddress := make(map[addressKey]models.ListedToken)
+
+	for priority, list := range r.lists {
+		for _, item := range list.Tokens {
+			token := models.ListedToken{
+				ChainID:  item.ChainID,
+				Address:  utils.NormalizeAddress(item.Address),
+				Symbol:   item.Symbol,
+				Name:     item.Name,
+				Decimals: item.Decimals,
+				LogoURI:  item.LogoURI,
+				List:     list.Name,
+			}
+
+			key := symbolKey{token.ChainID, strings.ToUpper(token.Symbol)}
+			bySymbol[key] = append(bySymbol[key], listedTokenRef{token: token, priority: priority})
+
+			if _, ok := byAddress[addressKey{token.ChainID, token.Address}]; !ok {
+				byAddress[addressKey{token.ChainID, token.Address}] = token
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.bySymbol = bySymbol
+	r.byAddress = byAddress
+	r.mu.Unlock()
+}
+
+// Resolve returns the lowercase address of a token reference on a chain. Addresses are
+// returned as they are; symbols are matched case-insensitively in the highest priority
+// list having them, and fail with ErrAmbiguousToken if that list has several tokens with
+// the symbol.
+func (r *TokenRegistry) Resolve(chainID uint64, token string) (string, error) {
+	if !utils.IsTokenSymbol(token) {
+		return utils.NormalizeAddress(token), nil
+	}
+
+	r.mu.RLock()
+	refs := r.bySymbol[symbolKey{chainID, strings.ToUpper(token)}]
+	r.mu.RUnlock()
+
+	if len(refs) == 0 {
+		return "", models.ErrUnknownToken.WithDetails(
+			fmt.Sprintf("No token with symbol %s on chain %d in the token lists, use its address", token, chainID))
+	}
+
+	// refs are in priority order; only the first list having the symbol counts
+	var candidates []string
+	for _, ref := range refs {
+		if ref.priority != refs[0].priority {
+			break
+		}
+		if !slices.Contains(candidates, ref.token.Address) {
+			candidates = append(candidates, ref.token.Address)
+		}
+	}
+
+	if len(candidates) > 1 {
+		return "", models.ErrAmbiguousToken.WithDetails(fmt.Sprintf(
+			"%s matches %s on chain %d in %s, use the address of the one you mean",
+			token, strings.Join(candidates, ", "), chainID, refs[0].token.List))
+	}
+	return candidates[0], nil
+}
+
+// Lookup returns the list details of a token address on a chain
+func (r *TokenRegistry) Lookup(chainID uint64, address string) (*models.ListedToken, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.byAddress[addressKey{chainID, utils.NormalizeAddress(address)}]
+	if !ok {
+		return nil, false
+	}
+	return &token, true
+}
+
+// Search returns up to limit tokens of a chain whose symbol starts with the query, whose
+// name contains it, or whose address is the query. Exact symbol matches come first, then
+// tokens by list priority and symbol.
+func (r *TokenRegistry) Search(chainID uint64, query string, limit int) []models.ListedToken {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	r.mu.RLock()
+	var matches []listedTokenRef
+	for key, refs := range r.bySymbol {
+		if key.chainID != chainID {
+			continue
+		}
+		for _, ref := range refs {
+			if strings.HasPrefix(strings.ToLower(ref.token.Symbol), query) ||
+				strings.Contains(strings.ToLower(ref.token.Name), query) || ref.token.Address == query {
+				matches = append(matches, ref)
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		exactI := strings.EqualFold(matches[i].token.Symbol, query)
+		exactJ := strings.EqualFold(matches[j].token.Symbol, query)
+		if exactI != exactJ {
+			return exactI
+		}
+		if matches[i].priority != matches[j].priority {
+			return matches[i].priority < matches[j].priority
+		}
+		if matches[i].token.Symbol != matches[j].token.Symbol {
+			return matches[i].token.Symbol < matches[j].token.Symbol
+		}
+		return matches[i].token.Address < matches[j].token.Address
+	})
+
+	// A token in several lists is listed once, from its highest priority list
+	tokens := make([]models.ListedToken, 0, min(len(matches), limit))
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if len(tokens) == limit {
+			break
+		}
+		if !seen[match.token.Address] {
+			seen[match.token.Address] = true
+			tokens = append(tokens, match.token)
+		}
+	}
+	return tokens
+}
+
+// SearchRequest answers a validated /tokens/search request on a chain
+func (r *TokenRegistry) SearchRequest(chainID uint64, req *models.TokenSearchRequest) *models.TokenSearchResponse {
+	limit := defaultTokenSearchLimit
+	if req.Limit != "" {
+		if value, err := strconv.Atoi(req.Limit); err == nil && value > 0 {
+			limit = min(value, maxTokenSearchLimit)
+		}
+	}
+	return &models.TokenSearchResponse{Tokens: r.Search(chainID, req.Query, limit)}
+}
+
+// Run reloads the lists whose files changed every interval until ctx is done. A list
+// that fails to reload keeps its previous version.
+func (r *TokenRegistry) Run(ctx context.Context, interval time.Duration) {
+	if len(r.paths) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reload()
+		}
+	}
+}
+
+// Reload reloads the lists whose file modification time or size changed and reports
+// whether any list was reloaded. It must not run concurrently with itself.
+func (r *TokenRegistry) Reload() bool {
+	changed := false
+	for i, path := range r.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("Token list %s unavailable, keeping the loaded version: %v", path, err)
+			continue
+		}
+		if info.ModTime().Equal(r.lists[i].modTime) && info.Size() == r.lists[i].size {
+			continue
+		}
+
+		list, err := loadTokenList(path)
+		if err != nil {
+			log.Printf("Token list %s not reloaded, keeping the loaded version: %v", path, err)
+			continue
+		}
+		r.lists[i] = list
+		changed = true
+		log.Printf("Reloaded token list %s (%d tokens)", list.Name, len(list.Tokens))
+	}
+
+	if changed {
+		r.index()
+	}
+	return changed
+}
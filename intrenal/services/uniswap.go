@@ -2,104 +2,454 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"math/big"
+	"strings"
+	"time"
+	"uniswap-est/intrenal/config"
 	"uniswap-est/intrenal/models"
 	"uniswap-est/intrenal/utils"
 )
 
 type UniswapService struct {
 	blockchain *BlockchainService
+	verifier   *Verifier
+	tokens     *TokenRegistry
+	audit      *AuditLog // may be nil
 }
 
-// NewUniswapService creates a new Uniswap service
-func NewUniswapService(blockchain *BlockchainService) *UniswapService {
+// NewUniswapService creates a new Uniswap service. Without a token registry, tokens
+// must be given by address.
+func NewUniswapService(blockchain *BlockchainService, verifier *Verifier, tokens *TokenRegistry) *UniswapService {
 	return &UniswapService{
 		blockchain: blockchain,
+		verifier:   verifier,
+		tokens:     tokens,
 	}
 }
 
+// SetAuditLog records the quotes served to clients in audit: those whose context
+// carries a Requester, and stream updates as they are sent to each subscriber.
+func (us *UniswapService) SetAuditLog(audit *AuditLog) {
+	us.audit = audit
+}
+
 // EstimateSwap performs the complete swap estimation
 // This is the main function that orchestrates everything!
 func (us *UniswapService) EstimateSwap(ctx context.Context, req *models.EstimateRequest) (*models.EstimateResponse, error) {
-	// Step 1: Normalize addresses
-	poolAddr := utils.NormalizeAddress(req.Pool)
-	srcAddr := utils.NormalizeAddress(req.Src)
-	dstAddr := utils.NormalizeAddress(req.Dst)
+	// Step 1: Resolve symbols and normalize addresses; native ETH is quoted as WETH and
+	// an omitted pool is derived from the tokens
+	resolved, err := us.resolveRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	poolAddr := utils.NormalizeAddress(resolved.Pool)
+	srcAddr := utils.NormalizeAddress(resolved.Src)
+	dstAddr := utils.NormalizeAddress(resolved.Dst)
 
 	// Step 2: Parse input amount
 	amountIn, err := utils.ParseBigInt(req.SrcAmount)
 	if err != nil {
-		return nil, models.ErrInvalidAmount
+		return nil, models.ErrInvalidAmount.WithCause(err)
 	}
 
-	// Step 3: Fetch pool reserves from blockchain (CURRENT STATE!)
-	reserves, err := us.blockchain.GetPoolReserves(ctx, poolAddr)
+	// Step 3: Pin the current block (CURRENT STATE!)
+	block, err := us.blockchain.LatestBlock(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Step 4: Get token information
-	srcToken, err := us.blockchain.GetTokenInfo(ctx, srcAddr)
+	// Step 4: Fetch pool reserves and token information at it in one batched read
+	state, err := us.blockchain.GetBatchState(ctx, []string{poolAddr}, []string{srcAddr, dstAddr}, block)
 	if err != nil {
 		return nil, err
 	}
 
-	dstToken, err := us.blockchain.GetTokenInfo(ctx, dstAddr)
+	// A derived pair may not have been created yet
+	if req.Pool == "" && state.NoCode[poolAddr] {
+		return nil, us.derivedPoolMissing(resolved)
+	}
+
+	response, err := us.quote(ctx, resolved, amountIn, state, block)
 	if err != nil {
 		return nil, err
 	}
+	us.describe(response, req, resolved)
+	us.record(ctx, 0, us.auditRecord(req, resolved, state, block, response))
+	return response, nil
+}
 
-	// Step 5: Determine token order and reserves
-	calculation, err := us.setupCalculation(amountIn, reserves, srcToken, dstToken)
+// EstimateBatch quotes several requests against one pinned block. Pools and tokens shared
+// between requests are fetched once, in a single batched read. Results keep request order;
+// a failing request only fails its own item.
+func (us *UniswapService) EstimateBatch(ctx context.Context, reqs []models.EstimateRequest) (*models.BatchEstimateResponse, error) {
+	response, records, err := us.estimateBatch(ctx, reqs)
 	if err != nil {
 		return nil, err
 	}
+	for i, record := range records {
+		us.record(ctx, i, record)
+	}
+	return response, nil
+}
 
-	// Step 6: Apply Uniswap V2 math (THE CORE CALCULATION!)
-	amountOut, err := utils.CalculateAmountOut(
-		calculation.AmountIn,
-		calculation.ReserveIn,
-		calculation.ReserveOut,
-	)
+// estimateBatch is EstimateBatch without recording: it returns the audit record of each
+// quoted item alongside, nil for failed items and when there is no audit log
+func (us *UniswapService) estimateBatch(ctx context.Context, reqs []models.EstimateRequest) (*models.BatchEstimateResponse, []*models.QuoteRecord, error) {
+	block, err := us.blockchain.LatestBlock(ctx)
 	if err != nil {
-		return nil, models.ErrInsufficientLiquidity
+		return nil, nil, err
+	}
+
+	// Symbols and native token sentinels are replaced and omitted pools derived before
+	// the read, so no ERC20 call is made for a sentinel
+	resolved := make([]*models.EstimateRequest, len(reqs))
+	resolveErrs := make([]error, len(reqs))
+	pools := make([]string, 0, len(reqs))
+	tokens := make([]string, 0, len(reqs)*2)
+	for i := range reqs {
+		resolved[i], resolveErrs[i] = us.resolveRequest(&reqs[i])
+		if resolveErrs[i] != nil {
+			continue
+		}
+		pools = append(pools, resolved[i].Pool)
+		tokens = append(tokens, resolved[i].Src, resolved[i].Dst)
+	}
+
+	state, err := us.blockchain.GetBatchState(ctx, pools, tokens, block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Derived pairs may not have been created yet
+	for i := range reqs {
+		if resolveErrs[i] == nil && reqs[i].Pool == "" && state.NoCode[utils.NormalizeAddress(resolved[i].Pool)] {
+			resolveErrs[i] = us.derivedPoolMissing(resolved[i])
+		}
+	}
+
+	response := &models.BatchEstimateResponse{
+		BlockNumber: block.Uint64(),
+		Results:     make([]models.BatchEstimateResult, len(reqs)),
+	}
+	records := make([]*models.QuoteRecord, len(reqs))
+
+	for i := range reqs {
+		result := models.BatchEstimateResult{Index: i}
+
+		amountIn, err := utils.ParseBigInt(reqs[i].SrcAmount)
+		switch {
+		case resolveErrs[i] != nil:
+			err = resolveErrs[i]
+		case err != nil:
+			err = models.ErrInvalidAmount.WithCause(err)
+		default:
+			result.Result, err = us.quote(ctx, resolved[i], amountIn, state, block)
+			if err == nil {
+				us.describe(result.Result, &reqs[i], resolved[i])
+				records[i] = us.auditRecord(&reqs[i], resolved[i], state, block, result.Result)
+			}
+		}
+
+		if err != nil {
+			result.Error = models.AsAPIError(err)
+		}
+
+		response.Results[i] = result
 	}
 
-	return &models.EstimateResponse{
-		DstAmount: amountOut.String(),
-	}, nil
+	return response, records, nil
 }
 
-// setupCalculation determines which reserves to use based on token order
-func (us *UniswapService) setupCalculation(
+// quote computes the swap output for one request from already fetched state and
+// cross-checks it against the router
+func (us *UniswapService) quote(
+	ctx context.Context,
+	req *models.EstimateRequest,
 	amountIn *big.Int,
-	reserves *models.PoolReserves,
-	srcToken, dstToken *models.TokenInfo,
-) (*models.SwapCalculation, error) {
-
-	srcAddr := utils.NormalizeAddress(srcToken.Address)
-	dstAddr := utils.NormalizeAddress(dstToken.Address)
-
-	// Determine if src is token0 or token1 in the pair
-	var reserveIn, reserveOut *big.Int
-
-	if srcAddr == reserves.Token0 && dstAddr == reserves.Token1 {
-		// src = token0, dst = token1
-		reserveIn = reserves.Reserve0
-		reserveOut = reserves.Reserve1
-	} else if srcAddr == reserves.Token1 && dstAddr == reserves.Token0 {
-		// src = token1, dst = token0
-		reserveIn = reserves.Reserve1
-		reserveOut = reserves.Reserve0
-	} else {
-		return nil, models.NewAPIError(400, "Token mismatch", "Provided tokens don't match the pool tokens")
-	}
-
-	return &models.SwapCalculation{
-		AmountIn:   amountIn,
-		ReserveIn:  reserveIn,
-		ReserveOut: reserveOut,
-		TokenIn:    srcToken,
-		TokenOut:   dstToken,
-	}, nil
+	state *BatchState,
+	block *big.Int,
+) (*models.EstimateResponse, error) {
+	response, calculation, poolAmountOut, err := us.simulate(req, amountIn, state)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 8: Cross-check against the router - on request, or sampled in background.
+	// The V2 router only quotes V2 pairs.
+	if us.verifier != nil && calculation.PoolType == models.PoolTypeV2 {
+		// The router knows nothing about transfer taxes, so compare the untaxed amounts
+		routerAmountOut := poolAmountOut
+		if calculation.TokenIn.TransferTaxBps > 0 {
+			routerAmountOut, err = state.Models[utils.NormalizeAddress(req.Pool)].AmountOut(amountIn,
+				utils.NormalizeAddress(req.Src), utils.NormalizeAddress(req.Dst))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		job := VerificationJob{
+			Pool:           utils.NormalizeAddress(req.Pool),
+			Src:            utils.NormalizeAddress(req.Src),
+			Dst:            utils.NormalizeAddress(req.Dst),
+			AmountIn:       amountIn,
+			OffChainAmount: routerAmountOut,
+			Block:          block,
+		}
+
+		if req.Verify {
+			response.Verification = us.verifier.Verify(ctx, job)
+		} else {
+			us.verifier.Sample(job)
+		}
+	}
+
+	return response, nil
+}
+
+// simulate computes the swap output from already fetched state without any RPC call,
+// with the pricing model of the pool. It also returns the calculation and the pool
+// output before the destination tax.
+func (us *UniswapService) simulate(
+	req *models.EstimateRequest,
+	amountIn *big.Int,
+	state *BatchState,
+) (*models.EstimateResponse, *models.SwapCalculation, *big.Int, error) {
+	poolAddr := utils.NormalizeAddress(req.Pool)
+	srcAddr := utils.NormalizeAddress(req.Src)
+	dstAddr := utils.NormalizeAddress(req.Dst)
+
+	// Step 5: Pick the pricing model of the pool
+	model := state.Models[poolAddr]
+	if model == nil {
+		if err := state.PoolErrors[poolAddr]; err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, nil, nil, models.ErrPoolNotFound
+	}
+
+	// A fee-on-transfer source token delivers less than amountIn to the pool
+	srcToken := state.Tokens[srcAddr]
+	dstToken := state.Tokens[dstAddr]
+	calculation := &models.SwapCalculation{
+		PoolType: model.PoolType(),
+		AmountIn: utils.ApplyTransferTax(amountIn, srcToken.TransferTaxBps),
+		TokenIn:  srcToken,
+		TokenOut: dstToken,
+	}
+
+	// Step 6: Apply the pool math (THE CORE CALCULATION!)
+	poolAmountOut, err := model.AmountOut(calculation.AmountIn, srcAddr, dstAddr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Step 7: The recipient receives the pool output minus the destination transfer tax
+	calculation.AmountOut = utils.ApplyTransferTax(poolAmountOut, dstToken.TransferTaxBps)
+
+	response := &models.EstimateResponse{
+		DstAmount:         calculation.AmountOut.String(),
+		PoolType:          calculation.PoolType,
+		FeeOnTransfer:     srcToken.TransferTaxBps > 0 || dstToken.TransferTaxBps > 0,
+		SrcTransferTaxBps: srcToken.TransferTaxBps,
+		DstTransferTaxBps: dstToken.TransferTaxBps,
+		Rebasing:          srcToken.Rebasing || dstToken.Rebasing,
+	}
+
+	return response, calculation, poolAmountOut, nil
+}
+
+// resolveRequest returns the request with token symbols resolved with the token lists,
+// native token sentinels replaced by the wrapped native token of the chain and an
+// omitted pool derived from the tokens. Pools only ever hold the wrapped token, and the
+// sentinel has no ERC20 contract to read metadata from.
+func (us *UniswapService) resolveRequest(req *models.EstimateRequest) (*models.EstimateRequest, error) {
+	if req.Pool != "" && !utils.IsTokenSymbol(req.Src) && !utils.IsTokenSymbol(req.Dst) &&
+		!utils.IsNativeToken(req.Src) && !utils.IsNativeToken(req.Dst) {
+		return req, nil
+	}
+
+	resolved := *req
+	var err error
+	if resolved.Src, err = us.resolveToken(req.Src); err != nil {
+		return nil, err
+	}
+	if resolved.Dst, err = us.resolveToken(req.Dst); err != nil {
+		return nil, err
+	}
+
+	chain := us.blockchain.chain
+	if utils.IsNativeToken(resolved.Src) {
+		resolved.Src = chain.WrappedNative
+	}
+	if utils.IsNativeToken(resolved.Dst) {
+		resolved.Dst = chain.WrappedNative
+	}
+
+	// Wrapping and unwrapping is 1:1 through the wrapped token contract, not a pool swap
+	if strings.EqualFold(resolved.Src, resolved.Dst) {
+		return nil, models.NewValidationError([]models.FieldError{{
+			Field:   "dst",
+			Rule:    "nefield",
+			Message: "must be different from src; the native token is quoted as " + chain.WrappedNative,
+		}})
+	}
+
+	if req.Pool == "" {
+		fork, ok := chain.Fork(req.Dex)
+		if !ok {
+			return nil, models.NewValidationError([]models.FieldError{{
+				Field:   "pool",
+				Rule:    "required",
+				Message: "is required: " + chain.Name + " has no V2 deployment " + req.Dex + " to derive it from, see /chains",
+			}})
+		}
+		resolved.Pool = utils.PairAddress(fork.FactoryAddress, fork.InitCodeHash, resolved.Src, resolved.Dst)
+	}
+	return &resolved, nil
+}
+
+// resolveToken returns the address of a token symbol, or the address given
+func (us *UniswapService) resolveToken(token string) (string, error) {
+	if !utils.IsTokenSymbol(token) {
+		return token, nil
+	}
+	if us.tokens == nil {
+		return "", models.ErrUnknownToken.WithDetails("No token lists are configured, use the address of " + token)
+	}
+	return us.tokens.Resolve(us.blockchain.chain.ChainID, token)
+}
+
+// derivedPoolMissing is the error of a pair derived from the tokens that has not been
+// created: the CREATE2 address is known before the factory deploys anything there, so
+// the batched read finds no contract at it
+func (us *UniswapService) derivedPoolMissing(resolved *models.EstimateRequest) error {
+	fork, _ := us.blockchain.chain.Fork(resolved.Dex)
+	return models.ErrPoolNotFound.WithDetails(fmt.Sprintf(
+		"The %s pair of %s and %s has not been created (derived address %s)",
+		fork.Name, utils.NormalizeAddress(resolved.Src), utils.NormalizeAddress(resolved.Dst), resolved.Pool))
+}
+
+// describe completes a response: the derived pool, the token list details of the
+// quoted tokens, flags for native token sentinels so that the swap is built with the
+// router's ETH variants, and a warning when a given V2 pool is not the canonical pair
+// of the tokens
+func (us *UniswapService) describe(response *models.EstimateResponse, req, resolved *models.EstimateRequest) {
+	chain := us.blockchain.chain
+
+	if req.Pool == "" {
+		response.Pool = resolved.Pool
+	} else if response.PoolType == models.PoolTypeV2 {
+		if warning := canonicalPairWarning(chain, resolved); warning != "" {
+			response.Warnings = append(response.Warnings, warning)
+		}
+	}
+
+	response.SrcNative = utils.IsNativeToken(req.Src)
+	response.DstNative = utils.IsNativeToken(req.Dst)
+	if response.SrcNative || response.DstNative {
+		response.WrappedNative = chain.WrappedNative
+	}
+
+	if us.tokens != nil {
+		response.SrcToken, _ = us.tokens.Lookup(chain.ChainID, resolved.Src)
+		response.DstToken, _ = us.tokens.Lookup(chain.ChainID, resolved.Dst)
+	}
+}
+
+// auditRecord describes a quote for the audit log: the request as received and
+// resolved, the block, the pool state and fee it was priced with, and the output. The
+// requester, item and time are filled in when it is recorded. It returns nil when
+// there is no audit log.
+func (us *UniswapService) auditRecord(
+	req, resolved *models.EstimateRequest,
+	state *BatchState,
+	block *big.Int,
+	response *models.EstimateResponse,
+) *models.QuoteRecord {
+	if us.audit == nil {
+		return nil
+	}
+
+	model := state.Models[utils.NormalizeAddress(resolved.Pool)]
+	reserves := make([]string, 0, 2)
+	for _, reserve := range model.Reserves() {
+		reserves = append(reserves, reserve.String())
+	}
+
+	chain := us.blockchain.chain
+	var dex string
+	if req.Pool == "" {
+		fork, _ := chain.Fork(req.Dex)
+		dex = fork.Name
+	}
+
+	return &models.QuoteRecord{
+		Chain:        chain.Name,
+		Pool:         utils.NormalizeAddress(resolved.Pool),
+		Src:          utils.NormalizeAddress(resolved.Src),
+		Dst:          utils.NormalizeAddress(resolved.Dst),
+		RequestedSrc: req.Src,
+		RequestedDst: req.Dst,
+		SrcAmount:    req.SrcAmount,
+		Dex:          dex,
+		BlockNumber:  block.Uint64(),
+		PoolType:     model.PoolType(),
+		FeeModel:     model.Fee(),
+		Reserves:     reserves,
+		DstAmount:    response.DstAmount,
+	}
+}
+
+// record files a quote served for the requester of ctx with the audit log. item is
+// the position of the request in a batch.
+func (us *UniswapService) record(ctx context.Context, item int, record *models.QuoteRecord) {
+	requester, ok := requesterOf(ctx)
+	if !ok {
+		return
+	}
+	us.recordFor(requester, requester.item(item), record)
+}
+
+// recordFor files a quote served to requester with the audit log as its item-th
+func (us *UniswapService) recordFor(requester Requester, item int, record *models.QuoteRecord) {
+	if record == nil {
+		return
+	}
+
+	served := *record
+	served.RequestID = requester.RequestID
+	served.ClientRequestID = requester.ClientRequestID
+	served.Item = item
+	served.Time = time.Now().UTC()
+	served.Requester = requester.Address
+	served.UserAgent = requester.UserAgent
+	served.Transport = requester.Transport
+	us.audit.Record(served)
+}
+
+// canonicalPairWarning returns a warning when pool is not the pair of src and dst of
+// any known V2 deployment of the chain, e.g. a pair deployed by an unknown factory
+func canonicalPairWarning(chain *config.ChainConfig, req *models.EstimateRequest) string {
+	forks := chain.AllForks()
+	if len(forks) == 0 {
+		return ""
+	}
+
+	pool := utils.NormalizeAddress(req.Pool)
+	for _, fork := range forks {
+		if utils.PairAddress(fork.FactoryAddress, fork.InitCodeHash, req.Src, req.Dst) == pool {
+			return ""
+		}
+	}
+	return fmt.Sprintf("pool %s is not the canonical %s pair of src and dst, which is %s", pool, forks[0].Name,
+		utils.PairAddress(forks[0].FactoryAddress, forks[0].InitCodeHash, req.Src, req.Dst))
+}
+
+// VerificationStats returns the router cross-check counters
+func (us *UniswapService) VerificationStats() models.VerificationStats {
+	if us.verifier == nil {
+		return models.VerificationStats{}
+	}
+	return us.verifier.Stats()
 }
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+)
+
+// verifySampleQueueSize bounds the number of sampled quotes waiting for a background check
+const verifySampleQueueSize = 256
+
+// VerificationJob describes one off-chain quote that should be checked against the router
+type VerificationJob struct {
+	Pool           string
+	Src            string
+	Dst            string
+	AmountIn       *big.Int
+	OffChainAmount *big.Int
+	Block          *big.Int
+}
+
+// Verifier cross-checks off-chain quotes with UniswapV2Router02.getAmountsOut
+// at the same pinned block. Mismatches are logged and counted.
+// The router prices the pair its factory deploys for the path, so only quotes on that
+// pair can be compared; the others are counted as not verifiable.
+type Verifier struct {
+	blockchain *BlockchainService
+	sampleRate float64
+	samples    chan VerificationJob
+
+	checks     atomic.Uint64
+	mismatches atomic.Uint64
+	failures   atomic.Uint64
+	skipped    atomic.Uint64
+	dropped    atomic.Uint64
+}
+
+// NewVerifier creates a new verifier; sampleRate is the fraction of quotes checked in background
+func NewVerifier(blockchain *BlockchainService, sampleRate float64) *Verifier {
+	return &Verifier{
+		blockchain: blockchain,
+		sampleRate: sampleRate,
+		samples:    make(chan VerificationJob, verifySampleQueueSize),
+	}
+}
+
+// Verify calls the router for the same path and block and compares it with the off-chain
+// amount. A router call that reverts or times out does not fail the quote: it is counted
+// and reported with the failed status.
+func (v *Verifier) Verify(ctx context.Context, job VerificationJob) *models.VerificationResult {
+	result := &models.VerificationResult{
+		BlockNumber:    job.Block.Uint64(),
+		OffChainAmount: job.OffChainAmount.String(),
+	}
+
+	if reason := v.unverifiable(job); reason != "" {
+		v.skipped.Add(1)
+		result.Status = models.VerificationNotVerifiable
+		result.Reason = reason
+		return result
+	}
+
+	amounts, err := v.blockchain.GetAmountsOut(ctx, job.AmountIn, []string{job.Src, job.Dst}, job.Block)
+	if err == nil && len(amounts) != 2 {
+		err = fmt.Errorf("router returned %d amounts for a 2 token path", len(amounts))
+	}
+	if err != nil {
+		v.failures.Add(1)
+		log.Printf("Verification failed for %s -> %s at block %s: %v", job.Src, job.Dst, job.Block, err)
+		result.Status = models.VerificationFailed
+		result.Reason = "The router call failed: " + err.Error()
+		return result
+	}
+
+	v.checks.Add(1)
+	onChain := amounts[1]
+	result.OnChainAmount = onChain.String()
+	result.Match = onChain.Cmp(job.OffChainAmount) == 0
+	result.Status = models.VerificationMatch
+	if !result.Match {
+		v.mismatches.Add(1)
+		result.Status = models.VerificationMismatch
+		log.Printf("Verification MISMATCH for %s -> %s at block %s: amountIn=%s offchain=%s onchain=%s",
+			job.Src, job.Dst, job.Block, job.AmountIn, job.OffChainAmount, onChain)
+	}
+
+	return result
+}
+
+// unverifiable returns why the router cannot price the pool of job, or "" when the pool
+// is the pair the router's factory deploys for the tokens
+func (v *Verifier) unverifiable(job VerificationJob) string {
+	chain := v.blockchain.chain
+	if chain.InitCodeHash == "" {
+		return fmt.Sprintf("The pairs of the %s router cannot be derived, see %s_INIT_CODE_HASH",
+			chain.Dex, strings.ToUpper(chain.Name))
+	}
+	if pair := utils.PairAddress(chain.FactoryAddress, chain.InitCodeHash, job.Src, job.Dst); pair != job.Pool {
+		return fmt.Sprintf("The router prices the %s pair %s, not pool %s", chain.Dex, pair, job.Pool)
+	}
+	return ""
+}
+
+// Sample queues the job for background verification according to the sample rate.
+// It never blocks: when the queue is full the job is dropped and counted.
+func (v *Verifier) Sample(job VerificationJob) {
+	if v.sampleRate <= 0 || rand.Float64() >= v.sampleRate {
+		return
+	}
+
+	select {
+	case v.samples <- job:
+	default:
+		v.dropped.Add(1)
+	}
+}
+
+// Run processes sampled jobs until ctx is cancelled
+func (v *Verifier) Run(ctx context.Context, timeout time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-v.samples:
+			jobCtx, cancel := context.WithTimeout(ctx, timeout)
+			v.Verify(jobCtx, job)
+			cancel()
+		}
+	}
+}
+
+// Stats returns a snapshot of the verification counters
+func (v *Verifier) Stats() models.VerificationStats {
+	return models.VerificationStats{
+		Checks:        v.checks.Load(),
+		Mismatches:    v.mismatches.Load(),
+		Failures:      v.failures.Load(),
+		NotVerifiable: v.skipped.Load(),
+		Dropped:       v.dropped.Load(),
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"math/big"
+)
+
+// Arbitrage math for cycles of Uniswap V2 pools. A chain of swaps through several pools
+// behaves like a single pool with virtual reserves (E0, E1):
+//
+//	amountOut = 997 * E1 * amountIn / (1000 * E0 + 997 * amountIn)
+//
+// which makes the profit-maximizing input a closed-form square root. Forks charging
+// another fee replace 997/1000 with their own fee factor.
+
+// Hop is one swap of a cycle, described by the reserves of the pool it trades against
+type Hop struct {
+	ReserveIn  *big.Int
+	ReserveOut *big.Int
+	FeeBps     uint32 // swap fee of the pool; zero means the Uniswap V2 0.3%
+}
+
+// fee returns the swap fee of the hop in basis points
+func (h Hop) fee() uint32 {
+	if h.FeeBps == 0 {
+		return 30
+	}
+	return h.FeeBps
+}
+
+// feeFactor returns the share of the input left after the fee: 997/1000 at 0.3%
+func (h Hop) feeFactor() *big.Rat {
+	return big.NewRat(int64(10000-h.fee()), 10000)
+}
+
+// VirtualReserves folds the hops of a path into the reserves of one equivalent pool.
+// Appending a pool (a, b) to virtual reserves (E0, E1), with the fee factor g of the pool:
+//
+//	E0' = E0 * a / (a + g * E1)
+//	E1' = g * E1 * b / (a + g * E1)
+func VirtualReserves(hops []Hop) (*big.Rat, *big.Rat) {
+	e0 := new(big.Rat).SetInt(hops[0].ReserveIn)
+	e1 := new(big.Rat).SetInt(hops[0].ReserveOut)
+
+	for _, hop := range hops[1:] {
+		a := new(big.Rat).SetInt(hop.ReserveIn)
+		b := new(big.Rat).SetInt(hop.ReserveOut)
+
+		scaledOut := new(big.Rat).Mul(hop.feeFactor(), e1)
+		denominator := new(big.Rat).Add(a, scaledOut)
+
+		e0.Mul(e0, a).Quo(e0, denominator)
+		e1 = scaledOut.Mul(scaledOut, b).Quo(scaledOut, denominator)
+	}
+
+	return e0, e1
+}
+
+// OptimalArbitrageInput returns the input that maximizes amountOut - amountIn along a
+// cycle, or zero when the cycle is not profitable. For two pools this is the well-known
+// closed form; longer cycles use their virtual reserves.
+// Formula: amountIn = (sqrt(g * E0 * E1) - E0) / g, profitable when g * E1 > E0, with
+// the fee factor g of the first pool
+func OptimalArbitrageInput(hops []Hop) *big.Int {
+	for _, hop := range hops {
+		if hop.ReserveIn.Sign() <= 0 || hop.ReserveOut.Sign() <= 0 {
+			return new(big.Int)
+		}
+	}
+
+	e0, e1 := VirtualReserves(hops)
+	feeFactor := hops[0].feeFactor()
+	if new(big.Rat).Mul(feeFactor, e1).Cmp(e0) <= 0 {
+		return new(big.Int)
+	}
+
+	// sqrt(n/d) = sqrt(n*d)/d
+	product := new(big.Rat).Mul(feeFactor, e0)
+	product.Mul(product, e1)
+	root := new(big.Int).Mul(product.Num(), product.Denom())
+	root.Sqrt(root)
+
+	amountIn := new(big.Rat).SetFrac(root, product.Denom())
+	amountIn.Sub(amountIn, e0).Quo(amountIn, feeFactor)
+	if amountIn.Sign() <= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Quo(amountIn.Num(), amountIn.Denom())
+}
+
+// CycleAmountOut swaps amountIn through every hop with the exact integer pool math
+func CycleAmountOut(amountIn *big.Int, hops []Hop) (*big.Int, error) {
+	amount := amountIn
+	for _, hop := range hops {
+		out, err := CalculateAmountOutWithFee(amount, hop.ReserveIn, hop.ReserveOut, hop.fee())
+		if err != nil {
+			return nil, err
+		}
+		amount = out
+	}
+	return amount, nil
+}
@@ -0,0 +1,373 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Balancer V2 weighted pool math: FixedPoint, LogExpMath and WeightedMath. All values
+// are 18-decimal fixed point. Solidity's signed division truncates towards zero, so the
+// LogExpMath port uses Quo and Rem, never Div and Mod.
+
+var (
+	one18 = big.NewInt(1e18)
+	one20 = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))
+	one36 = new(big.Int).Mul(one18, one18)
+
+	maxNaturalExponent = new(big.Int).Mul(big.NewInt(130), one18)
+	minNaturalExponent = new(big.Int).Mul(big.NewInt(-41), one18)
+	ln36LowerBound     = new(big.Int).Sub(one18, big.NewInt(1e17))
+	ln36UpperBound     = new(big.Int).Add(one18, big.NewInt(1e17))
+	mildExponentBound  = new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 254), one20)
+
+	// x0 and x1 are 18-decimal, a0 and a1 have no decimals
+	logX0 = decInt("128000000000000000000")
+	logA0 = decInt("38877084059945950922200000000000000000000000000000000000")
+	logX1 = decInt("64000000000000000000")
+	logA1 = decInt("6235149080811616882910000000")
+
+	// x2 to x11 are 2^5 to 2^-4 and a2 to a11 their exponentials, all 20-decimal
+	logX = []*big.Int{
+		decInt("3200000000000000000000"),
+		decInt("1600000000000000000000"),
+		decInt("800000000000000000000"),
+		decInt("400000000000000000000"),
+		decInt("200000000000000000000"),
+		decInt("100000000000000000000"),
+		decInt("50000000000000000000"),
+		decInt("25000000000000000000"),
+		decInt("12500000000000000000"),
+		decInt("6250000000000000000"),
+	}
+	logA = []*big.Int{
+		decInt("7896296018268069516100000000000000"),
+		decInt("888611052050787263676000000"),
+		decInt("298095798704172827474000"),
+		decInt("5459815003314423907810"),
+		decInt("738905609893065022723"),
+		decInt("271828182845904523536"),
+		decInt("164872127070012814685"),
+		decInt("128402541668774148407"),
+		decInt("113314845306682631683"),
+		decInt("106449445891785942956"),
+	}
+
+	// maxPowRelativeError bounds the error of LogExpMath.pow, 1e-14
+	maxPowRelativeError = big.NewInt(10000)
+
+	// WeightedMath trade size limits: 30% of the balance in or out
+	maxInRatio  = big.NewInt(3e17)
+	maxOutRatio = big.NewInt(3e17)
+
+	// ErrMaxInRatio and ErrMaxOutRatio are the BAL#304 and BAL#305 reverts
+	ErrMaxInRatio  = errors.New("amount in exceeds 30% of the pool balance")
+	ErrMaxOutRatio = errors.New("amount out exceeds 30% of the pool balance")
+)
+
+// decInt parses a decimal constant, panicking on programmer error
+func decInt(value string) *big.Int {
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		panic("utils: bad decimal constant " + value)
+	}
+	return parsed
+}
+
+// FixedPoint
+
+func mulDown(a, b *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	return product.Div(product, one18)
+}
+
+func mulUp(a, b *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	if product.Sign() == 0 {
+		return product
+	}
+	product.Sub(product, bigOne).Div(product, one18)
+	return product.Add(product, bigOne)
+}
+
+func divDown(a, b *big.Int) *big.Int {
+	numerator := new(big.Int).Mul(a, one18)
+	return numerator.Div(numerator, b)
+}
+
+func divUp(a, b *big.Int) *big.Int {
+	if a.Sign() == 0 {
+		return new(big.Int)
+	}
+	numerator := new(big.Int).Mul(a, one18)
+	numerator.Sub(numerator, bigOne).Div(numerator, b)
+	return numerator.Add(numerator, bigOne)
+}
+
+// complement returns 1 - x, floored at 0
+func complement(x *big.Int) *big.Int {
+	if x.Cmp(one18) >= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(one18, x)
+}
+
+// powUp returns x^y rounded up, like FixedPoint.powUp
+func powUp(x, y *big.Int) (*big.Int, error) {
+	switch {
+	case y.Cmp(one18) == 0:
+		return new(big.Int).Set(x), nil
+	case y.Cmp(big.NewInt(2e18)) == 0:
+		return mulUp(x, x), nil
+	case y.Cmp(big.NewInt(4e18)) == 0:
+		square := mulUp(x, x)
+		return mulUp(square, square), nil
+	}
+
+	raw, err := LogExpPow(x, y)
+	if err != nil {
+		return nil, err
+	}
+	maxError := mulUp(raw, maxPowRelativeError)
+	maxError.Add(maxError, bigOne)
+	return raw.Add(raw, maxError), nil
+}
+
+// LogExpMath
+
+// LogExpPow returns x^y for 18-decimal x and y, like LogExpMath.pow
+func LogExpPow(x, y *big.Int) (*big.Int, error) {
+	if y.Sign() == 0 {
+		return new(big.Int).Set(one18), nil
+	}
+	if x.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	if x.Sign() < 0 || x.BitLen() > 255 {
+		return nil, errors.New("pow: x out of bounds")
+	}
+	if y.Sign() < 0 || y.Cmp(mildExponentBound) >= 0 {
+		return nil, errors.New("pow: y out of bounds")
+	}
+
+	var logXTimesY *big.Int
+	if ln36LowerBound.Cmp(x) < 0 && x.Cmp(ln36UpperBound) < 0 {
+		ln36X := ln36(x)
+		// (ln36X / 1e18) * y + ((ln36X % 1e18) * y) / 1e18
+		logXTimesY = new(big.Int).Quo(ln36X, one18)
+		logXTimesY.Mul(logXTimesY, y)
+		fraction := new(big.Int).Rem(ln36X, one18)
+		fraction.Mul(fraction, y).Quo(fraction, one18)
+		logXTimesY.Add(logXTimesY, fraction)
+	} else {
+		logXTimesY = ln(x)
+		logXTimesY.Mul(logXTimesY, y)
+	}
+	logXTimesY.Quo(logXTimesY, one18)
+
+	if logXTimesY.Cmp(minNaturalExponent) < 0 || logXTimesY.Cmp(maxNaturalExponent) > 0 {
+		return nil, errors.New("pow: product out of bounds")
+	}
+	return logExp(logXTimesY)
+}
+
+// logExp returns e^x for 18-decimal x, like LogExpMath.exp
+func logExp(x *big.Int) (*big.Int, error) {
+	if x.Cmp(minNaturalExponent) < 0 || x.Cmp(maxNaturalExponent) > 0 {
+		return nil, errors.New("exp: invalid exponent")
+	}
+
+	if x.Sign() < 0 {
+		// e^x = 1 / e^-x
+		inverse, err := logExp(new(big.Int).Neg(x))
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Quo(one36, inverse), nil
+	}
+
+	x = new(big.Int).Set(x)
+	firstAN := bigOne
+	if x.Cmp(logX0) >= 0 {
+		x.Sub(x, logX0)
+		firstAN = logA0
+	} else if x.Cmp(logX1) >= 0 {
+		x.Sub(x, logX1)
+		firstAN = logA1
+	}
+
+	// The rest runs with 20 decimals, using x2 to x9
+	x.Mul(x, big.NewInt(100))
+	product := new(big.Int).Set(one20)
+	for i := 0; i < 8; i++ {
+		if x.Cmp(logX[i]) >= 0 {
+			x.Sub(x, logX[i])
+			product.Mul(product, logA[i]).Quo(product, one20)
+		}
+	}
+
+	// Taylor series up to the 12th term
+	seriesSum := new(big.Int).Set(one20)
+	term := new(big.Int).Set(x)
+	seriesSum.Add(seriesSum, term)
+	for n := int64(2); n <= 12; n++ {
+		term.Mul(term, x).Quo(term, one20).Quo(term, big.NewInt(n))
+		seriesSum.Add(seriesSum, term)
+	}
+
+	result := product.Mul(product, seriesSum)
+	result.Quo(result, one20).Mul(result, firstAN)
+	return result.Quo(result, big.NewInt(100)), nil
+}
+
+// ln returns the natural logarithm of an 18-decimal a, like LogExpMath._ln
+func ln(a *big.Int) *big.Int {
+	if a.Cmp(one18) < 0 {
+		// ln(a) = -ln(1 / a)
+		result := ln(new(big.Int).Quo(one36, a))
+		return result.Neg(result)
+	}
+
+	a = new(big.Int).Set(a)
+	sum := new(big.Int)
+	if a.Cmp(new(big.Int).Mul(logA0, one18)) >= 0 {
+		a.Quo(a, logA0)
+		sum.Add(sum, logX0)
+	}
+	if a.Cmp(new(big.Int).Mul(logA1, one18)) >= 0 {
+		a.Quo(a, logA1)
+		sum.Add(sum, logX1)
+	}
+
+	// The rest runs with 20 decimals, using x2 to x11
+	sum.Mul(sum, big.NewInt(100))
+	a.Mul(a, big.NewInt(100))
+	for i := range logA {
+		if a.Cmp(logA[i]) >= 0 {
+			a.Mul(a, one20).Quo(a, logA[i])
+			sum.Add(sum, logX[i])
+		}
+	}
+
+	// ln(a) = 2 * atanh(z) with z = (a - 1) / (a + 1), series up to z^11
+	z := new(big.Int).Sub(a, one20)
+	z.Mul(z, one20).Quo(z, new(big.Int).Add(a, one20))
+	zSquared := new(big.Int).Mul(z, z)
+	zSquared.Quo(zSquared, one20)
+
+	num := new(big.Int).Set(z)
+	seriesSum := new(big.Int).Set(num)
+	for n := int64(3); n <= 11; n += 2 {
+		num.Mul(num, zSquared).Quo(num, one20)
+		seriesSum.Add(seriesSum, new(big.Int).Quo(num, big.NewInt(n)))
+	}
+	seriesSum.Mul(seriesSum, bigTwo)
+
+	result := sum.Add(sum, seriesSum)
+	return result.Quo(result, big.NewInt(100))
+}
+
+// ln36 returns the natural logarithm of an 18-decimal x close to 1 with 36 decimals,
+// like LogExpMath._ln_36
+func ln36(x *big.Int) *big.Int {
+	x = new(big.Int).Mul(x, one18)
+
+	z := new(big.Int).Sub(x, one36)
+	z.Mul(z, one36).Quo(z, new(big.Int).Add(x, one36))
+	zSquared := new(big.Int).Mul(z, z)
+	zSquared.Quo(zSquared, one36)
+
+	num := new(big.Int).Set(z)
+	seriesSum := new(big.Int).Set(num)
+	for n := int64(3); n <= 15; n += 2 {
+		num.Mul(num, zSquared).Quo(num, one36)
+		seriesSum.Add(seriesSum, new(big.Int).Quo(num, big.NewInt(n)))
+	}
+	return seriesSum.Mul(seriesSum, bigTwo)
+}
+
+// WeightedMath
+
+// WeightedOutGivenIn returns the output of an exact input swap between two tokens of a
+// weighted pool, like WeightedMath._calcOutGivenIn. Amounts and balances are upscaled to
+// 18 decimals and amountIn has the swap fee already taken out.
+// Formula: balanceOut * (1 - (balanceIn / (balanceIn + amountIn))^(weightIn / weightOut))
+func WeightedOutGivenIn(balanceIn, weightIn, balanceOut, weightOut, amountIn *big.Int) (*big.Int, error) {
+	if amountIn.Cmp(mulDown(balanceIn, maxInRatio)) > 0 {
+		return nil, ErrMaxInRatio
+	}
+
+	base := divUp(balanceIn, new(big.Int).Add(balanceIn, amountIn))
+	exponent := divDown(weightIn, weightOut)
+	power, err := powUp(base, exponent)
+	if err != nil {
+		return nil, err
+	}
+	return mulDown(balanceOut, complement(power)), nil
+}
+
+// WeightedInGivenOut returns the input of an exact output swap, like
+// WeightedMath._calcInGivenOut, before the swap fee is added
+// Formula: balanceIn * ((balanceOut / (balanceOut - amountOut))^(weightOut / weightIn) - 1)
+func WeightedInGivenOut(balanceIn, weightIn, balanceOut, weightOut, amountOut *big.Int) (*big.Int, error) {
+	if amountOut.Cmp(mulDown(balanceOut, maxOutRatio)) > 0 {
+		return nil, ErrMaxOutRatio
+	}
+
+	base := divUp(balanceOut, new(big.Int).Sub(balanceOut, amountOut))
+	exponent := divUp(weightOut, weightIn)
+	power, err := powUp(base, exponent)
+	if err != nil {
+		return nil, err
+	}
+	if power.Cmp(one18) < 0 {
+		return nil, errors.New("pow below one")
+	}
+	return mulUp(balanceIn, power.Sub(power, one18)), nil
+}
+
+// BalancerSwapGivenIn returns the output of an exact input swap through a weighted pool
+// in token units, like BaseMinimalSwapInfoPool.onSwap: the fee is taken from the input,
+// then amounts are scaled to 18 decimals and the output scaled back down.
+// scalingIn and scalingOut are 10^(18 - decimals); swapFee is 18-decimal.
+func BalancerSwapGivenIn(amountIn, balanceIn, weightIn, scalingIn, balanceOut, weightOut, scalingOut, swapFee *big.Int) (*big.Int, error) {
+	if amountIn.Sign() <= 0 {
+		return nil, errors.New("amount in must be positive")
+	}
+	if balanceIn.Sign() <= 0 || balanceOut.Sign() <= 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+
+	amountIn = new(big.Int).Sub(amountIn, mulUp(amountIn, swapFee))
+	amountOut, err := WeightedOutGivenIn(
+		new(big.Int).Mul(balanceIn, scalingIn), weightIn,
+		new(big.Int).Mul(balanceOut, scalingOut), weightOut,
+		amountIn.Mul(amountIn, scalingIn),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return amountOut.Div(amountOut, scalingOut), nil
+}
+
+// BalancerSwapGivenOut returns the input of an exact output swap through a weighted
+// pool in token units, like BaseMinimalSwapInfoPool.onSwap: the input is rounded up and
+// the fee added on top
+func BalancerSwapGivenOut(amountOut, balanceIn, weightIn, scalingIn, balanceOut, weightOut, scalingOut, swapFee *big.Int) (*big.Int, error) {
+	if amountOut.Sign() <= 0 {
+		return nil, errors.New("amount out must be positive")
+	}
+	if balanceIn.Sign() <= 0 || balanceOut.Sign() <= 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+
+	amountIn, err := WeightedInGivenOut(
+		new(big.Int).Mul(balanceIn, scalingIn), weightIn,
+		new(big.Int).Mul(balanceOut, scalingOut), weightOut,
+		new(big.Int).Mul(amountOut, scalingOut),
+	)
+	if err != nil {
+		return nil, err
+	}
+	amountIn = divRoundingUp(amountIn, scalingIn)
+	return divUp(amountIn, complement(swapFee)), nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SortTokens returns two token addresses in the order a Uniswap V2 pair stores them,
+// lowest address first, lowercase
+func SortTokens(tokenA, tokenB string) (token0, token1 string) {
+	tokenA, tokenB = NormalizeAddress(tokenA), NormalizeAddress(tokenB)
+	if strings.Compare(tokenA, tokenB) > 0 {
+		return tokenB, tokenA
+	}
+	return tokenA, tokenB
+}
+
+// PairAddress computes the address of the Uniswap V2 pair of two tokens without any
+// RPC call. UniswapV2Factory.createPair deploys pairs with CREATE2 and the sorted
+// tokens as salt, so the address is
+//
+//	keccak256(0xff ++ factory ++ keccak256(token0 ++ token1) ++ initCodeHash)[12:]
+//
+// where initCodeHash is the keccak256 of the pair creation code of the fork.
+func PairAddress(factory, initCodeHash, tokenA, tokenB string) string {
+	token0, token1 := SortTokens(tokenA, tokenB)
+
+	salt := crypto.Keccak256(common.HexToAddress(token0).Bytes(), common.HexToAddress(token1).Bytes())
+	hash := crypto.Keccak256(
+		[]byte{0xff},
+		common.HexToAddress(factory).Bytes(),
+		salt,
+		common.HexToHash(initCodeHash).Bytes(),
+	)
+	return NormalizeAddress(common.BytesToAddress(hash[12:]).Hex())
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+)
+
+// UQ112x112 fixed point numbers as used by Uniswap V2 price accumulators: an unsigned
+// number with 112 integer bits and 112 fractional bits, i.e. the value times 2^112.
+
+var (
+	// Q112 is 2^112, the UQ112x112 representation of 1
+	Q112 = new(big.Int).Lsh(big.NewInt(1), 112)
+
+	uint256Modulus = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// EncodeUQ112x112 converts an integer into UQ112x112
+func EncodeUQ112x112(y *big.Int) *big.Int {
+	return new(big.Int).Mul(y, Q112)
+}
+
+// FractionUQ112x112 returns numerator/denominator as UQ112x112, like FixedPoint.fraction
+// and UQ112x112.uqdiv in the pair contract
+func FractionUQ112x112(numerator, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return new(big.Int).Div(EncodeUQ112x112(numerator), denominator), nil
+}
+
+// MulDecodeUQ112x112 multiplies a UQ112x112 price by an integer amount and truncates the
+// result to an integer, like FixedPoint.mul followed by decode144
+func MulDecodeUQ112x112(price, amount *big.Int) *big.Int {
+	product := new(big.Int).Mul(price, amount)
+	return product.Rsh(product, 112)
+}
+
+// UQ112x112ToDecimal renders a UQ112x112 price of raw token units as whole tokens
+// (out per in) with 18 decimals
+func UQ112x112ToDecimal(price *big.Int, decimalsIn, decimalsOut uint8) string {
+	value := new(big.Rat).SetFrac(
+		ConvertToTokenUnits(price, decimalsIn),
+		ConvertToTokenUnits(Q112, decimalsOut),
+	)
+	return value.FloatString(spotPriceDecimals)
+}
+
+// SubUint256 returns a-b modulo 2^256. Price accumulators are meant to overflow, and
+// the difference of two readings is still correct with wrapping subtraction.
+func SubUint256(a, b *big.Int) *big.Int {
+	diff := new(big.Int).Sub(a, b)
+	return diff.Mod(diff, uint256Modulus)
+}
+
+// CounterfactualCumulativePrices returns the price accumulators as they would read at
+// timestamp if the pair were synced then, like UniswapV2OracleLibrary.currentCumulativePrices.
+// The pair stores timestamps modulo 2^32, so the elapsed time is computed with uint32
+// wrap-around.
+func CounterfactualCumulativePrices(
+	price0Cumulative, price1Cumulative, reserve0, reserve1 *big.Int,
+	blockTimestampLast uint32,
+	timestamp uint64,
+) (*big.Int, *big.Int) {
+	price0 := new(big.Int).Set(price0Cumulative)
+	price1 := new(big.Int).Set(price1Cumulative)
+
+	elapsed := uint32(timestamp) - blockTimestampLast // overflow is desired
+	if elapsed == 0 || reserve0.Sign() == 0 || reserve1.Sign() == 0 {
+		return price0, price1
+	}
+
+	// Addition overflow is desired too
+	fraction0, _ := FractionUQ112x112(reserve1, reserve0)
+	fraction1, _ := FractionUQ112x112(reserve0, reserve1)
+	price0.Add(price0, fraction0.Mul(fraction0, big.NewInt(int64(elapsed)))).Mod(price0, uint256Modulus)
+	price1.Add(price1, fraction1.Mul(fraction1, big.NewInt(int64(elapsed)))).Mod(price1, uint256Modulus)
+
+	return price0, price1
+}
+
+// TimeWeightedAveragePrice returns the average UQ112x112 price between two readings of
+// a price accumulator taken elapsed seconds apart
+func TimeWeightedAveragePrice(cumulativeStart, cumulativeEnd *big.Int, elapsed uint64) (*big.Int, error) {
+	if elapsed == 0 {
+		return nil, errors.New("no time elapsed between observations")
+	}
+	average := SubUint256(cumulativeEnd, cumulativeStart)
+	return average.Div(average, new(big.Int).SetUint64(elapsed)), nil
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Uniswap V2 mint and burn math, following UniswapV2Pair and UniswapV2Library
+
+var (
+	// MinimumLiquidity is locked forever by the first mint of every pair
+	MinimumLiquidity = big.NewInt(1000)
+
+	big5 = big.NewInt(5)
+)
+
+// Quote returns the amount of token B worth amountA at the pool ratio, without fees
+// Formula: amountB = amountA * reserveB / reserveA
+func Quote(amountA, reserveA, reserveB *big.Int) (*big.Int, error) {
+	if amountA.Cmp(bigZero) <= 0 {
+		return nil, errors.New("insufficient amount")
+	}
+	if reserveA.Cmp(bigZero) <= 0 || reserveB.Cmp(bigZero) <= 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+
+	amountB := new(big.Int).Mul(amountA, reserveB)
+	return amountB.Div(amountB, reserveA), nil
+}
+
+// OptimalAddAmounts returns the amounts UniswapV2Router02.addLiquidity deposits for the
+// desired amounts: all of one token and the matching amount of the other. An empty pool
+// takes both desired amounts and sets the price.
+func OptimalAddAmounts(desiredA, desiredB, reserveA, reserveB *big.Int) (*big.Int, *big.Int, error) {
+	if reserveA.Sign() == 0 && reserveB.Sign() == 0 {
+		return new(big.Int).Set(desiredA), new(big.Int).Set(desiredB), nil
+	}
+
+	optimalB, err := Quote(desiredA, reserveA, reserveB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if optimalB.Cmp(desiredB) <= 0 {
+		return new(big.Int).Set(desiredA), optimalB, nil
+	}
+
+	optimalA, err := Quote(desiredB, reserveB, reserveA)
+	if err != nil {
+		return nil, nil, err
+	}
+	return optimalA, new(big.Int).Set(desiredB), nil
+}
+
+// ProtocolFeeLiquidity returns the LP tokens minted to feeTo before a mint or burn: one
+// sixth of the growth in sqrt(k) since kLast, like UniswapV2Pair._mintFee
+// Formula: totalSupply * (rootK - rootKLast) / (rootK * 5 + rootKLast)
+func ProtocolFeeLiquidity(reserve0, reserve1, totalSupply, kLast *big.Int) *big.Int {
+	if kLast.Sign() == 0 {
+		return new(big.Int)
+	}
+
+	rootK := new(big.Int).Sqrt(new(big.Int).Mul(reserve0, reserve1))
+	rootKLast := new(big.Int).Sqrt(kLast)
+	if rootK.Cmp(rootKLast) <= 0 {
+		return new(big.Int)
+	}
+
+	numerator := new(big.Int).Sub(rootK, rootKLast)
+	numerator.Mul(numerator, totalSupply)
+	denominator := new(big.Int).Mul(rootK, big5)
+	denominator.Add(denominator, rootKLast)
+	return numerator.Div(numerator, denominator)
+}
+
+// LiquidityMinted returns the LP tokens UniswapV2Pair.mint gives for depositing amount0
+// and amount1. totalSupply must already include the protocol fee. The first deposit
+// mints sqrt(amount0 * amount1) less MinimumLiquidity.
+func LiquidityMinted(amount0, amount1, reserve0, reserve1, totalSupply *big.Int) (*big.Int, error) {
+	var liquidity *big.Int
+	if totalSupply.Sign() == 0 {
+		liquidity = new(big.Int).Sqrt(new(big.Int).Mul(amount0, amount1))
+		liquidity.Sub(liquidity, MinimumLiquidity)
+	} else {
+		if reserve0.Cmp(bigZero) <= 0 || reserve1.Cmp(bigZero) <= 0 {
+			return nil, errors.New("insufficient liquidity")
+		}
+		liquidity0 := new(big.Int).Mul(amount0, totalSupply)
+		liquidity0.Div(liquidity0, reserve0)
+		liquidity1 := new(big.Int).Mul(amount1, totalSupply)
+		liquidity1.Div(liquidity1, reserve1)
+
+		liquidity = liquidity0
+		if liquidity1.Cmp(liquidity0) < 0 {
+			liquidity = liquidity1
+		}
+	}
+
+	if liquidity.Cmp(bigZero) <= 0 {
+		return nil, errors.New("insufficient liquidity minted")
+	}
+	return liquidity, nil
+}
+
+// BurnAmounts returns the tokens UniswapV2Pair.burn pays out for liquidity LP tokens.
+// totalSupply must already include the protocol fee.
+// Formula: amount = liquidity * balance / totalSupply
+func BurnAmounts(liquidity, balance0, balance1, totalSupply *big.Int) (*big.Int, *big.Int, error) {
+	if liquidity.Cmp(bigZero) <= 0 || liquidity.Cmp(totalSupply) > 0 {
+		return nil, nil, errors.New("liquidity must be positive and at most the total supply")
+	}
+
+	amount0 := new(big.Int).Mul(liquidity, balance0)
+	amount0.Div(amount0, totalSupply)
+	amount1 := new(big.Int).Mul(liquidity, balance1)
+	amount1.Div(amount1, totalSupply)
+
+	if amount0.Sign() == 0 || amount1.Sign() == 0 {
+		return nil, nil, errors.New("insufficient liquidity burned")
+	}
+	return amount0, amount1, nil
+}
+
+// PoolShare renders liquidity / totalSupply as a decimal fraction
+func PoolShare(liquidity, totalSupply *big.Int) string {
+	if totalSupply.Sign() == 0 {
+		return new(big.Rat).FloatString(spotPriceDecimals)
+	}
+	return new(big.Rat).SetFrac(liquidity, totalSupply).FloatString(spotPriceDecimals)
+}
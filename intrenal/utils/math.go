@@ -10,6 +10,8 @@ var (
 	big997  = big.NewInt(997)  // Fee factor (1000 - 3)
 	big1000 = big.NewInt(1000) // Fee denominator
 	bigZero = big.NewInt(0)
+
+	bigBasisPoints = big.NewInt(10000)
 )
 
 // CalculateAmountOut implements Uniswap V2 math with 0.3% fee
@@ -43,6 +45,61 @@ func CalculateAmountOut(amountIn, reserveIn, reserveOut *big.Int) (*big.Int, err
 	return amountOut, nil
 }
 
+// CalculateAmountOutWithFee implements the same math for Uniswap V2 forks charging
+// another swap fee, in basis points (25 for PancakeSwap). A fee of 30 gives exactly
+// CalculateAmountOut.
+// Formula: amountOut = (amountIn * (10000 - feeBps) * reserveOut) / (reserveIn * 10000 + amountIn * (10000 - feeBps))
+func CalculateAmountOutWithFee(amountIn, reserveIn, reserveOut *big.Int, feeBps uint32) (*big.Int, error) {
+	if feeBps == 30 {
+		return CalculateAmountOut(amountIn, reserveIn, reserveOut)
+	}
+	if amountIn.Cmp(bigZero) <= 0 {
+		return nil, errors.New("amount in must be positive")
+	}
+	if reserveIn.Cmp(bigZero) <= 0 || reserveOut.Cmp(bigZero) <= 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+	if feeBps >= 10000 {
+		return nil, errors.New("fee must be below 10000 basis points")
+	}
+
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(int64(10000-feeBps)))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, bigBasisPoints)
+	denominator.Add(denominator, amountInWithFee)
+
+	return numerator.Div(numerator, denominator), nil
+}
+
+// ApplyTransferTax returns the amount left after a fee-on-transfer tax in basis points
+// Formula: amount * (10000 - taxBps) / 10000
+func ApplyTransferTax(amount *big.Int, taxBps uint32) *big.Int {
+	if taxBps == 0 {
+		return new(big.Int).Set(amount)
+	}
+
+	remaining := new(big.Int).Mul(amount, big.NewInt(int64(10000-taxBps)))
+	return remaining.Div(remaining, bigBasisPoints)
+}
+
+// spotPriceDecimals is the number of decimals SpotPrice renders
+const spotPriceDecimals = 18
+
+// SpotPrice returns the marginal price of one whole input token in whole output tokens,
+// before the swap fee, as a decimal string
+// Formula: (reserveOut / 10^decimalsOut) / (reserveIn / 10^decimalsIn)
+func SpotPrice(reserveIn, reserveOut *big.Int, decimalsIn, decimalsOut uint8) (string, error) {
+	if reserveIn.Cmp(bigZero) <= 0 || reserveOut.Cmp(bigZero) <= 0 {
+		return "", errors.New("insufficient liquidity")
+	}
+
+	price := new(big.Rat).SetFrac(
+		ConvertToTokenUnits(reserveOut, decimalsIn),
+		ConvertToTokenUnits(reserveIn, decimalsOut),
+	)
+	return price.FloatString(spotPriceDecimals), nil
+}
+
 // ConvertToTokenUnits converts amount considering token decimals
 func ConvertToTokenUnits(amount *big.Int, decimals uint8) *big.Int {
 	if decimals == 0 {
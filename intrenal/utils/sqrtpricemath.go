@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Uniswap V3 FullMath and SqrtPriceMath. Results match the Solidity libraries bit for
+// bit, including where they round and where uint256 overflow changes the formula.
+
+var (
+	maxUint160 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+
+	errUint256Overflow = errors.New("result overflows uint256")
+)
+
+// MulDiv returns floor(a * b / denominator), like FullMath.mulDiv
+func MulDiv(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+	result := new(big.Int).Mul(a, b)
+	result.Div(result, denominator)
+	if result.Cmp(maxUint256) > 0 {
+		return nil, errUint256Overflow
+	}
+	return result, nil
+}
+
+// MulDivRoundingUp returns ceil(a * b / denominator), like FullMath.mulDivRoundingUp
+func MulDivRoundingUp(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+	result, remainder := new(big.Int).QuoRem(new(big.Int).Mul(a, b), denominator, new(big.Int))
+	if remainder.Sign() != 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	if result.Cmp(maxUint256) > 0 {
+		return nil, errUint256Overflow
+	}
+	return result, nil
+}
+
+// divRoundingUp returns ceil(a / b), like UnsafeMath.divRoundingUp
+func divRoundingUp(a, b *big.Int) *big.Int {
+	result, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+	if remainder.Sign() != 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	return result
+}
+
+// nextSqrtPriceFromAmount0RoundingUp moves the price by amount of token0, rounding up
+// Formula: liquidity * sqrtPrice / (liquidity ± amount * sqrtPrice)
+func nextSqrtPriceFromAmount0RoundingUp(sqrtPriceX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPriceX96), nil
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := new(big.Int).Mul(amount, sqrtPriceX96)
+	productOverflows := product.Cmp(maxUint256) > 0
+
+	if add {
+		if !productOverflows {
+			denominator := new(big.Int).Add(numerator1, product)
+			if denominator.Cmp(maxUint256) <= 0 {
+				return MulDivRoundingUp(numerator1, sqrtPriceX96, denominator)
+			}
+		}
+		// Fallback when the precise formula overflows: liquidity / (liquidity / sqrtPrice + amount)
+		denominator := new(big.Int).Div(numerator1, sqrtPriceX96)
+		return divRoundingUp(numerator1, denominator.Add(denominator, amount)), nil
+	}
+
+	if productOverflows || numerator1.Cmp(product) <= 0 {
+		return nil, errors.New("insufficient liquidity for the output amount")
+	}
+	next, err := MulDivRoundingUp(numerator1, sqrtPriceX96, new(big.Int).Sub(numerator1, product))
+	if err != nil || next.Cmp(maxUint160) > 0 {
+		return nil, errors.New("sqrt price overflows uint160")
+	}
+	return next, nil
+}
+
+// nextSqrtPriceFromAmount1RoundingDown moves the price by amount of token1, rounding down
+// Formula: sqrtPrice ± amount / liquidity
+func nextSqrtPriceFromAmount1RoundingDown(sqrtPriceX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	shifted := new(big.Int).Lsh(amount, 96)
+
+	if add {
+		next := shifted.Div(shifted, liquidity)
+		next.Add(next, sqrtPriceX96)
+		if next.Cmp(maxUint160) > 0 {
+			return nil, errors.New("sqrt price overflows uint160")
+		}
+		return next, nil
+	}
+
+	quotient := divRoundingUp(shifted, liquidity)
+	if sqrtPriceX96.Cmp(quotient) <= 0 {
+		return nil, errors.New("insufficient liquidity for the output amount")
+	}
+	return quotient.Sub(sqrtPriceX96, quotient), nil
+}
+
+// GetNextSqrtPriceFromInput returns the price after adding amountIn of token0
+// (zeroForOne) or token1, like SqrtPriceMath.getNextSqrtPriceFromInput
+func GetNextSqrtPriceFromInput(sqrtPriceX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPriceX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, errors.New("price and liquidity must be positive")
+	}
+	if zeroForOne {
+		return nextSqrtPriceFromAmount0RoundingUp(sqrtPriceX96, liquidity, amountIn, true)
+	}
+	return nextSqrtPriceFromAmount1RoundingDown(sqrtPriceX96, liquidity, amountIn, true)
+}
+
+// GetNextSqrtPriceFromOutput returns the price after removing amountOut of token1
+// (zeroForOne) or token0, like SqrtPriceMath.getNextSqrtPriceFromOutput
+func GetNextSqrtPriceFromOutput(sqrtPriceX96, liquidity, amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPriceX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, errors.New("price and liquidity must be positive")
+	}
+	if zeroForOne {
+		return nextSqrtPriceFromAmount1RoundingDown(sqrtPriceX96, liquidity, amountOut, false)
+	}
+	return nextSqrtPriceFromAmount0RoundingUp(sqrtPriceX96, liquidity, amountOut, false)
+}
+
+// GetAmount0Delta returns the token0 amount between two prices for a liquidity,
+// like SqrtPriceMath.getAmount0Delta
+// Formula: liquidity * (sqrtB - sqrtA) / (sqrtA * sqrtB)
+func GetAmount0Delta(sqrtRatioA, sqrtRatioB, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	if sqrtRatioA.Cmp(sqrtRatioB) > 0 {
+		sqrtRatioA, sqrtRatioB = sqrtRatioB, sqrtRatioA
+	}
+	if sqrtRatioA.Sign() <= 0 {
+		return nil, errors.New("sqrt price must be positive")
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioB, sqrtRatioA)
+
+	if roundUp {
+		amount, err := MulDivRoundingUp(numerator1, numerator2, sqrtRatioB)
+		if err != nil {
+			return nil, err
+		}
+		return divRoundingUp(amount, sqrtRatioA), nil
+	}
+
+	amount, err := MulDiv(numerator1, numerator2, sqrtRatioB)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Div(amount, sqrtRatioA), nil
+}
+
+// GetAmount1Delta returns the token1 amount between two prices for a liquidity,
+// like SqrtPriceMath.getAmount1Delta
+// Formula: liquidity * (sqrtB - sqrtA)
+func GetAmount1Delta(sqrtRatioA, sqrtRatioB, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	if sqrtRatioA.Cmp(sqrtRatioB) > 0 {
+		sqrtRatioA, sqrtRatioB = sqrtRatioB, sqrtRatioA
+	}
+
+	difference := new(big.Int).Sub(sqrtRatioB, sqrtRatioA)
+	if roundUp {
+		return MulDivRoundingUp(liquidity, difference, Q96)
+	}
+	return MulDiv(liquidity, difference, Q96)
+}
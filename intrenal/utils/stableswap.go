@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Stable-swap invariants: Solidly/Velodrome pairs (x³y + y³x = k) and Curve StableSwap.
+// Both solve their invariant with Newton iteration in integer math; the functions below
+// take the same steps and round the same way as the contracts.
+
+var (
+	bigOne = big.NewInt(1)
+	bigTwo = big.NewInt(2)
+	wad    = big.NewInt(1e18) // 18-decimal fixed-point one
+
+	// curveFeeDenominator is the unit of Curve pool fees, 4000000 = 0.04%
+	curveFeeDenominator = big.NewInt(1e10)
+
+	errNoConvergence = errors.New("invariant did not converge")
+)
+
+// newtonIterations is the iteration cap of every Newton loop in the contracts
+const newtonIterations = 255
+
+// SolidlyAmountOut returns the output of a Solidly-style pair, like Pair.getAmountOut.
+// decimalsIn and decimalsOut are 10^decimals as the pair stores them, feeBps is the
+// pair fee in basis points. Stable pairs use x³y + y³x = k on 18-decimal normalized
+// reserves, volatile pairs x * y = k.
+func SolidlyAmountOut(amountIn, reserveIn, reserveOut, decimalsIn, decimalsOut *big.Int, stable bool, feeBps uint32) (*big.Int, error) {
+	if amountIn.Sign() <= 0 {
+		return nil, errors.New("amount in must be positive")
+	}
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+
+	// The fee is taken from the input first
+	fee := new(big.Int).Mul(amountIn, big.NewInt(int64(feeBps)))
+	amountIn = new(big.Int).Sub(amountIn, fee.Div(fee, bigBasisPoints))
+
+	if !stable {
+		// Formula: amountIn * reserveOut / (reserveIn + amountIn)
+		numerator := new(big.Int).Mul(amountIn, reserveOut)
+		return numerator.Div(numerator, new(big.Int).Add(reserveIn, amountIn)), nil
+	}
+
+	if decimalsIn.Sign() <= 0 || decimalsOut.Sign() <= 0 {
+		return nil, errors.New("token decimals must be positive")
+	}
+
+	xy := solidlyK(reserveIn, reserveOut, decimalsIn, decimalsOut)
+
+	normalize := func(amount, decimals *big.Int) *big.Int {
+		normalized := new(big.Int).Mul(amount, wad)
+		return normalized.Div(normalized, decimals)
+	}
+	reserveA := normalize(reserveIn, decimalsIn)
+	reserveB := normalize(reserveOut, decimalsOut)
+	amountIn = normalize(amountIn, decimalsIn)
+
+	y, err := solidlyGetY(new(big.Int).Add(amountIn, reserveA), xy, reserveB)
+	if err != nil {
+		return nil, err
+	}
+
+	amountOut := new(big.Int).Sub(reserveB, y)
+	if amountOut.Sign() < 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+	amountOut.Mul(amountOut, decimalsOut)
+	return amountOut.Div(amountOut, wad), nil
+}
+
+// solidlyK returns the stable invariant x³y + y³x of normalized reserves, like Pair._k
+func solidlyK(x, y, decimalsX, decimalsY *big.Int) *big.Int {
+	normX := new(big.Int).Mul(x, wad)
+	normX.Div(normX, decimalsX)
+	normY := new(big.Int).Mul(y, wad)
+	normY.Div(normY, decimalsY)
+
+	// a = x * y, b = x² + y²
+	a := new(big.Int).Mul(normX, normY)
+	a.Div(a, wad)
+	b := new(big.Int).Mul(normX, normX)
+	b.Div(b, wad)
+	yy := new(big.Int).Mul(normY, normY)
+	b.Add(b, yy.Div(yy, wad))
+
+	k := a.Mul(a, b)
+	return k.Div(k, wad)
+}
+
+// solidlyF returns x0 * y³ + x0³ * y, like Pair._f
+func solidlyF(x0, y *big.Int) *big.Int {
+	y3 := new(big.Int).Mul(y, y)
+	y3.Div(y3, wad).Mul(y3, y).Div(y3, wad)
+	left := y3.Mul(x0, y3)
+	left.Div(left, wad)
+
+	x3 := new(big.Int).Mul(x0, x0)
+	x3.Div(x3, wad).Mul(x3, x0).Div(x3, wad)
+	right := x3.Mul(x3, y)
+	right.Div(right, wad)
+
+	return left.Add(left, right)
+}
+
+// solidlyD returns the derivative 3 * x0 * y² + x0³, like Pair._d
+func solidlyD(x0, y *big.Int) *big.Int {
+	y2 := new(big.Int).Mul(y, y)
+	y2.Div(y2, wad)
+	left := y2.Mul(big.NewInt(3), y2).Mul(y2, x0)
+	left.Div(left, wad)
+
+	x3 := new(big.Int).Mul(x0, x0)
+	x3.Div(x3, wad).Mul(x3, x0).Div(x3, wad)
+
+	return left.Add(left, x3)
+}
+
+// solidlyGetY solves f(x0, y) = xy for y starting from y, like Pair._get_y
+func solidlyGetY(x0, xy, y *big.Int) (*big.Int, error) {
+	y = new(big.Int).Set(y)
+	for i := 0; i < newtonIterations; i++ {
+		yPrev := new(big.Int).Set(y)
+		k := solidlyF(x0, y)
+		d := solidlyD(x0, y)
+		if d.Sign() == 0 {
+			return nil, errNoConvergence
+		}
+
+		if k.Cmp(xy) < 0 {
+			dy := new(big.Int).Sub(xy, k)
+			dy.Mul(dy, wad).Div(dy, d)
+			y.Add(y, dy)
+		} else {
+			dy := new(big.Int).Sub(k, xy)
+			dy.Mul(dy, wad).Div(dy, d)
+			y.Sub(y, dy)
+		}
+
+		if new(big.Int).Sub(y, yPrev).CmpAbs(bigOne) <= 0 {
+			return y, nil
+		}
+	}
+	// The pair returns the last estimate
+	return y, nil
+}
+
+// CurveAmountOut returns the output of a Curve StableSwap pool for dx of coin i into
+// coin j, like get_dy of the plain pools (3pool). rates[k] is 10^(36 - decimals of
+// coin k), amp is A() and fee is fee() in 1e-10 units.
+func CurveAmountOut(i, j int, dx *big.Int, balances, rates []*big.Int, amp, fee *big.Int) (*big.Int, error) {
+	n := len(balances)
+	if i == j || i < 0 || j < 0 || i >= n || j >= n || len(rates) != n {
+		return nil, errors.New("invalid coin index")
+	}
+	if dx.Sign() <= 0 {
+		return nil, errors.New("amount in must be positive")
+	}
+
+	xp := curveXP(balances, rates)
+	for _, balance := range xp {
+		if balance.Sign() <= 0 {
+			return nil, errors.New("insufficient liquidity")
+		}
+	}
+
+	// Formula: dy = (xp[j] - y - 1) * PRECISION / rates[j], minus fee * dy
+	x := new(big.Int).Mul(dx, rates[i])
+	x.Div(x, wad).Add(x, xp[i])
+
+	y, err := curveGetY(i, j, x, xp, amp)
+	if err != nil {
+		return nil, err
+	}
+
+	dy := new(big.Int).Sub(xp[j], y)
+	dy.Sub(dy, bigOne)
+	if dy.Sign() < 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+	dy.Mul(dy, wad).Div(dy, rates[j])
+
+	dyFee := new(big.Int).Mul(fee, dy)
+	dyFee.Div(dyFee, curveFeeDenominator)
+	return dy.Sub(dy, dyFee), nil
+}
+
+// curveXP returns the balances in 18-decimal precision, like _xp
+func curveXP(balances, rates []*big.Int) []*big.Int {
+	xp := make([]*big.Int, len(balances))
+	for k, balance := range balances {
+		xp[k] = new(big.Int).Mul(rates[k], balance)
+		xp[k].Div(xp[k], wad)
+	}
+	return xp
+}
+
+// CurveD returns the StableSwap invariant D of precision-adjusted balances, like get_D
+func CurveD(xp []*big.Int, amp *big.Int) (*big.Int, error) {
+	n := big.NewInt(int64(len(xp)))
+
+	sum := new(big.Int)
+	for _, x := range xp {
+		sum.Add(sum, x)
+	}
+	if sum.Sign() == 0 {
+		return sum, nil
+	}
+
+	d := new(big.Int).Set(sum)
+	ann := new(big.Int).Mul(amp, n)
+	for i := 0; i < newtonIterations; i++ {
+		// dP = D^(n+1) / (n^n * prod(xp))
+		dP := new(big.Int).Set(d)
+		for _, x := range xp {
+			denominator := new(big.Int).Mul(x, n)
+			if denominator.Sign() == 0 {
+				return nil, errors.New("insufficient liquidity")
+			}
+			dP.Mul(dP, d).Div(dP, denominator)
+		}
+		dPrev := d
+
+		// D = (Ann * S + dP * n) * D / ((Ann - 1) * D + (n + 1) * dP)
+		numerator := new(big.Int).Mul(ann, sum)
+		numerator.Add(numerator, new(big.Int).Mul(dP, n)).Mul(numerator, d)
+		denominator := new(big.Int).Sub(ann, bigOne)
+		denominator.Mul(denominator, d)
+		denominator.Add(denominator, new(big.Int).Mul(new(big.Int).Add(n, bigOne), dP))
+		d = numerator.Div(numerator, denominator)
+
+		if new(big.Int).Sub(d, dPrev).CmpAbs(bigOne) <= 0 {
+			return d, nil
+		}
+	}
+	return nil, errNoConvergence
+}
+
+// curveGetY returns the new balance of coin j when coin i is x, keeping D, like get_y
+func curveGetY(i, j int, x *big.Int, xp []*big.Int, amp *big.Int) (*big.Int, error) {
+	d, err := CurveD(xp, amp)
+	if err != nil {
+		return nil, err
+	}
+
+	n := big.NewInt(int64(len(xp)))
+	ann := new(big.Int).Mul(amp, n)
+
+	// c = D^(n+1) / (n^n * prod(x_k) * Ann), S = sum(x_k), over every coin but j
+	c := new(big.Int).Set(d)
+	sum := new(big.Int)
+	for k := range xp {
+		var balance *big.Int
+		switch k {
+		case i:
+			balance = x
+		case j:
+			continue
+		default:
+			balance = xp[k]
+		}
+		sum.Add(sum, balance)
+		c.Mul(c, d).Div(c, new(big.Int).Mul(balance, n))
+	}
+	c.Mul(c, d).Div(c, new(big.Int).Mul(ann, n))
+	b := new(big.Int).Div(d, ann)
+	b.Add(b, sum)
+
+	// y = (y² + c) / (2y + b - D)
+	y := new(big.Int).Set(d)
+	for iteration := 0; iteration < newtonIterations; iteration++ {
+		yPrev := y
+		numerator := new(big.Int).Mul(y, y)
+		numerator.Add(numerator, c)
+		denominator := new(big.Int).Mul(bigTwo, y)
+		denominator.Add(denominator, b).Sub(denominator, d)
+		if denominator.Sign() <= 0 {
+			return nil, errNoConvergence
+		}
+		y = numerator.Div(numerator, denominator)
+
+		if new(big.Int).Sub(y, yPrev).CmpAbs(bigOne) <= 0 {
+			return y, nil
+		}
+	}
+	return nil, errNoConvergence
+}
@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"uniswap-est/intrenal/models"
+)
+
+// Uniswap V3 SwapMath and the swap loop of UniswapV3Pool.swap, without the fee growth,
+// oracle and protocol fee bookkeeping that do not change the swapped amounts
+
+// feePipsDenominator is the fee unit: fee is in hundredths of a basis point
+var feePipsDenominator = big.NewInt(1000000)
+
+// ErrTicksNotLoaded is returned when a swap moves past the tick bitmap words that were
+// read from the pool
+var ErrTicksNotLoaded = errors.New("swap moves past the loaded tick range")
+
+// SwapStep is the outcome of one ComputeSwapStep
+type SwapStep struct {
+	SqrtRatioNextX96 *big.Int
+	AmountIn         *big.Int
+	AmountOut        *big.Int
+	FeeAmount        *big.Int
+}
+
+// ComputeSwapStep swaps within one price range of constant liquidity, towards
+// sqrtRatioTargetX96, like SwapMath.computeSwapStep. A positive amountRemaining is an
+// exact input, a negative one an exact output. feePips is the pool fee in 1e-6 units.
+func ComputeSwapStep(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, amountRemaining *big.Int, feePips uint32) (*SwapStep, error) {
+	zeroForOne := sqrtRatioCurrentX96.Cmp(sqrtRatioTargetX96) >= 0
+	exactIn := amountRemaining.Sign() >= 0
+	fee := big.NewInt(int64(feePips))
+	feeComplement := new(big.Int).Sub(feePipsDenominator, fee)
+
+	step := &SwapStep{}
+	var err error
+
+	if exactIn {
+		var amountRemainingLessFee *big.Int
+		amountRemainingLessFee, err = MulDiv(amountRemaining, feeComplement, feePipsDenominator)
+		if err != nil {
+			return nil, err
+		}
+		if zeroForOne {
+			step.AmountIn, err = GetAmount0Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, true)
+		} else {
+			step.AmountIn, err = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if amountRemainingLessFee.Cmp(step.AmountIn) >= 0 {
+			step.SqrtRatioNextX96 = new(big.Int).Set(sqrtRatioTargetX96)
+		} else {
+			step.SqrtRatioNextX96, err = GetNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, amountRemainingLessFee, zeroForOne)
+		}
+	} else {
+		if zeroForOne {
+			step.AmountOut, err = GetAmount1Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, false)
+		} else {
+			step.AmountOut, err = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if new(big.Int).Neg(amountRemaining).Cmp(step.AmountOut) >= 0 {
+			step.SqrtRatioNextX96 = new(big.Int).Set(sqrtRatioTargetX96)
+		} else {
+			step.SqrtRatioNextX96, err = GetNextSqrtPriceFromOutput(sqrtRatioCurrentX96, liquidity, new(big.Int).Neg(amountRemaining), zeroForOne)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reachedTarget := sqrtRatioTargetX96.Cmp(step.SqrtRatioNextX96) == 0
+
+	// Amounts for the range actually traversed
+	if zeroForOne {
+		if !reachedTarget || !exactIn {
+			if step.AmountIn, err = GetAmount0Delta(step.SqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, true); err != nil {
+				return nil, err
+			}
+		}
+		if !reachedTarget || exactIn {
+			if step.AmountOut, err = GetAmount1Delta(step.SqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, false); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if !reachedTarget || !exactIn {
+			if step.AmountIn, err = GetAmount1Delta(sqrtRatioCurrentX96, step.SqrtRatioNextX96, liquidity, true); err != nil {
+				return nil, err
+			}
+		}
+		if !reachedTarget || exactIn {
+			if step.AmountOut, err = GetAmount0Delta(sqrtRatioCurrentX96, step.SqrtRatioNextX96, liquidity, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The output of an exact output step is capped at the amount remaining
+	if !exactIn && step.AmountOut.Cmp(new(big.Int).Neg(amountRemaining)) > 0 {
+		step.AmountOut = new(big.Int).Neg(amountRemaining)
+	}
+
+	if exactIn && !reachedTarget {
+		// The price did not reach the target, so the rest of the input is the fee
+		step.FeeAmount = new(big.Int).Sub(amountRemaining, step.AmountIn)
+	} else {
+		step.FeeAmount, err = MulDivRoundingUp(step.AmountIn, fee, feeComplement)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return step, nil
+}
+
+// V3SwapResult holds the token deltas of a swap from the pool's point of view: positive
+// amounts are paid into the pool, negative amounts paid out
+type V3SwapResult struct {
+	Amount0         *big.Int
+	Amount1         *big.Int
+	SqrtPriceX96    *big.Int
+	Tick            int32
+	Liquidity       *big.Int
+	TicksCrossed    int
+	AmountUnswapped *big.Int // left of amountSpecified when the price limit was reached
+}
+
+// SwapV3 simulates UniswapV3Pool.swap on pool state. amountSpecified is an exact input
+// when positive and an exact output when negative. A nil sqrtPriceLimitX96 swaps up to
+// the price bounds like the Quoter does. Ticks outside the loaded bitmap words return
+// ErrTicksNotLoaded.
+func SwapV3(pool *models.V3PoolState, zeroForOne bool, amountSpecified, sqrtPriceLimitX96 *big.Int) (*V3SwapResult, error) {
+	if amountSpecified.Sign() == 0 {
+		return nil, errors.New("amount must not be zero")
+	}
+
+	if sqrtPriceLimitX96 == nil {
+		if zeroForOne {
+			sqrtPriceLimitX96 = new(big.Int).Add(MinSqrtRatio, big.NewInt(1))
+		} else {
+			sqrtPriceLimitX96 = new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1))
+		}
+	}
+	if zeroForOne {
+		if sqrtPriceLimitX96.Cmp(pool.SqrtPriceX96) >= 0 || sqrtPriceLimitX96.Cmp(MinSqrtRatio) <= 0 {
+			return nil, errors.New("price limit out of range")
+		}
+	} else if sqrtPriceLimitX96.Cmp(pool.SqrtPriceX96) <= 0 || sqrtPriceLimitX96.Cmp(MaxSqrtRatio) >= 0 {
+		return nil, errors.New("price limit out of range")
+	}
+
+	exactInput := amountSpecified.Sign() > 0
+	remaining := new(big.Int).Set(amountSpecified)
+	calculated := new(big.Int)
+	sqrtPrice := new(big.Int).Set(pool.SqrtPriceX96)
+	tick := pool.Tick
+	liquidity := new(big.Int).Set(pool.Liquidity)
+	crossed := 0
+
+	for remaining.Sign() != 0 && sqrtPrice.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStart := sqrtPrice
+
+		tickNext, initialized, err := nextInitializedTickWithinOneWord(pool, tick, zeroForOne)
+		if err != nil {
+			return nil, err
+		}
+		tickNext = max(MinTick, min(MaxTick, tickNext))
+
+		sqrtPriceNext, err := GetSqrtRatioAtTick(tickNext)
+		if err != nil {
+			return nil, err
+		}
+
+		target := sqrtPriceNext
+		if (zeroForOne && sqrtPriceNext.Cmp(sqrtPriceLimitX96) < 0) || (!zeroForOne && sqrtPriceNext.Cmp(sqrtPriceLimitX96) > 0) {
+			target = sqrtPriceLimitX96
+		}
+
+		step, err := ComputeSwapStep(sqrtPrice, target, liquidity, remaining, pool.Fee)
+		if err != nil {
+			return nil, err
+		}
+		sqrtPrice = step.SqrtRatioNextX96
+
+		if exactInput {
+			remaining.Sub(remaining, step.AmountIn).Sub(remaining, step.FeeAmount)
+			calculated.Sub(calculated, step.AmountOut)
+		} else {
+			remaining.Add(remaining, step.AmountOut)
+			calculated.Add(calculated, step.AmountIn).Add(calculated, step.FeeAmount)
+		}
+
+		if sqrtPrice.Cmp(sqrtPriceNext) == 0 {
+			// Crossed into the next range
+			if initialized {
+				liquidityNet, ok := pool.LiquidityNet[tickNext]
+				if !ok {
+					return nil, ErrTicksNotLoaded
+				}
+				if zeroForOne {
+					liquidity.Sub(liquidity, liquidityNet)
+				} else {
+					liquidity.Add(liquidity, liquidityNet)
+				}
+				if liquidity.Sign() < 0 {
+					return nil, errors.New("liquidity underflow")
+				}
+				crossed++
+			}
+			if zeroForOne {
+				tick = tickNext - 1
+			} else {
+				tick = tickNext
+			}
+		} else if sqrtPrice.Cmp(sqrtPriceStart) != 0 {
+			if tick, err = GetTickAtSqrtRatio(sqrtPrice); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	specifiedUsed := new(big.Int).Sub(amountSpecified, remaining)
+	result := &V3SwapResult{
+		SqrtPriceX96:    sqrtPrice,
+		Tick:            tick,
+		Liquidity:       liquidity,
+		TicksCrossed:    crossed,
+		AmountUnswapped: remaining,
+	}
+	if zeroForOne == exactInput {
+		result.Amount0, result.Amount1 = specifiedUsed, calculated
+	} else {
+		result.Amount0, result.Amount1 = calculated, specifiedUsed
+	}
+	return result, nil
+}
+
+// nextInitializedTickWithinOneWord returns the next initialized tick in the same bitmap
+// word as tick, or the word boundary, like TickBitmap.nextInitializedTickWithinOneWord.
+// lte searches towards lower ticks.
+func nextInitializedTickWithinOneWord(pool *models.V3PoolState, tick int32, lte bool) (int32, bool, error) {
+	spacing := pool.TickSpacing
+	compressed := tick / spacing
+	if tick < 0 && tick%spacing != 0 {
+		compressed-- // round towards negative infinity
+	}
+
+	if lte {
+		wordPos, bitPos := tickPosition(compressed)
+		word, ok := pool.TickBitmap[wordPos]
+		if !ok {
+			return 0, false, ErrTicksNotLoaded
+		}
+
+		// All the bits at or below bitPos
+		mask := new(big.Int).Lsh(big.NewInt(1), uint(bitPos)+1)
+		mask.Sub(mask, big.NewInt(1))
+		masked := mask.And(mask, word)
+
+		if masked.Sign() != 0 {
+			msb := int32(masked.BitLen() - 1)
+			return (compressed - (int32(bitPos) - msb)) * spacing, true, nil
+		}
+		return (compressed - int32(bitPos)) * spacing, false, nil
+	}
+
+	wordPos, bitPos := tickPosition(compressed + 1)
+	word, ok := pool.TickBitmap[wordPos]
+	if !ok {
+		return 0, false, ErrTicksNotLoaded
+	}
+
+	// All the bits at or above bitPos
+	masked := new(big.Int).Rsh(word, uint(bitPos))
+	if masked.Sign() != 0 {
+		lsb := int32(masked.TrailingZeroBits())
+		return (compressed + 1 + lsb) * spacing, true, nil
+	}
+	return (compressed + 1 + (255 - int32(bitPos))) * spacing, false, nil
+}
+
+// tickPosition returns the bitmap word and bit of a compressed tick, like TickBitmap.position
+func tickPosition(compressed int32) (int16, uint8) {
+	return int16(compressed >> 8), uint8(compressed)
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Uniswap V3 TickMath: conversion between ticks and sqrt prices as Q64.96 numbers,
+// sqrt(1.0001^tick) * 2^96, with the exact rounding of the Solidity library
+
+const (
+	// MinTick is the lowest tick, where the price is about 2^-128
+	MinTick int32 = -887272
+	// MaxTick is the highest tick, where the price is about 2^128
+	MaxTick int32 = -MinTick
+)
+
+var (
+	// MinSqrtRatio is GetSqrtRatioAtTick(MinTick)
+	MinSqrtRatio = big.NewInt(4295128739)
+	// MaxSqrtRatio is GetSqrtRatioAtTick(MaxTick)
+	MaxSqrtRatio = hexInt("fffd8963efd1fc6a506488495d951d5263988d26")
+
+	// Q96 is 2^96, the Q64.96 representation of 1
+	Q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+	q32Mask = big.NewInt(0xffffffff)
+
+	// sqrtRatioFactors[i] is 2^128 / sqrt(1.0001)^(2^i), as hard-coded in TickMath.sol
+	sqrtRatioFactors = []*big.Int{
+		hexInt("fffcb933bd6fad37aa2d162d1a594001"),
+		hexInt("fff97272373d413259a46990580e213a"),
+		hexInt("fff2e50f5f656932ef12357cf3c7fdcc"),
+		hexInt("ffe5caca7e10e4e61c3624eaa0941cd0"),
+		hexInt("ffcb9843d60f6159c9db58835c926644"),
+		hexInt("ff973b41fa98c081472e6896dfb254c0"),
+		hexInt("ff2ea16466c96a3843ec78b326b52861"),
+		hexInt("fe5dee046a99a2a811c461f1969c3053"),
+		hexInt("fcbe86c7900a88aedcffc83b479aa3a4"),
+		hexInt("f987a7253ac413176f2b074cf7815e54"),
+		hexInt("f3392b0822b70005940c7a398e4b70f3"),
+		hexInt("e7159475a2c29b7443b29c7fa6e889d9"),
+		hexInt("d097f3bdfd2022b8845ad8f792aa5825"),
+		hexInt("a9f746462d870fdf8a65dc1f90e061e5"),
+		hexInt("70d869a156d2a1b890bb3df62baf32f7"),
+		hexInt("31be135f97d08fd981231505542fcfa6"),
+		hexInt("9aa508b5b7a84e1c677de54f3e99bc9"),
+		hexInt("5d6af8dedb81196699c329225ee604"),
+		hexInt("2216e584f5fa1ea926041bedfe98"),
+		hexInt("48a170391f7dc42444e8fa2"),
+	}
+)
+
+// hexInt parses a hex constant, panicking on programmer error
+func hexInt(hex string) *big.Int {
+	value, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		panic("utils: bad hex constant " + hex)
+	}
+	return value
+}
+
+// GetSqrtRatioAtTick returns sqrt(1.0001^tick) * 2^96, like TickMath.getSqrtRatioAtTick
+func GetSqrtRatioAtTick(tick int32) (*big.Int, error) {
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+	if absTick > MaxTick {
+		return nil, errors.New("tick out of range")
+	}
+
+	ratio := new(big.Int).Lsh(big.NewInt(1), 128)
+	for i, factor := range sqrtRatioFactors {
+		if absTick&(1<<i) != 0 {
+			ratio.Mul(ratio, factor).Rsh(ratio, 128)
+		}
+	}
+
+	// The factors give the ratio of a negative tick, Q128.128
+	if tick > 0 {
+		ratio.Div(maxUint256, ratio)
+	}
+
+	// Down to Q64.96, rounding up so that GetTickAtSqrtRatio is consistent
+	sqrtPriceX96 := new(big.Int).Rsh(ratio, 32)
+	if new(big.Int).And(ratio, q32Mask).Sign() != 0 {
+		sqrtPriceX96.Add(sqrtPriceX96, big.NewInt(1))
+	}
+	return sqrtPriceX96, nil
+}
+
+// GetTickAtSqrtRatio returns the greatest tick whose sqrt ratio is at most sqrtPriceX96,
+// like TickMath.getTickAtSqrtRatio. It searches with GetSqrtRatioAtTick, so both
+// functions agree exactly.
+func GetTickAtSqrtRatio(sqrtPriceX96 *big.Int) (int32, error) {
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return 0, errors.New("sqrt price out of range")
+	}
+
+	low, high := MinTick, MaxTick-1
+	for low < high {
+		mid := low + (high-low+1)/2
+		ratio, _ := GetSqrtRatioAtTick(mid)
+		if ratio.Cmp(sqrtPriceX96) <= 0 {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
@@ -1,15 +1,44 @@
 package utils
 
 import (
+	"fmt"
+	"math/big"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"uniswap-est/intrenal/models"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 var (
 	// Ethereum address regex (0x followed by 40 hex characters)
 	ethAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+	// Token list symbol: letters, digits and the few punctuation marks symbols use (USDC.e, USD+)
+	tokenSymbolRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+_$-]{0,31}$`)
+
+	// Unsigned decimal integer without sign or whitespace
+	decimalRegex = regexp.MustCompile(`^[0-9]+$`)
+
+	// Largest value of a uint256: 2^256 - 1
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
 )
 
+// Addresses wallets and aggregators use for the native token (ETH, BNB, MATIC...),
+// which has no ERC20 contract; it is quoted as the wrapped native token of the chain
+const (
+	NativeZeroAddress = "0x0000000000000000000000000000000000000000"
+	NativeEeeeAddress = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+)
+
+// IsNativeToken reports whether an address, in any case, is a native token sentinel
+func IsNativeToken(address string) bool {
+	address = NormalizeAddress(address)
+	return address == NativeZeroAddress || address == NativeEeeeAddress
+}
+
 // IsValidEthereumAddress validates Ethereum address format
 func IsValidEthereumAddress(address string) bool {
 	if len(address) != 42 {
@@ -18,18 +47,156 @@ func IsValidEthereumAddress(address string) bool {
 	return ethAddressRegex.MatchString(address)
 }
 
+// IsValidChecksum reports whether a mixed-case address matches its EIP-55 checksum.
+// All-lowercase and all-uppercase addresses carry no checksum and are accepted, as are
+// native token sentinels, which are commonly written with an arbitrary mix of cases.
+func IsValidChecksum(address string) bool {
+	hex := address[2:]
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) || IsNativeToken(address) {
+		return true
+	}
+	return common.HexToAddress(address).Hex() == address
+}
+
+// IsTokenSymbol reports whether a token reference is a symbol rather than an address
+func IsTokenSymbol(token string) bool {
+	return !strings.HasPrefix(token, "0x") && tokenSymbolRegex.MatchString(token)
+}
+
 // NormalizeAddress converts address to lowercase (Ethereum standard)
 func NormalizeAddress(address string) string {
 	return strings.ToLower(address)
 }
 
-// IsValidAmount checks if amount string represents a positive integer
+// IsValidAmount checks if amount string represents a positive integer that fits in uint256
 func IsValidAmount(amount string) bool {
-	if amount == "" || amount == "0" {
+	if !decimalRegex.MatchString(amount) {
+		return false
+	}
+
+	value, err := ParseBigInt(amount)
+	if err != nil {
 		return false
 	}
-	
-	// Check if it's a valid big integer
-	_, err := ParseBigInt(amount)
-	return err == nil
-}
\ No newline at end of file
+	return value.Sign() > 0 && value.Cmp(maxUint256) <= 0
+}
+
+// ValidateStruct checks every string field of a struct against its `validate` tag and
+// returns all failures. Supported rules:
+//
+//	required         - field must not be empty
+//	len=N            - field must be exactly N characters
+//	eth_address      - 0x-prefixed 20 byte hex address, EIP-55 checksum if mixed case
+//	eth_address_list - comma separated eth_address values
+//	token            - eth_address, or a token symbol resolved with the token lists
+//	uint256          - positive decimal integer no larger than 2^256-1
+//	uint64           - decimal integer no larger than 2^64-1, zero included
+//	nefield=Field    - field must differ (case-insensitively) from another field; the
+//	                   native token sentinels count as the same token
+func ValidateStruct(s interface{}) []models.FieldError {
+	value := reflect.Indirect(reflect.ValueOf(s))
+	structType := value.Type()
+
+	var errs []models.FieldError
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		rules := field.Tag.Get("validate")
+		if rules == "" || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		fieldValue := value.Field(i).String()
+		for _, rule := range strings.Split(rules, ",") {
+			name, param, _ := strings.Cut(rule, "=")
+
+			message := checkRule(name, param, fieldValue, value)
+			if message != "" {
+				errs = append(errs, models.FieldError{
+					Field:   jsonFieldName(field),
+					Rule:    name,
+					Message: message,
+				})
+				break // report the first failing rule per field
+			}
+		}
+	}
+
+	return errs
+}
+
+// checkRule applies a single rule and returns a message describing the failure, or ""
+func checkRule(name, param, value string, parent reflect.Value) string {
+	// Only "required" applies to empty values, other rules would just repeat it
+	if value == "" && name != "required" {
+		return ""
+	}
+
+	switch name {
+	case "required":
+		if value == "" {
+			return "is required"
+		}
+	case "len":
+		length, err := strconv.Atoi(param)
+		if err != nil {
+			panic(fmt.Sprintf("validate: bad len parameter %q", param))
+		}
+		if len(value) != length {
+			return fmt.Sprintf("must be exactly %d characters", length)
+		}
+	case "eth_address":
+		if !IsValidEthereumAddress(value) {
+			return "must be a valid Ethereum address (0x followed by 40 hex characters)"
+		}
+		if !IsValidChecksum(value) {
+			return "has an invalid EIP-55 checksum"
+		}
+	case "eth_address_list":
+		for _, entry := range strings.Split(value, ",") {
+			address := strings.TrimSpace(entry)
+			if address == "" {
+				return "must not contain empty entries"
+			}
+			if message := checkRule("eth_address", "", address, parent); message != "" {
+				return fmt.Sprintf("entry %s %s", address, message)
+			}
+		}
+	case "token":
+		if IsTokenSymbol(value) {
+			return ""
+		}
+		if !strings.HasPrefix(value, "0x") {
+			return "must be a token address or a token list symbol"
+		}
+		return checkRule("eth_address", "", value, parent)
+	case "uint256":
+		if !IsValidAmount(value) {
+			return "must be a positive integer no larger than 2^256-1"
+		}
+	case "uint64":
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return "must be a non-negative integer no larger than 2^64-1"
+		}
+	case "nefield":
+		other := parent.FieldByName(param)
+		if !other.IsValid() {
+			panic(fmt.Sprintf("validate: unknown field %q", param))
+		}
+		if strings.EqualFold(value, other.String()) || (IsNativeToken(value) && IsNativeToken(other.String())) {
+			otherField, _ := parent.Type().FieldByName(param)
+			return fmt.Sprintf("must be different from %s", jsonFieldName(otherField))
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", name))
+	}
+	return ""
+}
+
+// jsonFieldName returns the JSON name of a struct field, as clients know it
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
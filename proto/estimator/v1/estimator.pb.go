@@ -0,0 +1,1053 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: estimator/v1/estimator.proto
+
+package estimatorv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EstimateSwapRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Derived from src and dst when empty, as the pair of dex
+	Pool      string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Src       string `protobuf:"bytes,2,opt,name=src,proto3" json:"src,omitempty"`
+	Dst       string `protobuf:"bytes,3,opt,name=dst,proto3" json:"dst,omitempty"`
+	SrcAmount string `protobuf:"bytes,4,opt,name=src_amount,json=srcAmount,proto3" json:"src_amount,omitempty"`
+	Verify    bool   `protobuf:"varint,5,opt,name=verify,proto3" json:"verify,omitempty"`
+	// V2 deployment a derived pool belongs to, default the chain's
+	Dex           string `protobuf:"bytes,6,opt,name=dex,proto3" json:"dex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateSwapRequest) Reset() {
+	*x = EstimateSwapRequest{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateSwapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateSwapRequest) ProtoMessage() {}
+
+func (x *EstimateSwapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateSwapRequest.ProtoReflect.Descriptor instead.
+func (*EstimateSwapRequest) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EstimateSwapRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *EstimateSwapRequest) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *EstimateSwapRequest) GetDst() string {
+	if x != nil {
+		return x.Dst
+	}
+	return ""
+}
+
+func (x *EstimateSwapRequest) GetSrcAmount() string {
+	if x != nil {
+		return x.SrcAmount
+	}
+	return ""
+}
+
+func (x *EstimateSwapRequest) GetVerify() bool {
+	if x != nil {
+		return x.Verify
+	}
+	return false
+}
+
+func (x *EstimateSwapRequest) GetDex() string {
+	if x != nil {
+		return x.Dex
+	}
+	return ""
+}
+
+type EstimateSwapResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	DstAmount         string                 `protobuf:"bytes,1,opt,name=dst_amount,json=dstAmount,proto3" json:"dst_amount,omitempty"`
+	Verification      *VerificationResult    `protobuf:"bytes,2,opt,name=verification,proto3" json:"verification,omitempty"`
+	FeeOnTransfer     bool                   `protobuf:"varint,3,opt,name=fee_on_transfer,json=feeOnTransfer,proto3" json:"fee_on_transfer,omitempty"`
+	SrcTransferTaxBps uint32                 `protobuf:"varint,4,opt,name=src_transfer_tax_bps,json=srcTransferTaxBps,proto3" json:"src_transfer_tax_bps,omitempty"`
+	DstTransferTaxBps uint32                 `protobuf:"varint,5,opt,name=dst_transfer_tax_bps,json=dstTransferTaxBps,proto3" json:"dst_transfer_tax_bps,omitempty"`
+	Rebasing          bool                   `protobuf:"varint,6,opt,name=rebasing,proto3" json:"rebasing,omitempty"`
+	// Detected type of the pool, e.g. uniswap_v2 or uniswap_v3, as in GET /estimate
+	PoolType string `protobuf:"bytes,7,opt,name=pool_type,json=poolType,proto3" json:"pool_type,omitempty"`
+	// Set when src or dst is a native token sentinel, quoted as wrapped_native; the swap
+	// must then use the router's ETH variants (swapExactETHForTokens, swapExactTokensForETH)
+	SrcNative     bool   `protobuf:"varint,8,opt,name=src_native,json=srcNative,proto3" json:"src_native,omitempty"`
+	DstNative     bool   `protobuf:"varint,9,opt,name=dst_native,json=dstNative,proto3" json:"dst_native,omitempty"`
+	WrappedNative string `protobuf:"bytes,10,opt,name=wrapped_native,json=wrappedNative,proto3" json:"wrapped_native,omitempty"`
+	// Token list entries of src and dst, when a token list has them; they show the
+	// address a symbol was resolved to
+	SrcToken *ListedToken `protobuf:"bytes,11,opt,name=src_token,json=srcToken,proto3" json:"src_token,omitempty"`
+	DstToken *ListedToken `protobuf:"bytes,12,opt,name=dst_token,json=dstToken,proto3" json:"dst_token,omitempty"`
+	// The pair derived from src and dst when pool was omitted
+	Pool string `protobuf:"bytes,13,opt,name=pool,proto3" json:"pool,omitempty"`
+	// Warnings that did not prevent the quote, e.g. a V2 pool that is not the canonical
+	// pair of its tokens
+	Warnings      []string `protobuf:"bytes,14,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateSwapResponse) Reset() {
+	*x = EstimateSwapResponse{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateSwapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateSwapResponse) ProtoMessage() {}
+
+func (x *EstimateSwapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateSwapResponse.ProtoReflect.Descriptor instead.
+func (*EstimateSwapResponse) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EstimateSwapResponse) GetDstAmount() string {
+	if x != nil {
+		return x.DstAmount
+	}
+	return ""
+}
+
+func (x *EstimateSwapResponse) GetVerification() *VerificationResult {
+	if x != nil {
+		return x.Verification
+	}
+	return nil
+}
+
+func (x *EstimateSwapResponse) GetFeeOnTransfer() bool {
+	if x != nil {
+		return x.FeeOnTransfer
+	}
+	return false
+}
+
+func (x *EstimateSwapResponse) GetSrcTransferTaxBps() uint32 {
+	if x != nil {
+		return x.SrcTransferTaxBps
+	}
+	return 0
+}
+
+func (x *EstimateSwapResponse) GetDstTransferTaxBps() uint32 {
+	if x != nil {
+		return x.DstTransferTaxBps
+	}
+	return 0
+}
+
+func (x *EstimateSwapResponse) GetRebasing() bool {
+	if x != nil {
+		return x.Rebasing
+	}
+	return false
+}
+
+func (x *EstimateSwapResponse) GetPoolType() string {
+	if x != nil {
+		return x.PoolType
+	}
+	return ""
+}
+
+func (x *EstimateSwapResponse) GetSrcNative() bool {
+	if x != nil {
+		return x.SrcNative
+	}
+	return false
+}
+
+func (x *EstimateSwapResponse) GetDstNative() bool {
+	if x != nil {
+		return x.DstNative
+	}
+	return false
+}
+
+func (x *EstimateSwapResponse) GetWrappedNative() string {
+	if x != nil {
+		return x.WrappedNative
+	}
+	return ""
+}
+
+func (x *EstimateSwapResponse) GetSrcToken() *ListedToken {
+	if x != nil {
+		return x.SrcToken
+	}
+	return nil
+}
+
+func (x *EstimateSwapResponse) GetDstToken() *ListedToken {
+	if x != nil {
+		return x.DstToken
+	}
+	return nil
+}
+
+func (x *EstimateSwapResponse) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *EstimateSwapResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+// ListedToken mirrors models.ListedToken, a token list entry
+type ListedToken struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainId       uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Symbol        string                 `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Decimals      uint32                 `protobuf:"varint,5,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	LogoUri       string                 `protobuf:"bytes,6,opt,name=logo_uri,json=logoUri,proto3" json:"logo_uri,omitempty"`
+	List          string                 `protobuf:"bytes,7,opt,name=list,proto3" json:"list,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListedToken) Reset() {
+	*x = ListedToken{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListedToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListedToken) ProtoMessage() {}
+
+func (x *ListedToken) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListedToken.ProtoReflect.Descriptor instead.
+func (*ListedToken) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListedToken) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *ListedToken) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ListedToken) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ListedToken) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListedToken) GetDecimals() uint32 {
+	if x != nil {
+		return x.Decimals
+	}
+	return 0
+}
+
+func (x *ListedToken) GetLogoUri() string {
+	if x != nil {
+		return x.LogoUri
+	}
+	return ""
+}
+
+func (x *ListedToken) GetList() string {
+	if x != nil {
+		return x.List
+	}
+	return ""
+}
+
+type VerificationResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	BlockNumber    uint64                 `protobuf:"varint,1,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	OffchainAmount string                 `protobuf:"bytes,2,opt,name=offchain_amount,json=offchainAmount,proto3" json:"offchain_amount,omitempty"`
+	OnchainAmount  string                 `protobuf:"bytes,3,opt,name=onchain_amount,json=onchainAmount,proto3" json:"onchain_amount,omitempty"`
+	Match          bool                   `protobuf:"varint,4,opt,name=match,proto3" json:"match,omitempty"`
+	Status         string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Reason         string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *VerificationResult) Reset() {
+	*x = VerificationResult{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerificationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerificationResult) ProtoMessage() {}
+
+func (x *VerificationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerificationResult.ProtoReflect.Descriptor instead.
+func (*VerificationResult) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerificationResult) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *VerificationResult) GetOffchainAmount() string {
+	if x != nil {
+		return x.OffchainAmount
+	}
+	return ""
+}
+
+func (x *VerificationResult) GetOnchainAmount() string {
+	if x != nil {
+		return x.OnchainAmount
+	}
+	return ""
+}
+
+func (x *VerificationResult) GetMatch() bool {
+	if x != nil {
+		return x.Match
+	}
+	return false
+}
+
+func (x *VerificationResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *VerificationResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type EstimateBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*EstimateSwapRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateBatchRequest) Reset() {
+	*x = EstimateBatchRequest{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateBatchRequest) ProtoMessage() {}
+
+func (x *EstimateBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateBatchRequest.ProtoReflect.Descriptor instead.
+func (*EstimateBatchRequest) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EstimateBatchRequest) GetRequests() []*EstimateSwapRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type EstimateBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockNumber   uint64                 `protobuf:"varint,1,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Results       []*EstimateBatchResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateBatchResponse) Reset() {
+	*x = EstimateBatchResponse{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateBatchResponse) ProtoMessage() {}
+
+func (x *EstimateBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateBatchResponse.ProtoReflect.Descriptor instead.
+func (*EstimateBatchResponse) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *EstimateBatchResponse) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *EstimateBatchResponse) GetResults() []*EstimateBatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type EstimateBatchResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	// Types that are valid to be assigned to Outcome:
+	//
+	//	*EstimateBatchResult_Result
+	//	*EstimateBatchResult_Error
+	Outcome       isEstimateBatchResult_Outcome `protobuf_oneof:"outcome"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateBatchResult) Reset() {
+	*x = EstimateBatchResult{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateBatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateBatchResult) ProtoMessage() {}
+
+func (x *EstimateBatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateBatchResult.ProtoReflect.Descriptor instead.
+func (*EstimateBatchResult) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EstimateBatchResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *EstimateBatchResult) GetOutcome() isEstimateBatchResult_Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+func (x *EstimateBatchResult) GetResult() *EstimateSwapResponse {
+	if x != nil {
+		if x, ok := x.Outcome.(*EstimateBatchResult_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *EstimateBatchResult) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Outcome.(*EstimateBatchResult_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isEstimateBatchResult_Outcome interface {
+	isEstimateBatchResult_Outcome()
+}
+
+type EstimateBatchResult_Result struct {
+	Result *EstimateSwapResponse `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+type EstimateBatchResult_Error struct {
+	Error *Error `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*EstimateBatchResult_Result) isEstimateBatchResult_Outcome() {}
+
+func (*EstimateBatchResult_Error) isEstimateBatchResult_Outcome() {}
+
+type EstimateStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Request       *EstimateSwapRequest   `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateStreamRequest) Reset() {
+	*x = EstimateStreamRequest{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateStreamRequest) ProtoMessage() {}
+
+func (x *EstimateStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateStreamRequest.ProtoReflect.Descriptor instead.
+func (*EstimateStreamRequest) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EstimateStreamRequest) GetRequest() *EstimateSwapRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type EstimateStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Outcome:
+	//
+	//	*EstimateStreamResponse_Result
+	//	*EstimateStreamResponse_Error
+	Outcome       isEstimateStreamResponse_Outcome `protobuf_oneof:"outcome"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateStreamResponse) Reset() {
+	*x = EstimateStreamResponse{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateStreamResponse) ProtoMessage() {}
+
+func (x *EstimateStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateStreamResponse.ProtoReflect.Descriptor instead.
+func (*EstimateStreamResponse) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EstimateStreamResponse) GetOutcome() isEstimateStreamResponse_Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+func (x *EstimateStreamResponse) GetResult() *EstimateSwapResponse {
+	if x != nil {
+		if x, ok := x.Outcome.(*EstimateStreamResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *EstimateStreamResponse) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Outcome.(*EstimateStreamResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isEstimateStreamResponse_Outcome interface {
+	isEstimateStreamResponse_Outcome()
+}
+
+type EstimateStreamResponse_Result struct {
+	Result *EstimateSwapResponse `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type EstimateStreamResponse_Error struct {
+	Error *Error `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*EstimateStreamResponse_Result) isEstimateStreamResponse_Outcome() {}
+
+func (*EstimateStreamResponse_Error) isEstimateStreamResponse_Outcome() {}
+
+// Error mirrors models.APIError for per-item failures
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	ErrorCode     string                 `protobuf:"bytes,2,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Details       string                 `protobuf:"bytes,4,opt,name=details,proto3" json:"details,omitempty"`
+	Fields        []*FieldError          `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Error) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Error) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Error) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+func (x *Error) GetFields() []*FieldError {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type FieldError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Rule          string                 `protobuf:"bytes,2,opt,name=rule,proto3" json:"rule,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldError) Reset() {
+	*x = FieldError{}
+	mi := &file_estimator_v1_estimator_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldError) ProtoMessage() {}
+
+func (x *FieldError) ProtoReflect() protoreflect.Message {
+	mi := &file_estimator_v1_estimator_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldError.ProtoReflect.Descriptor instead.
+func (*FieldError) Descriptor() ([]byte, []int) {
+	return file_estimator_v1_estimator_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FieldError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldError) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *FieldError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_estimator_v1_estimator_proto protoreflect.FileDescriptor
+
+const file_estimator_v1_estimator_proto_rawDesc = "" +
+	"\n" +
+	"\x1cestimator/v1/estimator.proto\x12\festimator.v1\"\x96\x01\n" +
+	"\x13EstimateSwapRequest\x12\x12\n" +
+	"\x04pool\x18\x01 \x01(\tR\x04pool\x12\x10\n" +
+	"\x03src\x18\x02 \x01(\tR\x03src\x12\x10\n" +
+	"\x03dst\x18\x03 \x01(\tR\x03dst\x12\x1d\n" +
+	"\n" +
+	"src_amount\x18\x04 \x01(\tR\tsrcAmount\x12\x16\n" +
+	"\x06verify\x18\x05 \x01(\bR\x06verify\x12\x10\n" +
+	"\x03dex\x18\x06 \x01(\tR\x03dex\"\xc3\x04\n" +
+	"\x14EstimateSwapResponse\x12\x1d\n" +
+	"\n" +
+	"dst_amount\x18\x01 \x01(\tR\tdstAmount\x12D\n" +
+	"\fverification\x18\x02 \x01(\v2 .estimator.v1.VerificationResultR\fverification\x12&\n" +
+	"\x0ffee_on_transfer\x18\x03 \x01(\bR\rfeeOnTransfer\x12/\n" +
+	"\x14src_transfer_tax_bps\x18\x04 \x01(\rR\x11srcTransferTaxBps\x12/\n" +
+	"\x14dst_transfer_tax_bps\x18\x05 \x01(\rR\x11dstTransferTaxBps\x12\x1a\n" +
+	"\brebasing\x18\x06 \x01(\bR\brebasing\x12\x1b\n" +
+	"\tpool_type\x18\a \x01(\tR\bpoolType\x12\x1d\n" +
+	"\n" +
+	"src_native\x18\b \x01(\bR\tsrcNative\x12\x1d\n" +
+	"\n" +
+	"dst_native\x18\t \x01(\bR\tdstNative\x12%\n" +
+	"\x0ewrapped_native\x18\n" +
+	" \x01(\tR\rwrappedNative\x126\n" +
+	"\tsrc_token\x18\v \x01(\v2\x19.estimator.v1.ListedTokenR\bsrcToken\x126\n" +
+	"\tdst_token\x18\f \x01(\v2\x19.estimator.v1.ListedTokenR\bdstToken\x12\x12\n" +
+	"\x04pool\x18\r \x01(\tR\x04pool\x12\x1a\n" +
+	"\bwarnings\x18\x0e \x03(\tR\bwarnings\"\xb9\x01\n" +
+	"\vListedToken\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06symbol\x18\x03 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x1a\n" +
+	"\bdecimals\x18\x05 \x01(\rR\bdecimals\x12\x19\n" +
+	"\blogo_uri\x18\x06 \x01(\tR\alogoUri\x12\x12\n" +
+	"\x04list\x18\a \x01(\tR\x04list\"\xcd\x01\n" +
+	"\x12VerificationResult\x12!\n" +
+	"\fblock_number\x18\x01 \x01(\x04R\vblockNumber\x12'\n" +
+	"\x0foffchain_amount\x18\x02 \x01(\tR\x0eoffchainAmount\x12%\n" +
+	"\x0eonchain_amount\x18\x03 \x01(\tR\ronchainAmount\x12\x14\n" +
+	"\x05match\x18\x04 \x01(\bR\x05match\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\"U\n" +
+	"\x14EstimateBatchRequest\x12=\n" +
+	"\brequests\x18\x01 \x03(\v2!.estimator.v1.EstimateSwapRequestR\brequests\"w\n" +
+	"\x15EstimateBatchResponse\x12!\n" +
+	"\fblock_number\x18\x01 \x01(\x04R\vblockNumber\x12;\n" +
+	"\aresults\x18\x02 \x03(\v2!.estimator.v1.EstimateBatchResultR\aresults\"\xa1\x01\n" +
+	"\x13EstimateBatchResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12<\n" +
+	"\x06result\x18\x02 \x01(\v2\".estimator.v1.EstimateSwapResponseH\x00R\x06result\x12+\n" +
+	"\x05error\x18\x03 \x01(\v2\x13.estimator.v1.ErrorH\x00R\x05errorB\t\n" +
+	"\aoutcome\"T\n" +
+	"\x15EstimateStreamRequest\x12;\n" +
+	"\arequest\x18\x01 \x01(\v2!.estimator.v1.EstimateSwapRequestR\arequest\"\x8e\x01\n" +
+	"\x16EstimateStreamResponse\x12<\n" +
+	"\x06result\x18\x01 \x01(\v2\".estimator.v1.EstimateSwapResponseH\x00R\x06result\x12+\n" +
+	"\x05error\x18\x02 \x01(\v2\x13.estimator.v1.ErrorH\x00R\x05errorB\t\n" +
+	"\aoutcome\"\xa0\x01\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x02 \x01(\tR\terrorCode\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x18\n" +
+	"\adetails\x18\x04 \x01(\tR\adetails\x120\n" +
+	"\x06fields\x18\x05 \x03(\v2\x18.estimator.v1.FieldErrorR\x06fields\"P\n" +
+	"\n" +
+	"FieldError\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x12\n" +
+	"\x04rule\x18\x02 \x01(\tR\x04rule\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage2\xa4\x02\n" +
+	"\x10EstimatorService\x12U\n" +
+	"\fEstimateSwap\x12!.estimator.v1.EstimateSwapRequest\x1a\".estimator.v1.EstimateSwapResponse\x12X\n" +
+	"\rEstimateBatch\x12\".estimator.v1.EstimateBatchRequest\x1a#.estimator.v1.EstimateBatchResponse\x12_\n" +
+	"\x0eEstimateStream\x12#.estimator.v1.EstimateStreamRequest\x1a$.estimator.v1.EstimateStreamResponse(\x010\x01B,Z*uniswap-est/proto/estimator/v1;estimatorv1b\x06proto3"
+
+var (
+	file_estimator_v1_estimator_proto_rawDescOnce sync.Once
+	file_estimator_v1_estimator_proto_rawDescData []byte
+)
+
+func file_estimator_v1_estimator_proto_rawDescGZIP() []byte {
+	file_estimator_v1_estimator_proto_rawDescOnce.Do(func() {
+		file_estimator_v1_estimator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_estimator_v1_estimator_proto_rawDesc), len(file_estimator_v1_estimator_proto_rawDesc)))
+	})
+	return file_estimator_v1_estimator_proto_rawDescData
+}
+
+var file_estimator_v1_estimator_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_estimator_v1_estimator_proto_goTypes = []any{
+	(*EstimateSwapRequest)(nil),    // 0: estimator.v1.EstimateSwapRequest
+	(*EstimateSwapResponse)(nil),   // 1: estimator.v1.EstimateSwapResponse
+	(*ListedToken)(nil),            // 2: estimator.v1.ListedToken
+	(*VerificationResult)(nil),     // 3: estimator.v1.VerificationResult
+	(*EstimateBatchRequest)(nil),   // 4: estimator.v1.EstimateBatchRequest
+	(*EstimateBatchResponse)(nil),  // 5: estimator.v1.EstimateBatchResponse
+	(*EstimateBatchResult)(nil),    // 6: estimator.v1.EstimateBatchResult
+	(*EstimateStreamRequest)(nil),  // 7: estimator.v1.EstimateStreamRequest
+	(*EstimateStreamResponse)(nil), // 8: estimator.v1.EstimateStreamResponse
+	(*Error)(nil),                  // 9: estimator.v1.Error
+	(*FieldError)(nil),             // 10: estimator.v1.FieldError
+}
+var file_estimator_v1_estimator_proto_depIdxs = []int32{
+	3,  // 0: estimator.v1.EstimateSwapResponse.verification:type_name -> estimator.v1.VerificationResult
+	2,  // 1: estimator.v1.EstimateSwapResponse.src_token:type_name -> estimator.v1.ListedToken
+	2,  // 2: estimator.v1.EstimateSwapResponse.dst_token:type_name -> estimator.v1.ListedToken
+	0,  // 3: estimator.v1.EstimateBatchRequest.requests:type_name -> estimator.v1.EstimateSwapRequest
+	6,  // 4: estimator.v1.EstimateBatchResponse.results:type_name -> estimator.v1.EstimateBatchResult
+	1,  // 5: estimator.v1.EstimateBatchResult.result:type_name -> estimator.v1.EstimateSwapResponse
+	9,  // 6: estimator.v1.EstimateBatchResult.error:type_name -> estimator.v1.Error
+	0,  // 7: estimator.v1.EstimateStreamRequest.request:type_name -> estimator.v1.EstimateSwapRequest
+	1,  // 8: estimator.v1.EstimateStreamResponse.result:type_name -> estimator.v1.EstimateSwapResponse
+	9,  // 9: estimator.v1.EstimateStreamResponse.error:type_name -> estimator.v1.Error
+	10, // 10: estimator.v1.Error.fields:type_name -> estimator.v1.FieldError
+	0,  // 11: estimator.v1.EstimatorService.EstimateSwap:input_type -> estimator.v1.EstimateSwapRequest
+	4,  // 12: estimator.v1.EstimatorService.EstimateBatch:input_type -> estimator.v1.EstimateBatchRequest
+	7,  // 13: estimator.v1.EstimatorService.EstimateStream:input_type -> estimator.v1.EstimateStreamRequest
+	1,  // 14: estimator.v1.EstimatorService.EstimateSwap:output_type -> estimator.v1.EstimateSwapResponse
+	5,  // 15: estimator.v1.EstimatorService.EstimateBatch:output_type -> estimator.v1.EstimateBatchResponse
+	8,  // 16: estimator.v1.EstimatorService.EstimateStream:output_type -> estimator.v1.EstimateStreamResponse
+	14, // [14:17] is the sub-list for method output_type
+	11, // [11:14] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_estimator_v1_estimator_proto_init() }
+func file_estimator_v1_estimator_proto_init() {
+	if File_estimator_v1_estimator_proto != nil {
+		return
+	}
+	file_estimator_v1_estimator_proto_msgTypes[6].OneofWrappers = []any{
+		(*EstimateBatchResult_Result)(nil),
+		(*EstimateBatchResult_Error)(nil),
+	}
+	file_estimator_v1_estimator_proto_msgTypes[8].OneofWrappers = []any{
+		(*EstimateStreamResponse_Result)(nil),
+		(*EstimateStreamResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_estimator_v1_estimator_proto_rawDesc), len(file_estimator_v1_estimator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_estimator_v1_estimator_proto_goTypes,
+		DependencyIndexes: file_estimator_v1_estimator_proto_depIdxs,
+		MessageInfos:      file_estimator_v1_estimator_proto_msgTypes,
+	}.Build()
+	File_estimator_v1_estimator_proto = out.File
+	file_estimator_v1_estimator_proto_goTypes = nil
+	file_estimator_v1_estimator_proto_depIdxs = nil
+}
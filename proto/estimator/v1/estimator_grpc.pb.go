@@ -0,0 +1,204 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             (unknown)
+// source: estimator/v1/estimator.proto
+
+package estimatorv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EstimatorService_EstimateSwap_FullMethodName   = "/estimator.v1.EstimatorService/EstimateSwap"
+	EstimatorService_EstimateBatch_FullMethodName  = "/estimator.v1.EstimatorService/EstimateBatch"
+	EstimatorService_EstimateStream_FullMethodName = "/estimator.v1.EstimatorService/EstimateStream"
+)
+
+// EstimatorServiceClient is the client API for EstimatorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// EstimatorService quotes swaps off-chain from live pool state.
+// It shares the UniswapService with the REST API; amounts are decimal strings.
+type EstimatorServiceClient interface {
+	// EstimateSwap quotes one swap, like GET /estimate
+	EstimateSwap(ctx context.Context, in *EstimateSwapRequest, opts ...grpc.CallOption) (*EstimateSwapResponse, error)
+	// EstimateBatch quotes several swaps at one block, like POST /estimate/batch
+	EstimateBatch(ctx context.Context, in *EstimateBatchRequest, opts ...grpc.CallOption) (*EstimateBatchResponse, error)
+	// EstimateStream answers every request on the stream with one result, in order
+	EstimateStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EstimateStreamRequest, EstimateStreamResponse], error)
+}
+
+type estimatorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEstimatorServiceClient(cc grpc.ClientConnInterface) EstimatorServiceClient {
+	return &estimatorServiceClient{cc}
+}
+
+func (c *estimatorServiceClient) EstimateSwap(ctx context.Context, in *EstimateSwapRequest, opts ...grpc.CallOption) (*EstimateSwapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateSwapResponse)
+	err := c.cc.Invoke(ctx, EstimatorService_EstimateSwap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *estimatorServiceClient) EstimateBatch(ctx context.Context, in *EstimateBatchRequest, opts ...grpc.CallOption) (*EstimateBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateBatchResponse)
+	err := c.cc.Invoke(ctx, EstimatorService_EstimateBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *estimatorServiceClient) EstimateStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EstimateStreamRequest, EstimateStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EstimatorService_ServiceDesc.Streams[0], EstimatorService_EstimateStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EstimateStreamRequest, EstimateStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EstimatorService_EstimateStreamClient = grpc.BidiStreamingClient[EstimateStreamRequest, EstimateStreamResponse]
+
+// EstimatorServiceServer is the server API for EstimatorService service.
+// All implementations must embed UnimplementedEstimatorServiceServer
+// for forward compatibility.
+//
+// EstimatorService quotes swaps off-chain from live pool state.
+// It shares the UniswapService with the REST API; amounts are decimal strings.
+type EstimatorServiceServer interface {
+	// EstimateSwap quotes one swap, like GET /estimate
+	EstimateSwap(context.Context, *EstimateSwapRequest) (*EstimateSwapResponse, error)
+	// EstimateBatch quotes several swaps at one block, like POST /estimate/batch
+	EstimateBatch(context.Context, *EstimateBatchRequest) (*EstimateBatchResponse, error)
+	// EstimateStream answers every request on the stream with one result, in order
+	EstimateStream(grpc.BidiStreamingServer[EstimateStreamRequest, EstimateStreamResponse]) error
+	mustEmbedUnimplementedEstimatorServiceServer()
+}
+
+// UnimplementedEstimatorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEstimatorServiceServer struct{}
+
+func (UnimplementedEstimatorServiceServer) EstimateSwap(context.Context, *EstimateSwapRequest) (*EstimateSwapResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method EstimateSwap not implemented")
+}
+func (UnimplementedEstimatorServiceServer) EstimateBatch(context.Context, *EstimateBatchRequest) (*EstimateBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method EstimateBatch not implemented")
+}
+func (UnimplementedEstimatorServiceServer) EstimateStream(grpc.BidiStreamingServer[EstimateStreamRequest, EstimateStreamResponse]) error {
+	return status.Error(codes.Unimplemented, "method EstimateStream not implemented")
+}
+func (UnimplementedEstimatorServiceServer) mustEmbedUnimplementedEstimatorServiceServer() {}
+func (UnimplementedEstimatorServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeEstimatorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EstimatorServiceServer will
+// result in compilation errors.
+type UnsafeEstimatorServiceServer interface {
+	mustEmbedUnimplementedEstimatorServiceServer()
+}
+
+func RegisterEstimatorServiceServer(s grpc.ServiceRegistrar, srv EstimatorServiceServer) {
+	// If the following call panics, it indicates UnimplementedEstimatorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EstimatorService_ServiceDesc, srv)
+}
+
+func _EstimatorService_EstimateSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateSwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EstimatorServiceServer).EstimateSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EstimatorService_EstimateSwap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EstimatorServiceServer).EstimateSwap(ctx, req.(*EstimateSwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EstimatorService_EstimateBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EstimatorServiceServer).EstimateBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EstimatorService_EstimateBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EstimatorServiceServer).EstimateBatch(ctx, req.(*EstimateBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EstimatorService_EstimateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EstimatorServiceServer).EstimateStream(&grpc.GenericServerStream[EstimateStreamRequest, EstimateStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EstimatorService_EstimateStreamServer = grpc.BidiStreamingServer[EstimateStreamRequest, EstimateStreamResponse]
+
+// EstimatorService_ServiceDesc is the grpc.ServiceDesc for EstimatorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EstimatorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "estimator.v1.EstimatorService",
+	HandlerType: (*EstimatorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "EstimateSwap",
+			Handler:    _EstimatorService_EstimateSwap_Handler,
+		},
+		{
+			MethodName: "EstimateBatch",
+			Handler:    _EstimatorService_EstimateBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EstimateStream",
+			Handler:       _EstimatorService_EstimateStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "estimator/v1/estimator.proto",
+}
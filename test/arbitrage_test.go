@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+const (
+	forkPair  = "0x9999999999999999999999999999999999999999"
+	thirdPair = "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	thirdCoin = "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func newArbitrageService(t *testing.T, chain *stubChain) *services.ArbitrageService {
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewArbitrageService(blockchain, []string{stubPair, forkPair}, 3, 10)
+}
+
+func TestOptimalArbitrageInputMatchesClosedForm(t *testing.T) {
+	// Buy token1 at 3 per token0 and sell it back at 2 per token0
+	hops := []utils.Hop{
+		{ReserveIn: big.NewInt(1000000), ReserveOut: big.NewInt(3000000)},
+		{ReserveIn: big.NewInt(2000000), ReserveOut: big.NewInt(1000000)},
+	}
+
+	// Two-pool closed form with reserves (a1, b1) then (a2, b2):
+	// (997 * sqrt(a1 * b1 * a2 * b2) - 1000 * a1 * a2) * 1000 / (997 * (1000 * a2 + 997 * b1))
+	root := new(big.Int).Sqrt(new(big.Int).Mul(big.NewInt(3000000000000), big.NewInt(2000000000000)))
+	numerator := new(big.Int).Mul(root, big.NewInt(997))
+	numerator.Sub(numerator, big.NewInt(2000000000000000))
+	numerator.Mul(numerator, big.NewInt(1000))
+	expected := numerator.Div(numerator, big.NewInt(997*(1000*2000000+997*3000000)))
+
+	amountIn := utils.OptimalArbitrageInput(hops)
+	if diff := new(big.Int).Sub(amountIn, expected); diff.CmpAbs(big.NewInt(1)) > 0 {
+		t.Fatalf("Expected optimal input %s, got %s", expected, amountIn)
+	}
+
+	// No other input does better
+	profit := func(amount *big.Int) *big.Int {
+		out, err := utils.CycleAmountOut(amount, hops)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return out.Sub(out, amount)
+	}
+	best := profit(amountIn)
+	for _, delta := range []int64{-1000, -10, 10, 1000} {
+		other := new(big.Int).Add(amountIn, big.NewInt(delta))
+		if profit(other).Cmp(best) > 0 {
+			t.Fatalf("Expected %s to beat %s, got profit %s > %s", amountIn, other, profit(other), best)
+		}
+	}
+
+	// The reverse direction loses money
+	reverse := []utils.Hop{
+		{ReserveIn: big.NewInt(1000000), ReserveOut: big.NewInt(2000000)},
+		{ReserveIn: big.NewInt(3000000), ReserveOut: big.NewInt(1000000)},
+	}
+	if amount := utils.OptimalArbitrageInput(reverse); amount.Sign() != 0 {
+		t.Fatalf("Expected no input for an unprofitable cycle, got %s", amount)
+	}
+}
+
+func TestArbitrageBetweenForks(t *testing.T) {
+	chain := newStubChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(2000000))
+	chain.deployPair(forkPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(3000000))
+
+	response, err := newArbitrageService(t, chain).FindArbitrage(context.Background(), &models.ArbitrageRequest{
+		Start: stubToken0,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(response.Opportunities) != 1 {
+		t.Fatalf("Expected one opportunity, got %+v", response.Opportunities)
+	}
+	opportunity := response.Opportunities[0]
+	if opportunity.Pools[0] != forkPair || opportunity.Pools[1] != stubPair {
+		t.Fatalf("Expected to buy on the fork and sell on the pair, got %v", opportunity.Pools)
+	}
+	if opportunity.AmountIn != "88854" || opportunity.Profit != "19643" {
+		t.Fatalf("Expected 88854 in for 19643 profit, got %s in for %s", opportunity.AmountIn, opportunity.Profit)
+	}
+}
+
+func TestArbitrageTriangularCycle(t *testing.T) {
+	chain := newStubChain()
+	// token0 -> token1 -> coin -> token0 multiplies by 2 * 2 * 0.5 = 2 before fees
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(2000000))
+	chain.deployPair(forkPair, stubToken1, thirdCoin, big.NewInt(1000000), big.NewInt(2000000))
+	chain.deployPair(thirdPair, stubToken0, thirdCoin, big.NewInt(1000000), big.NewInt(2000000))
+
+	response, err := newArbitrageService(t, chain).FindArbitrage(context.Background(), &models.ArbitrageRequest{
+		Pools: stubPair + "," + forkPair + "," + thirdPair + "," + missingPool,
+		Start: stubToken0,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(response.SkippedPools) != 1 || response.SkippedPools[0] != missingPool {
+		t.Fatalf("Expected %s to be skipped, got %v", missingPool, response.SkippedPools)
+	}
+	if len(response.Opportunities) != 1 {
+		t.Fatalf("Expected one opportunity, got %+v", response.Opportunities)
+	}
+
+	opportunity := response.Opportunities[0]
+	expectedPath := []string{stubToken0, stubToken1, thirdCoin, stubToken0}
+	for i, token := range expectedPath {
+		if opportunity.Path[i] != token {
+			t.Fatalf("Expected path %v, got %v", expectedPath, opportunity.Path)
+		}
+	}
+
+	// Limited to two hops the triangle is out of reach
+	response, err = newArbitrageService(t, chain).FindArbitrage(context.Background(), &models.ArbitrageRequest{
+		Pools: stubPair + "," + forkPair + "," + thirdPair, MaxHops: "2",
+	})
+	if err != nil || len(response.Opportunities) != 0 {
+		t.Fatalf("Expected no two-hop opportunities, got %+v (%v)", response.Opportunities, err)
+	}
+}
+
+func TestArbitrageRejectsSinglePool(t *testing.T) {
+	service := newArbitrageService(t, newStubChain())
+
+	_, err := service.FindArbitrage(context.Background(), &models.ArbitrageRequest{Pools: stubPair})
+	if !errors.Is(err, models.ErrInvalidPoolList) {
+		t.Fatalf("Expected INVALID_POOL_LIST, got %v", err)
+	}
+}
+
+func TestArbitrageReportsEachCycleOnce(t *testing.T) {
+	chain := newStubChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(2000000))
+	chain.deployPair(forkPair, stubToken1, thirdCoin, big.NewInt(1000000), big.NewInt(2000000))
+	chain.deployPair(thirdPair, stubToken0, thirdCoin, big.NewInt(1000000), big.NewInt(2000000))
+
+	// Without a start token the triangle is reachable from all three of its tokens
+	response, err := newArbitrageService(t, chain).FindArbitrage(context.Background(), &models.ArbitrageRequest{
+		Pools: stubPair + "," + forkPair + "," + thirdPair,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(response.Opportunities) != 1 {
+		t.Fatalf("Expected the triangle once, got %+v", response.Opportunities)
+	}
+	if start := response.Opportunities[0].StartToken; start != stubToken0 {
+		t.Fatalf("Expected the rotation from the lowest start token %s, got %s", stubToken0, start)
+	}
+}
+
+func TestArbitrageUsesForkFees(t *testing.T) {
+	ethereum, _ := config.KnownChain("ethereum")
+	ethereum.Forks = []config.ForkConfig{{
+		Name:           "sushiswap",
+		FactoryAddress: "0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac",
+		InitCodeHash:   "0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c520e9a4d5cd8b0e73ba6b",
+		FeeBps:         100,
+	}}
+	sushiPair := utils.PairAddress(ethereum.Forks[0].FactoryAddress, ethereum.Forks[0].InitCodeHash, stubToken0, stubToken1)
+
+	chain := newStubChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(2000000))
+	chain.deployPair(sushiPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(3000000))
+
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, ethereum, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	response, err := services.NewArbitrageService(blockchain, nil, 3, 10).FindArbitrage(context.Background(), &models.ArbitrageRequest{
+		Pools: stubPair + "," + sushiPair, Start: stubToken0,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(response.Opportunities) != 1 {
+		t.Fatalf("Expected one opportunity, got %+v", response.Opportunities)
+	}
+
+	// Buying on the 1% fork pair, selling on the 0.3% Uniswap pair
+	hops := []utils.Hop{
+		{ReserveIn: big.NewInt(1000000), ReserveOut: big.NewInt(3000000), FeeBps: 100},
+		{ReserveIn: big.NewInt(2000000), ReserveOut: big.NewInt(1000000), FeeBps: 30},
+	}
+	amountIn := utils.OptimalArbitrageInput(hops)
+	amountOut, _ := utils.CycleAmountOut(amountIn, hops)
+	opportunity := response.Opportunities[0]
+	if opportunity.Pools[0] != sushiPair || opportunity.AmountIn != amountIn.String() || opportunity.AmountOut != amountOut.String() {
+		t.Fatalf("Expected %s in for %s out through %s first, got %+v", amountIn, amountOut, sushiPair, opportunity)
+	}
+	if opportunity.AmountIn == "88854" {
+		t.Fatalf("Expected the fork fee to change the optimal input, got the 0.3%% optimum")
+	}
+}
+
+func TestLoadConfigMaxArbitragePools(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+
+	for _, value := range []string{"0", "-3", "all"} {
+		t.Setenv("MAX_ARBITRAGE_POOLS", value)
+		if _, err := config.LoadConfig(); err == nil || !strings.Contains(err.Error(), "MAX_ARBITRAGE_POOLS") {
+			t.Errorf("Expected MAX_ARBITRAGE_POOLS=%s to be rejected, got %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/grpcapi"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	estimatorv1 "uniswap-est/proto/estimator/v1"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// auditAdminToken is the admin token of the audited test app
+const auditAdminToken = "audit-admin-token-0123456789"
+
+// newAuditedApp serves the estimate and audit routes over the stub pair, recording
+// quotes in an audit log written every 10ms
+func newAuditedApp(t *testing.T) (*fiber.App, *services.Chains, *services.AuditLog) {
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chains := newChains(t, blockchain)
+
+	audit, err := services.OpenAuditLog(filepath.Join(t.TempDir(), "audit.db"), 100, 100, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chains.All()[0].Uniswap.SetAuditLog(audit)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go audit.Run(ctx, 10*time.Millisecond)
+	t.Cleanup(func() {
+		cancel()
+		audit.Close()
+	})
+
+	estimate := handlers.NewEstimateHandler(chains, 5*time.Second, 10)
+	auditHandler := handlers.NewAuditHandler(audit, auditAdminToken, 5*time.Second)
+	app := fiber.New()
+	app.Use(handlers.RequestID())
+	app.Get("/estimate", estimate.EstimateSwap)
+	app.Post("/estimate/batch", estimate.EstimateBatch)
+	app.Get("/audit/quotes/:request_id", auditHandler.Lookup)
+	app.Get("/audit/stats", auditHandler.Stats)
+	return app, chains, audit
+}
+
+// lookupQuotes waits for the quotes of a request ID to be written and returns them
+func lookupQuotes(t *testing.T, app *fiber.App, requestID string, count int) []models.QuoteRecord {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		req := httptest.NewRequest("GET", "/audit/quotes/"+requestID, nil)
+		req.Header.Set("Authorization", "Bearer "+auditAdminToken)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var lookup models.AuditLookupResponse
+		json.NewDecoder(resp.Body).Decode(&lookup)
+		if resp.StatusCode == 200 && len(lookup.Quotes) >= count {
+			return lookup.Quotes
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d quotes under %s, got status %d and %+v", count, requestID, resp.StatusCode, lookup)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuditLogRecordsServedQuotes(t *testing.T) {
+	app, _, audit := newAuditedApp(t)
+
+	req := httptest.NewRequest("GET", "/estimate?pool="+stubPair+"&src="+stubToken0+"&dst="+stubToken1+"&src_amount=1000000000", nil)
+	req.Header.Set("X-Request-ID", "compliance-1")
+	req.Header.Set("User-Agent", "audit-test")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The client's request ID is kept alongside, quotes are recorded under a new one
+	requestID := resp.Header.Get("X-Request-ID")
+	if resp.StatusCode != 200 || requestID == "" || requestID == "compliance-1" {
+		t.Fatalf("Expected 200 with a generated request ID, got %d and %q", resp.StatusCode, requestID)
+	}
+
+	quotes := lookupQuotes(t, app, requestID, 1)
+	quote := quotes[0]
+	expected := models.QuoteRecord{
+		RequestID: requestID, ClientRequestID: "compliance-1", Item: 0, Time: quote.Time, Requester: quote.Requester,
+		UserAgent: "audit-test", Transport: services.TransportHTTP, Chain: "ethereum",
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, RequestedSrc: stubToken0, RequestedDst: stubToken1,
+		SrcAmount: "1000000000", BlockNumber: 1000, PoolType: models.PoolTypeV2, FeeModel: "30 bps",
+		Reserves: []string{"100000000000", "50000000000000000000"}, DstAmount: "493579017198530649",
+	}
+	if !reflect.DeepEqual(quotes, []models.QuoteRecord{expected}) {
+		t.Fatalf("Expected %+v, got %+v", expected, quotes)
+	}
+	if time.Since(quote.Time) > time.Minute || quote.Requester == "" {
+		t.Errorf("Expected the time and address of the request, got %v and %q", quote.Time, quote.Requester)
+	}
+
+	// Batch items keep their position; failed and invalid items are not recorded
+	body := `{"requests":[
+		{"pool":"` + stubPair + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1000000000"},
+		{"pool":"` + missingPool + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1"},
+		{"pool":"not-an-address","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1"},
+		{"pool":"` + stubPair + `","src":"` + stubToken1 + `","dst":"` + stubToken0 + `","src_amount":"1000000000000000000"}
+	]}`
+	req = httptest.NewRequest("POST", "/estimate/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	requestID = resp.Header.Get("X-Request-ID")
+	if requestID == "" {
+		t.Fatal("Expected a generated request ID")
+	}
+
+	quotes = lookupQuotes(t, app, requestID, 2)
+	if len(quotes) != 2 || quotes[0].Item != 0 || quotes[1].Item != 3 || quotes[1].Src != stubToken1 {
+		t.Fatalf("Expected items 0 and 3, got %+v", quotes)
+	}
+
+	for _, unknown := range []string{"unknown", "compliance-1"} {
+		req = httptest.NewRequest("GET", "/audit/quotes/"+unknown, nil)
+		req.Header.Set("Authorization", "Bearer "+auditAdminToken)
+		resp, _ = app.Test(req)
+		if resp.StatusCode != 404 {
+			t.Errorf("Expected 404 for request ID %s, got %d", unknown, resp.StatusCode)
+		}
+	}
+	if stats := audit.Stats(); stats.Written != 3 || stats.Dropped != 0 || stats.Failed != 0 || stats.Pending != 0 {
+		t.Errorf("Expected 3 written records, got %+v", stats)
+	}
+}
+
+func TestAuditLogRecordsGRPCCalls(t *testing.T) {
+	app, chains, _ := newAuditedApp(t)
+
+	server, _ := grpcapi.NewGRPCServer(grpcapi.NewServer(chains, 5*time.Second, 10))
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "grpc-1")
+	_, err = estimatorv1.NewEstimatorServiceClient(conn).EstimateSwap(ctx, &estimatorv1.EstimateSwapRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := header.Get("x-request-id")
+	if len(got) != 1 || got[0] == "" || got[0] == "grpc-1" {
+		t.Fatalf("Expected a generated request ID in the header, got %v", got)
+	}
+
+	quotes := lookupQuotes(t, app, got[0], 1)
+	if quotes[0].Transport != services.TransportGRPC || quotes[0].ClientRequestID != "grpc-1" ||
+		quotes[0].DstAmount != "493579017198530649" {
+		t.Errorf("Expected the gRPC quote with the client's request ID, got %+v", quotes[0])
+	}
+}
+
+func TestAuditLogRecordsStreamUpdates(t *testing.T) {
+	app, chains, _ := newAuditedApp(t)
+	hub := chains.All()[0].Quotes
+
+	// Updates are recorded for each subscriber they are sent to, numbered by position;
+	// the failed quote of the missing pool is not recorded
+	subscriptions := []models.EstimateRequest{
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"},
+		{Pool: missingPool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1"},
+		{Pool: stubPair, Src: stubToken1, Dst: stubToken0, SrcAmount: "1000000000000000000"},
+	}
+	first := hub.Subscribe(subscriptions, services.Requester{
+		RequestID: "stream-1", Address: "192.0.2.1", UserAgent: "stream-test", Transport: services.TransportHTTP,
+	})
+	defer first.Close()
+	second := hub.Subscribe(subscriptions[:1], services.Requester{RequestID: "stream-2", Transport: services.TransportHTTP})
+	defer second.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	waitForBlock(t, first, 3, 1000)
+	waitForBlock(t, second, 1, 1000)
+
+	quotes := lookupQuotes(t, app, "stream-1", 2)
+	if len(quotes) != 2 || quotes[0].Item != 0 || quotes[1].Item != 2 {
+		t.Fatalf("Expected items 0 and 2 recorded, got %+v", quotes)
+	}
+	if quotes[0].Requester != "192.0.2.1" || quotes[0].UserAgent != "stream-test" || quotes[0].BlockNumber != 1000 ||
+		quotes[0].DstAmount != "493579017198530649" || quotes[0].PoolType != models.PoolTypeV2 {
+		t.Errorf("Expected the subscriber's address and user agent with the quote, got %+v", quotes[0])
+	}
+	if quotes := lookupQuotes(t, app, "stream-2", 1); len(quotes) != 1 || quotes[0].Item != 0 {
+		t.Errorf("Expected the shared quote recorded for the second subscriber, got %+v", quotes)
+	}
+}
+
+func TestAuditLogCountsDroppedAndFailedWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	audit, err := services.OpenAuditLog(path, 10, 2, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Nothing writes yet, so only two records fit in the queue
+	for i := 0; i < 5; i++ {
+		audit.Record(models.QuoteRecord{RequestID: "full", Item: i, Time: time.Now()})
+	}
+	if stats := audit.Stats(); stats.Dropped != 3 || stats.Pending != 2 {
+		t.Fatalf("Expected 3 dropped and 2 pending records, got %+v", stats)
+	}
+
+	// A write that fails loses its batch, counted
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := db.Exec("DROP TABLE quotes"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	audit.Run(ctx, time.Hour) // writes the queue on the way out
+	audit.Close()
+
+	if stats := audit.Stats(); stats.Failed != 2 || stats.Written != 0 || stats.Pending != 0 {
+		t.Errorf("Expected 2 failed records, got %+v", stats)
+	}
+}
+
+func TestAuditLogCloseWritesQueueBeforeRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	audit, err := services.OpenAuditLog(path, 10, 10, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A shutdown before the goroutine of Run got to start
+	for i := 0; i < 3; i++ {
+		audit.Record(models.QuoteRecord{RequestID: "early", Item: i, Time: time.Now()})
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	audit.Run(context.Background(), time.Hour) // returns at once on a closed log
+
+	if stats := audit.Stats(); stats.Written != 3 || stats.Pending != 0 {
+		t.Fatalf("Expected the 3 queued records written by Close, got %+v", stats)
+	}
+	reopened, err := services.OpenAuditLog(path, 10, 10, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer reopened.Close()
+	if records, _ := reopened.Lookup(context.Background(), "early"); len(records) != 3 {
+		t.Errorf("Expected 3 records on disk, got %+v", records)
+	}
+}
+
+func TestAuditLogExportAndPrune(t *testing.T) {
+	audit, err := services.OpenAuditLog(filepath.Join(t.TempDir(), "audit.db"), 10, 10, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	day := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		audit.Record(models.QuoteRecord{
+			RequestID: "day-" + string(rune('a'+i)), Time: day.AddDate(0, 0, i), Transport: services.TransportHTTP,
+			Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000", BlockNumber: uint64(100 + i),
+			PoolType: models.PoolTypeV2, FeeModel: "30 bps", Reserves: []string{"1", "2"}, DstAmount: "996",
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	audit.Run(ctx, time.Hour)
+	defer audit.Close()
+
+	var out strings.Builder
+	count, err := audit.Export(context.Background(), &out, day.AddDate(0, 0, 1), time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if count != 2 || len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 records from the second day, got %d records:\n%s", count, out.String())
+	}
+	if !strings.HasPrefix(lines[0], "request_id,client_request_id,item,time,") ||
+		!strings.HasPrefix(lines[1], "day-b,,0,2026-09-02T12:00:00Z,") || !strings.Contains(lines[1], ",30 bps,1 2,996") {
+		t.Errorf("Expected CSV records oldest first, got:\n%s", out.String())
+	}
+
+	pruned, err := audit.Prune(context.Background(), day.AddDate(0, 0, 2))
+	if err != nil || pruned != 2 {
+		t.Fatalf("Expected 2 records pruned, got %d, %v", pruned, err)
+	}
+	if records, _ := audit.Lookup(context.Background(), "day-c"); len(records) != 1 {
+		t.Errorf("Expected the latest record kept, got %+v", records)
+	}
+}
+
+func TestAuditLookupNeedsAdminToken(t *testing.T) {
+	app, _, _ := newAuditedApp(t)
+	refused := fiber.New()
+	refused.Get("/audit/quotes/:request_id", handlers.NewAuditHandler(nil, "", time.Second).Lookup)
+
+	for _, tc := range []struct {
+		app           *fiber.App
+		authorization string
+		status        int
+	}{
+		{app, "", 401},
+		{app, "Bearer wrong-token-0123456789", 401},
+		{app, auditAdminToken, 401},
+		{app, "Bearer " + auditAdminToken, 404},
+		{refused, "Bearer ", 401}, // no token configured
+	} {
+		req := httptest.NewRequest("GET", "/audit/quotes/unknown", nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		resp, err := tc.app.Test(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var apiErr models.APIError
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if resp.StatusCode != tc.status {
+			t.Errorf("Expected %d for %q, got %d %s", tc.status, tc.authorization, resp.StatusCode, apiErr.ErrorCode)
+		}
+		if tc.status == 401 && apiErr.ErrorCode != models.CodeUnauthorized {
+			t.Errorf("Expected %s for %q, got %s", models.CodeUnauthorized, tc.authorization, apiErr.ErrorCode)
+		}
+	}
+}
+
+func TestLoadConfigAuditAdminToken(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+
+	t.Setenv("AUDIT_ADMIN_TOKEN", auditAdminToken)
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg.AuditAdminToken != auditAdminToken {
+		t.Fatalf("Expected the admin token, got %+v (%v)", cfg, err)
+	}
+
+	t.Setenv("AUDIT_ADMIN_TOKEN", "short")
+	if _, err := config.LoadConfig(); err == nil || !strings.Contains(err.Error(), "AUDIT_ADMIN_TOKEN") {
+		t.Errorf("Expected a short admin token to be rejected, got %v", err)
+	}
+}
+
+func TestAuditDisabled(t *testing.T) {
+	app := fiber.New()
+	app.Get("/audit/quotes/:request_id", handlers.NewAuditHandler(nil, auditAdminToken, time.Second).Lookup)
+
+	req := httptest.NewRequest("GET", "/audit/quotes/abc", nil)
+	req.Header.Set("Authorization", "Bearer "+auditAdminToken)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var apiErr models.APIError
+	json.NewDecoder(resp.Body).Decode(&apiErr)
+	if resp.StatusCode != 404 || apiErr.ErrorCode != models.CodeAuditDisabled {
+		t.Errorf("Expected 404 %s, got %d %s", models.CodeAuditDisabled, resp.StatusCode, apiErr.ErrorCode)
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Expected values are checked against exact decimal arithmetic: LogExpMath agrees to
+// well within its 1e-14 relative error bound, and swaps round in favour of the pool
+
+const (
+	balancerPool  = "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb01"
+	balancerVault = "0xba12222222228d8ba445958a75a0704d566bf2c8"
+)
+
+func TestLogExpPow(t *testing.T) {
+	tests := []struct {
+		x, y     string
+		expected string // x^y to 18 decimals, rounded down
+	}{
+		{"2000000000000000000", "500000000000000000", "1414213562373095048"},
+		{"1500000000000000000", "3300000000000000000", "3811545907166887148"},
+		{"987654321000000000", "4000000000000000000", "951524275264729222"},
+		{"1000000000000000", "250000000000000000", "177827941003892280"},
+	}
+
+	tolerance := big.NewInt(1e4) // 1e-14 of 1e18
+	for _, tt := range tests {
+		result, err := utils.LogExpPow(bigInt(tt.x), bigInt(tt.y))
+		if err != nil {
+			t.Fatalf("%s^%s: expected no error, got %v", tt.x, tt.y, err)
+		}
+		expected := bigInt(tt.expected)
+		diff := new(big.Int).Sub(result, expected)
+		if diff.Abs(diff).Mul(diff, expandTo18Decimals(1)).Cmp(new(big.Int).Mul(tolerance, expected)) > 0 {
+			t.Fatalf("%s^%s: expected about %s, got %s", tt.x, tt.y, tt.expected, result)
+		}
+	}
+
+	if _, err := utils.LogExpPow(big.NewInt(-1), expandTo18Decimals(1)); err == nil {
+		t.Fatal("Expected an error for a negative base")
+	}
+}
+
+// 80/20 pool of 1000 MKR (18 decimals) and 2,000,000 USDT (6 decimals) with a 0.3% fee
+var (
+	weightedBalances = []*big.Int{expandTo18Decimals(1000), big.NewInt(2000000000000)}
+	weightedWeights  = []*big.Int{bigInt("800000000000000000"), bigInt("200000000000000000")}
+	weightedScaling  = []*big.Int{big.NewInt(1), big.NewInt(1000000000000)}
+	weightedFee      = bigInt("3000000000000000")
+)
+
+func TestBalancerSwapGivenIn(t *testing.T) {
+	// Exactly 77810.94231152 USDT for 10 MKR
+	amountOut, err := utils.BalancerSwapGivenIn(expandTo18Decimals(10),
+		weightedBalances[0], weightedWeights[0], weightedScaling[0],
+		weightedBalances[1], weightedWeights[1], weightedScaling[1], weightedFee)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if amountOut.String() != "77810942311" {
+		t.Fatalf("Expected 77810942311, got %s", amountOut)
+	}
+
+	// More than 30% of the input balance is refused
+	_, err = utils.BalancerSwapGivenIn(expandTo18Decimals(400),
+		weightedBalances[0], weightedWeights[0], weightedScaling[0],
+		weightedBalances[1], weightedWeights[1], weightedScaling[1], weightedFee)
+	if !errors.Is(err, utils.ErrMaxInRatio) {
+		t.Fatalf("Expected ErrMaxInRatio, got %v", err)
+	}
+}
+
+func TestBalancerSwapGivenOut(t *testing.T) {
+	// Exactly 12.944729167462554870 MKR for 100,000 USDT, rounded up
+	amountIn, err := utils.BalancerSwapGivenOut(big.NewInt(100000000000),
+		weightedBalances[0], weightedWeights[0], weightedScaling[0],
+		weightedBalances[1], weightedWeights[1], weightedScaling[1], weightedFee)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if amountIn.String() != "12944729167472715146" {
+		t.Fatalf("Expected 12944729167472715146, got %s", amountIn)
+	}
+
+	// More than 30% of the output balance is refused
+	_, err = utils.BalancerSwapGivenOut(big.NewInt(700000000000),
+		weightedBalances[0], weightedWeights[0], weightedScaling[0],
+		weightedBalances[1], weightedWeights[1], weightedScaling[1], weightedFee)
+	if !errors.Is(err, utils.ErrMaxOutRatio) {
+		t.Fatalf("Expected ErrMaxOutRatio, got %v", err)
+	}
+}
+
+func TestEstimateDetectsBalancerPool(t *testing.T) {
+	var poolID [32]byte
+	copy(poolID[:], common.HexToAddress(balancerPool).Bytes())
+
+	chain := newMetadataChain()
+	chain.deploy(balancerPool, stubContract{
+		"getPoolId()":            abiEncode([]string{"bytes32"}, poolID),
+		"getVault()":             encodeAddress(balancerVault),
+		"getNormalizedWeights()": abiEncode([]string{"uint256[]"}, weightedWeights),
+		"getSwapFeePercentage()": abiEncode([]string{"uint256"}, weightedFee),
+	})
+	chain.deploy(balancerVault, stubContract{
+		withArgs("getPoolTokens(bytes32)", []string{"bytes32"}, poolID): abiEncode(
+			[]string{"address[]", "uint256[]", "uint256"},
+			[]common.Address{common.HexToAddress(stubToken1), common.HexToAddress(stubToken0)},
+			weightedBalances, big.NewInt(900)),
+	})
+	service := newStableEstimateService(t, chain)
+
+	response, err := service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: balancerPool, Src: stubToken1, Dst: stubToken0, SrcAmount: expandTo18Decimals(10).String(),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.PoolType != models.PoolTypeBalancerWeighted || response.DstAmount != "77810942311" {
+		t.Fatalf("Expected a weighted quote of 77810942311, got %s %s", response.PoolType, response.DstAmount)
+	}
+
+	// Pool and tokens, then one read probing all pool types, the Vault and the decimals
+	if chain.batches != 4 {
+		t.Fatalf("Expected 4 batched reads, got %d", chain.batches)
+	}
+
+	// Swaps over the max in ratio cannot be quoted
+	_, err = service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: balancerPool, Src: stubToken1, Dst: stubToken0, SrcAmount: expandTo18Decimals(400).String(),
+	})
+	if !errors.Is(err, models.ErrInsufficientLiquidity) {
+		t.Fatalf("Expected INSUFFICIENT_LIQUIDITY, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	stubPair    = "0x6666666666666666666666666666666666666666"
+	stubToken0  = standardToken
+	stubToken1  = bytes32Token
+	missingPool = "0x7777777777777777777777777777777777777777"
+)
+
+func newBatchApp(t *testing.T) (*fiber.App, *stubChain) {
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	handler := handlers.NewEstimateHandler(newChains(t, blockchain), 5*time.Second, 10)
+
+	app := fiber.New()
+	app.Post("/estimate", handler.EstimateSwap)
+	app.Post("/estimate/batch", handler.EstimateBatch)
+	return app, chain
+}
+
+func TestEstimatePostJSON(t *testing.T) {
+	app, _ := newBatchApp(t)
+
+	body := `{"pool":"` + stubPair + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1000000000"}`
+	req := httptest.NewRequest("POST", "/estimate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result models.EstimateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Expected JSON response, got %v", err)
+	}
+
+	// 1000 USDT into 100k USDT / 50 ETH pool
+	if result.DstAmount != "493579017198530649" {
+		t.Fatalf("Expected dst_amount 493579017198530649, got %s", result.DstAmount)
+	}
+}
+
+func TestEstimateBatch(t *testing.T) {
+	app, chain := newBatchApp(t)
+
+	body := `{"requests":[
+		{"pool":"` + stubPair + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1000000000"},
+		{"pool":"` + stubPair + `","src":"` + stubToken1 + `","dst":"` + stubToken0 + `","src_amount":"1000000000000000000"},
+		{"pool":"` + missingPool + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1"},
+		{"pool":"not-an-address","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1"}
+	]}`
+	req := httptest.NewRequest("POST", "/estimate/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var batch models.BatchEstimateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		t.Fatalf("Expected JSON response, got %v", err)
+	}
+
+	if len(batch.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(batch.Results))
+	}
+	for i, result := range batch.Results {
+		if result.Index != i {
+			t.Fatalf("Expected result %d to keep its index, got %d", i, result.Index)
+		}
+	}
+
+	if batch.Results[0].Result == nil || batch.Results[1].Result == nil {
+		t.Fatalf("Expected first two items to succeed, got %+v", batch.Results[:2])
+	}
+	if batch.Results[2].Error == nil || batch.Results[2].Error.Code != 404 {
+		t.Fatalf("Expected pool not found for item 2, got %+v", batch.Results[2])
+	}
+	if batch.Results[3].Error == nil || batch.Results[3].Error.Code != 400 {
+		t.Fatalf("Expected validation error for item 3, got %+v", batch.Results[3])
+	}
+
+	// 2 unique pools x 3 calls + 2 unique tokens x 3 calls, then stable() probing the
+	// pair, which is not the pair of a V2 factory; the missing pool has no code to probe
+	if chain.calls != 13 {
+		t.Fatalf("Expected shared pools and tokens to be fetched once (13 calls), got %d", chain.calls)
+	}
+}
+
+func TestLoadConfigMaxBatchSize(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+
+	t.Setenv("MAX_BATCH_SIZE", "20")
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg.MaxBatchSize != 20 {
+		t.Fatalf("Expected a batch size of 20, got %v (%v)", cfg, err)
+	}
+
+	for _, value := range []string{"0", "-5", "fifty"} {
+		t.Setenv("MAX_BATCH_SIZE", value)
+		if _, err := config.LoadConfig(); err == nil || !strings.Contains(err.Error(), "MAX_BATCH_SIZE") {
+			t.Errorf("Expected MAX_BATCH_SIZE=%s to be rejected, got %v", value, err)
+		}
+	}
+}
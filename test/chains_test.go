@@ -0,0 +1,252 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testServiceConfig holds the service limits the tests build chains with
+func testServiceConfig() *config.Config {
+	return &config.Config{
+		RequestTimeout:     5 * time.Second,
+		StreamPollInterval: time.Second,
+		MaxHistoryPoints:   100,
+		MaxArbitrageHops:   3,
+		MaxArbitragePools:  10,
+	}
+}
+
+// newChains registers the services of one blockchain service as the only chain
+func newChains(t *testing.T, blockchain *services.BlockchainService) *services.Chains {
+	return services.NewChains(services.NewChain(testServiceConfig(), blockchain, services.NewReserveCache(t.TempDir()), nil))
+}
+
+// newStubChainService connects a blockchain service for a chain preset to a stub chain
+func newStubChainService(t *testing.T, name string, client *stubChain) *services.BlockchainService {
+	chain, ok := config.KnownChain(name)
+	if !ok {
+		t.Fatalf("Expected a preset for %s", name)
+	}
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, chain, client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return blockchain
+}
+
+// newTwoChains registers ethereum (default) and bsc, both serving the same pair with
+// the same reserves so that only their fees differ
+func newTwoChains(t *testing.T) *services.Chains {
+	cache := services.NewReserveCache(t.TempDir())
+	var chains []*services.Chain
+	for _, name := range []string{"ethereum", "bsc"} {
+		client := newMetadataChain()
+		reserve1 := new(big.Int)
+		reserve1.SetString("50000000000000000000", 10)
+		client.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+		chains = append(chains, services.NewChain(testServiceConfig(), newStubChainService(t, name, client), cache, nil))
+	}
+	return services.NewChains(chains...)
+}
+
+func TestChainsGet(t *testing.T) {
+	chains := newTwoChains(t)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"", "ethereum"},
+		{"ethereum", "ethereum"},
+		{"BSC", "bsc"},
+		{"56", "bsc"},
+		{" 1 ", "ethereum"},
+	}
+	for _, tt := range tests {
+		chain, err := chains.Get(tt.name)
+		if err != nil {
+			t.Fatalf("%q: expected no error, got %v", tt.name, err)
+		}
+		if chain.Config.Name != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.name, tt.expected, chain.Config.Name)
+		}
+	}
+
+	_, err := chains.Get("polygon")
+	if !errors.Is(err, models.ErrUnsupportedChain) {
+		t.Fatalf("Expected UNSUPPORTED_CHAIN, got %v", err)
+	}
+}
+
+func TestCheckChainID(t *testing.T) {
+	client := newStubChain()
+	if err := newStubChainService(t, "ethereum", client).CheckChainID(context.Background()); err != nil {
+		t.Fatalf("Expected chain ID 1 to match, got %v", err)
+	}
+
+	// A BSC endpoint configured for Base must be refused
+	client.chainID = 56
+	err := newStubChainService(t, "base", client).CheckChainID(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "chain ID 56, expected 8453") {
+		t.Fatalf("Expected a chain ID mismatch, got %v", err)
+	}
+}
+
+func TestCalculateAmountOutWithFee(t *testing.T) {
+	amountIn := big.NewInt(1000000000)
+	reserveIn := big.NewInt(100000000000)
+	reserveOut := expandTo18Decimals(50)
+
+	// 30 bps is the Uniswap V2 formula
+	standard, _ := utils.CalculateAmountOut(amountIn, reserveIn, reserveOut)
+	got, err := utils.CalculateAmountOutWithFee(amountIn, reserveIn, reserveOut, 30)
+	if err != nil || got.Cmp(standard) != 0 {
+		t.Errorf("Expected the 0.3%% fee to match CalculateAmountOut, got %v, %v", got, err)
+	}
+
+	// PancakeSwap: 1e9 * 9975 * 50e18 / (1e11 * 10000 + 1e9 * 9975)
+	expected := bigInt("493824104557043491")
+	got, err = utils.CalculateAmountOutWithFee(amountIn, reserveIn, reserveOut, 25)
+	if err != nil || got.Cmp(expected) != 0 {
+		t.Errorf("Expected %s with a 0.25%% fee, got %v, %v", expected, got, err)
+	}
+
+	if _, err := utils.CalculateAmountOutWithFee(amountIn, reserveIn, reserveOut, 10000); err == nil {
+		t.Error("Expected a 100% fee to be rejected")
+	}
+}
+
+func TestEstimateUsesChainFee(t *testing.T) {
+	chains := newTwoChains(t)
+	app := fiber.New()
+	app.Get("/estimate", handlers.NewEstimateHandler(chains, 5*time.Second, 10).EstimateSwap)
+
+	quote := func(chain string) models.EstimateResponse {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", "/estimate?chain="+chain+"&pool="+stubPair+
+			"&src="+stubToken0+"&dst="+stubToken1+"&src_amount=1000000000", nil))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected 200 on %s, got %d: %s", chain, resp.StatusCode, body)
+		}
+		var response models.EstimateResponse
+		json.Unmarshal(body, &response)
+		return response
+	}
+
+	if got := quote("ethereum").DstAmount; got != "493579017198530649" {
+		t.Errorf("Expected the 0.3%% fee on ethereum, got %s", got)
+	}
+	if got := quote("bsc").DstAmount; got != "493824104557043491" {
+		t.Errorf("Expected the 0.25%% fee on bsc, got %s", got)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/estimate?chain=solana&pool="+stubPair+
+		"&src="+stubToken0+"&dst="+stubToken1+"&src_amount=1000000000", nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var apiErr models.APIError
+	json.NewDecoder(resp.Body).Decode(&apiErr)
+	if resp.StatusCode != fiber.StatusBadRequest || apiErr.ErrorCode != models.CodeUnsupportedChain {
+		t.Fatalf("Expected 400 UNSUPPORTED_CHAIN, got %d %s", resp.StatusCode, apiErr.ErrorCode)
+	}
+}
+
+func TestBatchRejectsItemOnOtherChain(t *testing.T) {
+	app := fiber.New()
+	app.Post("/estimate/batch", handlers.NewEstimateHandler(newTwoChains(t), 5*time.Second, 10).EstimateBatch)
+
+	item := `"pool":"` + stubPair + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1000000000"`
+	body := `{"chain":"bsc","requests":[{` + item + `},{"chain":"56",` + item + `},{"chain":"ethereum",` + item + `}]}`
+	req := httptest.NewRequest("POST", "/estimate/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var response models.BatchEstimateResponse
+	json.NewDecoder(resp.Body).Decode(&response)
+
+	if len(response.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(response.Results))
+	}
+	for i := 0; i < 2; i++ {
+		if response.Results[i].Result == nil || response.Results[i].Result.DstAmount != "493824104557043491" {
+			t.Errorf("Expected item %d quoted on bsc, got %+v", i, response.Results[i])
+		}
+	}
+	if response.Results[2].Error == nil || response.Results[2].Error.ErrorCode != models.CodeUnsupportedChain {
+		t.Errorf("Expected item 2 to fail with UNSUPPORTED_CHAIN, got %+v", response.Results[2])
+	}
+}
+
+func TestReserveCacheSeparatesChains(t *testing.T) {
+	dir := t.TempDir()
+	cache := services.NewReserveCache(dir)
+
+	reserves := &models.PoolReserves{Reserve0: big.NewInt(1), Reserve1: big.NewInt(2), BlockTime: 3}
+	if err := cache.Put(1, stubPair, map[uint64]*models.PoolReserves{100: reserves}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := cache.Get(56, stubPair, 100); ok {
+		t.Fatal("Expected the same pool address on another chain to miss")
+	}
+	if got, ok := cache.Get(1, stubPair, 100); !ok || got.Reserve1.Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("Expected a hit on chain 1, got %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1", stubPair+".json")); err != nil {
+		t.Fatalf("Expected the pool file under the chain ID directory, got %v", err)
+	}
+}
+
+func TestLoadConfigLegacyRouterAddress(t *testing.T) {
+	const legacy = "0x1111111111111111111111111111111111111111"
+	const perChain = "0x2222222222222222222222222222222222222222"
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+	t.Setenv("UNISWAP_ROUTER_ADDRESS", legacy)
+
+	tests := []struct {
+		name     string
+		router   string
+		expected string
+	}{
+		{"legacy name alone", "", legacy},
+		{"per-chain name wins", perChain, perChain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ETHEREUM_ROUTER_ADDRESS", tt.router)
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			ethereum, _ := cfg.Chain("ethereum")
+			if !strings.EqualFold(ethereum.RouterAddress, tt.expected) {
+				t.Fatalf("Expected router %s, got %s", tt.expected, ethereum.RouterAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+func TestPairAddress(t *testing.T) {
+	ethereum, _ := config.KnownChain("ethereum")
+	bsc, _ := config.KnownChain("bsc")
+	polygon, _ := config.KnownChain("polygon")
+	uniswap, _ := ethereum.Fork("")
+	pancakeswap, _ := bsc.Fork("")
+	quickswap, ok := polygon.Fork("quickswap")
+	if !ok {
+		t.Fatal("Expected the quickswap deployment on polygon")
+	}
+
+	const (
+		usdc = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	)
+	tests := []struct {
+		fork     config.ForkConfig
+		tokenA   string
+		tokenB   string
+		expected string
+	}{
+		{uniswap, usdc, weth, "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"},
+		{uniswap, weth, usdc, "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"}, // token order does not matter
+		{uniswap, usdtChecksummed, weth, usdtWethPair},
+		// WBNB/BUSD and WMATIC/USDC.e
+		{pancakeswap, "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c", "0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56", "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16"},
+		{quickswap, "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270", "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", "0x6e7a5fafcec6bb1e78bae2a1f0b612012bf14827"},
+	}
+	for _, tt := range tests {
+		if got := utils.PairAddress(tt.fork.FactoryAddress, tt.fork.InitCodeHash, tt.tokenA, tt.tokenB); got != tt.expected {
+			t.Errorf("%s pair of %s and %s: expected %s, got %s", tt.fork.Name, tt.tokenA, tt.tokenB, tt.expected, got)
+		}
+	}
+}
+
+// uniswapPair returns the Uniswap V2 pair of the stub tokens on ethereum, a pair whose
+// type needs no probing
+func uniswapPair() string {
+	ethereum, _ := config.KnownChain("ethereum")
+	return utils.PairAddress(ethereum.FactoryAddress, ethereum.InitCodeHash, stubToken0, stubToken1)
+}
+
+// newDerivedPairService deploys the stub pair at its CREATE2 address on ethereum, where
+// a second deployment has no pair of the stub tokens
+func newDerivedPairService(t *testing.T) (*services.UniswapService, *stubChain, string) {
+	ethereum, _ := config.KnownChain("ethereum")
+	ethereum.Forks = []config.ForkConfig{{
+		Name:           "sushiswap",
+		FactoryAddress: "0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac",
+		InitCodeHash:   "0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c520e9a4d5cd8b0e73ba6b",
+	}}
+	derived := utils.PairAddress(ethereum.FactoryAddress, ethereum.InitCodeHash, stubToken0, stubToken1)
+
+	chain := newMetadataChain()
+	chain.deployPair(derived, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, ethereum, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewUniswapService(blockchain, nil, nil), chain, derived
+}
+
+func TestEstimateDerivesOmittedPool(t *testing.T) {
+	uniswap, chain, derived := newDerivedPairService(t)
+
+	response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Pool != derived || response.DstAmount != "493579017198530649" || len(response.Warnings) != 0 {
+		t.Fatalf("Expected a quote on the derived pool %s, got %+v", derived, response)
+	}
+
+	// The sushiswap pair of the tokens was never created
+	_, err = uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Dex: "sushiswap",
+	})
+	if !errors.Is(err, models.ErrPoolNotFound) || !strings.Contains(models.AsAPIError(err).Details, "has not been created") {
+		t.Fatalf("Expected POOL_NOT_FOUND for an uncreated pair, got %v", err)
+	}
+
+	_, err = uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Dex: "curve",
+	})
+	if !errors.Is(err, models.ErrValidationFailed) {
+		t.Fatalf("Expected a validation error for an unknown dex, got %v", err)
+	}
+
+	// In a batch, only the item of the missing pair fails; both pairs are checked in
+	// the batched read
+	batches := chain.batches
+	batch, err := uniswap.EstimateBatch(context.Background(), []models.EstimateRequest{
+		{Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"},
+		{Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Dex: "sushiswap"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if batch.Results[0].Result == nil || batch.Results[0].Result.Pool != derived {
+		t.Errorf("Expected item 0 quoted on %s, got %+v", derived, batch.Results[0])
+	}
+	if batch.Results[1].Error == nil || batch.Results[1].Error.ErrorCode != models.CodePoolNotFound {
+		t.Errorf("Expected item 1 to fail with POOL_NOT_FOUND, got %+v", batch.Results[1])
+	}
+	if reads := chain.batches - batches; reads != 1 {
+		t.Errorf("Expected one batched read, got %d", reads)
+	}
+}
+
+func TestEstimateWarnsOnNonCanonicalPool(t *testing.T) {
+	uniswap, _, derived := newDerivedPairService(t)
+
+	response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(response.Warnings) != 1 || !strings.Contains(response.Warnings[0], derived) || response.Pool != "" {
+		t.Fatalf("Expected a warning naming the canonical pair %s, got %+v", derived, response)
+	}
+
+	response, err = uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: derived, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(response.Warnings) != 0 {
+		t.Fatalf("Expected no warning for the canonical pair, got %v", response.Warnings)
+	}
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPIErrorWrapping(t *testing.T) {
+	err := fmt.Errorf("fetching reserves: %w", models.ErrBlockchainConnection.WithCause(context.DeadlineExceeded))
+
+	if !errors.Is(err, models.ErrBlockchainConnection) {
+		t.Fatalf("Expected wrapped error to match ErrBlockchainConnection")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected wrapped error to expose its cause")
+	}
+	if errors.Is(err, models.ErrPoolNotFound) {
+		t.Fatalf("Expected wrapped error not to match a different code")
+	}
+
+	// A service call that ran out of time is a request timeout, not an RPC failure
+	apiErr := handlers.ToAPIError(err)
+	if apiErr.ErrorCode != models.CodeRequestTimeout || apiErr.Code != 408 {
+		t.Fatalf("Expected %s with status 408, got %s %d", models.CodeRequestTimeout, apiErr.ErrorCode, apiErr.Code)
+	}
+	if !errors.Is(apiErr, models.ErrBlockchainConnection) {
+		t.Fatalf("Expected the timeout to keep the service error as cause")
+	}
+
+	// gRPC, streams and batch items share the mapping
+	if got := models.AsAPIError(err).ErrorCode; got != models.CodeRequestTimeout {
+		t.Fatalf("Expected %s from models.AsAPIError, got %s", models.CodeRequestTimeout, got)
+	}
+
+	refused := fmt.Errorf("fetching reserves: %w", models.ErrBlockchainConnection.WithCause(errors.New("connection refused")))
+	if got := handlers.ToAPIError(refused).ErrorCode; got != models.CodeRPCUnavailable {
+		t.Fatalf("Expected %s, got %s", models.CodeRPCUnavailable, got)
+	}
+
+	timeout := handlers.ToAPIError(fmt.Errorf("quote: %w", context.DeadlineExceeded))
+	if timeout.ErrorCode != models.CodeRequestTimeout {
+		t.Fatalf("Expected wrapped deadline to map to %s, got %s", models.CodeRequestTimeout, timeout.ErrorCode)
+	}
+}
+
+func TestErrorCatalogueCodesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, entry := range models.ErrorCatalogue() {
+		if entry.ErrorCode == "" || seen[entry.ErrorCode] {
+			t.Fatalf("Expected unique non-empty error codes, got %q twice or empty", entry.ErrorCode)
+		}
+		seen[entry.ErrorCode] = true
+	}
+
+	for _, code := range []string{models.CodePoolNotFound, models.CodeTokenMismatch, models.CodeInsufficientLiquidity, models.CodeRPCUnavailable} {
+		if !seen[code] {
+			t.Fatalf("Expected %s in the catalogue", code)
+		}
+	}
+}
+
+func TestErrorResponsesShareShape(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.WriteError})
+	app.Get("/estimate", handlers.NewEstimateHandler(nil, 0, 1).EstimateSwap)
+	app.Get("/errors", handlers.ListErrors)
+
+	decode := func(path string) models.APIError {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var apiErr models.APIError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			t.Fatalf("Expected JSON response, got %v", err)
+		}
+		if apiErr.Code != resp.StatusCode {
+			t.Fatalf("Expected code %d to match status %d", apiErr.Code, resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if got := decode("/estimate").ErrorCode; got != models.CodeValidationFailed {
+		t.Fatalf("Expected %s from handler, got %s", models.CodeValidationFailed, got)
+	}
+	if got := decode("/does-not-exist").ErrorCode; got != models.CodeRouteNotFound {
+		t.Fatalf("Expected %s from error handler, got %s", models.CodeRouteNotFound, got)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/errors", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Expected /errors to return 200, got %v %v", resp, err)
+	}
+}
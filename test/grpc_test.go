@@ -0,0 +1,253 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/grpcapi"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	estimatorv1 "uniswap-est/proto/estimator/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newGRPCConn(t *testing.T) *grpc.ClientConn {
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return serveGRPC(t, newChains(t, blockchain))
+}
+
+// serveGRPC serves the estimator over chains on an in-memory listener
+func serveGRPC(t *testing.T, chains *services.Chains) *grpc.ClientConn {
+	server, _ := grpcapi.NewGRPCServer(grpcapi.NewServer(chains, 5*time.Second, 10))
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCEstimateSwap(t *testing.T) {
+	client := estimatorv1.NewEstimatorServiceClient(newGRPCConn(t))
+
+	resp, err := client.EstimateSwap(context.Background(), &estimatorv1.EstimateSwapRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.DstAmount != "493579017198530649" {
+		t.Fatalf("Expected dst_amount 493579017198530649, got %s", resp.DstAmount)
+	}
+	if resp.PoolType != models.PoolTypeV2 {
+		t.Errorf("Expected pool type %s, got %q", models.PoolTypeV2, resp.PoolType)
+	}
+}
+
+func TestGRPCEstimateNativeToken(t *testing.T) {
+	uniswap, _ := newNativeService(t)
+	ethereum, _ := config.KnownChain("ethereum")
+	chains := services.NewChains(&services.Chain{Config: ethereum, Uniswap: uniswap})
+	client := estimatorv1.NewEstimatorServiceClient(serveGRPC(t, chains))
+
+	resp, err := client.EstimateSwap(context.Background(), &estimatorv1.EstimateSwapRequest{
+		Pool: stubPair, Src: nativeEeee, Dst: stubToken0, SrcAmount: "1000000000000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The calldata builder must use the router's ETH variants
+	if !resp.SrcNative || resp.DstNative || resp.WrappedNative != stubToken1 {
+		t.Errorf("Expected src_native with wrapped_native %s, got %+v", stubToken1, resp)
+	}
+}
+
+func TestGRPCEstimateResolvesSymbols(t *testing.T) {
+	registry, err := services.NewTokenRegistry(writeTokenLists(t))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chains := services.NewChains(&services.Chain{
+		Config: blockchain.Chain(), Uniswap: services.NewUniswapService(blockchain, nil, registry), Tokens: registry,
+	})
+	client := estimatorv1.NewEstimatorServiceClient(serveGRPC(t, chains))
+
+	resp, err := client.EstimateSwap(context.Background(), &estimatorv1.EstimateSwapRequest{
+		Pool: stubPair, Src: "usdt", Dst: "MKR", SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The caller sees which addresses the symbols were quoted against
+	if resp.SrcToken.GetAddress() != stubToken0 || resp.SrcToken.GetSymbol() != "USDT" ||
+		resp.DstToken.GetAddress() != stubToken1 || resp.DstToken.GetName() != "Maker" {
+		t.Errorf("Expected token list details, got %+v %+v", resp.SrcToken, resp.DstToken)
+	}
+}
+
+func TestGRPCEstimateDerivesOmittedPool(t *testing.T) {
+	uniswap, _, derived := newDerivedPairService(t)
+	ethereum, _ := config.KnownChain("ethereum")
+	client := estimatorv1.NewEstimatorServiceClient(serveGRPC(t, services.NewChains(&services.Chain{Config: ethereum, Uniswap: uniswap})))
+
+	resp, err := client.EstimateSwap(context.Background(), &estimatorv1.EstimateSwapRequest{
+		Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Pool != derived || len(resp.Warnings) != 0 {
+		t.Fatalf("Expected a quote on the derived pool %s, got %+v", derived, resp)
+	}
+
+	// The dex reaches the service: the sushiswap pair was never created
+	_, err = client.EstimateSwap(context.Background(), &estimatorv1.EstimateSwapRequest{
+		Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Dex: "sushiswap",
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound for the uncreated sushiswap pair, got %v", err)
+	}
+
+	// A pool that is not the canonical pair of its tokens is quoted with a warning
+	resp, err = client.EstimateSwap(context.Background(), &estimatorv1.EstimateSwapRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Pool != "" || len(resp.Warnings) != 1 {
+		t.Errorf("Expected one warning and no derived pool, got %+v", resp)
+	}
+}
+
+func TestGRPCErrorMapping(t *testing.T) {
+	client := estimatorv1.NewEstimatorServiceClient(newGRPCConn(t))
+
+	tests := []struct {
+		name   string
+		req    *estimatorv1.EstimateSwapRequest
+		code   codes.Code
+		reason string
+	}{
+		{"validation", &estimatorv1.EstimateSwapRequest{Pool: "bad", Src: stubToken0, Dst: stubToken1, SrcAmount: "1"}, codes.InvalidArgument, "VALIDATION_FAILED"},
+		{"missing pool", &estimatorv1.EstimateSwapRequest{Pool: missingPool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1"}, codes.NotFound, "POOL_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.EstimateSwap(context.Background(), tt.req)
+			st, ok := status.FromError(err)
+			if !ok || st.Code() != tt.code {
+				t.Fatalf("Expected %v, got %v", tt.code, err)
+			}
+
+			var reason string
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					reason = info.Reason
+				}
+			}
+			if reason != tt.reason {
+				t.Fatalf("Expected ErrorInfo reason %s, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestGRPCEstimateBatch(t *testing.T) {
+	client := estimatorv1.NewEstimatorServiceClient(newGRPCConn(t))
+
+	resp, err := client.EstimateBatch(context.Background(), &estimatorv1.EstimateBatchRequest{Requests: []*estimatorv1.EstimateSwapRequest{
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"},
+		{Pool: missingPool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1"},
+		{Pool: "not-an-address", Src: stubToken0, Dst: stubToken1, SrcAmount: "1"},
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(resp.Results))
+	}
+	if resp.Results[0].GetResult().GetDstAmount() != "493579017198530649" {
+		t.Fatalf("Expected first item to be quoted, got %v", resp.Results[0])
+	}
+	if resp.Results[1].GetError().GetErrorCode() != "POOL_NOT_FOUND" {
+		t.Fatalf("Expected POOL_NOT_FOUND, got %v", resp.Results[1])
+	}
+	if resp.Results[2].GetError().GetErrorCode() != "VALIDATION_FAILED" {
+		t.Fatalf("Expected VALIDATION_FAILED, got %v", resp.Results[2])
+	}
+}
+
+func TestGRPCEstimateStream(t *testing.T) {
+	client := estimatorv1.NewEstimatorServiceClient(newGRPCConn(t))
+
+	stream, err := client.EstimateStream(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	requests := []*estimatorv1.EstimateSwapRequest{
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"},
+		{Pool: missingPool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1"},
+	}
+	for _, req := range requests {
+		if err := stream.Send(&estimatorv1.EstimateStreamRequest{Request: req}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	stream.CloseSend()
+
+	first, err := stream.Recv()
+	if err != nil || first.GetResult().GetDstAmount() != "493579017198530649" {
+		t.Fatalf("Expected quote, got %v (%v)", first, err)
+	}
+	second, err := stream.Recv()
+	if err != nil || second.GetError().GetErrorCode() != "POOL_NOT_FOUND" {
+		t.Fatalf("Expected POOL_NOT_FOUND, got %v (%v)", second, err)
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	client := healthpb.NewHealthClient(newGRPCConn(t))
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: estimatorv1.EstimatorService_ServiceDesc.ServiceName})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING, got %v", resp.Status)
+	}
+}
@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+func newHistoryService(t *testing.T, chain *stubChain, cacheDir string) *services.HistoryService {
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewHistoryService(services.NewUniswapService(blockchain, nil, nil), blockchain,
+		services.NewReserveCache(cacheDir), 100)
+}
+
+func TestHistorySeriesIsCachedOnDisk(t *testing.T) {
+	chain := newMetadataChain()
+	pool := uniswapPair()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(pool, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+	chain.setBlock(2000)
+
+	cacheDir := t.TempDir()
+	req := &models.HistoryRequest{
+		Pool: pool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+		FromBlock: "1000", ToBlock: "1010", Step: "5",
+	}
+
+	response, err := newHistoryService(t, chain, cacheDir).EstimateHistory(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(response.Points) != 3 || response.Points[2].BlockNumber != 1010 {
+		t.Fatalf("Expected points at 1000, 1005 and 1010, got %+v", response.Points)
+	}
+	for _, point := range response.Points {
+		if point.DstAmount != "493579017198530649" {
+			t.Fatalf("Expected dst_amount 493579017198530649, got %+v", point)
+		}
+		// 50 ETH per 100k USDT
+		if point.SpotPrice != "0.000500000000000000" {
+			t.Fatalf("Expected spot price 0.0005, got %s", point.SpotPrice)
+		}
+	}
+
+	// Pool and token metadata (9 calls) plus one getReserves per block
+	if chain.callCount() != 12 {
+		t.Fatalf("Expected 12 eth_calls, got %d", chain.callCount())
+	}
+
+	if _, err := os.Stat(filepath.Join(cacheDir, "1", pool+".json")); err != nil {
+		t.Fatalf("Expected cache file, got %v", err)
+	}
+
+	// A fresh service, e.g. after a restart, reads the reserves from disk
+	if _, err := newHistoryService(t, chain, cacheDir).EstimateHistory(context.Background(), req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if chain.callCount() != 21 {
+		t.Fatalf("Expected only the 9 metadata calls on a cached range, got %d calls", chain.callCount()-12)
+	}
+}
+
+func TestHistoryDoesNotCacheUnfinalizedBlocks(t *testing.T) {
+	chain := newMetadataChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(1000000), big.NewInt(1000000))
+
+	cacheDir := t.TempDir()
+	req := &models.HistoryRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000",
+		FromBlock: "990", ToBlock: "1000",
+	}
+
+	if _, err := newHistoryService(t, chain, cacheDir).EstimateHistory(context.Background(), req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "1", stubPair+".json")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no cache file for blocks near the head, got %v", err)
+	}
+}
+
+func TestHistoryRejectsInvalidRanges(t *testing.T) {
+	chain := newMetadataChain()
+	service := newHistoryService(t, chain, t.TempDir())
+
+	tests := []struct {
+		name     string
+		from, to string
+		step     string
+	}{
+		{"reversed", "1000", "900", ""},
+		{"zero step", "900", "1000", "0"},
+		{"too many points", "0", "1000", "1"},
+		{"after head", "990", "1001", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.EstimateHistory(context.Background(), &models.HistoryRequest{
+				Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1",
+				FromBlock: tt.from, ToBlock: tt.to, Step: tt.step,
+			})
+			if !errors.Is(err, models.ErrInvalidBlockRange) {
+				t.Fatalf("Expected INVALID_BLOCK_RANGE, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReserveCacheKeepsRecentPoolsInMemory(t *testing.T) {
+	dir := t.TempDir()
+	cache := services.NewReserveCache(dir)
+
+	// More pools than are kept in memory, every one written through to disk
+	reserves := &models.PoolReserves{Reserve0: big.NewInt(1), Reserve1: big.NewInt(2)}
+	pools := make([]string, 300)
+	for i := range pools {
+		pools[i] = fmt.Sprintf("0x%040x", i+1)
+		if err := cache.Put(1, pools[i], map[uint64]*models.PoolReserves{100: reserves}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, ok := cache.Get(1, pools[0], 100); !ok {
+		t.Fatal("Expected a dropped pool to be read from disk again")
+	}
+
+	// With the files gone, only pools still in memory hit
+	if err := os.RemoveAll(filepath.Join(dir, "1")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := cache.Get(1, pools[299], 100); !ok {
+		t.Error("Expected a recent pool to be served from memory")
+	}
+	if _, ok := cache.Get(1, pools[1], 100); ok {
+		t.Error("Expected the least recently used pool to have left memory")
+	}
+}
+
+func TestLoadConfigMaxHistoryPoints(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+
+	for _, value := range []string{"0", "-10", "many"} {
+		t.Setenv("MAX_HISTORY_POINTS", value)
+		if _, err := config.LoadConfig(); err == nil || !strings.Contains(err.Error(), "MAX_HISTORY_POINTS") {
+			t.Errorf("Expected MAX_HISTORY_POINTS=%s to be rejected, got %v", value, err)
+		}
+	}
+}
+
+func TestSpotPrice(t *testing.T) {
+	// 1000 USDC (6 decimals) against 1 WETH (18 decimals)
+	reserveUSDC := big.NewInt(1000000000)
+	reserveWETH, _ := new(big.Int).SetString("1000000000000000000", 10)
+
+	price, err := utils.SpotPrice(reserveWETH, reserveUSDC, 18, 6)
+	if err != nil || price != "1000.000000000000000000" {
+		t.Fatalf("Expected 1000 USDC per WETH, got %s (%v)", price, err)
+	}
+
+	if _, err := utils.SpotPrice(big.NewInt(0), reserveUSDC, 18, 6); err == nil {
+		t.Fatalf("Expected error for an empty pool")
+	}
+}
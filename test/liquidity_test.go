@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+const stubFactory = "0x8888888888888888888888888888888888888888"
+
+// newLiquidityService deploys a pair with LP token state. feeTo switches the protocol fee on.
+func newLiquidityService(t *testing.T, reserve0, reserve1, totalSupply, kLast int64, feeTo string) *services.LiquidityService {
+	chain := newMetadataChain()
+	chain.deploy(stubPair, stubContract{
+		"getReserves()": abiEncode([]string{"uint112", "uint112", "uint32"}, big.NewInt(reserve0), big.NewInt(reserve1), uint32(1700000000)),
+		"token0()":      encodeAddress(stubToken0),
+		"token1()":      encodeAddress(stubToken1),
+		"totalSupply()": encodeUint(totalSupply),
+		"kLast()":       encodeUint(kLast),
+		"factory()":     encodeAddress(stubFactory),
+	})
+	chain.deploy(stubFactory, stubContract{"feeTo()": encodeAddress(feeTo)})
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewLiquidityService(blockchain)
+}
+
+func TestAddLiquidityAtPoolRatio(t *testing.T) {
+	service := newLiquidityService(t, 1000000, 4000000, 2000000, 0, "0x0000000000000000000000000000000000000000")
+
+	// token_b given first: 5000 of token1 is more than 1000 of token0 is worth
+	response, err := service.AddLiquidity(context.Background(), &models.AddLiquidityRequest{
+		Pool: stubPair, TokenA: stubToken1, TokenB: stubToken0, AmountA: "5000", AmountB: "1000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if response.AmountA != "4000" || response.AmountB != "1000" {
+		t.Fatalf("Expected 4000 and 1000 deposited, got %s and %s", response.AmountA, response.AmountB)
+	}
+	if response.Liquidity != "2000" || response.TotalSupply != "2002000" {
+		t.Fatalf("Expected 2000 LP tokens of 2002000, got %s of %s", response.Liquidity, response.TotalSupply)
+	}
+}
+
+func TestLiquidityWithProtocolFee(t *testing.T) {
+	// sqrt(k) grew from 1900000 to 2000000 since the last liquidity event
+	service := newLiquidityService(t, 1000000, 4000000, 2000000, 3610000000000, stubFactory)
+
+	// 2000000 * 100000 / (2000000 * 5 + 1900000) = 16806 LP tokens minted to feeTo
+	added, err := service.AddLiquidity(context.Background(), &models.AddLiquidityRequest{
+		Pool: stubPair, TokenA: stubToken0, TokenB: stubToken1, AmountA: "1000", AmountB: "4000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if added.Liquidity != "2016" {
+		t.Fatalf("Expected 2016 LP tokens, got %s", added.Liquidity)
+	}
+
+	removed, err := service.RemoveLiquidity(context.Background(), &models.RemoveLiquidityRequest{
+		Pool: stubPair, Liquidity: "1008403",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if removed.TotalSupply != "2016806" || removed.Amount0 != "500000" || removed.Amount1 != "2000000" {
+		t.Fatalf("Expected half the pool of 2016806 LP tokens, got %+v", removed)
+	}
+	if removed.PoolShare != "0.500000000000000000" {
+		t.Fatalf("Expected pool share 0.5, got %s", removed.PoolShare)
+	}
+
+	// Without feeTo the stale kLast is ignored
+	withoutFee := newLiquidityService(t, 1000000, 4000000, 2000000, 3610000000000, "0x0000000000000000000000000000000000000000")
+	removed, err = withoutFee.RemoveLiquidity(context.Background(), &models.RemoveLiquidityRequest{
+		Pool: stubPair, Liquidity: "1000000",
+	})
+	if err != nil || removed.Amount0 != "500000" {
+		t.Fatalf("Expected 500000 of token0 without the protocol fee, got %+v (%v)", removed, err)
+	}
+}
+
+func TestRemoveLiquidityRejectsInvalidBalances(t *testing.T) {
+	service := newLiquidityService(t, 1000000, 4000000, 2000000, 0, "0x0000000000000000000000000000000000000000")
+
+	for _, liquidity := range []string{"0", "2000001", "1"} {
+		_, err := service.RemoveLiquidity(context.Background(), &models.RemoveLiquidityRequest{
+			Pool: stubPair, Liquidity: liquidity,
+		})
+		if !errors.Is(err, models.ErrInsufficientLiquidity) {
+			t.Fatalf("Expected INSUFFICIENT_LIQUIDITY for %s, got %v", liquidity, err)
+		}
+	}
+}
+
+func TestLiquidityMintMath(t *testing.T) {
+	// The first deposit locks MINIMUM_LIQUIDITY
+	liquidity, err := utils.LiquidityMinted(big.NewInt(1000000), big.NewInt(4000000), big.NewInt(0), big.NewInt(0), big.NewInt(0))
+	if err != nil || liquidity.Int64() != 1999000 {
+		t.Fatalf("Expected 1999000 LP tokens, got %v (%v)", liquidity, err)
+	}
+
+	if _, err := utils.LiquidityMinted(big.NewInt(1000), big.NewInt(1000), big.NewInt(0), big.NewInt(0), big.NewInt(0)); err == nil {
+		t.Fatalf("Expected error when the first deposit is below MINIMUM_LIQUIDITY")
+	}
+
+	// Imbalanced deposits are credited for the smaller side
+	liquidity, _ = utils.LiquidityMinted(big.NewInt(1000), big.NewInt(8000), big.NewInt(1000000), big.NewInt(4000000), big.NewInt(2000000))
+	if liquidity.Int64() != 2000 {
+		t.Fatalf("Expected 2000 LP tokens, got %s", liquidity)
+	}
+
+	amountB, _ := utils.Quote(big.NewInt(3), big.NewInt(2), big.NewInt(5))
+	if amountB.Int64() != 7 {
+		t.Fatalf("Expected 3 * 5 / 2 truncated to 7, got %s", amountB)
+	}
+
+	if fee := utils.ProtocolFeeLiquidity(big.NewInt(100), big.NewInt(100), big.NewInt(100), big.NewInt(10000)); fee.Sign() != 0 {
+		t.Fatalf("Expected no protocol fee without growth in k, got %s", fee)
+	}
+}
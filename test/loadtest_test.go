@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/loadtest"
+	"uniswap-est/intrenal/models"
+)
+
+func startLoadtestServer(t *testing.T, chain *loadtest.StubChain) *loadtest.Server {
+	server, err := loadtest.StartServer(&config.Config{RequestTimeout: 5 * time.Second, MaxBatchSize: 10}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func TestLoadtestInProcessStubChain(t *testing.T) {
+	chain := loadtest.NewStubChain(5, 0)
+	server := startLoadtestServer(t, chain)
+
+	requests := loadtest.Synthesize(chain.Pools(), 20, 1, 7)
+	if !reflect.DeepEqual(requests, loadtest.Synthesize(chain.Pools(), 20, 1, 7)) {
+		t.Fatal("Expected the same requests from the same seed")
+	}
+
+	report, err := loadtest.Run(context.Background(), requests, loadtest.Options{
+		Target: server.URL, RPS: 200, Duration: 250 * time.Millisecond, Concurrency: 16, Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Sent+report.Skipped != 50 || report.Sent == 0 {
+		t.Fatalf("Expected 50 requests due at 200 rps over 250ms, got %d sent and %d skipped", report.Sent, report.Skipped)
+	}
+	if report.Succeeded != report.Sent || report.Statuses[200] != report.Sent || len(report.Errors) != 0 {
+		t.Fatalf("Expected every synthesised quote to succeed, got %+v", report)
+	}
+	latency := report.Latency
+	if latency.P50 <= 0 || latency.P50 > latency.P90 || latency.P90 > latency.P99 || latency.P99 > latency.Max {
+		t.Errorf("Expected ordered positive percentiles, got %+v", latency)
+	}
+	if report.Throughput <= 0 {
+		t.Errorf("Expected a throughput, got %v", report.Throughput)
+	}
+	if chain.Calls() == 0 {
+		t.Error("Expected the server to read the stub chain")
+	}
+}
+
+func TestLoadtestReplaysLogWithErrorBreakdown(t *testing.T) {
+	chain := loadtest.NewStubChain(1, 0)
+	server := startLoadtestServer(t, chain)
+	pool := chain.Pools()[0]
+
+	log := strings.Join([]string{
+		"Server starting on http://localhost:1337",
+		"15:04:05 200 - GET /estimate?pool=" + pool.Address + "&src=" + pool.Src + "&dst=" + pool.Dst + "&src_amount=1000 (1.2ms)",
+		`15:04:05 404 - GET /estimate?pool=` + missingPool + `&src=` + pool.Src + `&dst=` + pool.Dst + `&src_amount=1000 (90µs)`,
+		`{"method":"post","path":"/estimate/batch","body":{"requests":[{"pool":"` + pool.Address +
+			`","src":"` + pool.Src + `","dst":"` + pool.Dst + `","src_amount":"5"},{"src":"x"}]}}`,
+		`127.0.0.1 - - "GET /estimate?pool=0x1 HTTP/1.1" 400`,
+	}, "\n")
+
+	requests, err := loadtest.ReadLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(requests) != 4 || requests[2].Method != "POST" || requests[3].Path != "/estimate?pool=0x1" {
+		t.Fatalf("Expected 4 requests from the log, got %+v", requests)
+	}
+
+	// One pass over the log
+	report, err := loadtest.Run(context.Background(), requests, loadtest.Options{
+		Target: server.URL, RPS: 100, Duration: 40 * time.Millisecond, Concurrency: 4, Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Succeeded != 2 || report.Failed != 2 {
+		t.Fatalf("Expected 2 successes and 2 failures, got %+v", report)
+	}
+	expected := map[string]int{models.CodePoolNotFound: 1, models.CodeValidationFailed: 1}
+	if !reflect.DeepEqual(report.Errors, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, report.Errors)
+	}
+	if report.ItemErrors[models.CodeValidationFailed] != 1 {
+		t.Errorf("Expected the invalid batch item counted, got %v", report.ItemErrors)
+	}
+
+	if _, err := loadtest.ReadLog(strings.NewReader("nothing to replay\n")); err == nil {
+		t.Error("Expected an error for a log without requests")
+	}
+}
+
+func TestLoadtestReportsTransportErrors(t *testing.T) {
+	requests := []loadtest.Request{{Method: "GET", Path: "/health"}}
+	report, err := loadtest.Run(context.Background(), requests, loadtest.Options{
+		Target: "http://127.0.0.1:1", RPS: 50, Duration: 100 * time.Millisecond, Concurrency: 4, Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Failed != report.Sent || report.Errors[loadtest.CodeTransport] != report.Sent {
+		t.Errorf("Expected every request to fail with %s, got %+v", loadtest.CodeTransport, report)
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+const nativeEeee = "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"
+
+// newNativeService quotes the stub pair on a chain whose wrapped native token is stubToken1
+func newNativeService(t *testing.T) (*services.UniswapService, *stubChain) {
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	ethereum, _ := config.KnownChain("ethereum")
+	ethereum.WrappedNative = stubToken1
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, ethereum, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewUniswapService(blockchain, nil, nil), chain
+}
+
+func TestValidateAcceptsNativeSentinels(t *testing.T) {
+	for _, native := range []string{nativeEeee, "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "0xEeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", utils.NativeZeroAddress} {
+		req := &models.EstimateRequest{Pool: usdtWethPair, Src: native, Dst: usdtChecksummed, SrcAmount: "1"}
+		if errs := utils.ValidateStruct(req); len(errs) != 0 {
+			t.Fatalf("Expected %s to be accepted, got %+v", native, errs)
+		}
+	}
+
+	// Both sentinels name the same token
+	req := &models.EstimateRequest{Pool: usdtWethPair, Src: nativeEeee, Dst: utils.NativeZeroAddress, SrcAmount: "1"}
+	errs := utils.ValidateStruct(req)
+	if len(errs) != 1 || errs[0].Field != "dst" || errs[0].Rule != "nefield" {
+		t.Fatalf("Expected nefield error on dst, got %+v", errs)
+	}
+}
+
+func TestEstimateNativeTokenAsWrapped(t *testing.T) {
+	uniswap, chain := newNativeService(t)
+
+	erc20, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: stubToken1, Dst: stubToken0, SrcAmount: "1000000000000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	erc20Calls := chain.callCount()
+
+	// Native in: quoted as the wrapped token, without any call to the sentinel
+	native, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: nativeEeee, Dst: stubToken0, SrcAmount: "1000000000000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if native.DstAmount != erc20.DstAmount {
+		t.Errorf("Expected %s as for the wrapped token, got %s", erc20.DstAmount, native.DstAmount)
+	}
+	if !native.SrcNative || native.DstNative || native.WrappedNative != stubToken1 {
+		t.Errorf("Expected src_native with wrapped_native %s, got %+v", stubToken1, native)
+	}
+	if calls := chain.callCount() - erc20Calls; calls != erc20Calls {
+		t.Errorf("Expected %d calls like the ERC20 quote, got %d", erc20Calls, calls)
+	}
+	if erc20.SrcNative || erc20.WrappedNative != "" {
+		t.Errorf("Expected no native flags on an ERC20 quote, got %+v", erc20)
+	}
+
+	// Native out with the zero address, in a batch
+	batch, err := uniswap.EstimateBatch(context.Background(), []models.EstimateRequest{
+		{Pool: stubPair, Src: stubToken0, Dst: utils.NativeZeroAddress, SrcAmount: "1000000000"},
+		{Pool: stubPair, Src: stubToken1, Dst: utils.NativeZeroAddress, SrcAmount: "1000000000"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result := batch.Results[0].Result; result == nil || result.DstAmount != "493579017198530649" || !result.DstNative {
+		t.Errorf("Expected native output quoted as the wrapped token, got %+v", batch.Results[0])
+	}
+
+	// Wrapping is not a pool swap
+	if apiErr := batch.Results[1].Error; apiErr == nil || !errors.Is(apiErr, models.ErrValidationFailed) {
+		t.Errorf("Expected a validation error for WETH to ETH, got %+v", batch.Results[1])
+	}
+}
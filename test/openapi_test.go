@@ -0,0 +1,233 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/client"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/openapi"
+	"uniswap-est/intrenal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newRoutedApp builds the full route table on top of a stub chain
+func newRoutedApp(t *testing.T) (*fiber.App, *openapi.Document) {
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	chains := services.NewChains(services.NewChain(testServiceConfig(), blockchain, services.NewReserveCache(t.TempDir()), nil))
+	routes := handlers.Routes(handlers.Handlers{
+		Estimate:  handlers.NewEstimateHandler(chains, 5*time.Second, 10),
+		Stream:    handlers.NewStreamHandler(chains, 10),
+		History:   handlers.NewHistoryHandler(chains, 5*time.Second),
+		Oracle:    handlers.NewOracleHandler(chains, 5*time.Second),
+		Liquidity: handlers.NewLiquidityHandler(chains, 5*time.Second),
+		Arbitrage: handlers.NewArbitrageHandler(chains, 5*time.Second),
+		Chains:    handlers.NewChainsHandler(chains),
+		Tokens:    handlers.NewTokensHandler(chains),
+		Audit:     handlers.NewAuditHandler(nil, "", 5*time.Second),
+		Health:    handlers.NewHealthHandler("test"),
+	})
+
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.WriteError})
+	handlers.SetupRoutes(app, routes, "test")
+	return app, handlers.OpenAPIDocument(routes, "test")
+}
+
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	app, document := newRoutedApp(t)
+
+	registered := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		path := strings.TrimPrefix(route.Path, handlers.APIPrefix)
+		if path == "" {
+			path = "/"
+		}
+		path = openapi.PathTemplate(path)
+		registered[route.Method+" "+path] = true
+
+		if document.Operation(route.Method, path) == nil {
+			t.Errorf("Route %s %s is not in the OpenAPI document", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range document.Paths {
+		for method := range *item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("OpenAPI operation %s %s has no handler", method, path)
+			}
+		}
+	}
+}
+
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	_, document := newRoutedApp(t)
+
+	generated, err := openapi.GenerateClient(document, "client")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	committed, err := os.ReadFile("../client/client.go")
+	if err != nil {
+		t.Fatalf("Expected client/client.go, got %v", err)
+	}
+
+	if !bytes.Equal(generated, committed) {
+		t.Fatalf("client/client.go is stale, run: go generate ./client")
+	}
+}
+
+func TestResponsesMatchOpenAPISchemas(t *testing.T) {
+	app, document := newRoutedApp(t)
+
+	estimateQuery := "?pool=" + stubPair + "&src=" + stubToken0 + "&dst=" + stubToken1 + "&src_amount=1000000000"
+	estimateBody := `{"pool":"` + stubPair + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1000000000"}`
+
+	// Example requests that succeed; every other operation is called without input
+	// and must then answer with a documented error
+	examples := map[string]struct{ query, body string }{
+		"estimateSwap":     {query: estimateQuery},
+		"estimateSwapPost": {body: estimateBody},
+		"estimateBatch":    {body: `{"requests":[` + estimateBody + `,{"pool":"0x1"}]}`},
+		"history":          {query: estimateQuery + "&from_block=990&to_block=1000&step=5"},
+	}
+
+	for path, item := range document.Paths {
+		for method, operation := range *item {
+			example := examples[operation.OperationID]
+
+			var body io.Reader
+			if example.body != "" {
+				body = strings.NewReader(example.body)
+			} else if operation.RequestBody != nil {
+				body = strings.NewReader("{}")
+			}
+
+			req := httptest.NewRequest(strings.ToUpper(method), path+example.query, body)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %v", operation.OperationID, err)
+			}
+
+			response, ok := operation.Responses[strconv.Itoa(resp.StatusCode)]
+			if !ok {
+				t.Errorf("%s: status %d is not documented", operation.OperationID, resp.StatusCode)
+				continue
+			}
+
+			var decoded interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+				t.Errorf("%s: expected JSON body, got %v", operation.OperationID, err)
+				continue
+			}
+
+			for _, media := range response.Content {
+				checkSchema(t, document, operation.OperationID, media.Schema, decoded)
+			}
+		}
+	}
+}
+
+// checkSchema fails the test when value does not conform to schema
+func checkSchema(t *testing.T, document *openapi.Document, where string, schema *openapi.Schema, value interface{}) {
+	schema = document.Resolve(schema)
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: expected object, got %T", where, value)
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				t.Errorf("%s: missing required property %q", where, name)
+			}
+		}
+		for name, property := range object {
+			switch {
+			case schema.Properties[name] != nil:
+				checkSchema(t, document, where+"."+name, schema.Properties[name], property)
+			case schema.AdditionalProperties != nil:
+				checkSchema(t, document, where+"."+name, schema.AdditionalProperties, property)
+			case len(schema.Properties) > 0:
+				t.Errorf("%s: undocumented property %q", where, name)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			t.Errorf("%s: expected array, got %T", where, value)
+			return
+		}
+		for i, item := range items {
+			checkSchema(t, document, where+"["+strconv.Itoa(i)+"]", schema.Items, item)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			t.Errorf("%s: expected string, got %T", where, value)
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			t.Errorf("%s: expected number, got %T", where, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			t.Errorf("%s: expected boolean, got %T", where, value)
+		}
+	}
+}
+
+func TestGeneratedClientAgainstServer(t *testing.T) {
+	app, _ := newRoutedApp(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	api := client.New("http://" + listener.Addr().String())
+
+	result, err := api.EstimateSwap(context.Background(), client.EstimateSwapParams{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.DstAmount != "493579017198530649" {
+		t.Fatalf("Expected dst_amount 493579017198530649, got %s", result.DstAmount)
+	}
+
+	_, err = api.EstimateSwap(context.Background(), client.EstimateSwapParams{Pool: stubPair})
+	apiErr, ok := err.(*client.APIError)
+	if !ok || apiErr.ErrorCode != "VALIDATION_FAILED" {
+		t.Fatalf("Expected VALIDATION_FAILED APIError, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+)
+
+func TestPinBlockQuotesAtPastBlock(t *testing.T) {
+	past, current := big.NewInt(50000000000), expandTo18Decimals(20)
+
+	chain := newMetadataChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	chain.deployAt(900, stubPair, stubContract{
+		"getReserves()": abiEncode([]string{"uint112", "uint112", "uint32"}, past, current, uint32(1700000000)),
+		"token0()":      encodeAddress(stubToken0),
+		"token1()":      encodeAddress(stubToken1),
+	})
+
+	// The same state as the latest one of another chain
+	reference := newMetadataChain()
+	reference.deployPair(stubPair, stubToken0, stubToken1, past, current)
+
+	req := &models.EstimateRequest{Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"}
+	quote := func(client services.ChainClient) string {
+		blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, client)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		response, err := services.NewUniswapService(blockchain, nil, nil).EstimateSwap(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return response.DstAmount
+	}
+
+	pinned := services.PinBlock(chain, 900)
+	if block, _ := pinned.BlockNumber(context.Background()); block != 900 {
+		t.Fatalf("Expected the pinned block 900 as latest, got %d", block)
+	}
+
+	atPast, atLatest, expected := quote(pinned), quote(chain), quote(reference)
+	if atPast != expected {
+		t.Fatalf("Expected %s from the reserves at block 900, got %s", expected, atPast)
+	}
+	if atPast == atLatest {
+		t.Fatalf("Expected the latest quote to differ from the one at block 900, both are %s", atPast)
+	}
+}
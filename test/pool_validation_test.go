@@ -0,0 +1,239 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	otherFactory   = "0x7777777777777777777777777777777777777777"
+	trustedFactory = "0x8888888888888888888888888888888888888888"
+)
+
+// deployFactoryPair deploys the stub pair at address, answering factory() with factory
+func (s *stubChain) deployFactoryPair(address, factory string) {
+	s.deployPair(address, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	s.contracts[common.HexToAddress(address)]["factory()"] = encodeAddress(factory)
+}
+
+// newValidatingService quotes ethereum with pool validation on. The canonical Uniswap
+// pair of the stub tokens is genuine; stubPair claims the Uniswap factory without being
+// its pair.
+func newValidatingService(t *testing.T, chain *stubChain, ethereum *config.ChainConfig) (*services.BlockchainService, string) {
+	canonical := utils.PairAddress(ethereum.FactoryAddress, ethereum.InitCodeHash, stubToken0, stubToken1)
+	chain.deployFactoryPair(canonical, ethereum.FactoryAddress)
+	chain.deployFactoryPair(stubPair, ethereum.FactoryAddress)
+
+	blockchain, err := services.NewChainBlockchainService(&config.Config{ValidatePools: true}, ethereum, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return blockchain, canonical
+}
+
+func TestPoolValidationRejectsFakePairs(t *testing.T) {
+	chain := newMetadataChain()
+	ethereum, _ := config.KnownChain("ethereum")
+	blockchain, canonical := newValidatingService(t, chain, ethereum)
+
+	const (
+		untrusted = "0x9999999999999999999999999999999999999991"
+		noFactory = "0x9999999999999999999999999999999999999992"
+	)
+	chain.deployFactoryPair(untrusted, otherFactory)
+	chain.deployPair(noFactory, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+
+	if _, err := blockchain.GetPoolReserves(context.Background(), canonical); err != nil {
+		t.Fatalf("Expected the canonical pair to be trusted, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pool string
+	}{
+		{"not the CREATE2 pair of its factory", stubPair},
+		{"untrusted factory", untrusted},
+		{"no factory()", noFactory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := blockchain.GetPoolReserves(context.Background(), tt.pool)
+			if !errors.Is(err, models.ErrUntrustedPool) {
+				t.Fatalf("Expected UNTRUSTED_POOL, got %v", err)
+			}
+		})
+	}
+
+	// Quotes go through the same check, per batch item
+	uniswap := services.NewUniswapService(blockchain, nil, nil)
+	batch, err := uniswap.EstimateBatch(context.Background(), []models.EstimateRequest{
+		{Pool: canonical, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"},
+		{Pool: untrusted, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if batch.Results[0].Result == nil {
+		t.Errorf("Expected item 0 quoted, got %+v", batch.Results[0].Error)
+	}
+	if batch.Results[1].Error == nil || batch.Results[1].Error.ErrorCode != models.CodeUntrustedPool {
+		t.Errorf("Expected item 1 to fail with UNTRUSTED_POOL, got %+v", batch.Results[1])
+	}
+
+	// So does the liquidity estimate, which reads the pair on its own
+	liquidity := services.NewLiquidityService(blockchain)
+	_, err = liquidity.RemoveLiquidity(context.Background(), &models.RemoveLiquidityRequest{Pool: stubPair, Liquidity: "1000"})
+	if !errors.Is(err, models.ErrUntrustedPool) {
+		t.Fatalf("Expected UNTRUSTED_POOL from the liquidity estimate, got %v", err)
+	}
+}
+
+func TestPoolValidationCachesVerdicts(t *testing.T) {
+	chain := newMetadataChain()
+	ethereum, _ := config.KnownChain("ethereum")
+	blockchain, canonical := newValidatingService(t, chain, ethereum)
+
+	for _, pool := range []string{canonical, stubPair} {
+		blockchain.GetPoolReserves(context.Background(), pool)
+
+		before := chain.callCount()
+		_, first := blockchain.GetPoolReserves(context.Background(), pool)
+		reads := chain.callCount() - before
+
+		// A genuine pair stays genuine even if a later factory() answer would differ
+		chain.contracts[common.HexToAddress(pool)]["factory()"] = encodeAddress(otherFactory)
+		before = chain.callCount()
+		_, second := blockchain.GetPoolReserves(context.Background(), pool)
+		if chain.callCount()-before != reads || (first == nil) != (second == nil) {
+			t.Errorf("Expected the verdict on %s to be cached, got %v then %v", pool, first, second)
+		}
+	}
+}
+
+func TestPoolValidationTrustedFactoriesAndCodeHashes(t *testing.T) {
+	chain := newMetadataChain()
+	ethereum, _ := config.KnownChain("ethereum")
+	ethereum.TrustedFactories = []string{trustedFactory}
+
+	// A trusted factory without an init code hash is asked for its pair
+	const forkPair = "0x9999999999999999999999999999999999999993"
+	chain.deployFactoryPair(forkPair, trustedFactory)
+	chain.deploy(trustedFactory, stubContract{
+		withArgs("getPair(address,address)", []string{"address", "address"},
+			common.HexToAddress(stubToken0), common.HexToAddress(stubToken1)): encodeAddress(forkPair),
+	})
+	blockchain, _ := newValidatingService(t, chain, ethereum)
+
+	if _, err := blockchain.GetPoolReserves(context.Background(), forkPair); err != nil {
+		t.Fatalf("Expected the pair of a trusted factory to be trusted, got %v", err)
+	}
+
+	// The stub chain serves the same placeholder code for every contract
+	stubCodeHash := crypto.Keccak256Hash([]byte{0x60, 0x80}).Hex()
+	for _, tt := range []struct {
+		hashes  []string
+		trusted bool
+	}{
+		{[]string{stubCodeHash}, true},
+		{[]string{"0x" + common.Bytes2Hex(make([]byte, 32))}, false},
+	} {
+		ethereum.PairCodeHashes = tt.hashes
+		blockchain, err := services.NewChainBlockchainService(&config.Config{ValidatePools: true}, ethereum, chain)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		_, err = blockchain.GetPoolReserves(context.Background(), forkPair)
+		if (err == nil) != tt.trusted || (err != nil && !errors.Is(err, models.ErrUntrustedPool)) {
+			t.Errorf("Code hashes %v: expected trusted=%v, got %v", tt.hashes, tt.trusted, err)
+		}
+	}
+}
+
+// deploySolidlyPair deploys a stable Solidly-style pair of the stub tokens at address,
+// answering factory() with factory
+func (s *stubChain) deploySolidlyPair(address, factory string) {
+	s.deploy(address, stubContract{
+		"getReserves()": abiEncode([]string{"uint112", "uint112", "uint32"},
+			big.NewInt(1000000000000), expandTo18Decimals(1000000), uint32(1700000000)),
+		"token0()":  encodeAddress(stubToken0),
+		"token1()":  encodeAddress(stubToken1),
+		"stable()":  abiEncode([]string{"bool"}, true),
+		"factory()": encodeAddress(factory),
+	})
+}
+
+func TestPoolValidationChecksEveryPoolType(t *testing.T) {
+	chain := newMetadataChain()
+	ethereum, _ := config.KnownChain("ethereum")
+	ethereum.TrustedFactories = []string{solidlyFactory, trustedFactory}
+
+	const (
+		byGetPair    = "0x9999999999999999999999999999999999999994"
+		notListed    = "0x9999999999999999999999999999999999999995"
+		claimsV2     = "0x9999999999999999999999999999999999999996"
+		untrustedOwn = "0x9999999999999999999999999999999999999997"
+	)
+	// Velodrome V1 style factories answer isPair, Solidly style ones getPair with stable
+	chain.deploySolidlyPair(solidlyPair, solidlyFactory)
+	chain.deploy(solidlyFactory, stubContract{
+		withArgs("isPair(address)", []string{"address"}, common.HexToAddress(solidlyPair)): abiEncode([]string{"bool"}, true),
+	})
+	chain.deploySolidlyPair(byGetPair, trustedFactory)
+	chain.deploy(trustedFactory, stubContract{
+		withArgs("getPair(address,address,bool)", []string{"address", "address", "bool"},
+			common.HexToAddress(stubToken0), common.HexToAddress(stubToken1), true): encodeAddress(byGetPair),
+	})
+
+	// Pairs answering stable() that their factory does not know
+	chain.deploySolidlyPair(notListed, solidlyFactory)
+	chain.deploySolidlyPair(claimsV2, ethereum.FactoryAddress)
+	chain.deploySolidlyPair(untrustedOwn, otherFactory)
+
+	// A V3 pool cannot be authenticated
+	chain.deployV3Pool(v3Pool, &models.V3PoolState{
+		Token0: stubToken0, Token1: stubToken1,
+		SqrtPriceX96: new(big.Int).Lsh(big.NewInt(1), 96), Liquidity: expandTo18Decimals(1),
+		Fee: 3000, TickSpacing: 60,
+	})
+
+	blockchain, _ := newValidatingService(t, chain, ethereum)
+	uniswap := services.NewUniswapService(blockchain, nil, nil)
+
+	for _, pool := range []string{solidlyPair, byGetPair} {
+		response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+			Pool: pool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+		})
+		if err != nil || response.PoolType != models.PoolTypeSolidlyStable {
+			t.Fatalf("Expected a stable quote from %s, got %+v (%v)", pool, response, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		pool string
+	}{
+		{"Solidly pair unknown to its factory", notListed},
+		{"Solidly pair claiming a V2 factory", claimsV2},
+		{"Solidly pair of an untrusted factory", untrustedOwn},
+		{"V3 pool", v3Pool},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+				Pool: tt.pool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+			})
+			if !errors.Is(err, models.ErrUntrustedPool) {
+				t.Fatalf("Expected UNTRUSTED_POOL, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+)
+
+// exportStubSnapshot exports the stub pair from a stub chain and writes it to a file
+func exportStubSnapshot(t *testing.T, chain *stubChain) string {
+	ethereum, _ := config.KnownChain("ethereum")
+	snapshot, err := services.ExportSnapshot(context.Background(), &config.Config{}, ethereum, chain, []string{stubPair})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(snapshot.Pools) != 1 || snapshot.Pools[0].PoolType != models.PoolTypeV2 ||
+		snapshot.Pools[0].Reserves[0] != "100000000000" || len(snapshot.Tokens) != 2 || snapshot.Tokens[1].Symbol != "MKR" {
+		t.Fatalf("Expected the stub pair and its tokens, got %+v", snapshot)
+	}
+	if snapshot.Block.Number != 1000 || snapshot.Block.Timestamp != stubGenesisTime+12*1000 {
+		t.Errorf("Expected block 1000 and its timestamp, got %+v", snapshot.Block)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := snapshot.Write(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return path
+}
+
+func TestSnapshotServesQuotesWithoutNode(t *testing.T) {
+	chain := newMetadataChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	path := exportStubSnapshot(t, chain)
+
+	live, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	req := &models.EstimateRequest{Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"}
+	expected, err := services.NewUniswapService(live, nil, nil).EstimateSwap(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The node is gone; the snapshot answers with the state it had
+	chain.down = true
+	snapshot, err := services.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	offline, err := services.NewChainBlockchainService(&config.Config{}, snapshot.ChainConfig(), services.NewSnapshotClient(snapshot))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	uniswap := services.NewUniswapService(offline, nil, nil)
+
+	response, err := uniswap.EstimateSwap(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.DstAmount != expected.DstAmount {
+		t.Errorf("Expected %s as from the node, got %s", expected.DstAmount, response.DstAmount)
+	}
+
+	token, err := offline.GetTokenInfo(context.Background(), stubToken1)
+	if err != nil || token.Symbol != "MKR" || token.Decimals != 18 {
+		t.Errorf("Expected MKR metadata from the snapshot, got %+v, %v", token, err)
+	}
+
+	// Pools that were not exported do not exist
+	_, err = uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: missingPool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if !errors.Is(err, models.ErrPoolNotFound) {
+		t.Errorf("Expected POOL_NOT_FOUND for a pool outside the snapshot, got %v", err)
+	}
+}
+
+func TestSnapshotExportAndLoadErrors(t *testing.T) {
+	ethereum, _ := config.KnownChain("ethereum")
+	_, err := services.ExportSnapshot(context.Background(), &config.Config{}, ethereum, newMetadataChain(), []string{missingPool})
+	if !errors.Is(err, models.ErrPoolNotFound) {
+		t.Errorf("Expected POOL_NOT_FOUND exporting a missing pool, got %v", err)
+	}
+
+	chain := newMetadataChain()
+	chain.chainID = 56
+	_, err = services.ExportSnapshot(context.Background(), &config.Config{}, ethereum, chain, []string{stubPair})
+	if err == nil || !strings.Contains(err.Error(), "chain ID") {
+		t.Errorf("Expected a chain ID mismatch, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "old.json")
+	os.WriteFile(path, []byte(`{"version":99,"chain":{"name":"ethereum","chain_id":1}}`), 0o644)
+	if _, err := services.LoadSnapshot(path); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("Expected an unsupported version error, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+// Expected values follow the contract code step by step: Solidly BaseV1Pair
+// getAmountOut/_get_y and Curve 3pool get_dy/get_y/get_D
+
+const (
+	solidlyPair    = "0xdddddddddddddddddddddddddddddddddddddddd"
+	solidlyFactory = "0xdddddddddddddddddddddddddddddddddddddd01"
+	curvePool      = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee01"
+)
+
+func TestSolidlyAmountOut(t *testing.T) {
+	usdc := big.NewInt(1000000)
+	dai := expandTo18Decimals(1)
+	million := big.NewInt(1000000)
+
+	tests := []struct {
+		name                  string
+		amountIn              *big.Int
+		reserveIn, reserveOut *big.Int
+		decimalsIn, decimals  *big.Int
+		stable                bool
+		feeBps                uint32
+		expected              string
+	}{
+		{"stable small", new(big.Int).Mul(big.NewInt(1000), usdc), new(big.Int).Mul(million, usdc),
+			new(big.Int).Mul(million, dai), usdc, dai, true, 1, "999899999500199970501"},
+		{"stable half the pool", new(big.Int).Mul(big.NewInt(500000), dai), new(big.Int).Mul(million, dai),
+			new(big.Int).Mul(million, usdc), dai, usdc, true, 1, "472564278761"},
+		{"volatile", new(big.Int).Mul(big.NewInt(1000), usdc), new(big.Int).Mul(million, usdc),
+			new(big.Int).Mul(big.NewInt(500), dai), usdc, dai, false, 2, "499400699180958877"},
+	}
+
+	for _, tt := range tests {
+		amountOut, err := utils.SolidlyAmountOut(tt.amountIn, tt.reserveIn, tt.reserveOut, tt.decimalsIn, tt.decimals, tt.stable, tt.feeBps)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+		if amountOut.String() != tt.expected {
+			t.Fatalf("%s: expected %s, got %s", tt.name, tt.expected, amountOut)
+		}
+	}
+
+	// A stable pair gives far more than x * y = k near the peg
+	constantProduct, _ := utils.CalculateAmountOut(expandTo18Decimals(500000), expandTo18Decimals(1000000), expandTo18Decimals(1000000))
+	stable, _ := utils.SolidlyAmountOut(expandTo18Decimals(500000), expandTo18Decimals(1000000), expandTo18Decimals(1000000), dai, dai, true, 1)
+	if stable.Cmp(constantProduct) <= 0 {
+		t.Fatalf("Expected the stable curve to beat x * y = k, got %s <= %s", stable, constantProduct)
+	}
+}
+
+func TestCurveAmountOut(t *testing.T) {
+	// 3pool-like: DAI, USDC, USDT with A = 2000 and a 0.01% fee
+	balances := []*big.Int{expandTo18Decimals(1000000), big.NewInt(1100000000000), big.NewInt(900000000000)}
+	rates := []*big.Int{bigInt("1000000000000000000"), bigInt("1000000000000000000000000000000"), bigInt("1000000000000000000000000000000")}
+	amp, fee := big.NewInt(2000), big.NewInt(1000000)
+
+	xp := []*big.Int{expandTo18Decimals(1000000), expandTo18Decimals(1100000), expandTo18Decimals(900000)}
+	d, err := utils.CurveD(xp, amp)
+	if err != nil || d.String() != "2999994952052907371267668" {
+		t.Fatalf("Expected D 2999994952052907371267668, got %s (%v)", d, err)
+	}
+
+	tests := []struct {
+		i, j     int
+		dx       *big.Int
+		expected string
+	}{
+		{0, 1, expandTo18Decimals(1000), "999945425"},
+		{2, 0, big.NewInt(1000000000), "999955515453429826717"},
+	}
+	for _, tt := range tests {
+		dy, err := utils.CurveAmountOut(tt.i, tt.j, tt.dx, balances, rates, amp, fee)
+		if err != nil {
+			t.Fatalf("%d -> %d: expected no error, got %v", tt.i, tt.j, err)
+		}
+		if dy.String() != tt.expected {
+			t.Fatalf("%d -> %d: expected %s, got %s", tt.i, tt.j, tt.expected, dy)
+		}
+	}
+
+	if _, err := utils.CurveAmountOut(1, 1, big.NewInt(1), balances, rates, amp, fee); err == nil {
+		t.Fatalf("Expected an error for swapping a coin into itself")
+	}
+}
+
+func newStableEstimateService(t *testing.T, chain *stubChain) *services.UniswapService {
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewUniswapService(blockchain, nil, nil)
+}
+
+func TestEstimateDetectsSolidlyPair(t *testing.T) {
+	chain := newMetadataChain()
+	chain.deploy(solidlyPair, stubContract{
+		"getReserves()": abiEncode([]string{"uint112", "uint112", "uint32"},
+			big.NewInt(1000000000000), expandTo18Decimals(1000000), uint32(1700000000)),
+		"token0()":  encodeAddress(stubToken0),
+		"token1()":  encodeAddress(stubToken1),
+		"stable()":  abiEncode([]string{"bool"}, true),
+		"factory()": encodeAddress(solidlyFactory),
+	})
+	service := newStableEstimateService(t, chain)
+
+	// The original Solidly factory has no getFee, so the pair charges 0.01%
+	response, err := service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: solidlyPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.PoolType != models.PoolTypeSolidlyStable || response.DstAmount != "999899999500199970501" {
+		t.Fatalf("Expected a stable quote of 999899999500199970501, got %s %s", response.PoolType, response.DstAmount)
+	}
+
+	// A volatile pair under a Velodrome factory uses x * y = k with the factory fee
+	chain.deploy(solidlyPair, stubContract{
+		"getReserves()": abiEncode([]string{"uint112", "uint112", "uint32"},
+			big.NewInt(1000000000000), expandTo18Decimals(500), uint32(1700000000)),
+		"token0()":  encodeAddress(stubToken0),
+		"token1()":  encodeAddress(stubToken1),
+		"stable()":  abiEncode([]string{"bool"}, false),
+		"factory()": encodeAddress(solidlyFactory),
+	})
+	chain.deploy(solidlyFactory, stubContract{"getFee(bool)": encodeUint(2)})
+
+	response, err = service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: solidlyPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.PoolType != models.PoolTypeSolidlyVolatile || response.DstAmount != "499400699180958877" {
+		t.Fatalf("Expected a volatile quote of 499400699180958877, got %s %s", response.PoolType, response.DstAmount)
+	}
+}
+
+func TestEstimateDetectsCurvePool(t *testing.T) {
+	chain := newMetadataChain()
+	chain.deploy(curvePool, stubContract{
+		"A()":   encodeUint(100),
+		"fee()": encodeUint(4000000),
+		withArgs("coins(uint256)", []string{"uint256"}, big.NewInt(0)):    encodeAddress(stubToken1),
+		withArgs("coins(uint256)", []string{"uint256"}, big.NewInt(1)):    encodeAddress(stubToken0),
+		withArgs("balances(uint256)", []string{"uint256"}, big.NewInt(0)): abiEncode([]string{"uint256"}, expandTo18Decimals(1000000)),
+		withArgs("balances(uint256)", []string{"uint256"}, big.NewInt(1)): encodeUint(1000000000000),
+	})
+	service := newStableEstimateService(t, chain)
+
+	response, err := service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: curvePool, Src: stubToken1, Dst: stubToken0, SrcAmount: expandTo18Decimals(1000000).String(),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.PoolType != models.PoolTypeCurve || response.DstAmount != "933739120500" {
+		t.Fatalf("Expected a Curve quote of 933739120500, got %s %s", response.PoolType, response.DstAmount)
+	}
+
+	// Coins the pool does not hold are a mismatch
+	_, err = service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: curvePool, Src: stubToken1, Dst: noSymbolToken, SrcAmount: "1",
+	})
+	if !errors.Is(err, models.ErrTokenMismatch) {
+		t.Fatalf("Expected TOKEN_MISMATCH, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// hubChains registers a quote hub as the only chain
+func hubChains(hub *services.QuoteHub) *services.Chains {
+	ethereum, _ := config.KnownChain("ethereum")
+	return services.NewChains(&services.Chain{Config: ethereum, Quotes: hub})
+}
+
+func newQuoteHub(t *testing.T) (*services.QuoteHub, *stubChain) {
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(uniswapPair(), stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return services.NewQuoteHub(services.NewUniswapService(blockchain, nil, nil), blockchain, 10*time.Millisecond, 5*time.Second), chain
+}
+
+// waitForBlock drains sub until every one of its n subscriptions was quoted at block
+func waitForBlock(t *testing.T, sub *services.QuoteSubscriber, n int, block uint64) []models.QuoteUpdate {
+	t.Helper()
+
+	latest := make([]models.QuoteUpdate, n)
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case <-sub.Updates():
+			for _, update := range sub.Drain() {
+				latest[update.Index] = update
+			}
+		case <-timeout:
+			t.Fatalf("Expected updates at block %d, got %+v", block, latest)
+		}
+
+		done := true
+		for _, update := range latest {
+			done = done && update.BlockNumber == block
+		}
+		if done {
+			return latest
+		}
+	}
+}
+
+func TestQuoteHubDeduplicatesSubscriptions(t *testing.T) {
+	hub, chain := newQuoteHub(t)
+	pool := uniswapPair()
+
+	forward := models.EstimateRequest{Pool: pool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"}
+	same := models.EstimateRequest{Pool: strings.ToUpper(pool[:2]) + pool[2:], Src: stubToken0, Dst: stubToken1, SrcAmount: "0001000000000"}
+	reverse := models.EstimateRequest{Pool: pool, Src: stubToken1, Dst: stubToken0, SrcAmount: "1000000000000000000"}
+
+	first := hub.Subscribe([]models.EstimateRequest{forward, reverse}, services.Requester{})
+	second := hub.Subscribe([]models.EstimateRequest{same}, services.Requester{})
+	if hub.Subscriptions() != 2 {
+		t.Fatalf("Expected 2 unique subscriptions, got %d", hub.Subscriptions())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	updates := waitForBlock(t, first, 2, 1000)
+	if updates[0].Result == nil || updates[0].Result.DstAmount != "493579017198530649" {
+		t.Fatalf("Expected dst_amount 493579017198530649, got %+v", updates[0])
+	}
+	waitForBlock(t, second, 1, 1000)
+
+	// One pool and two tokens, read once for both subscriptions
+	if chain.callCount() != 9 {
+		t.Fatalf("Expected 9 eth_calls, got %d", chain.callCount())
+	}
+
+	// Nothing is re-quoted until the next block
+	time.Sleep(50 * time.Millisecond)
+	if chain.callCount() != 9 {
+		t.Fatalf("Expected no eth_calls without a new block, got %d", chain.callCount())
+	}
+
+	chain.setBlock(1001)
+	waitForBlock(t, first, 2, 1001)
+	waitForBlock(t, second, 1, 1001)
+	if chain.callCount() != 18 {
+		t.Fatalf("Expected 18 eth_calls, got %d", chain.callCount())
+	}
+
+	first.Close()
+	second.Close()
+	if hub.Subscriptions() != 0 {
+		t.Fatalf("Expected no subscriptions after close, got %d", hub.Subscriptions())
+	}
+}
+
+func TestQuoteHubCoalescesUpdatesForSlowConsumers(t *testing.T) {
+	hub, chain := newQuoteHub(t)
+	pool := uniswapPair()
+
+	req := models.EstimateRequest{Pool: pool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000"}
+	slow := hub.Subscribe([]models.EstimateRequest{req}, services.Requester{})
+	fast := hub.Subscribe([]models.EstimateRequest{req}, services.Requester{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	waitForBlock(t, fast, 1, 1000)
+	for block := uint64(1001); block <= 1005; block++ {
+		chain.setBlock(block)
+		waitForBlock(t, fast, 1, block)
+	}
+
+	// The slow consumer only has the latest update pending
+	updates := slow.Drain()
+	if len(updates) != 1 || updates[0].BlockNumber != 1005 {
+		t.Fatalf("Expected one update at block 1005, got %+v", updates)
+	}
+
+	// Shutting the hub down ends every subscriber
+	cancel()
+	select {
+	case <-slow.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected subscriber to be closed on shutdown")
+	}
+}
+
+func TestStreamServerSentEvents(t *testing.T) {
+	hub, _ := newQuoteHub(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	handler := handlers.NewStreamHandler(hubChains(hub), 2)
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.WriteError})
+	app.Get("/estimate/stream", handler.Subscribe)
+	app.Post("/estimate/stream", handler.Subscribe)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	go app.Listener(listener)
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/estimate/stream?pool=" + uniswapPair() +
+		"&src=" + stubToken0 + "&dst=" + stubToken1 + "&src_amount=1000000000")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var update models.QuoteUpdate
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &update); err != nil {
+				t.Fatalf("Expected JSON event data, got %v", err)
+			}
+			break
+		}
+	}
+	if update.Result == nil || update.Result.DstAmount != "493579017198530649" || update.BlockNumber != 1000 {
+		t.Fatalf("Expected quote at block 1000, got %+v", update)
+	}
+
+	// Stopping the hub ends the stream, so the server can shut down
+	cancel()
+	for scanner.Scan() {
+	}
+	if err := app.ShutdownWithTimeout(2 * time.Second); err != nil {
+		t.Fatalf("Expected clean shutdown, got %v", err)
+	}
+}
+
+func TestStreamRejectsInvalidSubscriptions(t *testing.T) {
+	hub, _ := newQuoteHub(t)
+	handler := handlers.NewStreamHandler(hubChains(hub), 2)
+	app := fiber.New()
+	app.Post("/estimate/stream", handler.Subscribe)
+
+	valid := `{"pool":"` + uniswapPair() + `","src":"` + stubToken0 + `","dst":"` + stubToken1 + `","src_amount":"1"}`
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"too many", `{"requests":[` + valid + `,` + valid + `,` + valid + `]}`, models.CodeTooManySubscriptions},
+		{"none", `{"requests":[]}`, models.CodeTooManySubscriptions},
+		{"invalid item", `{"requests":[` + valid + `,{"pool":"0x1"}]}`, models.CodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/estimate/stream", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if resp.StatusCode != 400 {
+				t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var apiErr models.APIError
+			if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+				t.Fatalf("Expected JSON error, got %v", err)
+			}
+			if apiErr.ErrorCode != tt.code {
+				t.Fatalf("Expected %s, got %s", tt.code, apiErr.ErrorCode)
+			}
+			if tt.code == models.CodeValidationFailed && !strings.HasPrefix(apiErr.Fields[0].Field, "requests[1].") {
+				t.Fatalf("Expected field errors prefixed with the item position, got %+v", apiErr.Fields)
+			}
+		})
+	}
+
+	if hub.Subscriptions() != 0 {
+		t.Fatalf("Expected rejected requests not to subscribe, got %d", hub.Subscriptions())
+	}
+}
+
+func TestLoadConfigMaxStreamSubscriptions(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+
+	for _, value := range []string{"0", "-1", "ten"} {
+		t.Setenv("MAX_STREAM_SUBSCRIPTIONS", value)
+		if _, err := config.LoadConfig(); err == nil || !strings.Contains(err.Error(), "MAX_STREAM_SUBSCRIPTIONS") {
+			t.Errorf("Expected MAX_STREAM_SUBSCRIPTIONS=%s to be rejected, got %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"uniswap-est/intrenal/services"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stubContract maps a function signature such as "symbol()" to its raw return data.
+// A key made by withArgs answers only those arguments and takes precedence.
+// Functions that are not listed revert.
+type stubContract map[string][]byte
+
+// withArgs keys a stubContract entry to one set of call arguments
+func withArgs(signature string, types []string, values ...interface{}) string {
+	return signature + "@" + hex.EncodeToString(abiEncode(types, values...))
+}
+
+// stubChain is an in-memory ChainClient serving stub contracts.
+// Tests that use it from several goroutines go through setBlock and callCount.
+type stubChain struct {
+	mu        sync.Mutex
+	contracts map[common.Address]stubContract
+	history   map[uint64]map[common.Address]stubContract // state that differs at a past block
+	block     uint64
+	times     map[uint64]uint64 // block timestamps that differ from the 12 second default
+	chainID   uint64            // eth_chainId answer, 1 when unset
+	down      bool
+	calls     int // number of eth_calls served
+	batches   int // number of batched reads served
+	headers   int // number of block timestamps served
+}
+
+func newStubChain() *stubChain {
+	return &stubChain{contracts: make(map[common.Address]stubContract), block: 1000}
+}
+
+func (s *stubChain) deploy(address string, contract stubContract) {
+	s.contracts[common.HexToAddress(address)] = contract
+}
+
+// deployAt sets the state of a contract as read at one block only
+func (s *stubChain) deployAt(block uint64, address string, contract stubContract) {
+	if s.history == nil {
+		s.history = make(map[uint64]map[common.Address]stubContract)
+	}
+	if s.history[block] == nil {
+		s.history[block] = make(map[common.Address]stubContract)
+	}
+	s.history[block][common.HexToAddress(address)] = contract
+}
+
+func (s *stubChain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if s.down {
+		return nil, errors.New("dial tcp 127.0.0.1:8545: connect: connection refused")
+	}
+
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+
+	contract, ok := s.contracts[*msg.To]
+	if blockNumber != nil {
+		if past, found := s.history[blockNumber.Uint64()][*msg.To]; found {
+			contract, ok = past, true
+		}
+	}
+	if !ok {
+		return []byte{}, nil // no code at address
+	}
+
+	for key, result := range contract {
+		signature, args, _ := strings.Cut(key, "@")
+		if args != "" && string(crypto.Keccak256([]byte(signature))[:4]) == string(msg.Data[:4]) &&
+			args == hex.EncodeToString(msg.Data[4:]) {
+			return result, nil
+		}
+	}
+	for signature, result := range contract {
+		if string(crypto.Keccak256([]byte(signature))[:4]) == string(msg.Data[:4]) {
+			return result, nil
+		}
+	}
+	return nil, services.RevertError{}
+}
+
+func (s *stubChain) BatchCallContract(ctx context.Context, calls []services.ContractCall) error {
+	if s.down {
+		return errors.New("dial tcp 127.0.0.1:8545: connect: connection refused")
+	}
+
+	s.mu.Lock()
+	s.batches++
+	s.mu.Unlock()
+
+	for i := range calls {
+		calls[i].Result, calls[i].Error = s.CallContract(ctx, calls[i].Msg, calls[i].Block)
+	}
+	return nil
+}
+
+func (s *stubChain) BlockNumber(ctx context.Context) (uint64, error) {
+	if s.down {
+		return 0, errors.New("dial tcp 127.0.0.1:8545: connect: connection refused")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.block, nil
+}
+
+func (s *stubChain) ChainID(ctx context.Context) (*big.Int, error) {
+	if s.down {
+		return nil, errors.New("dial tcp 127.0.0.1:8545: connect: connection refused")
+	}
+	if s.chainID == 0 {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int).SetUint64(s.chainID), nil
+}
+
+// CodeAt returns placeholder code for deployed stub contracts and nothing elsewhere
+func (s *stubChain) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if s.down {
+		return nil, errors.New("dial tcp 127.0.0.1:8545: connect: connection refused")
+	}
+
+	_, ok := s.contracts[account]
+	if blockNumber != nil {
+		if _, found := s.history[blockNumber.Uint64()][account]; found {
+			ok = true
+		}
+	}
+	if !ok {
+		return []byte{}, nil
+	}
+	return []byte{0x60, 0x80}, nil
+}
+
+// stubGenesisTime is the timestamp of block 0; blocks follow every 12 seconds
+const stubGenesisTime = 1700000000
+
+func (s *stubChain) BlockTimestamp(ctx context.Context, number *big.Int) (uint64, error) {
+	if s.down {
+		return 0, errors.New("dial tcp 127.0.0.1:8545: connect: connection refused")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers++
+
+	block := s.block
+	if number != nil {
+		block = number.Uint64()
+	}
+	if timestamp, ok := s.times[block]; ok {
+		return timestamp, nil
+	}
+	return stubGenesisTime + 12*block, nil
+}
+
+// setBlock moves the chain head
+func (s *stubChain) setBlock(block uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.block = block
+}
+
+// callCount returns the number of eth_calls served so far
+func (s *stubChain) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *stubChain) Close() {}
+
+// abiEncode packs values with the given ABI types, panicking on programmer error
+func abiEncode(types []string, values ...interface{}) []byte {
+	args := make(abi.Arguments, len(types))
+	for i, typeName := range types {
+		abiType, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args[i] = abi.Argument{Type: abiType}
+	}
+
+	packed, err := args.Pack(values...)
+	if err != nil {
+		panic(err)
+	}
+	return packed
+}
+
+func encodeString(value string) []byte {
+	return abiEncode([]string{"string"}, value)
+}
+
+func encodeBytes32(value string) []byte {
+	var padded [32]byte
+	copy(padded[:], value)
+	return abiEncode([]string{"bytes32"}, padded)
+}
+
+func encodeUint(value int64) []byte {
+	return abiEncode([]string{"uint256"}, big.NewInt(value))
+}
+
+func encodeAddress(address string) []byte {
+	return abiEncode([]string{"address"}, common.HexToAddress(address))
+}
+
+// deployPair deploys a stub Uniswap V2 pair with the given tokens and reserves
+func (s *stubChain) deployPair(pair, token0, token1 string, reserve0, reserve1 *big.Int) {
+	s.deploy(pair, stubContract{
+		"getReserves()": abiEncode([]string{"uint112", "uint112", "uint32"}, reserve0, reserve1, uint32(1700000000)),
+		"token0()":      encodeAddress(token0),
+		"token1()":      encodeAddress(token1),
+	})
+}
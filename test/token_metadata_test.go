@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+)
+
+const (
+	standardToken  = "0x1111111111111111111111111111111111111111"
+	bytes32Token   = "0x2222222222222222222222222222222222222222"
+	noSymbolToken  = "0x3333333333333333333333333333333333333333"
+	noDecimalToken = "0x4444444444444444444444444444444444444444"
+	noCodeAddress  = "0x5555555555555555555555555555555555555555"
+)
+
+func newMetadataChain() *stubChain {
+	chain := newStubChain()
+
+	chain.deploy(standardToken, stubContract{
+		"decimals()": encodeUint(6),
+		"symbol()":   encodeString("USDT"),
+		"name()":     encodeString("Tether USD"),
+	})
+
+	// MKR-style token: bytes32 symbol and name
+	chain.deploy(bytes32Token, stubContract{
+		"decimals()": encodeUint(18),
+		"symbol()":   encodeBytes32("MKR"),
+		"name()":     encodeBytes32("Maker"),
+	})
+
+	chain.deploy(noSymbolToken, stubContract{
+		"decimals()": encodeUint(18),
+	})
+
+	chain.deploy(noDecimalToken, stubContract{
+		"symbol()": encodeString("NODEC"),
+		"name()":   encodeString("No Decimals"),
+	})
+
+	return chain
+}
+
+func TestGetTokenInfoMetadataVariants(t *testing.T) {
+	service, err := services.NewBlockchainServiceWithClient(&config.Config{}, newMetadataChain())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cases := []struct {
+		address   string
+		decimals  uint8
+		symbol    string
+		name      string
+		available bool
+	}{
+		{standardToken, 6, "USDT", "Tether USD", true},
+		{bytes32Token, 18, "MKR", "Maker", true},
+		{noSymbolToken, 18, "", "", false},
+		{noDecimalToken, 0, "NODEC", "No Decimals", false},
+		{noCodeAddress, 0, "", "", false},
+	}
+
+	for _, tc := range cases {
+		token, err := service.GetTokenInfo(context.Background(), tc.address)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.address, err)
+		}
+
+		if token.Decimals != tc.decimals || token.Symbol != tc.symbol || token.Name != tc.name {
+			t.Fatalf("%s: expected %d/%q/%q, got %d/%q/%q",
+				tc.address, tc.decimals, tc.symbol, tc.name, token.Decimals, token.Symbol, token.Name)
+		}
+
+		if token.MetadataAvailable != tc.available {
+			t.Fatalf("%s: expected MetadataAvailable=%t", tc.address, tc.available)
+		}
+	}
+}
+
+func TestGetTokenInfoConnectionError(t *testing.T) {
+	chain := newMetadataChain()
+	chain.down = true
+
+	service, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err = service.GetTokenInfo(context.Background(), standardToken)
+	if !errors.Is(err, models.ErrBlockchainConnection) {
+		t.Fatalf("Expected blockchain connection error, got %v", err)
+	}
+}
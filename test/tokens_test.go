@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+)
+
+const (
+	usdtList = `{"name":"Default","tokens":[
+		{"chainId":1,"address":"0x1111111111111111111111111111111111111111","symbol":"USDT","name":"Tether USD","decimals":6,"logoURI":"https://example.com/usdt.png"},
+		{"chainId":1,"address":"0x2222222222222222222222222222222222222222","symbol":"MKR","name":"Maker","decimals":18},
+		{"chainId":1,"address":"0x3333333333333333333333333333333333333333","symbol":"USDC","name":"USD Coin","decimals":6},
+		{"chainId":1,"address":"0x4444444444444444444444444444444444444444","symbol":"USDC","name":"USD Coin (bridged)","decimals":6},
+		{"chainId":56,"address":"0x5555555555555555555555555555555555555555","symbol":"USDT","name":"Tether USD","decimals":18}
+	]}`
+	extraList = `{"name":"Extra","tokens":[
+		{"chainId":1,"address":"0x6666666666666666666666666666666666666666","symbol":"MKR","name":"Fake Maker","decimals":18},
+		{"chainId":1,"address":"0x7777777777777777777777777777777777777777","symbol":"DAI","name":"Dai Stablecoin","decimals":18}
+	]}`
+)
+
+// writeTokenLists writes the default and extra lists, in priority order
+func writeTokenLists(t *testing.T) []string {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "default.json"), filepath.Join(dir, "extra.json")}
+	for i, content := range []string{usdtList, extraList} {
+		if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	return paths
+}
+
+func TestTokenRegistryResolve(t *testing.T) {
+	registry, err := services.NewTokenRegistry(writeTokenLists(t))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		chainID  uint64
+		token    string
+		expected string
+	}{
+		{1, "USDT", "0x1111111111111111111111111111111111111111"},
+		{1, "usdt", "0x1111111111111111111111111111111111111111"},
+		{56, "USDT", "0x5555555555555555555555555555555555555555"},
+		{1, "MKR", "0x2222222222222222222222222222222222222222"}, // the default list wins over the extra one
+		{1, "DAI", "0x7777777777777777777777777777777777777777"},
+		{1, "0xABCDEFabcdefABCDEFabcdefABCDEFabcdefABCD", "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd"},
+	}
+	for _, tt := range tests {
+		address, err := registry.Resolve(tt.chainID, tt.token)
+		if err != nil || address != tt.expected {
+			t.Errorf("%s on %d: expected %s, got %s, %v", tt.token, tt.chainID, tt.expected, address, err)
+		}
+	}
+
+	if _, err := registry.Resolve(1, "USDC"); !errors.Is(err, models.ErrAmbiguousToken) {
+		t.Errorf("Expected AMBIGUOUS_TOKEN for USDC, got %v", err)
+	}
+	if _, err := registry.Resolve(56, "DAI"); !errors.Is(err, models.ErrUnknownToken) {
+		t.Errorf("Expected UNKNOWN_TOKEN for DAI on BSC, got %v", err)
+	}
+
+	token, ok := registry.Lookup(1, "0x1111111111111111111111111111111111111111")
+	if !ok || token.Name != "Tether USD" || token.LogoURI != "https://example.com/usdt.png" || token.List != "Default" {
+		t.Errorf("Expected the USDT list entry, got %+v", token)
+	}
+}
+
+func TestTokenRegistrySearch(t *testing.T) {
+	registry, err := services.NewTokenRegistry(writeTokenLists(t))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Exact symbol first, then symbol prefixes and names by priority
+	tokens := registry.Search(1, "usd", 10)
+	if len(tokens) != 3 || tokens[0].Symbol != "USDC" || tokens[2].Symbol != "USDT" {
+		t.Fatalf("Expected USDC, USDC, USDT, got %+v", tokens)
+	}
+	tokens = registry.Search(1, "usdt", 10)
+	if len(tokens) != 1 || tokens[0].ChainID != 1 {
+		t.Fatalf("Expected USDT on chain 1 only, got %+v", tokens)
+	}
+	if tokens = registry.Search(1, "maker", 1); len(tokens) != 1 || tokens[0].List != "Default" {
+		t.Fatalf("Expected the default list's Maker first, got %+v", tokens)
+	}
+}
+
+func TestTokenRegistryReloadsChangedLists(t *testing.T) {
+	paths := writeTokenLists(t)
+	registry, err := services.NewTokenRegistry(paths)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if registry.Reload() {
+		t.Fatal("Expected unchanged lists not to reload")
+	}
+
+	// A broken file keeps the loaded version
+	os.WriteFile(paths[1], []byte(`{"tokens":[`), 0o644)
+	if registry.Reload() {
+		t.Fatal("Expected a broken list not to reload")
+	}
+
+	updated := `{"name":"Extra","tokens":[{"chainId":1,"address":"0x8888888888888888888888888888888888888888","symbol":"DAI","name":"Dai","decimals":18}]}`
+	os.WriteFile(paths[1], []byte(updated), 0o644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(paths[1], later, later)
+
+	if !registry.Reload() {
+		t.Fatal("Expected the changed list to reload")
+	}
+	if address, _ := registry.Resolve(1, "DAI"); address != "0x8888888888888888888888888888888888888888" {
+		t.Fatalf("Expected DAI from the reloaded list, got %s", address)
+	}
+}
+
+func TestEstimateResolvesSymbols(t *testing.T) {
+	registry, err := services.NewTokenRegistry(writeTokenLists(t))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	chain := newMetadataChain()
+	reserve1 := new(big.Int)
+	reserve1.SetString("50000000000000000000", 10)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), reserve1)
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	uniswap := services.NewUniswapService(blockchain, nil, registry)
+
+	response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: "usdt", Dst: "MKR", SrcAmount: "1000000000",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.DstAmount != "493579017198530649" {
+		t.Errorf("Expected 493579017198530649, got %s", response.DstAmount)
+	}
+	if response.SrcToken == nil || response.SrcToken.Symbol != "USDT" || response.DstToken == nil || response.DstToken.Name != "Maker" {
+		t.Errorf("Expected token list details, got %+v %+v", response.SrcToken, response.DstToken)
+	}
+
+	_, err = uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: "USDC", Dst: "MKR", SrcAmount: "1000000000",
+	})
+	if !errors.Is(err, models.ErrAmbiguousToken) {
+		t.Errorf("Expected AMBIGUOUS_TOKEN, got %v", err)
+	}
+
+	// Without token lists only addresses work
+	_, err = services.NewUniswapService(blockchain, nil, nil).EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: "USDT", Dst: "MKR", SrcAmount: "1000000000",
+	})
+	if !errors.Is(err, models.ErrUnknownToken) {
+		t.Errorf("Expected UNKNOWN_TOKEN without lists, got %v", err)
+	}
+}
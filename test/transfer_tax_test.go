@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+func TestApplyTransferTax(t *testing.T) {
+	amount := big.NewInt(1000000)
+
+	cases := []struct {
+		taxBps   uint32
+		expected int64
+	}{
+		{0, 1000000},
+		{100, 990000},  // 1% tax
+		{1000, 900000}, // 10% tax
+		{10000, 0},     // everything taxed away
+	}
+
+	for _, tc := range cases {
+		result := utils.ApplyTransferTax(amount, tc.taxBps)
+		if result.Cmp(big.NewInt(tc.expected)) != 0 {
+			t.Fatalf("tax %d bps: expected %d, got %s", tc.taxBps, tc.expected, result)
+		}
+	}
+
+	if amount.Cmp(big.NewInt(1000000)) != 0 {
+		t.Fatalf("Expected input to be left unchanged, got %s", amount)
+	}
+}
+
+func TestTransferTaxReducesAmountOut(t *testing.T) {
+	reserveIn := big.NewInt(100000000000)
+	reserveOut := new(big.Int)
+	reserveOut.SetString("50000000000000000000", 10)
+	amountIn := big.NewInt(1000000000)
+
+	standard, err := utils.CalculateAmountOut(amountIn, reserveIn, reserveOut)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	taxed, err := utils.CalculateAmountOut(utils.ApplyTransferTax(amountIn, 500), reserveIn, reserveOut)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if taxed.Cmp(standard) >= 0 {
+		t.Fatalf("Expected taxed output %s to be below standard output %s", taxed, standard)
+	}
+}
+
+func TestLoadConfigTokenTaxes(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+
+	t.Setenv("ETHEREUM_FEE_ON_TRANSFER_TOKENS", " 0xAbC0000000000000000000000000000000000001:100, 0xabc0000000000000000000000000000000000002:9999")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ethereum, _ := cfg.Chain("ethereum")
+	expected := map[string]uint32{
+		"0xabc0000000000000000000000000000000000001": 100, // keys are lowercased
+		"0xabc0000000000000000000000000000000000002": 9999,
+	}
+	if len(ethereum.FeeOnTransferTokens) != len(expected) {
+		t.Fatalf("Expected taxes %v, got %v", expected, ethereum.FeeOnTransferTokens)
+	}
+	for token, bps := range expected {
+		if ethereum.FeeOnTransferTokens[token] != bps {
+			t.Errorf("Expected %d bps for %s, got %v", bps, token, ethereum.FeeOnTransferTokens)
+		}
+	}
+
+	// A 100% tax leaves nothing to swap
+	for _, value := range []string{"0xabc0000000000000000000000000000000000001:10000", "0xabc0000000000000000000000000000000000001", "0xabc0000000000000000000000000000000000001:-1"} {
+		t.Setenv("ETHEREUM_FEE_ON_TRANSFER_TOKENS", value)
+		if _, err := config.LoadConfig(); err == nil || !strings.Contains(err.Error(), "ETHEREUM_FEE_ON_TRANSFER_TOKENS") {
+			t.Errorf("Expected ETHEREUM_FEE_ON_TRANSFER_TOKENS=%s to be rejected, got %v", value, err)
+		}
+	}
+}
+
+func TestLoadConfigTokenTaxesPerChain(t *testing.T) {
+	t.Setenv("CHAINS", "ethereum,bsc")
+	t.Setenv("ETHEREUM_RPC_URL", "http://127.0.0.1:8545")
+	t.Setenv("BSC_RPC_URL", "http://127.0.0.1:8546")
+	token := "0xabc0000000000000000000000000000000000001"
+
+	// The legacy names apply to ethereum only
+	t.Setenv("FEE_ON_TRANSFER_TOKENS", token+":100")
+	t.Setenv("REBASING_TOKENS", token)
+	t.Setenv("BSC_FEE_ON_TRANSFER_TOKENS", token+":300")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ethereum, _ := cfg.Chain("ethereum")
+	bsc, _ := cfg.Chain("bsc")
+	if ethereum.FeeOnTransferTokens[token] != 100 || !ethereum.RebasingTokens[token] {
+		t.Errorf("Expected the legacy settings on ethereum, got %v and %v", ethereum.FeeOnTransferTokens, ethereum.RebasingTokens)
+	}
+	if bsc.FeeOnTransferTokens[token] != 300 || bsc.RebasingTokens[token] {
+		t.Errorf("Expected only the bsc settings on bsc, got %v and %v", bsc.FeeOnTransferTokens, bsc.RebasingTokens)
+	}
+
+	// The per-chain names take precedence over the legacy ones
+	t.Setenv("ETHEREUM_FEE_ON_TRANSFER_TOKENS", token+":200")
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ethereum, _ = cfg.Chain("ethereum"); ethereum.FeeOnTransferTokens[token] != 200 {
+		t.Errorf("Expected ETHEREUM_FEE_ON_TRANSFER_TOKENS to win, got %v", ethereum.FeeOnTransferTokens)
+	}
+}
+
+// newTaxedService quotes the stub pair at its canonical address with a 1% tax on
+// transfers of token0 and a 2% tax on token1, token1 rebasing, and a router answering getAmountsOut with
+// the untaxed output of 1000 token0
+func newTaxedService(t *testing.T) (*services.UniswapService, string) {
+	ethereum, _ := config.KnownChain("ethereum")
+	canonical := utils.PairAddress(ethereum.FactoryAddress, ethereum.InitCodeHash, stubToken0, stubToken1)
+
+	chain := newMetadataChain()
+	chain.deployPair(canonical, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	chain.deploy(ethereum.RouterAddress, stubContract{
+		"getAmountsOut(uint256,address[])": abiEncode([]string{"uint256[]"},
+			[]*big.Int{big.NewInt(1000000000), bigInt("493579017198530649")}),
+	})
+
+	ethereum.FeeOnTransferTokens = map[string]uint32{stubToken0: 100, stubToken1: 200}
+	ethereum.RebasingTokens = map[string]bool{stubToken1: true}
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, ethereum, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewUniswapService(blockchain, services.NewVerifier(blockchain, 0), nil), canonical
+}
+
+func TestEstimateSwapWithTransferTax(t *testing.T) {
+	uniswap, canonical := newTaxedService(t)
+
+	response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: canonical, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Verify: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The pair receives 990 token0 and pays out 488691468597502075, of which the
+	// recipient receives 98%
+	if response.DstAmount != "478917639225552033" {
+		t.Errorf("Expected dst_amount 478917639225552033, got %s", response.DstAmount)
+	}
+	if !response.FeeOnTransfer || response.SrcTransferTaxBps != 100 || response.DstTransferTaxBps != 200 || !response.Rebasing {
+		t.Errorf("Expected fee_on_transfer with 100 and 200 bps and rebasing, got %+v", response)
+	}
+
+	// The router knows nothing about taxes and is compared with the untaxed output
+	verification := response.Verification
+	if verification == nil || verification.Status != models.VerificationMatch ||
+		verification.OffChainAmount != "493579017198530649" {
+		t.Errorf("Expected the untaxed output to match the router, got %+v", verification)
+	}
+}
@@ -0,0 +1,158 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+// oraclePair is the stub pair state at one block
+func oraclePair(reserve0, reserve1 *big.Int, blockTimestampLast uint32, price0Cumulative *big.Int) stubContract {
+	return stubContract{
+		"getReserves()":          abiEncode([]string{"uint112", "uint112", "uint32"}, reserve0, reserve1, blockTimestampLast),
+		"token0()":               encodeAddress(stubToken0),
+		"token1()":               encodeAddress(stubToken1),
+		"price0CumulativeLast()": abiEncode([]string{"uint256"}, price0Cumulative),
+		"price1CumulativeLast()": encodeUint(0),
+	}
+}
+
+// newOracleChain sets up a pair priced at 0.002 token1 per token0 until block 1050 and
+// at 0.004 afterwards. The pair syncs at blocks 1000 and 1050 only, so the read at
+// 1100 needs the counterfactual accumulation. times overrides block timestamps.
+func newOracleChain(t *testing.T, startCumulative *big.Int, times map[uint64]uint64) (*services.OracleService, *stubChain) {
+	chain := newMetadataChain()
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(1), big.NewInt(1))
+	chain.setBlock(1100)
+	chain.times = times
+
+	timestamp := func(block uint64) uint64 {
+		value, _ := chain.BlockTimestamp(context.Background(), new(big.Int).SetUint64(block))
+		return value
+	}
+
+	// 1000 USDT against 2 and then 4 of the 18 decimal token
+	usdt := big.NewInt(1000000000)
+	two, _ := new(big.Int).SetString("2000000000000000000", 10)
+	four, _ := new(big.Int).SetString("4000000000000000000", 10)
+	price, _ := utils.FractionUQ112x112(two, usdt)
+
+	// Accumulated during the 600 seconds from block 1000 to 1050
+	accumulated := new(big.Int).Add(startCumulative, new(big.Int).Mul(price, big.NewInt(int64(timestamp(1050)-timestamp(1000)))))
+	accumulated.Mod(accumulated, new(big.Int).Lsh(big.NewInt(1), 256))
+
+	chain.deployAt(1000, stubPair, oraclePair(usdt, two, uint32(timestamp(1000)), startCumulative))
+	chain.deployAt(1050, stubPair, oraclePair(usdt, four, uint32(timestamp(1050)), accumulated))
+	chain.deployAt(1100, stubPair, oraclePair(usdt, four, uint32(timestamp(1050)), accumulated))
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chain.headers = 0
+	return services.NewOracleService(blockchain), chain
+}
+
+func TestTWAPBetweenBlocks(t *testing.T) {
+	oracle, chain := newOracleChain(t, big.NewInt(12345), nil)
+
+	response, err := oracle.TWAP(context.Background(), &models.TwapRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+		FromBlock: "1000", ToBlock: "1100",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 600 seconds at 0.002 and 600 seconds at 0.004
+	if response.Price != "0.003000000000000000" {
+		t.Fatalf("Expected price 0.003, got %s", response.Price)
+	}
+	if response.DstAmount != "3000000000000000000" {
+		t.Fatalf("Expected dst_amount 3000000000000000000, got %s", response.DstAmount)
+	}
+	if response.ToTimestamp-response.FromTimestamp != 1200 {
+		t.Fatalf("Expected 1200 seconds, got %d", response.ToTimestamp-response.FromTimestamp)
+	}
+
+	// One header per end of the interval; the observations do not read them again
+	if chain.headers != 2 {
+		t.Fatalf("Expected 2 block headers read, got %d", chain.headers)
+	}
+}
+
+func TestTWAPHandlesOverflow(t *testing.T) {
+	// The accumulator wraps past 2^256 and the uint32 timestamps past 2^32 in between
+	start := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), utils.Q112)
+	wrap := uint64(1) << 32
+	oracle, _ := newOracleChain(t, start, map[uint64]uint64{1000: wrap - 300, 1050: wrap + 300, 1100: wrap + 900})
+
+	response, err := oracle.TWAP(context.Background(), &models.TwapRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, FromBlock: "1000", ToBlock: "1100",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Price != "0.003000000000000000" {
+		t.Fatalf("Expected price 0.003 across the overflow, got %s", response.Price)
+	}
+}
+
+func TestTWAPOverTimeWindow(t *testing.T) {
+	oracle, _ := newOracleChain(t, big.NewInt(0), nil)
+
+	// The last 600 seconds start exactly at block 1050, after the price change
+	response, err := oracle.TWAP(context.Background(), &models.TwapRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, Window: "600",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.FromBlock != 1050 || response.ToBlock != 1100 {
+		t.Fatalf("Expected blocks 1050 to 1100, got %d to %d", response.FromBlock, response.ToBlock)
+	}
+	if response.Price != "0.004000000000000000" {
+		t.Fatalf("Expected price 0.004, got %s", response.Price)
+	}
+}
+
+func TestTWAPRejectsInvalidIntervals(t *testing.T) {
+	oracle, _ := newOracleChain(t, big.NewInt(0), nil)
+
+	for _, req := range []*models.TwapRequest{
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1},
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1, FromBlock: "1000", Window: "600"},
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1, FromBlock: "1100", ToBlock: "1000"},
+		{Pool: stubPair, Src: stubToken0, Dst: stubToken1, FromBlock: "1000", ToBlock: "1200"},
+	} {
+		if _, err := oracle.TWAP(context.Background(), req); !errors.Is(err, models.ErrInvalidBlockRange) {
+			t.Fatalf("Expected INVALID_BLOCK_RANGE for %+v, got %v", req, err)
+		}
+	}
+}
+
+func TestUQ112x112(t *testing.T) {
+	half, _ := utils.FractionUQ112x112(big.NewInt(1), big.NewInt(2))
+	if half.Cmp(new(big.Int).Rsh(utils.Q112, 1)) != 0 {
+		t.Fatalf("Expected 1/2 as 2^111, got %s", half)
+	}
+	if amount := utils.MulDecodeUQ112x112(half, big.NewInt(7)); amount.Int64() != 3 {
+		t.Fatalf("Expected 7 * 1/2 truncated to 3, got %s", amount)
+	}
+
+	// Wrapping subtraction of accumulators
+	if diff := utils.SubUint256(big.NewInt(5), new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(5))); diff.Int64() != 10 {
+		t.Fatalf("Expected 10 across the 2^256 boundary, got %s", diff)
+	}
+
+	// 100 seconds since the last sync, across the uint32 boundary
+	price0, _ := utils.CounterfactualCumulativePrices(big.NewInt(0), big.NewInt(0), big.NewInt(1), big.NewInt(2), ^uint32(0)-49, 1<<32+50)
+	if price0.Cmp(new(big.Int).Mul(utils.Q112, big.NewInt(200))) != 0 {
+		t.Fatalf("Expected 2 * 100 seconds, got %s", price0)
+	}
+}
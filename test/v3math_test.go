@@ -0,0 +1,376 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+)
+
+// Vectors from the Uniswap V3 core test suite (TickMath, SqrtPriceMath and SwapMath specs)
+
+// encodePriceSqrt(reserve1, reserve0) as used by the V3 tests
+var (
+	price1to1      = bigInt("79228162514264337593543950336")
+	price101to100  = bigInt("79623317895830914510639640423")
+	price1000to100 = bigInt("250541448375047931186413801569")
+	price121to100  = bigInt("87150978765690771352898345369")
+)
+
+func bigInt(value string) *big.Int {
+	result, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		panic("bad integer " + value)
+	}
+	return result
+}
+
+func expandTo18Decimals(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), bigInt("1000000000000000000"))
+}
+
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	vectors := []struct {
+		tick     int32
+		expected string
+	}{
+		{utils.MinTick, "4295128739"},
+		{utils.MinTick + 1, "4295343490"},
+		{0, "79228162514264337593543950336"},
+		{utils.MaxTick - 1, "1461373636630004318706518188784493106690254656249"},
+		{utils.MaxTick, "1461446703485210103287273052203988822378723970342"},
+	}
+	for _, v := range vectors {
+		ratio, err := utils.GetSqrtRatioAtTick(v.tick)
+		if err != nil || ratio.String() != v.expected {
+			t.Fatalf("tick %d: expected %s, got %v (%v)", v.tick, v.expected, ratio, err)
+		}
+	}
+
+	for _, tick := range []int32{utils.MinTick - 1, utils.MaxTick + 1} {
+		if _, err := utils.GetSqrtRatioAtTick(tick); err == nil {
+			t.Fatalf("tick %d: expected out of range error", tick)
+		}
+	}
+
+	// Within 1e-12 of sqrt(1.0001^tick) * 2^96 across the whole range
+	for _, tick := range []int32{50, 100, 250, 500, 1000, 2500, 3000, 4000, 5000, 50000, 150000, 250000, 500000, 738203} {
+		for _, signed := range []int32{tick, -tick} {
+			ratio, _ := utils.GetSqrtRatioAtTick(signed)
+			actual, _ := new(big.Float).Quo(new(big.Float).SetInt(ratio), new(big.Float).SetInt(utils.Q96)).Float64()
+			expected := math.Exp(float64(signed) * math.Log1p(0.0001) / 2)
+			if math.Abs(actual-expected)/expected > 1e-12 {
+				t.Fatalf("tick %d: expected %g, got %g", signed, expected, actual)
+			}
+		}
+	}
+}
+
+func TestGetTickAtSqrtRatio(t *testing.T) {
+	vectors := []struct {
+		ratio    *big.Int
+		expected int32
+	}{
+		{utils.MinSqrtRatio, utils.MinTick},
+		{bigInt("4295343490"), utils.MinTick + 1},
+		{bigInt("1461373636630004318706518188784493106690254656249"), utils.MaxTick - 1},
+		{new(big.Int).Sub(utils.MaxSqrtRatio, big.NewInt(1)), utils.MaxTick - 1},
+		{price1to1, 0},
+	}
+	for _, v := range vectors {
+		tick, err := utils.GetTickAtSqrtRatio(v.ratio)
+		if err != nil || tick != v.expected {
+			t.Fatalf("ratio %s: expected tick %d, got %d (%v)", v.ratio, v.expected, tick, err)
+		}
+	}
+
+	for _, ratio := range []*big.Int{new(big.Int).Sub(utils.MinSqrtRatio, big.NewInt(1)), utils.MaxSqrtRatio} {
+		if _, err := utils.GetTickAtSqrtRatio(ratio); err == nil {
+			t.Fatalf("ratio %s: expected out of range error", ratio)
+		}
+	}
+}
+
+func TestSqrtPriceMath(t *testing.T) {
+	tenth := new(big.Int).Div(expandTo18Decimals(1), big.NewInt(10))
+
+	cases := []struct {
+		name     string
+		fn       func() (*big.Int, error)
+		expected string
+	}{
+		{"input amount of 0.1 token1", func() (*big.Int, error) {
+			return utils.GetNextSqrtPriceFromInput(price1to1, expandTo18Decimals(1), tenth, false)
+		}, "87150978765690771352898345369"},
+		{"input amount of 0.1 token0", func() (*big.Int, error) {
+			return utils.GetNextSqrtPriceFromInput(price1to1, expandTo18Decimals(1), tenth, true)
+		}, "72025602285694852357767227579"},
+		{"amountIn > type(uint96).max and zeroForOne", func() (*big.Int, error) {
+			return utils.GetNextSqrtPriceFromInput(price1to1, expandTo18Decimals(10), new(big.Int).Lsh(big.NewInt(1), 100), true)
+		}, "624999999995069620"},
+		{"can return 1 with enough amountIn and zeroForOne", func() (*big.Int, error) {
+			maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+			return utils.GetNextSqrtPriceFromInput(price1to1, big.NewInt(1), new(big.Int).Rsh(maxUint256, 1), true)
+		}, "1"},
+		{"output amount of 0.1 token1", func() (*big.Int, error) {
+			return utils.GetNextSqrtPriceFromOutput(price1to1, expandTo18Decimals(1), tenth, false)
+		}, "88031291682515930659493278152"},
+		{"output amount of 0.1 token0", func() (*big.Int, error) {
+			return utils.GetNextSqrtPriceFromOutput(price1to1, expandTo18Decimals(1), tenth, true)
+		}, "71305346262837903834189555302"},
+		{"getAmount0Delta rounding up", func() (*big.Int, error) {
+			return utils.GetAmount0Delta(price1to1, price121to100, expandTo18Decimals(1), true)
+		}, "90909090909090910"},
+		{"getAmount0Delta rounding down", func() (*big.Int, error) {
+			return utils.GetAmount0Delta(price1to1, price121to100, expandTo18Decimals(1), false)
+		}, "90909090909090909"},
+		{"getAmount1Delta rounding up", func() (*big.Int, error) {
+			return utils.GetAmount1Delta(price1to1, price121to100, expandTo18Decimals(1), true)
+		}, "100000000000000000"},
+		{"getAmount1Delta rounding down", func() (*big.Int, error) {
+			return utils.GetAmount1Delta(price1to1, price121to100, expandTo18Decimals(1), false)
+		}, "99999999999999999"},
+	}
+
+	for _, tc := range cases {
+		result, err := tc.fn()
+		if err != nil || result.String() != tc.expected {
+			t.Fatalf("%s: expected %s, got %v (%v)", tc.name, tc.expected, result, err)
+		}
+	}
+
+	// Output larger than the pool holds
+	if _, err := utils.GetNextSqrtPriceFromOutput(price1to1, big.NewInt(1), big.NewInt(4), false); err == nil {
+		t.Fatalf("Expected error when the output exceeds the virtual reserves")
+	}
+}
+
+func TestComputeSwapStep(t *testing.T) {
+	cases := []struct {
+		name                             string
+		price, target, liquidity, amount *big.Int
+		fee                              uint32
+		amountIn, amountOut, feeAmount   string
+		sqrtQ                            *big.Int // nil: only amounts are checked
+	}{
+		{"exact amount in capped at price target in one for zero",
+			price1to1, price101to100, expandTo18Decimals(2), expandTo18Decimals(1), 600,
+			"9975124224178055", "9925619580021728", "5988667735148", price101to100},
+		{"exact amount out capped at price target in one for zero",
+			price1to1, price101to100, expandTo18Decimals(2), new(big.Int).Neg(expandTo18Decimals(1)), 600,
+			"9975124224178055", "9925619580021728", "5988667735148", price101to100},
+		{"exact amount in fully spent in one for zero",
+			price1to1, price1000to100, expandTo18Decimals(2), expandTo18Decimals(1), 600,
+			"999400000000000000", "666399946655997866", "600000000000000", nil},
+		{"amount out is capped at the desired amount out",
+			bigInt("417332158212080721273783715441582"), bigInt("1452870262520218020823638996"),
+			bigInt("159344665391607089467575320103"), big.NewInt(-1), 1,
+			"1", "1", "1", bigInt("417332158212080721273783715441581")},
+		{"target price of 1 uses partial input amount",
+			big.NewInt(2), big.NewInt(1), big.NewInt(1), bigInt("3915081100057732413702495386755767"), 1,
+			"39614081257132168796771975168", "0", "39614120871253040049813", big.NewInt(1)},
+		{"entire input amount taken as fee",
+			big.NewInt(2413), bigInt("79887613182836312"), bigInt("1985041575832132834610021537970"), big.NewInt(10), 1872,
+			"0", "0", "10", big.NewInt(2413)},
+		{"intermediate insufficient liquidity in zero for one exact output",
+			bigInt("20282409603651670423947251286016"), bigInt("22310650564016837466341976414617"),
+			big.NewInt(1024), big.NewInt(-4), 3000,
+			"26215", "0", "79", bigInt("22310650564016837466341976414617")},
+		{"intermediate insufficient liquidity in one for zero exact output",
+			bigInt("20282409603651670423947251286016"), bigInt("18254168643286503381552526157414"),
+			big.NewInt(1024), big.NewInt(-263000), 3000,
+			"1", "26214", "1", bigInt("18254168643286503381552526157414")},
+	}
+
+	for _, tc := range cases {
+		step, err := utils.ComputeSwapStep(tc.price, tc.target, tc.liquidity, tc.amount, tc.fee)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.name, err)
+		}
+		if step.AmountIn.String() != tc.amountIn || step.AmountOut.String() != tc.amountOut || step.FeeAmount.String() != tc.feeAmount {
+			t.Fatalf("%s: expected in=%s out=%s fee=%s, got in=%s out=%s fee=%s", tc.name,
+				tc.amountIn, tc.amountOut, tc.feeAmount, step.AmountIn, step.AmountOut, step.FeeAmount)
+		}
+		if tc.sqrtQ != nil && step.SqrtRatioNextX96.Cmp(tc.sqrtQ) != 0 {
+			t.Fatalf("%s: expected sqrtQ %s, got %s", tc.name, tc.sqrtQ, step.SqrtRatioNextX96)
+		}
+	}
+}
+
+// newV3Pool is a 0.3% pool at price 1 with liquidity 2e18 in [-600, 600] and another
+// 1e18 in [-1200, 1200]; all ticks are in bitmap word 0 and -1
+func newV3Pool() *models.V3PoolState {
+	pool := &models.V3PoolState{
+		SqrtPriceX96: new(big.Int).Set(price1to1),
+		Tick:         0,
+		Liquidity:    expandTo18Decimals(3),
+		Fee:          3000,
+		TickSpacing:  60,
+		TickBitmap:   map[int16]*big.Int{-1: new(big.Int), 0: new(big.Int)},
+		LiquidityNet: map[int32]*big.Int{},
+	}
+
+	for tick, net := range map[int32]*big.Int{
+		-1200: expandTo18Decimals(1), -600: expandTo18Decimals(2),
+		600: new(big.Int).Neg(expandTo18Decimals(2)), 1200: new(big.Int).Neg(expandTo18Decimals(1)),
+	} {
+		compressed := tick / 60
+		word := pool.TickBitmap[int16(compressed>>8)]
+		word.SetBit(word, int(uint8(compressed)), 1)
+		pool.LiquidityNet[tick] = net
+	}
+	return pool
+}
+
+func TestSwapV3WithinOneRange(t *testing.T) {
+	pool := newV3Pool()
+	amountIn := expandTo18Decimals(1)
+	amountIn.Div(amountIn, big.NewInt(100))
+
+	result, err := utils.SwapV3(pool, true, amountIn, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A single step of the in-range liquidity gives the same output
+	step, _ := utils.ComputeSwapStep(price1to1, utils.MinSqrtRatio, pool.Liquidity, amountIn, 3000)
+	if new(big.Int).Neg(result.Amount1).Cmp(step.AmountOut) != 0 || result.Amount0.Cmp(amountIn) != 0 {
+		t.Fatalf("Expected %s out for %s in, got %s for %s", step.AmountOut, amountIn, result.Amount1, result.Amount0)
+	}
+	if result.TicksCrossed != 0 || result.Tick >= 0 || result.Tick < -600 {
+		t.Fatalf("Expected to stay in the first range, got tick %d after %d crossings", result.Tick, result.TicksCrossed)
+	}
+}
+
+func TestSwapV3CrossesTicks(t *testing.T) {
+	pool := newV3Pool()
+
+	// Enough token1 to push the price through tick 600 into the outer range
+	amountIn := new(big.Int).Div(expandTo18Decimals(1), big.NewInt(10))
+	result, err := utils.SwapV3(pool, false, amountIn, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.TicksCrossed != 1 || result.Tick < 600 || result.Tick >= 1200 {
+		t.Fatalf("Expected to end between ticks 600 and 1200, got tick %d after %d crossings", result.Tick, result.TicksCrossed)
+	}
+	if result.Liquidity.Cmp(expandTo18Decimals(1)) != 0 {
+		t.Fatalf("Expected the outer range liquidity 1e18, got %s", result.Liquidity)
+	}
+
+	// Exact output for the amount just received needs the same input, give or take rounding
+	exactOut, err := utils.SwapV3(pool, false, result.Amount0, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if diff := new(big.Int).Sub(exactOut.Amount1, amountIn); diff.CmpAbs(big.NewInt(2)) > 0 {
+		t.Fatalf("Expected about %s in for the exact output, got %s", amountIn, exactOut.Amount1)
+	}
+
+	// Past tick 1200 the bitmap runs out of loaded words
+	if _, err := utils.SwapV3(pool, false, expandTo18Decimals(1000), nil); err != utils.ErrTicksNotLoaded {
+		t.Fatalf("Expected ErrTicksNotLoaded, got %v", err)
+	}
+}
+
+const v3Pool = "0xcccccccccccccccccccccccccccccccccccccccc"
+
+// deployV3Pool deploys a stub Uniswap V3 pool serving the given state; bitmap words
+// that are not in the state are empty
+func (s *stubChain) deployV3Pool(address string, pool *models.V3PoolState) {
+	contract := stubContract{
+		"slot0()": abiEncode([]string{"uint160", "int24", "uint16", "uint16", "uint16", "uint8", "bool"},
+			pool.SqrtPriceX96, big.NewInt(int64(pool.Tick)), uint16(0), uint16(1), uint16(1), uint8(0), true),
+		"liquidity()":       abiEncode([]string{"uint128"}, pool.Liquidity),
+		"fee()":             abiEncode([]string{"uint24"}, big.NewInt(int64(pool.Fee))),
+		"tickSpacing()":     abiEncode([]string{"int24"}, big.NewInt(int64(pool.TickSpacing))),
+		"token0()":          encodeAddress(pool.Token0),
+		"token1()":          encodeAddress(pool.Token1),
+		"tickBitmap(int16)": encodeUint(0),
+	}
+	for word, bits := range pool.TickBitmap {
+		contract[withArgs("tickBitmap(int16)", []string{"int16"}, word)] = abiEncode([]string{"uint256"}, bits)
+	}
+	for tick, net := range pool.LiquidityNet {
+		contract[withArgs("ticks(int24)", []string{"int24"}, big.NewInt(int64(tick)))] = abiEncode(
+			[]string{"uint128", "int128", "uint256", "uint256", "int56", "uint160", "uint32", "bool"},
+			new(big.Int).Abs(net), net, new(big.Int), new(big.Int), new(big.Int), new(big.Int), uint32(0), true)
+	}
+	s.deploy(address, contract)
+}
+
+func newV3EstimateService(t *testing.T, tickWords int) (*services.UniswapService, *services.BlockchainService) {
+	chain := newMetadataChain()
+	pool := newV3Pool()
+	pool.Token0, pool.Token1 = stubToken0, stubToken1
+	chain.deployV3Pool(v3Pool, pool)
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+
+	blockchain, err := services.NewBlockchainServiceWithClient(&config.Config{V3TickWords: tickWords}, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewUniswapService(blockchain, nil, nil), blockchain
+}
+
+func TestEstimateDetectsV3Pool(t *testing.T) {
+	service, blockchain := newV3EstimateService(t, 1)
+
+	// token1 -> token0 across tick 600, as in TestSwapV3CrossesTicks
+	amountIn := new(big.Int).Div(expandTo18Decimals(1), big.NewInt(10))
+	expected, err := utils.SwapV3(newV3Pool(), false, amountIn, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	response, err := service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: v3Pool, Src: stubToken1, Dst: stubToken0, SrcAmount: amountIn.String(),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.PoolType != models.PoolTypeV3 {
+		t.Fatalf("Expected pool type %s, got %s", models.PoolTypeV3, response.PoolType)
+	}
+	if response.DstAmount != new(big.Int).Neg(expected.Amount0).String() {
+		t.Fatalf("Expected dst_amount %s, got %s", new(big.Int).Neg(expected.Amount0), response.DstAmount)
+	}
+
+	// V2 pairs are still quoted with the constant product formula
+	response, err = service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000",
+	})
+	if err != nil || response.PoolType != models.PoolTypeV2 || response.DstAmount != "493579017198530649" {
+		t.Fatalf("Expected the V2 quote 493579017198530649, got %+v (%v)", response, err)
+	}
+
+	// Endpoints built on reserves refuse V3 pools
+	if _, err := blockchain.GetPoolReserves(context.Background(), v3Pool); !errors.Is(err, models.ErrUnsupportedPool) {
+		t.Fatalf("Expected UNSUPPORTED_POOL_TYPE, got %v", err)
+	}
+}
+
+func TestEstimateV3PastLoadedTicks(t *testing.T) {
+	// Only word 0 is loaded, so a token0 -> token1 swap runs out of ticks below tick 0
+	service, _ := newV3EstimateService(t, 0)
+
+	_, err := service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: v3Pool, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000",
+	})
+	if !errors.Is(err, models.ErrInsufficientLiquidity) {
+		t.Fatalf("Expected INSUFFICIENT_LIQUIDITY, got %v", err)
+	}
+
+	// The wrong tokens are a mismatch like for V2 pairs
+	_, err = service.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: v3Pool, Src: stubToken0, Dst: noSymbolToken, SrcAmount: "1000000",
+	})
+	if !errors.Is(err, models.ErrTokenMismatch) {
+		t.Fatalf("Expected TOKEN_MISMATCH, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	usdtChecksummed = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	usdtBadChecksum = "0xdac17F958D2ee523a2206206994597C13D831ec7"
+	wethLower       = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	usdtWethPair    = "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852"
+)
+
+func TestValidateStructAmounts(t *testing.T) {
+	maxUint256 := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+	cases := []struct {
+		amount string
+		valid  bool
+	}{
+		{"1", true},
+		{maxUint256, true},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639936", false},
+		{"0", false},
+		{"-5", false},
+		{"+5", false},
+		{"1.5", false},
+		{"1e18", false},
+	}
+
+	for _, tc := range cases {
+		req := &models.EstimateRequest{Pool: usdtWethPair, Src: usdtChecksummed, Dst: wethLower, SrcAmount: tc.amount}
+		errs := utils.ValidateStruct(req)
+		if (len(errs) == 0) != tc.valid {
+			t.Fatalf("amount %q: expected valid=%t, got errors %+v", tc.amount, tc.valid, errs)
+		}
+	}
+}
+
+func TestValidateStructBlockNumbers(t *testing.T) {
+	cases := []struct {
+		block string
+		valid bool
+	}{
+		{"0", true},
+		{"18446744073709551615", true},
+		{"18446744073709551616", false},
+		{"-1", false},
+		{"+1", false},
+		{"0x10", false},
+	}
+
+	for _, tc := range cases {
+		req := &models.HistoryRequest{
+			Pool: usdtWethPair, Src: usdtChecksummed, Dst: wethLower, SrcAmount: "1",
+			FromBlock: tc.block, ToBlock: "1",
+		}
+		errs := utils.ValidateStruct(req)
+		if (len(errs) == 0) != tc.valid {
+			t.Fatalf("block %q: expected valid=%t, got errors %+v", tc.block, tc.valid, errs)
+		}
+	}
+}
+
+func TestValidateStructAddressList(t *testing.T) {
+	cases := []struct {
+		pools string
+		valid bool
+	}{
+		{usdtWethPair, true},
+		{usdtWethPair + ", " + wethLower, true},
+		{usdtWethPair + ",", false},
+		{usdtWethPair + ",0x1234", false},
+	}
+
+	for _, tc := range cases {
+		errs := utils.ValidateStruct(&models.ArbitrageRequest{Pools: tc.pools})
+		if (len(errs) == 0) != tc.valid {
+			t.Fatalf("pools %q: expected valid=%t, got errors %+v", tc.pools, tc.valid, errs)
+		}
+	}
+}
+
+func TestValidateStructChecksum(t *testing.T) {
+	for _, address := range []string{usdtChecksummed, wethLower, "0xC02AAA39B223FE8D0A0E5C4F27EAD9083C756CC2"} {
+		if !utils.IsValidChecksum(address) {
+			t.Fatalf("Expected %s to be accepted", address)
+		}
+	}
+
+	req := &models.EstimateRequest{Pool: usdtWethPair, Src: usdtBadChecksum, Dst: wethLower, SrcAmount: "1"}
+	errs := utils.ValidateStruct(req)
+	if len(errs) != 1 || errs[0].Field != "src" || errs[0].Rule != "token" {
+		t.Fatalf("Expected checksum error on src, got %+v", errs)
+	}
+}
+
+func TestEstimateReturnsAllFieldErrors(t *testing.T) {
+	app := fiber.New()
+	app.Get("/estimate", handlers.NewEstimateHandler(nil, 0, 1).EstimateSwap)
+
+	req := httptest.NewRequest("GET", "/estimate?pool=0x123&src="+wethLower+"&dst="+wethLower+"&src_amount=-5", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+	}
+
+	var apiErr models.APIError
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+		t.Fatalf("Expected JSON response, got %v", err)
+	}
+
+	expected := map[string]string{"pool": "len", "dst": "nefield", "src_amount": "uint256"}
+	if len(apiErr.Fields) != len(expected) {
+		t.Fatalf("Expected %d field errors, got %+v", len(expected), apiErr.Fields)
+	}
+	for _, field := range apiErr.Fields {
+		if expected[field.Field] != field.Rule {
+			t.Fatalf("Unexpected field error %+v", field)
+		}
+	}
+}
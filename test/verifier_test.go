@@ -0,0 +1,169 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"uniswap-est/intrenal/config"
+	"uniswap-est/intrenal/handlers"
+	"uniswap-est/intrenal/models"
+	"uniswap-est/intrenal/services"
+	"uniswap-est/intrenal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newVerifiedService deploys the stub pair at its canonical address and at stubPair, and
+// a router answering getAmountsOut with routerAmount, or reverting when it is nil
+func newVerifiedService(t *testing.T, routerAmount *big.Int) (*services.UniswapService, string) {
+	ethereum, _ := config.KnownChain("ethereum")
+	canonical := utils.PairAddress(ethereum.FactoryAddress, ethereum.InitCodeHash, stubToken0, stubToken1)
+
+	chain := newMetadataChain()
+	chain.deployPair(canonical, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+
+	router := stubContract{}
+	if routerAmount != nil {
+		router["getAmountsOut(uint256,address[])"] = abiEncode([]string{"uint256[]"},
+			[]*big.Int{big.NewInt(1000000000), routerAmount})
+	}
+	chain.deploy(ethereum.RouterAddress, router)
+
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, ethereum, chain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return services.NewUniswapService(blockchain, services.NewVerifier(blockchain, 0), nil), canonical
+}
+
+func TestVerifyQuote(t *testing.T) {
+	tests := []struct {
+		name         string
+		routerAmount *big.Int
+		stats        models.VerificationStats
+		status       string
+	}{
+		{"match", bigInt("493579017198530649"), models.VerificationStats{Checks: 1}, models.VerificationMatch},
+		{"mismatch", bigInt("493579017198530600"), models.VerificationStats{Checks: 1, Mismatches: 1}, models.VerificationMismatch},
+		{"router revert", nil, models.VerificationStats{Failures: 1}, models.VerificationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uniswap, canonical := newVerifiedService(t, tt.routerAmount)
+
+			// A failing router call does not fail the quote
+			response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+				Pool: canonical, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Verify: true,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if response.DstAmount != "493579017198530649" {
+				t.Fatalf("Expected dst_amount 493579017198530649, got %s", response.DstAmount)
+			}
+
+			verification := response.Verification
+			if verification == nil || verification.Status != tt.status || verification.BlockNumber != 1000 ||
+				verification.Match != (tt.status == models.VerificationMatch) {
+				t.Fatalf("Expected a %s verification at block 1000, got %+v", tt.status, verification)
+			}
+			if tt.routerAmount != nil && verification.OnChainAmount != tt.routerAmount.String() {
+				t.Errorf("Expected onchain_amount %s, got %s", tt.routerAmount, verification.OnChainAmount)
+			}
+			if tt.routerAmount == nil && verification.Reason == "" {
+				t.Error("Expected a reason for the failed router call")
+			}
+			if stats := uniswap.VerificationStats(); stats != tt.stats {
+				t.Errorf("Expected stats %+v, got %+v", tt.stats, stats)
+			}
+		})
+	}
+}
+
+func TestVerifyNonCanonicalPool(t *testing.T) {
+	uniswap, canonical := newVerifiedService(t, bigInt("1"))
+
+	// The router prices the canonical pair, so its answer says nothing about stubPair
+	response, err := uniswap.EstimateSwap(context.Background(), &models.EstimateRequest{
+		Pool: stubPair, Src: stubToken0, Dst: stubToken1, SrcAmount: "1000000000", Verify: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	verification := response.Verification
+	if verification == nil || verification.Status != models.VerificationNotVerifiable ||
+		verification.Match || verification.OnChainAmount != "" {
+		t.Fatalf("Expected a not verifiable result, got %+v", verification)
+	}
+	if !strings.Contains(verification.Reason, canonical) || !strings.Contains(verification.Reason, stubPair) {
+		t.Errorf("Expected the reason to name %s and %s, got %q", canonical, stubPair, verification.Reason)
+	}
+
+	expected := models.VerificationStats{NotVerifiable: 1}
+	if stats := uniswap.VerificationStats(); stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+func TestVerifierSampleQueueFull(t *testing.T) {
+	ethereum, _ := config.KnownChain("ethereum")
+	blockchain, err := services.NewChainBlockchainService(&config.Config{}, ethereum, newStubChain())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Nothing runs the queue, so every job past its capacity is dropped
+	verifier := services.NewVerifier(blockchain, 1)
+	job := services.VerificationJob{
+		Src: stubToken0, Dst: stubToken1, AmountIn: big.NewInt(1), OffChainAmount: big.NewInt(1), Block: big.NewInt(1000),
+	}
+	for range 300 {
+		verifier.Sample(job)
+	}
+
+	if stats := verifier.Stats(); stats.Dropped != 44 || stats.Checks != 0 {
+		t.Fatalf("Expected 44 jobs dropped past a queue of 256, got %+v", stats)
+	}
+}
+
+func TestVerificationStatsEndpoint(t *testing.T) {
+	ethereum, _ := config.KnownChain("ethereum")
+	canonical := utils.PairAddress(ethereum.FactoryAddress, ethereum.InitCodeHash, stubToken0, stubToken1)
+	chain := newMetadataChain()
+	chain.deployPair(canonical, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+	chain.deployPair(stubPair, stubToken0, stubToken1, big.NewInt(100000000000), expandTo18Decimals(50))
+
+	handler := handlers.NewEstimateHandler(newChains(t, newStubChainService(t, "ethereum", chain)), 5*time.Second, 10)
+	app := fiber.New()
+	app.Get("/estimate", handler.EstimateSwap)
+	app.Get("/verify/stats", handler.VerificationStats)
+
+	// No router is deployed, so the canonical pair fails to verify; stubPair cannot be
+	for _, pool := range []string{canonical, stubPair, stubPair} {
+		resp, err := app.Test(httptest.NewRequest("GET",
+			"/estimate?verify=true&src_amount=1000000000&src="+stubToken0+"&dst="+stubToken1+"&pool="+pool, nil))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.StatusCode != 200 {
+			t.Fatalf("Expected status 200 for pool %s, got %d", pool, resp.StatusCode)
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/verify/stats", nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var stats models.VerificationStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Expected JSON response, got %v", err)
+	}
+	if expected := (models.VerificationStats{Failures: 1, NotVerifiable: 2}); stats != expected {
+		t.Fatalf("Expected stats %+v, got %+v", expected, stats)
+	}
+}